@@ -0,0 +1,44 @@
+// Command guandan-bot is the reference engine for the Guandan text protocol
+// (see sdk/protocol). It reads commands on stdin and answers on stdout, so it
+// can be plugged into the simulator with -seats exec:guandan-bot or used as a
+// starting point for engines written in other languages.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	
+	"guandan/sdk/bot"
+	"guandan/sdk/protocol"
+)
+
+func main() {
+	agentName := flag.String("agent", "heuristic", "strategy: heuristic, mc or mc-belief")
+	iterations := flag.Int("iterations", 0, "simulations per decision for Monte Carlo (0 = default)")
+	duration := flag.Duration("duration", 0, "thinking time per decision for Monte Carlo (0 = use iterations)")
+	seed := flag.Int64("seed", 0, "random seed for Monte Carlo (0 = time based)")
+	flag.Parse()
+	
+	// stdout carries the protocol, so diagnostics go to stderr
+	log.SetOutput(os.Stderr)
+	
+	var agent bot.Agent
+	switch *agentName {
+	case "heuristic":
+		agent = bot.NewHeuristic()
+	case "mc", "mc-belief":
+		agent = bot.NewMonteCarlo(bot.SearchConfig{
+			Iterations: *iterations,
+			Duration:   *duration,
+			Seed:       *seed,
+			Belief:     *agentName == "mc-belief",
+		})
+	default:
+		log.Fatalf("Unknown agent %q", *agentName)
+	}
+	
+	if err := protocol.Serve(os.Stdin, os.Stdout, agent, "guandan-bot "+*agentName); err != nil {
+		log.Fatalf("Protocol error: %v", err)
+	}
+}
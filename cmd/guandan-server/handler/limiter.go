@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Failed attempts allowed before further attempts are refused
+const (
+	clientAttemptLimit = 5  // per client address, across all rooms
+	roomAttemptLimit   = 20 // per room, across all clients; valid credentials still pass
+	attemptWindow      = time.Minute
+	maxLimiterKeys     = 1024 // expired keys are swept once there are more
+)
+
+// attemptLimiter counts failed attempts per key in a sliding window
+type attemptLimiter struct {
+	limit    int
+	window   time.Duration
+	failures map[string][]time.Time
+	mutex    sync.Mutex
+}
+
+func newAttemptLimiter(limit int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		limit:    limit,
+		window:   window,
+		failures: make(map[string][]time.Time),
+	}
+}
+
+// Allow reports whether the key has failed fewer times than the limit within the window
+func (l *attemptLimiter) Allow(key string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	return len(l.recent(key, time.Now())) < l.limit
+}
+
+// Fail records a failed attempt for the key
+func (l *attemptLimiter) Fail(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	now := time.Now()
+	l.failures[key] = append(l.recent(key, now), now)
+	if len(l.failures) > maxLimiterKeys {
+		for other := range l.failures {
+			l.recent(other, now)
+		}
+	}
+}
+
+// recent drops the failures of a key that left the window. The caller must hold l.mutex
+func (l *attemptLimiter) recent(key string, now time.Time) []time.Time {
+	failures := l.failures[key]
+	for len(failures) > 0 && now.Sub(failures[0]) >= l.window {
+		failures = failures[1:]
+	}
+	if len(failures) == 0 {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = failures
+	return failures
+}
+
+// clientAddress returns the address the request came from, without the port
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
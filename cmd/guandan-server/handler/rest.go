@@ -1,53 +1,102 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
-
+	
 	"github.com/gorilla/mux"
 	"guandan/cmd/guandan-server/room"
 	"guandan/sdk/domain"
+	"guandan/sdk/notation"
 	"guandan/sdk/service"
 )
 
 // RestHandler handles REST API requests
 type RestHandler struct {
-	gameService service.GameService
-	rooms       map[string]*room.RoomKernel
-	roomsMutex  sync.RWMutex
+	gameService    service.GameService
+	rooms          map[string]*room.RoomKernel
+	roomsMutex     sync.RWMutex
+	clientAttempts *attemptLimiter // failed passwords, invite codes and tokens by client address
+	roomAttempts   *attemptLimiter // failed passwords, invite codes and tokens by room
 }
 
 // NewRestHandler creates a new REST handler
 func NewRestHandler(gameService service.GameService) *RestHandler {
 	return &RestHandler{
-		gameService: gameService,
-		rooms:       make(map[string]*room.RoomKernel),
+		gameService:    gameService,
+		rooms:          make(map[string]*room.RoomKernel),
+		clientAttempts: newAttemptLimiter(clientAttemptLimit, attemptWindow),
+		roomAttempts:   newAttemptLimiter(roomAttemptLimit, attemptWindow),
 	}
 }
 
 // CreateRoomRequest represents a request to create a room
 type CreateRoomRequest struct {
-	RoomName string `json:"roomName"`
+	RoomName     string            `json:"roomName"`
+	Rules        string            `json:"rules,omitempty"`        // rule preset: "casual" (default), "standard" or "tournament"
+	ActionTime   int               `json:"actionTime,omitempty"`   // seconds per action, overrides the preset
+	TimeBank     int               `json:"timeBank,omitempty"`     // seconds of time bank per seat per deal, overrides the preset
+	CardTracker  bool              `json:"cardTracker,omitempty"`  // enable the per-seat card tracker feed
+	TargetLevel  string            `json:"targetLevel,omitempty"`  // level at which the match ends, "2" to "A" (default)
+	TrusteeDelay int               `json:"trusteeDelay,omitempty"` // seconds before the bot takes over a disconnected seat, 0 uses the default, -1 disables
+	Visibility   string            `json:"visibility,omitempty"`   // "public" (default) or "private"
+	Password     string            `json:"password,omitempty"`     // optional password for joining
+	Spectators   *SpectatorOptions `json:"spectators,omitempty"`   // public spectators without a delay by default
+}
+
+// SpectatorOptions sets what spectators of a new room see
+type SpectatorOptions struct {
+	Mode         string `json:"mode"`                   // "public", "seat", "omniscient" or "off"
+	Delay        int    `json:"delay,omitempty"`        // seconds events are held back
+	DelayActions int    `json:"delayActions,omitempty"` // plays, passes and tributes events are held back
 }
 
 // CreateRoomResponse represents a response to create a room
 type CreateRoomResponse struct {
-	RoomID string `json:"roomId"`
+	RoomID     string `json:"roomId"`
+	OwnerToken string `json:"ownerToken"` // manages invites and bots of a protected room; send it as X-Room-Token
 }
 
 // JoinRoomRequest represents a request to join a room
 type JoinRoomRequest struct {
-	Seat int `json:"seat"`
+	Seat      int    `json:"seat"`
+	Password  string `json:"password,omitempty"`
+	Invite    string `json:"invite,omitempty"`    // invite code of a protected room
+	Reconnect string `json:"reconnect,omitempty"` // token from the Joined message, to take back a seat kept after a disconnect
 }
 
 // JoinRoomResponse represents a response to join a room
 type JoinRoomResponse struct {
-	WSUrl string `json:"wsUrl"`
+	WSUrl  string `json:"wsUrl"`
+	Ticket string `json:"ticket,omitempty"` // lets the WebSocket connection take the seat of a protected room; included in wsUrl
+}
+
+// CreateInviteRequest represents a request to create an invite code
+type CreateInviteRequest struct {
+	MaxUses int `json:"maxUses"`       // 1 for a single-use code, 0 for no limit
+	TTL     int `json:"ttl,omitempty"` // seconds until the code expires, 0 for never
+}
+
+// AddBotRequest represents a request to fill a seat with a bot
+type AddBotRequest struct {
+	Seat  int    `json:"seat"`
+	Agent string `json:"agent,omitempty"` // "heuristic" (default) or "mc"
 }
 
+// ownerTokenHeader carries the owner token of a room
+const ownerTokenHeader = "X-Room-Token"
+
+// maxPasswordLength limits room passwords
+const maxPasswordLength = 64
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -61,11 +110,46 @@ func (h *RestHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	config := room.DefaultRoomConfig
+	if err := config.ApplySettings(h.settingsUpdate(req)); err != nil {
+		h.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.TrusteeDelay < -1 {
+		h.sendError(w, "Trustee delay must be -1 or more", http.StatusBadRequest)
+		return
+	}
+	if req.TrusteeDelay != 0 {
+		config.TrusteeDelay = time.Duration(max(req.TrusteeDelay, 0)) * time.Second
+	}
+	switch req.Visibility {
+	case "":
+	case room.VisibilityPublic, room.VisibilityPrivate:
+		config.Visibility = req.Visibility
+	default:
+		h.sendError(w, "Visibility must be public or private", http.StatusBadRequest)
+		return
+	}
+	if len(req.Password) > maxPasswordLength {
+		h.sendError(w, fmt.Sprintf("Password is longer than %d bytes", maxPasswordLength), http.StatusBadRequest)
+		return
+	}
+	config.Password = req.Password
+	if req.Spectators != nil {
+		config.Spectators.Mode = req.Spectators.Mode
+		config.Spectators.Delay = time.Duration(req.Spectators.Delay) * time.Second
+		config.Spectators.DelayActions = req.Spectators.DelayActions
+		if err := config.Spectators.Validate(); err != nil {
+			h.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	
 	// Generate room ID
 	roomID := h.generateRoomID()
 	
 	// Create room kernel
-	roomKernel := room.NewRoomKernel(roomID, h.gameService, room.DefaultRoomConfig)
+	roomKernel := room.NewRoomKernel(roomID, h.gameService, config)
 	
 	// Start room kernel
 	if err := roomKernel.Start(); err != nil {
@@ -80,7 +164,8 @@ func (h *RestHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	
 	// Send response
 	response := CreateRoomResponse{
-		RoomID: roomID,
+		RoomID:     roomID,
+		OwnerToken: roomKernel.OwnerToken(),
 	}
 	
 	h.sendJSON(w, response)
@@ -113,12 +198,25 @@ func (h *RestHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Check if room is full
-	if roomKernel.GetPlayerCount() >= 4 {
+	// Check if room is full; a disconnected player may take their seat back with their reconnect token
+	seat := h.parseSeat(req.Seat)
+	if roomKernel.GetPlayerCount() >= 4 && !roomKernel.CanReconnect(seat, req.Reconnect) {
 		h.sendError(w, "Room is full", http.StatusBadRequest)
 		return
 	}
 	
+	// Protected rooms check the password or invite code here and hand out a ticket for the WebSocket
+	ticket := ""
+	if roomKernel.Protected() {
+		credentials := room.Credentials{Password: req.Password, Invite: req.Invite}
+		if !h.authorize(w, r, roomID, func() (err error) {
+			ticket, err = roomKernel.IssueTicket(seat, credentials)
+			return err
+		}) {
+			return
+		}
+	}
+	
 	// Generate WebSocket URL
 	// Check if request comes through nginx proxy (port 5173) or direct (port 8080)
 	host := r.Host
@@ -133,41 +231,115 @@ func (h *RestHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	wsURL := fmt.Sprintf("ws://%s/api/room/%s/ws?seat=%d", host, roomID, req.Seat)
+	if ticket != "" {
+		wsURL += "&ticket=" + ticket
+	}
+	if req.Reconnect != "" {
+		wsURL += "&reconnect=" + url.QueryEscape(req.Reconnect)
+	}
 	
 	// Send response
 	response := JoinRoomResponse{
-		WSUrl: wsURL,
+		WSUrl:  wsURL,
+		Ticket: ticket,
 	}
 	
 	h.sendJSON(w, response)
 }
 
-// GetRoomInfo handles GET /api/room/{id}
+// GetRoomInfo handles GET /api/room/{id}; protected rooms need credentials like joining
 func (h *RestHandler) GetRoomInfo(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	roomID := vars["id"]
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireAccess(w, r, roomKernel) {
+		return
+	}
 	
-	// Check if room exists
-	h.roomsMutex.RLock()
-	roomKernel, exists := h.rooms[roomID]
-	h.roomsMutex.RUnlock()
+	h.sendRoomInfo(w, roomKernel)
+}
+
+// AddBot handles POST /api/room/{id}/bots and returns the updated room; it needs the owner token
+func (h *RestHandler) AddBot(w http.ResponseWriter, r *http.Request) {
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireOwner(w, r, roomKernel) {
+		return
+	}
 	
-	if !exists {
-		h.sendError(w, "Room not found", http.StatusNotFound)
+	var req AddBotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Seat < 0 || req.Seat > 3 {
+		h.sendError(w, "Invalid seat number", http.StatusBadRequest)
 		return
 	}
 	
-	// Get room snapshot
-	snapshot, err := roomKernel.GetSnapshot()
+	if err := roomKernel.AddBot(h.parseSeat(req.Seat), req.Agent); err != nil {
+		h.sendRoomError(w, err)
+		return
+	}
+	h.sendRoomInfo(w, roomKernel)
+}
+
+// RemoveBot handles DELETE /api/room/{id}/bots/{seat} and returns the updated room; it needs the owner token
+func (h *RestHandler) RemoveBot(w http.ResponseWriter, r *http.Request) {
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireOwner(w, r, roomKernel) {
+		return
+	}
+	seat, err := strconv.Atoi(mux.Vars(r)["seat"])
+	if err != nil || seat < 0 || seat > 3 {
+		h.sendError(w, "Invalid seat number", http.StatusBadRequest)
+		return
+	}
+	
+	if err := roomKernel.RemoveBot(h.parseSeat(seat)); err != nil {
+		h.sendRoomError(w, err)
+		return
+	}
+	h.sendRoomInfo(w, roomKernel)
+}
+
+// CreateInvite handles POST /api/room/{id}/invites; it needs the owner token
+func (h *RestHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireOwner(w, r, roomKernel) {
+		return
+	}
+	
+	var req CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.MaxUses < 0 || req.TTL < 0 {
+		h.sendError(w, "Invite uses and lifetime cannot be negative", http.StatusBadRequest)
+		return
+	}
+	
+	invite, err := roomKernel.CreateInvite(req.MaxUses, time.Duration(req.TTL)*time.Second)
 	if err != nil {
-		h.sendError(w, "Failed to get room info", http.StatusInternalServerError)
+		h.sendRoomError(w, err)
+		return
+	}
+	h.sendJSON(w, invite)
+}
+
+// RevokeInvite handles DELETE /api/room/{id}/invites/{code}; it needs the owner token
+func (h *RestHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireOwner(w, r, roomKernel) {
 		return
 	}
 	
-	h.sendJSON(w, snapshot)
+	if err := roomKernel.RevokeInvite(mux.Vars(r)["code"]); err != nil {
+		h.sendRoomError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// ListRooms handles GET /api/rooms
+// ListRooms handles GET /api/rooms; private rooms are left out
 func (h *RestHandler) ListRooms(w http.ResponseWriter, r *http.Request) {
 	h.roomsMutex.RLock()
 	defer h.roomsMutex.RUnlock()
@@ -175,11 +347,17 @@ func (h *RestHandler) ListRooms(w http.ResponseWriter, r *http.Request) {
 	rooms := make([]map[string]interface{}, 0, len(h.rooms))
 	
 	for roomID, roomKernel := range h.rooms {
+		if roomKernel.Private() {
+			continue
+		}
 		roomInfo := map[string]interface{}{
 			"roomId":      roomID,
+			"name":        roomKernel.Settings().Name,
 			"playerCount": roomKernel.GetPlayerCount(),
 			"maxPlayers":  4,
 			"isEmpty":     roomKernel.IsEmpty(),
+			"password":    roomKernel.HasPassword(),
+			"spectators":  roomKernel.SpectatorCount(),
 		}
 		rooms = append(rooms, roomInfo)
 	}
@@ -196,6 +374,77 @@ func (h *RestHandler) Health(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ListDeals handles GET /api/room/{id}/deals
+func (h *RestHandler) ListDeals(w http.ResponseWriter, r *http.Request) {
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireAccess(w, r, roomKernel) {
+		return
+	}
+	
+	deals, err := roomKernel.Deals()
+	if err != nil {
+		h.sendRoomError(w, err)
+		return
+	}
+	
+	h.sendJSON(w, deals)
+}
+
+// GetDealRecord handles GET /api/room/{id}/deals/{deal} and returns the deal in GDN
+func (h *RestHandler) GetDealRecord(w http.ResponseWriter, r *http.Request) {
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireAccess(w, r, roomKernel) {
+		return
+	}
+	deal, err := strconv.Atoi(mux.Vars(r)["deal"])
+	if err != nil {
+		h.sendError(w, "Invalid deal number", http.StatusBadRequest)
+		return
+	}
+	
+	rec, err := roomKernel.DealRecord(deal)
+	if err != nil {
+		h.sendRoomError(w, err)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	notation.Write(w, rec)
+}
+
+// GetDealAnalysis handles GET /api/room/{id}/deals/{deal}/analysis.
+// The first request starts the analysis; until it finishes the response is 202 with status "running"
+func (h *RestHandler) GetDealAnalysis(w http.ResponseWriter, r *http.Request) {
+	roomKernel, ok := h.findRoom(w, r)
+	if !ok || !h.requireAccess(w, r, roomKernel) {
+		return
+	}
+	deal, err := strconv.Atoi(mux.Vars(r)["deal"])
+	if err != nil {
+		h.sendError(w, "Invalid deal number", http.StatusBadRequest)
+		return
+	}
+	
+	report, err := roomKernel.AnalyzeDeal(deal)
+	if err != nil {
+		var roomErr room.RoomError
+		if errors.As(err, &roomErr) {
+			h.sendRoomError(w, err)
+			return
+		}
+		h.sendError(w, "Failed to analyze deal: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"status": room.AnalysisRunning})
+		return
+	}
+	
+	h.sendJSON(w, report)
+}
+
 // GetRoom returns a room by ID
 func (h *RestHandler) GetRoom(roomID string) (*room.RoomKernel, bool) {
 	h.roomsMutex.RLock()
@@ -235,8 +484,86 @@ func (h *RestHandler) CleanupEmptyRooms() {
 
 // Helper methods
 
+// settingsUpdate maps the room settings of a create request; zero values keep the preset
+func (h *RestHandler) settingsUpdate(req CreateRoomRequest) room.SettingsUpdate {
+	update := room.SettingsUpdate{Name: &req.RoomName}
+	if req.Rules != "" {
+		update.Rules = &req.Rules
+	}
+	if req.ActionTime != 0 {
+		update.ActionTime = &req.ActionTime
+	}
+	if req.TimeBank != 0 {
+		update.TimeBank = &req.TimeBank
+	}
+	if req.CardTracker {
+		update.CardTracker = &req.CardTracker
+	}
+	if req.TargetLevel != "" {
+		update.TargetLevel = &req.TargetLevel
+	}
+	return update
+}
+
+// authorize runs a credential check under the failed-attempt limits. It sends 429 while the client
+// is over its limit and 403 when the check fails. The room limit only refuses failing checks, so
+// guesses from many clients cannot lock out players with the right credentials or the owner
+func (h *RestHandler) authorize(w http.ResponseWriter, r *http.Request, roomID string, check func() error) bool {
+	client := clientAddress(r)
+	if !h.clientAttempts.Allow(client) {
+		h.sendError(w, room.ErrTooManyAttempts.Message, http.StatusTooManyRequests)
+		return false
+	}
+	if err := check(); err != nil {
+		h.clientAttempts.Fail(client)
+		if !h.roomAttempts.Allow(roomID) {
+			h.sendError(w, room.ErrTooManyAttempts.Message, http.StatusTooManyRequests)
+			return false
+		}
+		h.roomAttempts.Fail(roomID)
+		h.sendError(w, room.ToRoomError(err).Message, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// requireOwner checks the owner token of the room in the request path
+func (h *RestHandler) requireOwner(w http.ResponseWriter, r *http.Request, roomKernel *room.RoomKernel) bool {
+	return h.authorize(w, r, mux.Vars(r)["id"], func() error {
+		return roomKernel.CheckOwner(r.Header.Get(ownerTokenHeader))
+	})
+}
+
+// requireAccess checks the credentials for reading a protected room: the owner token, a seat's
+// ticket with its seat, the password or an invite code, from the query string like the WebSocket
+func (h *RestHandler) requireAccess(w http.ResponseWriter, r *http.Request, roomKernel *room.RoomKernel) bool {
+	if !roomKernel.Protected() {
+		return true
+	}
+	
+	query := r.URL.Query()
+	return h.authorize(w, r, mux.Vars(r)["id"], func() error {
+		if token := r.Header.Get(ownerTokenHeader); token != "" {
+			return roomKernel.CheckOwner(token)
+		}
+		if ticket := query.Get("ticket"); ticket != "" {
+			seatNum, err := strconv.Atoi(query.Get("seat"))
+			if err != nil || seatNum < 0 || seatNum > 3 {
+				return room.ErrAccessDenied
+			}
+			return roomKernel.CheckTicket(h.parseSeat(seatNum), ticket)
+		}
+		return roomKernel.CheckAccess(query.Get("password"), query.Get("invite"))
+	})
+}
+
+// generateRoomID returns an unguessable room ID, since the ID is all a client needs for public rooms
 func (h *RestHandler) generateRoomID() string {
-	return fmt.Sprintf("room_%d", time.Now().UnixNano())
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return "room_" + hex.EncodeToString(buf)
 }
 
 func (h *RestHandler) sendJSON(w http.ResponseWriter, data interface{}) {
@@ -244,6 +571,42 @@ func (h *RestHandler) sendJSON(w http.ResponseWriter, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// findRoom looks up the room in the request path and sends 404 when it does not exist
+func (h *RestHandler) findRoom(w http.ResponseWriter, r *http.Request) (*room.RoomKernel, bool) {
+	roomKernel, exists := h.GetRoom(mux.Vars(r)["id"])
+	if !exists {
+		h.sendError(w, "Room not found", http.StatusNotFound)
+	}
+	return roomKernel, exists
+}
+
+// sendRoomInfo sends the room snapshot
+func (h *RestHandler) sendRoomInfo(w http.ResponseWriter, roomKernel *room.RoomKernel) {
+	snapshot, err := roomKernel.GetSnapshot()
+	if err != nil {
+		h.sendError(w, "Failed to get room info", http.StatusInternalServerError)
+		return
+	}
+	
+	h.sendJSON(w, snapshot)
+}
+
+// sendRoomError sends a room error: missing deals and bots as 404, bad requests as 400
+// and requests that conflict with the room's state as 409
+func (h *RestHandler) sendRoomError(w http.ResponseWriter, err error) {
+	roomErr := room.ToRoomError(err)
+	status := http.StatusConflict
+	switch roomErr.Code {
+	case room.ErrGameNotStarted.Code, room.ErrDealNotFound.Code, room.ErrBotNotFound.Code:
+		status = http.StatusNotFound
+	case room.ErrInviteNotFound.Code:
+		status = http.StatusNotFound
+	case room.ErrInvalidSeat.Code, room.ErrInvalidBot.Code, room.ErrInvalidSettings.Code:
+		status = http.StatusBadRequest
+	}
+	h.sendError(w, roomErr.Message, status)
+}
+
 func (h *RestHandler) sendError(w http.ResponseWriter, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
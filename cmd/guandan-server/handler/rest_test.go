@@ -3,10 +3,12 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/gorilla/mux"
 	"guandan/sdk/service"
 )
 
@@ -32,6 +34,54 @@ func TestRestHandler_CreateRoom(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
+		{
+			name:           "Room with turn timer",
+			requestBody:    CreateRoomRequest{RoomName: "Timed", ActionTime: 20, TimeBank: 60},
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "Negative action time",
+			requestBody:    CreateRoomRequest{RoomName: "Timed", ActionTime: -5},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Time bank without action time",
+			requestBody:    CreateRoomRequest{RoomName: "Timed", TimeBank: 60},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Rule preset with target level",
+			requestBody:    CreateRoomRequest{RoomName: "Ranked", Rules: "tournament", TargetLevel: "10"},
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "Unknown rule preset",
+			requestBody:    CreateRoomRequest{RoomName: "Ranked", Rules: "speed"},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Invalid target level",
+			requestBody:    CreateRoomRequest{RoomName: "Ranked", TargetLevel: "15"},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "Delayed omniscient spectators",
+			requestBody:    CreateRoomRequest{RoomName: "Final", Spectators: &SpectatorOptions{Mode: "omniscient", Delay: 60}},
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "Omniscient spectators without a delay",
+			requestBody:    CreateRoomRequest{RoomName: "Final", Spectators: &SpectatorOptions{Mode: "omniscient"}},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
 		{
 			name:           "Invalid JSON",
 			requestBody:    "invalid json",
@@ -347,3 +397,188 @@ func TestRestHandler_RemoveRoom(t *testing.T) {
 	handler.RemoveRoom("non-existent-room")
 }
 
+
+func TestRestHandler_PrivateRoom(t *testing.T) {
+	handler := NewRestHandler(service.NewGameService())
+
+	send := func(handle http.HandlerFunc, vars map[string]string, body string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(ownerTokenHeader, token)
+		}
+		rr := httptest.NewRecorder()
+		handle(rr, mux.SetURLVars(req, vars))
+		return rr
+	}
+
+	rr := send(handler.CreateRoom, nil, `{"roomName":"Club","visibility":"private","password":"secret"}`, "")
+	var created CreateRoomResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.OwnerToken == "" {
+		t.Fatalf("Expected a room with an owner token, got %s", rr.Body.String())
+	}
+	vars := map[string]string{"id": created.RoomID}
+	if rr := send(handler.CreateRoom, nil, `{"roomName":"Club","visibility":"friends"}`, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown visibility, got %d", rr.Code)
+	}
+
+	// Private rooms are not listed
+	rr = send(handler.ListRooms, nil, "", "")
+	var rooms []map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &rooms)
+	if len(rooms) != 0 {
+		t.Errorf("Expected the private room to be hidden, got %v", rooms)
+	}
+
+	// The password gets a ticket for the WebSocket
+	if rr := send(handler.JoinRoom, vars, `{"seat":0,"password":"wrong"}`, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a wrong password, got %d", rr.Code)
+	}
+	rr = send(handler.JoinRoom, vars, `{"seat":0,"password":"secret"}`, "")
+	var joined JoinRoomResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &joined); err != nil || joined.Ticket == "" {
+		t.Fatalf("Expected a ticket, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Invites need the owner token. A single-use code is only used up when it takes a seat over the WebSocket
+	if rr := send(handler.CreateInvite, vars, `{"maxUses":1}`, "wrong"); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the owner token, got %d", rr.Code)
+	}
+	rr = send(handler.CreateInvite, vars, `{"maxUses":1}`, created.OwnerToken)
+	var invite struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &invite); err != nil || invite.Code == "" {
+		t.Fatalf("Expected an invite code, got %d %s", rr.Code, rr.Body.String())
+	}
+	body := `{"seat":1,"invite":"` + invite.Code + `"}`
+	if rr := send(handler.JoinRoom, vars, body, ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected the invite to work, got %d", rr.Code)
+	}
+	if rr := send(handler.JoinRoom, vars, body, ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected the invite to work until a seat is taken, got %d", rr.Code)
+	}
+
+	// Failures from many clients do not lock out the right password or the owner token
+	for i := 0; i < roomAttemptLimit+5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewBufferString(`{"seat":0,"password":"wrong"}`))
+		req.RemoteAddr = fmt.Sprintf("198.51.100.%d:1234", i)
+		handler.JoinRoom(httptest.NewRecorder(), mux.SetURLVars(req, vars))
+	}
+	if rr := send(handler.JoinRoom, vars, `{"seat":0,"password":"wrong"}`, ""); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 for a failure once the room is over its limit, got %d", rr.Code)
+	}
+	if rr := send(handler.JoinRoom, vars, `{"seat":0,"password":"secret"}`, ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected the right password to pass the room limit, got %d", rr.Code)
+	}
+	if rr := send(handler.CreateInvite, vars, `{"maxUses":1}`, created.OwnerToken); rr.Code != http.StatusOK {
+		t.Errorf("Expected the owner token to pass the room limit, got %d", rr.Code)
+	}
+
+	// The fifth failure from one client blocks even the right password
+	send(handler.JoinRoom, vars, `{"seat":0,"password":"wrong"}`, "")
+	send(handler.JoinRoom, vars, `{"seat":0,"password":"wrong"}`, "")
+	if rr := send(handler.JoinRoom, vars, `{"seat":0,"password":"secret"}`, ""); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 after five failures, got %d", rr.Code)
+	}
+}
+
+func TestRestHandler_PrivateRoomReads(t *testing.T) {
+	handler := NewRestHandler(service.NewGameService())
+
+	get := func(handle http.HandlerFunc, vars map[string]string, query string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api?"+query, nil)
+		if token != "" {
+			req.Header.Set(ownerTokenHeader, token)
+		}
+		rr := httptest.NewRecorder()
+		handle(rr, mux.SetURLVars(req, vars))
+		return rr
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/room", bytes.NewBufferString(`{"roomName":"Club","password":"secret"}`))
+	rr := httptest.NewRecorder()
+	handler.CreateRoom(rr, req)
+	var created CreateRoomResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to create the room: %s", rr.Body.String())
+	}
+	vars := map[string]string{"id": created.RoomID}
+
+	// The room and its deals need the same credentials as joining
+	if rr := get(handler.GetRoomInfo, vars, "", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the room without credentials, got %d", rr.Code)
+	}
+	if rr := get(handler.ListDeals, vars, "password=wrong", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the deals with a wrong password, got %d", rr.Code)
+	}
+	if rr := get(handler.GetRoomInfo, vars, "password=secret", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected the password to read the room, got %d", rr.Code)
+	}
+	if rr := get(handler.GetRoomInfo, vars, "", created.OwnerToken); rr.Code != http.StatusOK {
+		t.Errorf("Expected the owner token to read the room, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api", bytes.NewBufferString(`{"seat":2,"password":"secret"}`))
+	rr = httptest.NewRecorder()
+	handler.JoinRoom(rr, mux.SetURLVars(req, vars))
+	var joined JoinRoomResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &joined); err != nil || joined.Ticket == "" {
+		t.Fatalf("Expected a ticket, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := get(handler.GetRoomInfo, vars, "seat=1&ticket="+joined.Ticket, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a ticket of another seat, got %d", rr.Code)
+	}
+	if rr := get(handler.GetRoomInfo, vars, "seat=2&ticket="+joined.Ticket, ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected the ticket to read the room, got %d", rr.Code)
+	}
+}
+
+func TestRestHandler_RoomIDs(t *testing.T) {
+	handler := NewRestHandler(service.NewGameService())
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := handler.generateRoomID()
+		if len(id) != len("room_")+24 || seen[id] {
+			t.Fatalf("Expected unique random room IDs, got %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestRestHandler_BotsNeedOwnerToken(t *testing.T) {
+	handler := NewRestHandler(service.NewGameService())
+
+	send := func(handle http.HandlerFunc, vars map[string]string, body string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api", bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set(ownerTokenHeader, token)
+		}
+		rr := httptest.NewRecorder()
+		handle(rr, mux.SetURLVars(req, vars))
+		return rr
+	}
+
+	rr := send(handler.CreateRoom, nil, `{"roomName":"Open"}`, "")
+	var created CreateRoomResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.OwnerToken == "" {
+		t.Fatalf("Expected a room with an owner token, got %s", rr.Body.String())
+	}
+	vars := map[string]string{"id": created.RoomID}
+	seatVars := map[string]string{"id": created.RoomID, "seat": "1"}
+
+	// Public rooms need the token as well
+	if rr := send(handler.AddBot, vars, `{"seat":1}`, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the owner token, got %d", rr.Code)
+	}
+	if rr := send(handler.AddBot, vars, `{"seat":1}`, created.OwnerToken); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the owner to add a bot, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := send(handler.RemoveBot, seatVars, "", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the owner token, got %d", rr.Code)
+	}
+	if rr := send(handler.RemoveBot, seatVars, "", created.OwnerToken); rr.Code != http.StatusOK {
+		t.Errorf("Expected the owner to remove the bot, got %d %s", rr.Code, rr.Body.String())
+	}
+}
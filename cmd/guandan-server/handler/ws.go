@@ -6,7 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"time"
-
+	
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"guandan/cmd/guandan-server/room"
@@ -62,6 +62,21 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	
+	// Protected rooms need the ticket from JoinRoom, the password or an invite code. They are checked
+	// here without being used up and again when the player takes the seat
+	query := r.URL.Query()
+	credentials := room.Credentials{
+		Ticket:    query.Get("ticket"),
+		Password:  query.Get("password"),
+		Invite:    query.Get("invite"),
+		Reconnect: query.Get("reconnect"),
+	}
+	if roomKernel.Protected() && !h.restHandler.authorize(w, r, roomID, func() error {
+		return h.admit(roomKernel, seat, credentials)
+	}) {
+		return
+	}
+	
 	// Upgrade connection to WebSocket
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -73,22 +88,82 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 	playerID := h.generatePlayerID(roomID, seat)
 	
 	// Add player to room
-	err = roomKernel.AddPlayer(playerID, seat, conn)
+	err = roomKernel.JoinPlayer(playerID, seat, conn, credentials)
 	if err != nil {
 		log.Printf("Failed to add player to room: %v", err)
+		conn.WriteJSON(room.NewErrorMessage(err, query.Get("lang")))
 		conn.Close()
 		return
 	}
 	
+	// Error messages are localized with the optional lang parameter
+	roomKernel.SetPlayerLocale(seat, query.Get("lang"))
+	
 	// Handle connection
-	h.handleConnection(roomKernel, seat, conn)
+	h.handleConnection(roomKernel, conn)
+}
+
+// HandleSpectator handles read-only WebSocket connections that watch a room
+func (h *WebSocketHandler) HandleSpectator(w http.ResponseWriter, r *http.Request) {
+	roomID := mux.Vars(r)["id"]
+	roomKernel, exists := h.restHandler.GetRoom(roomID)
+	if !exists {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	
+	// Seat mode watches the hand of the seat in the query
+	var seat *domain.SeatID
+	if seatStr := r.URL.Query().Get("seat"); seatStr != "" {
+		seatNum, err := strconv.Atoi(seatStr)
+		if err != nil || seatNum < 0 || seatNum > 3 {
+			http.Error(w, "Invalid seat parameter", http.StatusBadRequest)
+			return
+		}
+		parsed := h.parseSeat(seatNum)
+		seat = &parsed
+	}
+	
+	// Protected rooms need the password or an invite code to watch too; watching does not use up the invite
+	query := r.URL.Query()
+	if roomKernel.Protected() && !h.restHandler.authorize(w, r, roomID, func() error {
+		return roomKernel.CheckAccess(query.Get("password"), query.Get("invite"))
+	}) {
+		return
+	}
+	
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+	
+	if err := roomKernel.AddSpectator(conn, seat); err != nil {
+		log.Printf("Failed to add spectator to room %s: %v", roomID, err)
+		conn.WriteJSON(room.NewErrorMessage(err, query.Get("lang")))
+		return
+	}
+	defer roomKernel.RemoveSpectator(conn)
+	
+	// Spectators only read; their messages are dropped
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	}
 }
 
 // handleConnection handles a WebSocket connection
-func (h *WebSocketHandler) handleConnection(roomKernel *room.RoomKernel, seat domain.SeatID, conn *websocket.Conn) {
+func (h *WebSocketHandler) handleConnection(roomKernel *room.RoomKernel, conn *websocket.Conn) {
 	defer func() {
 		conn.Close()
-		roomKernel.RemovePlayer(seat)
+		// The player may have changed seats in the lobby
+		if seat, ok := roomKernel.SeatOf(conn); ok {
+			roomKernel.DisconnectPlayer(seat, conn)
+		}
 	}()
 	
 	// Set up connection parameters
@@ -112,13 +187,25 @@ func (h *WebSocketHandler) handleConnection(roomKernel *room.RoomKernel, seat do
 		// Reset read deadline
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		
-		// Handle message
+		// Handle message from the player's current seat; a kicked player has none
+		seat, ok := roomKernel.SeatOf(conn)
+		if !ok {
+			break
+		}
 		roomKernel.HandleMessage(seat, msg)
 	}
 }
 
 // Helper methods
 
+// admit checks the credentials of a WebSocket connection to a protected room without using them up
+func (h *WebSocketHandler) admit(roomKernel *room.RoomKernel, seat domain.SeatID, credentials room.Credentials) error {
+	if credentials.Ticket != "" {
+		return roomKernel.CheckTicket(seat, credentials.Ticket)
+	}
+	return roomKernel.CheckAccess(credentials.Password, credentials.Invite)
+}
+
 func (h *WebSocketHandler) generatePlayerID(roomID string, seat domain.SeatID) string {
 	return fmt.Sprintf("%s_player_%s", roomID, seat)
 }
@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 
+	"guandan/cmd/guandan-server/room"
 	"guandan/sdk/service"
 )
 
@@ -17,6 +18,14 @@ const (
 )
 
 func main() {
+	// Choose the bot that plays for absent players
+	if agent := os.Getenv("TRUSTEE_AGENT"); agent != "" {
+		if err := room.ValidateTrusteeAgent(agent); err != nil {
+			log.Fatalf("Invalid TRUSTEE_AGENT: %v", err)
+		}
+		room.DefaultRoomConfig.TrusteeAgent = agent
+	}
+	
 	// Create game service
 	gameService := service.NewGameService()
 	
@@ -0,0 +1,335 @@
+package room
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+	
+	"github.com/gorilla/websocket"
+	"guandan/sdk/domain"
+)
+
+// Room visibility
+const (
+	VisibilityPublic  = "public"  // listed, anyone can join unless a password is set
+	VisibilityPrivate = "private" // hidden from the room list, players need the password or an invite code
+)
+
+// ticketTTL is how long a join ticket can wait for its WebSocket connection
+const ticketTTL = 2 * time.Minute
+
+// inviteAlphabet leaves out the letters and digits that are easy to confuse
+var inviteAlphabet = base32.NewEncoding("ABCDEFGHJKLMNPQRSTUVWXYZ23456789").WithPadding(base32.NoPadding)
+
+// Invite is an invite code for a protected room
+type Invite struct {
+	Code      string     `json:"code"`
+	MaxUses   int        `json:"maxUses"` // 1 for a single-use code, 0 for no limit
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// roomAccess guards who can take a seat in a protected room. Its fields are guarded by rk.mutex
+type roomAccess struct {
+	ownerToken   string
+	passwordSalt []byte
+	passwordHash []byte // nil when the room has no password
+	invites      map[string]*Invite
+	tickets      map[string]*ticket
+}
+
+// ticket lets one WebSocket connection take a seat after JoinRoom checked the credentials.
+// Once used it stays valid for reconnecting to the seat until the seat is freed
+type ticket struct {
+	seat    domain.SeatID
+	invite  string    // invite code counted when the ticket takes its seat, empty for the password
+	expires time.Time // zero once the ticket has been used
+}
+
+func newRoomAccess(password string) *roomAccess {
+	access := &roomAccess{
+		ownerToken: randomToken(16),
+		invites:    make(map[string]*Invite),
+		tickets:    make(map[string]*ticket),
+	}
+	if password != "" {
+		access.passwordSalt = []byte(randomToken(8))
+		access.passwordHash = hashPassword(access.passwordSalt, password)
+	}
+	return access
+}
+
+func hashPassword(salt []byte, password string) []byte {
+	sum := sha256.Sum256(append(append([]byte{}, salt...), password...))
+	return sum[:]
+}
+
+// randomToken returns n random bytes in hex
+func randomToken(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// OwnerToken returns the token that manages the room's invites and bots
+func (rk *RoomKernel) OwnerToken() string {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	return rk.access.ownerToken
+}
+
+// Private reports whether the room is hidden from the room list
+func (rk *RoomKernel) Private() bool {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	return rk.config.Visibility == VisibilityPrivate
+}
+
+// Protected reports whether players need a password, an invite code or a ticket to take a seat
+func (rk *RoomKernel) Protected() bool {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	return rk.protected()
+}
+
+// HasPassword reports whether the room has a password
+func (rk *RoomKernel) HasPassword() bool {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	return rk.access.passwordHash != nil
+}
+
+// protected reports whether the room checks credentials. The caller must hold rk.mutex
+func (rk *RoomKernel) protected() bool {
+	return rk.config.Visibility == VisibilityPrivate || rk.access.passwordHash != nil
+}
+
+// CheckOwner verifies the owner token
+func (rk *RoomKernel) CheckOwner(token string) error {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	if subtle.ConstantTimeCompare([]byte(token), []byte(rk.access.ownerToken)) != 1 {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// Credentials are what a player shows to take a seat in a protected room: the ticket from
+// JoinRoom, the password or an invite code. Reconnect takes back a seat kept after a disconnect
+type Credentials struct {
+	Ticket    string
+	Password  string
+	Invite    string
+	Reconnect string
+}
+
+// CheckAccess checks the password or the invite code of a player or spectator without using up
+// the invite; a player's use is counted by JoinPlayer once the seat is taken. Unprotected rooms let everyone in
+func (rk *RoomKernel) CheckAccess(password, code string) error {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	if !rk.protected() {
+		return nil
+	}
+	_, err := rk.checkAccess(password, code)
+	return err
+}
+
+// checkAccess returns the invite that lets a player in, nil for the password. The caller must hold rk.mutex
+func (rk *RoomKernel) checkAccess(password, code string) (*Invite, error) {
+	access := rk.access
+	if password != "" && access.passwordHash != nil &&
+		subtle.ConstantTimeCompare(hashPassword(access.passwordSalt, password), access.passwordHash) == 1 {
+		return nil, nil
+	}
+	
+	code = strings.ToUpper(strings.TrimSpace(code))
+	invite, exists := access.invites[code]
+	if code == "" || !exists {
+		return nil, ErrAccessDenied
+	}
+	if invite.ExpiresAt != nil && time.Now().After(*invite.ExpiresAt) {
+		delete(access.invites, code)
+		return nil, ErrAccessDenied
+	}
+	return invite, nil
+}
+
+// useInvite counts one use of an invite. The caller must hold rk.mutex
+func (rk *RoomKernel) useInvite(invite *Invite) {
+	invite.Uses++
+	if invite.MaxUses > 0 && invite.Uses >= invite.MaxUses {
+		delete(rk.access.invites, invite.Code)
+	}
+	log.Printf("Invite %s used in room %s", invite.Code, rk.roomID)
+}
+
+// JoinPlayer adds a player to a protected room after checking their credentials. A ticket or
+// an invite code is used up only when the player actually takes the seat. Unprotected rooms let everyone in
+func (rk *RoomKernel) JoinPlayer(playerID string, seat domain.SeatID, conn *websocket.Conn, credentials Credentials) error {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	if !rk.protected() {
+		return rk.addPlayer(playerID, seat, conn, credentials.Reconnect)
+	}
+	
+	var t *ticket
+	var invite *Invite
+	var err error
+	if credentials.Ticket != "" {
+		t, invite, err = rk.checkTicket(seat, credentials.Ticket)
+	} else {
+		invite, err = rk.checkAccess(credentials.Password, credentials.Invite)
+	}
+	if err != nil {
+		return err
+	}
+	
+	if err := rk.addPlayer(playerID, seat, conn, credentials.Reconnect); err != nil {
+		return err
+	}
+	if t != nil {
+		t.expires = time.Time{}
+	}
+	if invite != nil {
+		rk.useInvite(invite)
+	}
+	return nil
+}
+
+// CreateInvite creates an invite code with a number of uses, 0 for no limit, and an optional lifetime
+func (rk *RoomKernel) CreateInvite(maxUses int, ttl time.Duration) (Invite, error) {
+	if maxUses < 0 || ttl < 0 {
+		return Invite{}, ErrInvalidSettings
+	}
+	
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return Invite{}, err
+	}
+	invite := &Invite{Code: inviteAlphabet.EncodeToString(buf), MaxUses: maxUses}
+	if ttl > 0 {
+		expires := time.Now().Add(ttl)
+		invite.ExpiresAt = &expires
+	}
+	rk.access.invites[invite.Code] = invite
+	return *invite, nil
+}
+
+// RevokeInvite deletes an invite code
+func (rk *RoomKernel) RevokeInvite(code string) error {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	code = strings.ToUpper(code)
+	if _, exists := rk.access.invites[code]; !exists {
+		return ErrInviteNotFound
+	}
+	delete(rk.access.invites, code)
+	return nil
+}
+
+// IssueTicket checks the password or the invite code of a player and returns a ticket for their
+// WebSocket connection. An invite is not used up here; its use is counted when the ticket takes the seat
+func (rk *RoomKernel) IssueTicket(seat domain.SeatID, credentials Credentials) (string, error) {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	t := &ticket{seat: seat}
+	if rk.protected() {
+		invite, err := rk.checkAccess(credentials.Password, credentials.Invite)
+		if err != nil {
+			return "", err
+		}
+		if invite != nil {
+			t.invite = invite.Code
+		}
+	}
+	
+	now := time.Now()
+	for token, other := range rk.access.tickets {
+		if !other.expires.IsZero() && now.After(other.expires) {
+			delete(rk.access.tickets, token)
+		}
+	}
+	t.expires = now.Add(ticketTTL)
+	token := randomToken(16)
+	rk.access.tickets[token] = t
+	return token, nil
+}
+
+// CheckTicket checks a ticket for a seat without using it
+func (rk *RoomKernel) CheckTicket(seat domain.SeatID, token string) error {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	_, _, err := rk.checkTicket(seat, token)
+	return err
+}
+
+// checkTicket returns a valid ticket for a seat and, for a ticket that has not taken its seat yet,
+// the invite whose use it still has to count. The caller must hold rk.mutex
+func (rk *RoomKernel) checkTicket(seat domain.SeatID, token string) (*ticket, *Invite, error) {
+	t, exists := rk.access.tickets[token]
+	if !exists || t.seat != seat {
+		return nil, nil, ErrAccessDenied
+	}
+	if t.expires.IsZero() {
+		return t, nil, nil
+	}
+	if time.Now().After(t.expires) {
+		delete(rk.access.tickets, token)
+		return nil, nil, ErrAccessDenied
+	}
+	if t.invite == "" {
+		return t, nil, nil
+	}
+	// The invite may have been used up or revoked since JoinRoom
+	invite, err := rk.checkAccess("", t.invite)
+	if err != nil {
+		delete(rk.access.tickets, token)
+		return nil, nil, err
+	}
+	return t, invite, nil
+}
+
+// dropTickets invalidates the tickets of a freed seat. The caller must hold rk.mutex
+func (rk *RoomKernel) dropTickets(seat domain.SeatID) {
+	for token, t := range rk.access.tickets {
+		if t.seat == seat {
+			delete(rk.access.tickets, token)
+		}
+	}
+}
+
+// moveTickets follows the players of two swapped seats. The caller must hold rk.mutex
+func (rk *RoomKernel) moveTickets(a, b domain.SeatID) {
+	for _, t := range rk.access.tickets {
+		if !t.expires.IsZero() {
+			continue
+		}
+		switch t.seat {
+		case a:
+			t.seat = b
+		case b:
+			t.seat = a
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package room
+
+import (
+	"errors"
+	"testing"
+	"time"
+	
+	"guandan/sdk/domain"
+	"guandan/sdk/service"
+)
+
+func TestRoomAccess(t *testing.T) {
+	config := DefaultRoomConfig
+	config.Visibility = VisibilityPrivate
+	rk := NewRoomKernel("room", service.NewGameService(), config)
+	t.Cleanup(rk.Stop)
+	
+	if !rk.Protected() || rk.HasPassword() {
+		t.Fatal("Expected a private room without a password")
+	}
+	if err := rk.CheckAccess("", ""); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied, got %v", err)
+	}
+	
+	// A code with two uses takes two seats; checking it and watching do not use it up
+	invite, err := rk.CreateInvite(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := rk.CheckAccess("", invite.Code); err != nil {
+			t.Fatalf("Check %d of the invite failed: %v", i+1, err)
+		}
+	}
+	credentials := Credentials{Invite: invite.Code}
+	if err := rk.JoinPlayer("p-South", domain.SeatSouth, newTestConn(t), credentials); err != nil {
+		t.Fatal(err)
+	}
+	if err := rk.JoinPlayer("p-South", domain.SeatSouth, newTestConn(t), credentials); !errors.Is(err, ErrSeatTaken) {
+		t.Fatalf("Expected ErrSeatTaken, got %v", err)
+	}
+	if err := rk.JoinPlayer("p-North", domain.SeatNorth, newTestConn(t), credentials); err != nil {
+		t.Fatalf("A failed join should not use up the invite: %v", err)
+	}
+	if err := rk.CheckAccess("", invite.Code); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected a used up invite to be refused, got %v", err)
+	}
+	expired, _ := rk.CreateInvite(0, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if err := rk.CheckAccess("", expired.Code); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected an expired invite to be refused, got %v", err)
+	}
+	if err := rk.RevokeInvite(expired.Code); !errors.Is(err, ErrInviteNotFound) {
+		t.Errorf("Expected ErrInviteNotFound, got %v", err)
+	}
+	
+	// The tickets of a single-use code count the use when the first of them takes a seat
+	single, _ := rk.CreateInvite(1, 0)
+	first, err := rk.IssueTicket(domain.SeatEast, Credentials{Invite: single.Code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := rk.IssueTicket(domain.SeatWest, Credentials{Invite: single.Code})
+	if err != nil {
+		t.Fatalf("Issuing a ticket should not use up the invite: %v", err)
+	}
+	
+	// A ticket is bound to its seat, follows the player and ends with the seat
+	if err := rk.CheckTicket(domain.SeatSouth, first); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected a ticket for another seat to be refused, got %v", err)
+	}
+	if err := rk.JoinPlayer("p-East", domain.SeatEast, newTestConn(t), Credentials{Ticket: first}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rk.CheckTicket(domain.SeatWest, second); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected the other ticket of a used up invite to be refused, got %v", err)
+	}
+	rk.RemovePlayer(domain.SeatNorth)
+	if err := lobbyRequest(rk, domain.SeatEast, "ChangeSeat", seatData(domain.SeatNorth)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rk.CheckTicket(domain.SeatNorth, first); err != nil {
+		t.Errorf("Expected the ticket to follow the player, got %v", err)
+	}
+	rk.RemovePlayer(domain.SeatNorth)
+	if err := rk.CheckTicket(domain.SeatNorth, first); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected the ticket to end with the seat, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package room
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"time"
+	
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// lobbyAgents lists the bots that can fill a seat from the lobby. External engines are
+// left out because anyone who can reach the API could start them
+var lobbyAgents = []string{"heuristic", "mc"}
+
+// AddBot fills an empty seat with a bot before the match starts. Bots are always ready,
+// and at least one seat must be left to a player
+func (rk *RoomKernel) AddBot(seat domain.SeatID, agent string) error {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	return rk.addBot(seat, agent)
+}
+
+// addBot fills an empty seat with a bot. The caller must hold rk.mutex
+func (rk *RoomKernel) addBot(seat domain.SeatID, agent string) error {
+	if agent == "" {
+		agent = lobbyAgents[0]
+	}
+	if !slices.Contains(lobbyAgents, agent) {
+		return ErrInvalidBot
+	}
+	if !seat.IsValid() {
+		return ErrInvalidSeat
+	}
+	if rk.matchID != "" {
+		return ErrGameAlreadyStarted
+	}
+	if rk.seatCount() >= rk.config.MaxPlayers {
+		return ErrRoomFull
+	}
+	if rk.seatTaken(seat) {
+		return ErrSeatTaken
+	}
+	if len(rk.players) == 0 && rk.seatCount()+1 == rk.config.MaxPlayers {
+		return ErrBotsOnly
+	}
+	
+	rk.bots[seat] = agent
+	rk.lastActivity = time.Now()
+	log.Printf("Bot %s joined room %s at seat %s", agent, rk.roomID, seat)
+	
+	rk.lobbyChanged()
+	return nil
+}
+
+// RemoveBot frees a seat taken by a bot before the match starts
+func (rk *RoomKernel) RemoveBot(seat domain.SeatID) error {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	if rk.matchID != "" {
+		return ErrGameAlreadyStarted
+	}
+	if _, exists := rk.bots[seat]; !exists {
+		return ErrBotNotFound
+	}
+	
+	delete(rk.bots, seat)
+	rk.lastActivity = time.Now()
+	log.Printf("Bot left room %s at seat %s", rk.roomID, seat)
+	
+	rk.lobbyChanged()
+	return nil
+}
+
+// startBots hands the lobby bots their seats once the match exists. The caller must hold rk.mutex
+func (rk *RoomKernel) startBots() {
+	for seat, spec := range rk.bots {
+		agent, err := newTrusteeAgent(spec)
+		if err != nil {
+			log.Printf("Failed to create bot %s for seat %s: %v", spec, seat, err)
+			continue
+		}
+		if err := rk.startAgent(seat, agent, event.ControlReasonLobby); err != nil {
+			log.Printf("Failed to start bot for seat %s: %v", seat, err)
+		}
+	}
+}
+
+// seatCount returns the number of seats taken by players and bots. The caller must hold rk.mutex
+func (rk *RoomKernel) seatCount() int {
+	return len(rk.players) + len(rk.bots)
+}
+
+// seatTaken reports whether a player or a bot sits in the seat. The caller must hold rk.mutex
+func (rk *RoomKernel) seatTaken(seat domain.SeatID) bool {
+	_, player := rk.players[seat]
+	_, bot := rk.bots[seat]
+	return player || bot
+}
+
+// botPlayerID names the domain player of a bot seat, e.g. "bot-east"
+func botPlayerID(seat domain.SeatID) string {
+	return "bot-" + strings.ToLower(seat.String())
+}
@@ -0,0 +1,92 @@
+package room
+
+import (
+	"errors"
+	"testing"
+	"time"
+	
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/service"
+)
+
+func TestLobbyBots(t *testing.T) {
+	gs := service.NewGameService()
+	rk := NewRoomKernel("room", gs, DefaultRoomConfig)
+	t.Cleanup(rk.Stop)
+	
+	if err := rk.AddBot(domain.SeatSouth, "exec:engine"); !errors.Is(err, ErrInvalidBot) {
+		t.Errorf("Expected ErrInvalidBot, got %v", err)
+	}
+	if err := rk.RemoveBot(domain.SeatSouth); !errors.Is(err, ErrBotNotFound) {
+		t.Errorf("Expected ErrBotNotFound, got %v", err)
+	}
+	for _, seat := range []domain.SeatID{domain.SeatSouth, domain.SeatWest, domain.SeatNorth} {
+		if err := rk.AddBot(seat, "heuristic"); err != nil {
+			t.Fatalf("Failed to add a bot at %s: %v", seat, err)
+		}
+	}
+	if err := rk.AddBot(domain.SeatEast, "heuristic"); !errors.Is(err, ErrBotsOnly) {
+		t.Errorf("Expected ErrBotsOnly, got %v", err)
+	}
+	if err := rk.AddBot(domain.SeatSouth, "mc"); !errors.Is(err, ErrSeatTaken) {
+		t.Errorf("Expected ErrSeatTaken, got %v", err)
+	}
+	if err := rk.AddPlayer("p-East", domain.SeatSouth, newTestConn(t), ""); !errors.Is(err, ErrSeatTaken) {
+		t.Errorf("Expected a player to be refused a bot's seat, got %v", err)
+	}
+	for _, seat := range []domain.SeatID{domain.SeatWest, domain.SeatNorth} {
+		if err := rk.RemoveBot(seat); err != nil {
+			t.Fatalf("Failed to remove the bot at %s: %v", seat, err)
+		}
+	}
+	if !rk.IsEmpty() || rk.GetPlayerCount() != 1 {
+		t.Errorf("Expected an empty room with one bot, got %d seats taken", rk.GetPlayerCount())
+	}
+	
+	// One player and three bots play the match
+	if err := rk.AddPlayer("p-East", domain.SeatEast, newTestConn(t), ""); err != nil {
+		t.Fatalf("Failed to add the player: %v", err)
+	}
+	for _, seat := range []domain.SeatID{domain.SeatWest, domain.SeatNorth} {
+		if err := rk.AddBot(seat, "heuristic"); err != nil {
+			t.Fatalf("Failed to add a bot at %s: %v", seat, err)
+		}
+	}
+	if rk.matchID != "" {
+		t.Fatal("The match started before the host asked")
+	}
+	startMatch(t, rk)
+	if err := rk.RemoveBot(domain.SeatSouth); !errors.Is(err, ErrGameAlreadyStarted) {
+		t.Errorf("Expected ErrGameAlreadyStarted, got %v", err)
+	}
+	
+	snapshot, err := rk.GetSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range snapshot.Players {
+		if bot := info.Seat != domain.SeatEast; info.Bot != bot || (info.Agent != "") != bot || info.HandCount != 27 {
+			t.Errorf("Unexpected player info %+v", info)
+		}
+	}
+	state, _ := gs.GetMatchState(rk.matchID)
+	if state.BotSeats != [4]bool{false, true, true, true} {
+		t.Errorf("Expected South, West and North played by bots, got %v", state.BotSeats)
+	}
+	
+	// The bots play on once the player hands the seat over too
+	rk.HandleMessage(domain.SeatEast, WSMessage{Type: "Trustee"})
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err := gs.GetMatchState(rk.matchID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Phase == engine.PhaseRankList || state.Phase == engine.PhaseFinished {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Bots did not finish the deal")
+}
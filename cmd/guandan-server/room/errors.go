@@ -0,0 +1,113 @@
+package room
+
+import (
+	"errors"
+	
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// Rule violation errors sent to clients when an action is rejected
+var (
+	ErrCardsNotInHand     = RoomError{"CARDS_NOT_IN_HAND", "Cards are not in your hand"}
+	ErrInvalidCombination = RoomError{"INVALID_COMBINATION", "Cards do not form a valid combination"}
+	ErrCategoryMismatch   = RoomError{"CATEGORY_MISMATCH", "Must follow with the same combination type"}
+	ErrSizeMismatch       = RoomError{"SIZE_MISMATCH", "Must follow with the same number of cards"}
+	ErrTooWeak            = RoomError{"TOO_WEAK", "Cards cannot beat the current play"}
+	ErrWrongPhase         = RoomError{"WRONG_PHASE", "Action is not allowed in the current phase"}
+	ErrLeaderCannotPass   = RoomError{"LEADER_CANNOT_PASS", "Trick leader must play"}
+)
+
+// DefaultLocale is used when a client does not ask for a language
+const DefaultLocale = "en"
+
+// localizedMessages holds client-facing messages by error code and locale
+var localizedMessages = map[string]map[string]string{
+	"zh": {
+		"ROOM_FULL":            "房间已满",
+		"ROOM_NOT_FOUND":       "房间不存在",
+		"PLAYER_NOT_FOUND":     "玩家不存在",
+		"PLAYER_DISCONNECTED":  "玩家已断线",
+		"INVALID_SEAT":         "座位无效",
+		"SEAT_TAKEN":           "座位已被占用",
+		"GAME_NOT_STARTED":     "游戏尚未开始",
+		"GAME_ALREADY_STARTED": "游戏已经开始",
+		"INVALID_ACTION":       "操作无效",
+		"NOT_PLAYER_TURN":      "还没轮到你",
+		"DEAL_NOT_FOUND":       "牌局不存在",
+		"BOT_CONTROL":          "托管中，请先取消托管",
+		"BOT_NOT_FOUND":        "该座位没有机器人",
+		"INVALID_BOT":          "未知的机器人，请选择 heuristic 或 mc",
+		"BOTS_ONLY":            "房间至少需要一名玩家",
+		"NOT_HOST":             "只有房主可以这样做",
+		"KICKED":               "你已被房主移出房间",
+		"NOT_READY":            "四个座位都坐满并准备后才能开始",
+		"INVALID_SETTINGS":     "房间设置无效",
+		"ACCESS_DENIED":        "密码、邀请码或入场凭证错误",
+		"INVITE_NOT_FOUND":     "邀请码不存在",
+		"TOO_MANY_ATTEMPTS":    "失败次数过多，请稍后再试",
+		"SPECTATORS_OFF":       "本房间不允许观战",
+		"TOO_MANY_SPECTATORS":  "观战人数已满",
+		"CARDS_NOT_IN_HAND":    "手中没有这些牌",
+		"INVALID_COMBINATION":  "这些牌不构成牌型",
+		"CATEGORY_MISMATCH":    "必须出相同牌型",
+		"SIZE_MISMATCH":        "必须出相同张数",
+		"TOO_WEAK":             "压不过桌面上的牌",
+		"WRONG_PHASE":          "当前阶段不能执行此操作",
+		"LEADER_CANNOT_PASS":   "首家必须出牌",
+	},
+}
+
+// Localize returns the error with its message in the given locale, falling back to English
+func (e RoomError) Localize(locale string) RoomError {
+	if message, ok := localizedMessages[locale][e.Code]; ok {
+		e.Message = message
+	}
+	return e
+}
+
+// ToRoomError maps an error from the game service to a stable RoomError code
+func ToRoomError(err error) RoomError {
+	var roomErr RoomError
+	switch {
+	case errors.As(err, &roomErr):
+		return roomErr
+	case errors.Is(err, engine.ErrNotYourTurn):
+		return ErrNotPlayerTurn
+	case errors.Is(err, engine.ErrLeaderCannotPass):
+		return ErrLeaderCannotPass
+	case errors.Is(err, &engine.ErrWrongPhase{}):
+		return ErrWrongPhase
+	case errors.Is(err, &domain.ErrCardsNotInHand{}):
+		return ErrCardsNotInHand
+	case errors.Is(err, &domain.ErrInvalidCombination{}):
+		return ErrInvalidCombination
+	case errors.Is(err, &domain.ErrCategoryMismatch{}):
+		return ErrCategoryMismatch
+	case errors.Is(err, &domain.ErrSizeMismatch{}):
+		return ErrSizeMismatch
+	case errors.Is(err, &domain.ErrTooWeak{}):
+		return ErrTooWeak
+	default:
+		return ErrInvalidAction
+	}
+}
+
+// ErrorMessage is sent to a client whose request failed
+type ErrorMessage struct {
+	Type   string `json:"t"`
+	Code   string `json:"code"`
+	Error  string `json:"error"`            // localized message
+	Detail string `json:"detail,omitempty"` // original error, for logs and debugging
+}
+
+// NewErrorMessage builds the error message for err in the client's locale
+func NewErrorMessage(err error, locale string) ErrorMessage {
+	roomErr := ToRoomError(err).Localize(locale)
+	return ErrorMessage{
+		Type:   "Error",
+		Code:   roomErr.Code,
+		Error:  roomErr.Message,
+		Detail: err.Error(),
+	}
+}
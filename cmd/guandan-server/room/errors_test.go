@@ -0,0 +1,52 @@
+package room
+
+import (
+	"fmt"
+	"testing"
+
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+func TestToRoomError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{fmt.Errorf("failed to play cards: %w", engine.ErrNotYourTurn), "NOT_PLAYER_TURN"},
+		{engine.ErrLeaderCannotPass, "LEADER_CANNOT_PASS"},
+		{&engine.ErrWrongPhase{Phase: engine.PhaseTribute, Op: "play cards"}, "WRONG_PHASE"},
+		{&domain.ErrCardsNotInHand{}, "CARDS_NOT_IN_HAND"},
+		{&domain.ErrInvalidCombination{Reason: "no cards"}, "INVALID_COMBINATION"},
+		{&domain.ErrCategoryMismatch{Want: domain.Pair, Got: domain.Single}, "CATEGORY_MISMATCH"},
+		{fmt.Errorf("failed to play cards: %w", &domain.ErrSizeMismatch{Want: 6, Got: 5}), "SIZE_MISMATCH"},
+		{&domain.ErrTooWeak{}, "TOO_WEAK"},
+		{ErrSeatTaken, "SEAT_TAKEN"},
+		{fmt.Errorf("match not found"), "INVALID_ACTION"},
+	}
+	
+	for _, tt := range tests {
+		if code := ToRoomError(tt.err).Code; code != tt.expected {
+			t.Errorf("Expected code %s for %v, got %s", tt.expected, tt.err, code)
+		}
+	}
+}
+
+func TestNewErrorMessageLocalized(t *testing.T) {
+	err := fmt.Errorf("failed to play cards: %w", &domain.ErrTooWeak{})
+	
+	msg := NewErrorMessage(err, "zh")
+	if msg.Type != "Error" || msg.Code != "TOO_WEAK" {
+		t.Errorf("Expected Error message with TOO_WEAK, got %+v", msg)
+	}
+	if msg.Error != "压不过桌面上的牌" {
+		t.Errorf("Expected Chinese message, got %s", msg.Error)
+	}
+	if msg.Detail != err.Error() {
+		t.Errorf("Expected original error as detail, got %s", msg.Detail)
+	}
+	
+	if msg := NewErrorMessage(err, "fr"); msg.Error != ErrTooWeak.Message {
+		t.Errorf("Unknown locale should fall back to English, got %s", msg.Error)
+	}
+}
@@ -0,0 +1,68 @@
+package room
+
+import (
+	"testing"
+
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/service"
+)
+
+// hintService serves fixed suggestions; other GameService methods are not used
+type hintService struct {
+	service.GameService
+	suggestions []bot.Suggestion
+}
+
+func (s *hintService) SuggestPlays(matchID domain.MatchID, seat domain.SeatID) ([]bot.Suggestion, error) {
+	return s.suggestions, nil
+}
+
+func TestHintCyclesUntilStateChanges(t *testing.T) {
+	suggestions := []bot.Suggestion{
+		{Action: bot.Play([]domain.Card{domain.NewCard(domain.Hearts, domain.Five)}), Score: 3, Reason: bot.ReasonSmallestBeat},
+		{Action: bot.Play([]domain.Card{domain.NewCard(domain.Spades, domain.King)}), Score: 2, Reason: bot.ReasonKeepsBombs},
+		{Action: bot.Pass(), Score: 1, Reason: bot.ReasonSaveBombs},
+	}
+	rk := NewRoomKernel("room", &hintService{suggestions: suggestions}, DefaultRoomConfig)
+	rk.matchID = "match"
+	player := &PlayerConn{PlayerID: "p1", Seat: domain.SeatEast}
+	
+	var indexes []int
+	for i := 0; i < 4; i++ {
+		rk.handleHint(player)
+		indexes = append(indexes, rk.hintCursors[domain.SeatEast].index)
+	}
+	if expected := []int{0, 1, 2, 0}; !equalInts(indexes, expected) {
+		t.Errorf("Expected hint indexes %v, got %v", expected, indexes)
+	}
+	
+	rk.version++
+	rk.handleHint(player)
+	if index := rk.hintCursors[domain.SeatEast].index; index != 0 {
+		t.Errorf("Expected hints to restart after a state change, got index %d", index)
+	}
+	
+	result := newHintResult(suggestions, 1, rk.version)
+	if result.Type != "HintResult" || result.Index != 1 || len(result.Suggestions) != 3 {
+		t.Fatalf("Unexpected hint result: %+v", result)
+	}
+	if pass := result.Suggestions[2]; !pass.Pass || len(pass.Cards) != 0 || pass.Reason != "save_bombs" {
+		t.Errorf("Expected a pass suggestion, got %+v", pass)
+	}
+	if cards := result.Suggestions[0].Cards; len(cards) != 1 || cards[0] != domain.NewCard(domain.Hearts, domain.Five).String() {
+		t.Errorf("Expected the five of hearts, got %v", cards)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -6,11 +6,14 @@ import (
 	"log"
 	"sync"
 	"time"
-
+	
 	"github.com/gorilla/websocket"
+	"guandan/sdk/bot"
 	"guandan/sdk/domain"
+	"guandan/sdk/engine"
 	"guandan/sdk/event"
 	"guandan/sdk/service"
+	"guandan/sdk/tracker"
 )
 
 // RoomKernel manages a single room with multiple players
@@ -26,12 +29,37 @@ type RoomKernel struct {
 	ctx          context.Context
 	cancel       context.CancelFunc
 	lastActivity time.Time
+	hintCursors  map[domain.SeatID]hintCursor
+	tracker      *tracker.Tracker // nil unless the room enables the card tracker
+	archive      *dealArchive     // records of finished deals, nil before the match is created
+	trustees     map[domain.SeatID]*trustee      // seats played by the bot (托管)
+	takeovers    map[domain.SeatID]*time.Timer   // pending takeovers of disconnected seats
+	bots         map[domain.SeatID]string        // seats filled with bots from the lobby, by agent name
+	host         domain.SeatID                   // seat of the player who starts the match, noHost in an empty room
+	ready        map[domain.SeatID]bool          // players ready in the lobby
+	swaps        map[domain.SeatID]domain.SeatID // pending seat swap requests, from the asking seat
+	access       *roomAccess                     // password, invite codes and join tickets
+	spectators   *spectatorHub                   // read-only connections; they do not take seats
+	dealTimer    *time.Timer                     // deals the next hand once the entropy window closes
+	dealsEnded   int                             // last deal whose end scheduled the next one
+	kicked       map[string]bool                 // client addresses of kicked players; they cannot join again
+	lobbyOut     lobbyOutbox                     // sends LobbyUpdated messages in order
+}
+
+// hintCursor remembers which suggestion a seat saw last so repeated hints cycle
+type hintCursor struct {
+	version int
+	index   int
 }
 
 // NewRoomKernel creates a new room kernel
 func NewRoomKernel(roomID string, gameService service.GameService, config RoomConfig) *RoomKernel {
 	ctx, cancel := context.WithCancel(context.Background())
 	
+	// Only the password hash is kept
+	access := newRoomAccess(config.Password)
+	config.Password = ""
+	
 	return &RoomKernel{
 		roomID:       roomID,
 		gameService:  gameService,
@@ -41,6 +69,16 @@ func NewRoomKernel(roomID string, gameService service.GameService, config RoomCo
 		ctx:          ctx,
 		cancel:       cancel,
 		lastActivity: time.Now(),
+		hintCursors:  make(map[domain.SeatID]hintCursor),
+		trustees:     make(map[domain.SeatID]*trustee),
+		takeovers:    make(map[domain.SeatID]*time.Timer),
+		bots:         make(map[domain.SeatID]string),
+		host:         noHost,
+		ready:        make(map[domain.SeatID]bool),
+		swaps:        make(map[domain.SeatID]domain.SeatID),
+		kicked:       make(map[string]bool),
+		access:       access,
+		spectators:   newSpectatorHub(config.Spectators),
 	}
 }
 
@@ -66,6 +104,18 @@ func (rk *RoomKernel) Stop() {
 	for _, player := range rk.players {
 		player.Close()
 	}
+	rk.spectators.stop()
+	
+	// Stop the bots, pending takeovers and the pending deal
+	if rk.dealTimer != nil {
+		rk.dealTimer.Stop()
+	}
+	for _, timer := range rk.takeovers {
+		timer.Stop()
+	}
+	for _, t := range rk.trustees {
+		closeAgent(t.agent)
+	}
 	
 	// Unsubscribe from events
 	if rk.eventSub != nil {
@@ -73,47 +123,100 @@ func (rk *RoomKernel) Stop() {
 	}
 }
 
-// AddPlayer adds a player to the room
-func (rk *RoomKernel) AddPlayer(playerID string, seat domain.SeatID, conn *websocket.Conn) error {
+// AddPlayer adds a player to the room. A seat kept for a disconnected player is taken back
+// only with the reconnect token the player got when joining
+func (rk *RoomKernel) AddPlayer(playerID string, seat domain.SeatID, conn *websocket.Conn, reconnect string) error {
 	rk.mutex.Lock()
 	defer rk.mutex.Unlock()
 	
+	return rk.addPlayer(playerID, seat, conn, reconnect)
+}
+
+// addPlayer adds a player to the room. The caller must hold rk.mutex
+func (rk *RoomKernel) addPlayer(playerID string, seat domain.SeatID, conn *websocket.Conn, reconnect string) error {
+	// A player coming back to a seat kept for them takes it over again
+	if existing, exists := rk.players[seat]; exists && !existing.IsConnected() {
+		if !existing.canReconnect(reconnect) {
+			return ErrSeatTaken
+		}
+		rk.reconnectPlayer(existing, NewPlayerConn(playerID, seat, conn))
+		return nil
+	}
+	
+	// Kicked players stay out
+	if rk.kicked[connAddress(conn)] {
+		return ErrKicked
+	}
+	
 	// Check if room is full
-	if len(rk.players) >= rk.config.MaxPlayers {
+	if rk.seatCount() >= rk.config.MaxPlayers {
 		return ErrRoomFull
 	}
 	
 	// Check if seat is taken
-	if _, exists := rk.players[seat]; exists {
+	if rk.seatTaken(seat) {
 		return ErrSeatTaken
 	}
 	
 	// Create player connection
 	playerConn := NewPlayerConn(playerID, seat, conn)
+	playerConn.Reconnect = randomToken(16)
 	rk.players[seat] = playerConn
 	
 	// Update activity
 	rk.lastActivity = time.Now()
 	
-	// If we have all players, create the match
-	log.Printf("Room %s now has %d/%d players", rk.roomID, len(rk.players), rk.config.MaxPlayers)
-	if len(rk.players) == rk.config.MaxPlayers {
-		log.Printf("Room %s is full, creating match...", rk.roomID)
-		err := rk.createMatch()
-		if err != nil {
-			log.Printf("Failed to create match for room %s: %v", rk.roomID, err)
-			return err
-		}
-		log.Printf("Match created successfully for room %s", rk.roomID)
-	}
+	// The match starts when the host asks for it once everyone is ready
+	log.Printf("Room %s now has %d/%d players", rk.roomID, rk.seatCount(), rk.config.MaxPlayers)
+	rk.joinLobby(seat)
 	
-	// Send current state to new player
-	go rk.sendSnapshotToPlayer(playerConn)
+	// Send the reconnect token and the current state to the new player
+	go rk.welcomePlayer(playerConn, JoinedMessage{Type: "Joined", Seat: seat, Reconnect: playerConn.Reconnect})
 	
 	log.Printf("Player %s joined room %s at seat %s", playerID, rk.roomID, seat)
 	return nil
 }
 
+// reconnectPlayer replaces a disconnected player's connection and gives the seat back from the bot.
+// The caller must hold rk.mutex
+func (rk *RoomKernel) reconnectPlayer(previous, playerConn *PlayerConn) {
+	seat := playerConn.Seat
+	playerConn.Locale = previous.Locale
+	playerConn.Reconnect = previous.Reconnect
+	rk.players[seat] = playerConn
+	rk.lastActivity = time.Now()
+	
+	if timer, exists := rk.takeovers[seat]; exists {
+		timer.Stop()
+		delete(rk.takeovers, seat)
+	}
+	if err := rk.stopTrustee(seat, event.ControlReasonReconnect); err != nil {
+		log.Printf("Failed to return seat %s to its player: %v", seat, err)
+	}
+	
+	go rk.sendSnapshotToPlayer(playerConn)
+	log.Printf("Player %s reconnected to room %s at seat %s", playerConn.PlayerID, rk.roomID, seat)
+}
+
+// CanReconnect reports whether a seat is kept for a disconnected player whose reconnect token is token
+func (rk *RoomKernel) CanReconnect(seat domain.SeatID, token string) bool {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	player, exists := rk.players[seat]
+	return exists && !player.IsConnected() && player.canReconnect(token)
+}
+
+// SetPlayerLocale sets the language used for a player's error messages
+func (rk *RoomKernel) SetPlayerLocale(seat domain.SeatID, locale string) {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	if player, exists := rk.players[seat]; exists && locale != "" {
+		player.Locale = locale
+	}
+}
+
 // RemovePlayer removes a player from the room
 func (rk *RoomKernel) RemovePlayer(seat domain.SeatID) {
 	rk.mutex.Lock()
@@ -123,9 +226,36 @@ func (rk *RoomKernel) RemovePlayer(seat domain.SeatID) {
 		player.Close()
 		delete(rk.players, seat)
 		log.Printf("Player %s left room %s", player.PlayerID, rk.roomID)
+		
+		if rk.matchID == "" {
+			rk.leaveLobby(seat)
+			rk.lobbyChanged()
+		}
 	}
 }
 
+// DisconnectPlayer handles a closed connection. Once the match has started the seat is kept so the
+// player can reconnect, and the bot takes it over after TrusteeDelay; before that the seat is freed
+func (rk *RoomKernel) DisconnectPlayer(seat domain.SeatID, conn *websocket.Conn) {
+	rk.mutex.Lock()
+	player, exists := rk.players[seat]
+	if !exists || player.Conn != conn {
+		// The player has already reconnected on another connection
+		rk.mutex.Unlock()
+		return
+	}
+	if rk.matchID == "" || !rk.config.AllowReconnect {
+		rk.mutex.Unlock()
+		rk.RemovePlayer(seat)
+		return
+	}
+	defer rk.mutex.Unlock()
+	
+	player.Close()
+	rk.scheduleTrustee(seat, player)
+	log.Printf("Player %s disconnected from room %s, keeping seat %s", player.PlayerID, rk.roomID, seat)
+}
+
 // HandleMessage handles a message from a player
 func (rk *RoomKernel) HandleMessage(seat domain.SeatID, msg WSMessage) {
 	rk.mutex.Lock()
@@ -140,28 +270,58 @@ func (rk *RoomKernel) HandleMessage(seat domain.SeatID, msg WSMessage) {
 		return
 	}
 	
+	// Moves are the bot's while it plays the seat
+	if _, bot := rk.trustees[seat]; bot && (msg.Type == "PlayCards" || msg.Type == "Pass") {
+		player.Send(NewErrorMessage(ErrSeatUnderBotControl, player.Locale))
+		return
+	}
+	
+	if rk.handleLobby(player, msg) {
+		return
+	}
+	
 	// Handle different message types
 	switch msg.Type {
 	case "PlayCards":
-		rk.handlePlayCards(player, msg)
+		cards, err := parsePlayCards(msg)
+		if err != nil {
+			log.Printf("Invalid cards: %v", err)
+			player.Send(NewErrorMessage(err, player.Locale))
+			return
+		}
+		rk.handleAction(player, bot.Play(cards))
 	case "Pass":
-		rk.handlePass(player, msg)
+		rk.handleAction(player, bot.Pass())
+	case "Trustee":
+		rk.handleTrustee(player, msg)
+	case "Entropy":
+		rk.handleEntropy(player, msg)
+	case "Hint":
+		rk.handleHint(player)
 	default:
 		log.Printf("Unknown message type: %s", msg.Type)
 	}
 }
 
-// GetSnapshot returns the current game state snapshot
+// GetSnapshot returns the public game state snapshot, without any hand
 func (rk *RoomKernel) GetSnapshot() (*MatchSnapshot, error) {
 	rk.mutex.RLock()
 	defer rk.mutex.RUnlock()
 	
+	return rk.snapshotFor(noSeat)
+}
+
+// snapshotFor returns the snapshot a seat may see: the public state and its own hand.
+// The caller must hold rk.mutex
+func (rk *RoomKernel) snapshotFor(seat domain.SeatID) (*MatchSnapshot, error) {
 	if rk.matchID == "" {
 		return &MatchSnapshot{
-			MatchID: rk.roomID,
-			Players: rk.getPlayersInfo(),
-			Status:  "waiting",
-			Version: rk.version,
+			MatchID:    rk.roomID,
+			Players:    rk.getPlayersInfo(),
+			Status:     "waiting",
+			Version:    rk.version,
+			Lobby:      rk.lobbyState(),
+			Spectators: rk.spectators.count(),
 		}, nil
 	}
 	
@@ -173,10 +333,11 @@ func (rk *RoomKernel) GetSnapshot() (*MatchSnapshot, error) {
 	
 	// Convert to snapshot
 	snapshot := &MatchSnapshot{
-		MatchID: rk.roomID,
-		Players: rk.getPlayersInfo(),
-		Status:  "playing",
-		Version: rk.version,
+		MatchID:    rk.roomID,
+		Players:    rk.getPlayersInfo(),
+		Status:     "playing",
+		Version:    rk.version,
+		Spectators: rk.spectators.count(),
 	}
 	
 	if matchState.CurrentDeal > 0 {
@@ -190,7 +351,8 @@ func (rk *RoomKernel) GetSnapshot() (*MatchSnapshot, error) {
 				CurrentTurn: domain.SeatEast, // Default, would need to get from trick context
 				TablePlay:   nil,            // Would need to get from trick context
 				LastPlayer:  domain.SeatEast, // Default
-				PlayerHands: serviceSnapshot.Hands,
+				PlayerHands: handsView(serviceSnapshot.Hands, seat),
+				ShuffleCommitment: serviceSnapshot.DealCtx.ShuffleCommitment,
 			}
 		}
 	}
@@ -198,38 +360,51 @@ func (rk *RoomKernel) GetSnapshot() (*MatchSnapshot, error) {
 	return snapshot, nil
 }
 
-// GetPlayerCount returns the number of players in the room
+// GetPlayerCount returns the number of taken seats in the room, bots included
 func (rk *RoomKernel) GetPlayerCount() int {
 	rk.mutex.RLock()
 	defer rk.mutex.RUnlock()
-	return len(rk.players)
+	return rk.seatCount()
 }
 
-// IsEmpty returns true if the room is empty
+// IsEmpty returns true if no player is in the room; bots alone do not keep it open
 func (rk *RoomKernel) IsEmpty() bool {
-	return rk.GetPlayerCount() == 0
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	return len(rk.players) == 0
 }
 
 // Private methods
 
 func (rk *RoomKernel) createMatch() error {
 	// Create players array
-	players := make([]*domain.Player, 0, len(rk.players))
+	players := make([]*domain.Player, 0, rk.seatCount())
 	for seat, playerConn := range rk.players {
 		player := domain.NewPlayer(playerConn.PlayerID, playerConn.PlayerID, seat)
 		players = append(players, player)
 	}
+	for seat := range rk.bots {
+		players = append(players, domain.NewPlayer(botPlayerID(seat), botPlayerID(seat), seat))
+	}
 	
 	// Create match
+	targetLevel := rk.config.TargetLevel
 	matchID, err := rk.gameService.CreateMatch(players, &service.MatchOptions{
-		DealLimit: 0,
-		Seed:      time.Now().UnixNano(),
+		DealLimit:    0,
+		Seed:         service.RandomSeed(),
+		ProvablyFair: true,
+		TurnTimer:    rk.config.TurnTimer,
+		TargetLevel:  &targetLevel,
 	})
 	if err != nil {
 		return err
 	}
 	
 	rk.matchID = matchID
+	if rk.config.CardTracker {
+		rk.tracker = tracker.New()
+	}
+	rk.archive = newDealArchive(matchID, players, rk.config.Analysis)
 	
 	// Subscribe to match events
 	rk.eventSub, err = rk.gameService.Subscribe(matchID, rk.handleGameEvent)
@@ -237,122 +412,274 @@ func (rk *RoomKernel) createMatch() error {
 		return fmt.Errorf("failed to subscribe to match events: %w", err)
 	}
 	
-	// Start first deal
-	log.Printf("Starting first deal for match %s", matchID)
-	err = rk.gameService.StartNextDeal(matchID)
-	if err != nil {
-		log.Printf("Failed to start deal: %v", err)
-		return err
+	// The commitment was published before the room subscribed; pass it on so seats can add entropy
+	if dealNumber, commitment, err := rk.gameService.GetShuffleCommitment(matchID); err == nil && commitment != "" {
+		go rk.handleGameEvent(event.NewShuffleCommittedEvent(matchID, dealNumber, commitment))
 	}
-	log.Printf("First deal started successfully for match %s", matchID)
 	
-	// Send updated snapshot to all players with dealt cards
-	go func() {
-		snapshot, err := rk.GetSnapshot()
-		if err != nil {
-			log.Printf("Failed to get snapshot after deal: %v", err)
+	return rk.scheduleDeal()
+}
+
+// scheduleDeal deals the next hand once the entropy window closes, or straight away without one.
+// The caller must hold rk.mutex
+func (rk *RoomKernel) scheduleDeal() error {
+	if rk.config.EntropyWindow <= 0 {
+		return rk.startDeal()
+	}
+	
+	log.Printf("Dealing match %s in %s", rk.matchID, rk.config.EntropyWindow)
+	rk.dealTimer = time.AfterFunc(rk.config.EntropyWindow, func() {
+		rk.mutex.Lock()
+		defer rk.mutex.Unlock()
+		if rk.ctx.Err() != nil {
 			return
 		}
-		
-		snapshotMsg := SnapshotMessage{
-			Type:    "Snapshot",
-			Version: snapshot.Version,
-			Payload: snapshot,
+		if err := rk.startDeal(); err != nil {
+			log.Printf("Failed to start deal for room %s: %v", rk.roomID, err)
 		}
-		
-		log.Printf("Broadcasting updated snapshot with dealt cards to all players")
-		rk.broadcastMessage(snapshotMsg)
-	}()
-	
+	})
 	return nil
 }
 
-func (rk *RoomKernel) handlePlayCards(player *PlayerConn, msg WSMessage) {
-	if rk.matchID == "" {
-		return
+// startDeal deals the next hand; on the first deal the lobby bots take their seats. The caller must hold rk.mutex
+func (rk *RoomKernel) startDeal() error {
+	if err := rk.gameService.StartNextDeal(rk.matchID); err != nil {
+		log.Printf("Failed to start deal: %v", err)
+		return err
 	}
 	
-	// Parse cards from message
+	state, err := rk.gameService.GetMatchState(rk.matchID)
+	if err != nil {
+		return err
+	}
+	log.Printf("Deal %d started for match %s", state.CurrentDeal, rk.matchID)
+	
+	// Let the bots play their seats; they stay on for the following deals
+	if state.CurrentDeal == 1 {
+		rk.startBots()
+	}
+	
+	// Send updated snapshot to all players with dealt cards
+	go rk.broadcastSnapshot()
+	
+	return nil
+}
+
+// parsePlayCards reads the cards of a PlayCards message
+func parsePlayCards(msg WSMessage) ([]domain.Card, error) {
 	var playMsg PlayCardsMessage
 	if data, ok := msg.Data.(map[string]interface{}); ok {
 		if cardsData, ok := data["cards"].([]interface{}); ok {
-			cards := make([]domain.Card, len(cardsData))
-			for i, cardStr := range cardsData {
-				if str, ok := cardStr.(string); ok {
-					card, err := domain.ParseCard(str)
-					if err != nil {
-						log.Printf("Invalid card: %s", str)
-						return
-					}
-					cards[i] = card
+			for _, cardData := range cardsData {
+				if str, ok := cardData.(string); ok {
+					playMsg.Cards = append(playMsg.Cards, str)
 				}
 			}
-			playMsg.Cards = make([]string, len(cards))
-			for i, card := range cards {
-				playMsg.Cards[i] = card.String()
-			}
 		}
 	}
 	
-	// Convert string cards to domain cards
 	cards := make([]domain.Card, len(playMsg.Cards))
 	for i, cardStr := range playMsg.Cards {
 		card, err := domain.ParseCard(cardStr)
 		if err != nil {
-			log.Printf("Invalid card: %s", cardStr)
-			return
+			return nil, err
 		}
 		cards[i] = card
 	}
+	return cards, nil
+}
+
+// handleAction applies a player's move through the same path as the bots
+func (rk *RoomKernel) handleAction(player *PlayerConn, action bot.Action) {
+	if rk.matchID == "" {
+		return
+	}
 	
-	// Play cards
-	err := rk.gameService.PlayCards(rk.matchID, player.Seat, cards)
+	ended, err := rk.applyAction(rk.matchID, player.Seat, action)
 	if err != nil {
-		log.Printf("Failed to play cards: %v", err)
-		// Send error to player
-		errorMsg := map[string]interface{}{
-			"t":     "Error",
-			"error": err.Error(),
+		log.Printf("Failed to apply %s for %s: %v", action.Kind, player.Seat, err)
+		player.Send(NewErrorMessage(err, player.Locale))
+		return
+	}
+	rk.scheduleNextDeal(ended)
+}
+
+// applyAction applies a player's or a trustee's move. It returns the number of the deal the move
+// ended when the match goes on, 0 otherwise
+func (rk *RoomKernel) applyAction(matchID domain.MatchID, seat domain.SeatID, action bot.Action) (int, error) {
+	if err := rk.gameService.ApplyBotAction(matchID, seat, action); err != nil {
+		return 0, err
+	}
+	return rk.endedDeal(matchID), nil
+}
+
+// endedDeal returns the number of the deal that has just ended when the match goes on, 0 otherwise
+func (rk *RoomKernel) endedDeal(matchID domain.MatchID) int {
+	state, err := rk.gameService.GetMatchState(matchID)
+	if err != nil || state.IsFinished || state.Phase != engine.PhaseFinished {
+		return 0
+	}
+	return state.CurrentDeal
+}
+
+func (rk *RoomKernel) handleEntropy(player *PlayerConn, msg WSMessage) {
+	if rk.matchID == "" {
+		return
+	}
+	
+	var entropyMsg EntropyMessage
+	if data, ok := msg.Data.(map[string]interface{}); ok {
+		if entropy, ok := data["entropy"].(string); ok {
+			entropyMsg.Entropy = entropy
 		}
-		player.Send(errorMsg)
+	}
+	
+	err := rk.gameService.ContributeEntropy(rk.matchID, player.Seat, []byte(entropyMsg.Entropy))
+	if err != nil {
+		log.Printf("Failed to contribute entropy: %v", err)
+		player.Send(NewErrorMessage(err, player.Locale))
 	}
 }
 
-func (rk *RoomKernel) handlePass(player *PlayerConn, msg WSMessage) {
+func (rk *RoomKernel) handleHint(player *PlayerConn) {
 	if rk.matchID == "" {
 		return
 	}
 	
-	// Pass
-	err := rk.gameService.Pass(rk.matchID, player.Seat)
+	suggestions, err := rk.gameService.SuggestPlays(rk.matchID, player.Seat)
 	if err != nil {
-		log.Printf("Failed to pass: %v", err)
-		// Send error to player
-		errorMsg := map[string]interface{}{
-			"t":     "Error",
-			"error": err.Error(),
+		log.Printf("Failed to suggest plays: %v", err)
+		player.Send(NewErrorMessage(err, player.Locale))
+		return
+	}
+	
+	index := 0
+	if cursor, ok := rk.hintCursors[player.Seat]; ok && cursor.version == rk.version && len(suggestions) > 0 {
+		index = (cursor.index + 1) % len(suggestions)
+	}
+	rk.hintCursors[player.Seat] = hintCursor{version: rk.version, index: index}
+	
+	player.Send(newHintResult(suggestions, index, rk.version))
+}
+
+func newHintResult(suggestions []bot.Suggestion, index, version int) HintResultMessage {
+	result := HintResultMessage{
+		Type:        "HintResult",
+		Index:       index,
+		Suggestions: make([]HintSuggestion, len(suggestions)),
+		Version:     version,
+	}
+	for i, suggestion := range suggestions {
+		cards := make([]string, len(suggestion.Action.Cards))
+		for j, card := range suggestion.Action.Cards {
+			cards[j] = card.String()
+		}
+		result.Suggestions[i] = HintSuggestion{
+			Cards:  cards,
+			Pass:   suggestion.Action.Kind == bot.ActionPass,
+			Reason: string(suggestion.Reason),
+			Score:  suggestion.Score,
 		}
-		player.Send(errorMsg)
 	}
+	return result
 }
 
-func (rk *RoomKernel) handleGameEvent(event event.DomainEvent) {
-	// Increment version
+func (rk *RoomKernel) handleGameEvent(e event.DomainEvent) {
+	// Increment version; bot moves arrive while players read the snapshot
+	rk.mutex.Lock()
 	rk.version++
+	version := rk.version
+	rk.mutex.Unlock()
+	
+	// Every player gets their own view of the event; spectators get theirs later
+	rk.broadcastEvent(e, version)
+	rk.spectators.handle(e, version)
 	
-	// Create event message
-	eventMsg := EventMessage{
-		Type:    "Event",
-		Event:   event.EventType(),
-		Data:    event,
-		Version: rk.version,
+	if rk.tracker != nil && rk.tracker.Handle(e) {
+		rk.sendTrackerReports()
+	}
+	if rk.archive != nil {
+		rk.archive.handle(e)
+	}
+	// Timeouts are applied by the engine; the ping routine catches a dropped event
+	if _, ok := e.(*event.TurnTimerExpiredEvent); ok {
+		rk.mutex.Lock()
+		rk.checkDealEnd()
+		rk.mutex.Unlock()
 	}
 	
-	// Debug log
-	log.Printf("Broadcasting event: %s with data: %+v", event.EventType(), event)
+	rk.runTrustees()
+}
+
+// scheduleNextDeal schedules the deal after the one that has ended, once per deal; 0 means no deal
+// has ended. The caller must hold rk.mutex
+func (rk *RoomKernel) scheduleNextDeal(ended int) {
+	if ended <= rk.dealsEnded || rk.ctx.Err() != nil {
+		return
+	}
+	rk.dealsEnded = ended
+	if err := rk.scheduleDeal(); err != nil {
+		log.Printf("Failed to schedule the next deal for room %s: %v", rk.roomID, err)
+	}
+}
+
+// checkDealEnd schedules the next deal after a deal the room did not end itself, such as by a
+// turn timeout. The caller must hold rk.mutex
+func (rk *RoomKernel) checkDealEnd() {
+	if rk.matchID == "" {
+		return
+	}
+	rk.scheduleNextDeal(rk.endedDeal(rk.matchID))
+}
+
+// sendTrackerReports sends every seat its own card tracker view
+func (rk *RoomKernel) sendTrackerReports() {
+	snapshot, err := rk.gameService.GetSnapshot(rk.matchID)
+	if err != nil {
+		log.Printf("Failed to get snapshot for card tracker: %v", err)
+		return
+	}
+	
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
 	
-	// Broadcast to all players
-	rk.broadcastMessage(eventMsg)
+	for seat, player := range rk.players {
+		if !player.IsConnected() {
+			continue
+		}
+		report := rk.tracker.Report(seat, snapshot.Hands[seat])
+		if err := player.Send(newTrackerMessage(report, rk.version)); err != nil {
+			log.Printf("Failed to send card tracker to player %s: %v", player.PlayerID, err)
+		}
+	}
+}
+
+func newTrackerMessage(report *tracker.Report, version int) TrackerMessage {
+	msg := TrackerMessage{
+		Type:          "Tracker",
+		Version:       version,
+		Remaining:     make(map[string]int, len(report.Remaining)),
+		HandCounts:    report.HandCounts,
+		Known:         make(map[string][]string, len(report.Known)),
+		Bombs:         make([]string, len(report.Bombs)),
+		JokerBomb:     report.JokerBomb,
+		StraightFlush: report.StraightFlushPossible(),
+	}
+	for rank, count := range report.Remaining {
+		msg.Remaining[rank.String()] = count
+	}
+	for seat, cards := range report.Known {
+		for _, card := range cards {
+			msg.Known[seat.String()] = append(msg.Known[seat.String()], card.String())
+		}
+	}
+	for i, rank := range report.Bombs {
+		msg.Bombs[i] = rank.String()
+	}
+	for _, sf := range report.StraightFlushes {
+		msg.StraightFlushes = append(msg.StraightFlushes, fmt.Sprintf("%s%s-%s", sf.Suit, sf.High-4, sf.High))
+	}
+	return msg
 }
 
 func (rk *RoomKernel) broadcastMessage(msg interface{}) {
@@ -369,8 +696,58 @@ func (rk *RoomKernel) broadcastMessage(msg interface{}) {
 	}
 }
 
+// broadcastEvent sends every connected player their view of an event
+func (rk *RoomKernel) broadcastEvent(e event.DomainEvent, version int) {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	for seat, player := range rk.players {
+		view := eventView(e, seat)
+		if view == nil || !player.IsConnected() {
+			continue
+		}
+		msg := EventMessage{
+			Type:    "Event",
+			Event:   e.EventType(),
+			Data:    view,
+			Version: version,
+		}
+		if err := player.Send(msg); err != nil {
+			log.Printf("Failed to send event to player %s: %v", player.PlayerID, err)
+		}
+	}
+}
+
+// broadcastSnapshot sends every connected player the snapshot with their own hand
+func (rk *RoomKernel) broadcastSnapshot() {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	for _, player := range rk.players {
+		if player.IsConnected() {
+			rk.sendSnapshot(player)
+		}
+	}
+}
+
+// welcomePlayer sends a new player their seat and reconnect token, then the snapshot
+func (rk *RoomKernel) welcomePlayer(player *PlayerConn, joined JoinedMessage) {
+	if err := player.Send(joined); err != nil {
+		log.Printf("Failed to send reconnect token to player %s: %v", player.PlayerID, err)
+	}
+	rk.sendSnapshotToPlayer(player)
+}
+
 func (rk *RoomKernel) sendSnapshotToPlayer(player *PlayerConn) {
-	snapshot, err := rk.GetSnapshot()
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	rk.sendSnapshot(player)
+}
+
+// sendSnapshot sends a player the snapshot of their seat. The caller must hold rk.mutex
+func (rk *RoomKernel) sendSnapshot(player *PlayerConn) {
+	snapshot, err := rk.snapshotFor(player.Seat)
 	if err != nil {
 		log.Printf("Failed to get snapshot: %v", err)
 		return
@@ -389,7 +766,7 @@ func (rk *RoomKernel) sendSnapshotToPlayer(player *PlayerConn) {
 }
 
 func (rk *RoomKernel) getPlayersInfo() []PlayerInfo {
-	playersInfo := make([]PlayerInfo, 0, len(rk.players))
+	playersInfo := make([]PlayerInfo, 0, rk.seatCount())
 	
 	for seat, player := range rk.players {
 		info := PlayerInfo{
@@ -397,32 +774,45 @@ func (rk *RoomKernel) getPlayersInfo() []PlayerInfo {
 			Name:      player.PlayerID,
 			Seat:      seat,
 			Connected: player.IsConnected(),
+			Bot:       rk.trustees[seat] != nil,
+			HandCount: rk.getHandCount(seat),
 		}
-		
-		// Get hand count if match is active
-		if rk.matchID != "" {
-			matchState, err := rk.gameService.GetMatchState(rk.matchID)
-			if err != nil {
-				log.Printf("Failed to get match state for hand count: %v", err)
-			} else {
-				log.Printf("Match state retrieved, %d players found", len(matchState.Players))
-				for _, p := range matchState.Players {
-					if p.SeatID == seat {
-						handSize := len(p.GetHand())
-						info.HandCount = handSize
-						log.Printf("Player %s has %d cards in hand", seat, handSize)
-						break
-					}
-				}
-			}
-		}
-		
 		playersInfo = append(playersInfo, info)
 	}
+	for seat, agent := range rk.bots {
+		playersInfo = append(playersInfo, PlayerInfo{
+			ID:        botPlayerID(seat),
+			Name:      botPlayerID(seat),
+			Seat:      seat,
+			Connected: true,
+			Bot:       true,
+			Agent:     agent,
+			HandCount: rk.getHandCount(seat),
+		})
+	}
 	
 	return playersInfo
 }
 
+// getHandCount returns the number of cards in a seat's hand, 0 before the match starts
+func (rk *RoomKernel) getHandCount(seat domain.SeatID) int {
+	if rk.matchID == "" {
+		return 0
+	}
+	
+	matchState, err := rk.gameService.GetMatchState(rk.matchID)
+	if err != nil {
+		log.Printf("Failed to get match state for hand count: %v", err)
+		return 0
+	}
+	for _, p := range matchState.Players {
+		if p.SeatID == seat {
+			return len(p.GetHand())
+		}
+	}
+	return 0
+}
+
 func (rk *RoomKernel) pingRoutine() {
 	ticker := time.NewTicker(rk.config.PingInterval)
 	defer ticker.Stop()
@@ -438,8 +828,12 @@ func (rk *RoomKernel) pingRoutine() {
 }
 
 func (rk *RoomKernel) pingPlayers() {
-	rk.mutex.RLock()
-	defer rk.mutex.RUnlock()
+	rk.spectators.ping()
+	
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	rk.checkDealEnd()
 	
 	for _, player := range rk.players {
 		if player.IsConnected() {
@@ -0,0 +1,510 @@
+package room
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	
+	"github.com/gorilla/websocket"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// noHost marks a room without players
+const noHost domain.SeatID = -1
+
+// maxRoomName limits the length of room names
+const maxRoomName = 40
+
+// rulePreset bundles the timer and card tracker settings of a common table type
+type rulePreset struct {
+	actionTime  int
+	timeBank    int
+	cardTracker bool
+}
+
+// rulePresets lists the presets a room can choose from
+var rulePresets = map[string]rulePreset{
+	"casual":     {},
+	"standard":   {actionTime: 20, timeBank: 60, cardTracker: true},
+	"tournament": {actionTime: 15, timeBank: 30},
+}
+
+// Settings returns the configuration as the lobby shows it
+func (c RoomConfig) Settings() RoomSettings {
+	settings := RoomSettings{
+		Name:        c.Name,
+		Rules:       c.Rules,
+		CardTracker: c.CardTracker,
+		TargetLevel: c.TargetLevel.String(),
+		Visibility:  c.Visibility,
+	}
+	if c.TurnTimer != nil {
+		settings.ActionTime = int(c.TurnTimer.ActionTime / time.Second)
+		settings.TimeBank = int(c.TurnTimer.TimeBank / time.Second)
+	}
+	return settings
+}
+
+// ApplySettings validates a settings update and applies it; the configuration is unchanged when it fails
+func (c *RoomConfig) ApplySettings(update SettingsUpdate) error {
+	settings := c.Settings()
+	if update.Rules != nil {
+		preset, ok := rulePresets[*update.Rules]
+		if !ok {
+			return fmt.Errorf("%w: unknown rule preset %q", ErrInvalidSettings, *update.Rules)
+		}
+		settings.Rules = *update.Rules
+		settings.ActionTime = preset.actionTime
+		settings.TimeBank = preset.timeBank
+		settings.CardTracker = preset.cardTracker
+	}
+	if update.Name != nil {
+		settings.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.ActionTime != nil {
+		settings.ActionTime = *update.ActionTime
+	}
+	if update.TimeBank != nil {
+		settings.TimeBank = *update.TimeBank
+	}
+	if update.CardTracker != nil {
+		settings.CardTracker = *update.CardTracker
+	}
+	if update.TargetLevel != nil {
+		settings.TargetLevel = *update.TargetLevel
+	}
+	
+	if len([]rune(settings.Name)) > maxRoomName {
+		return fmt.Errorf("%w: room name is longer than %d characters", ErrInvalidSettings, maxRoomName)
+	}
+	if settings.ActionTime < 0 || settings.TimeBank < 0 {
+		return fmt.Errorf("%w: turn timer values cannot be negative", ErrInvalidSettings)
+	}
+	if settings.TimeBank > 0 && settings.ActionTime == 0 {
+		return fmt.Errorf("%w: time bank requires an action time", ErrInvalidSettings)
+	}
+	level, ok := parseLevel(settings.TargetLevel)
+	if !ok {
+		return fmt.Errorf("%w: target level must be 2 to A, got %q", ErrInvalidSettings, settings.TargetLevel)
+	}
+	
+	c.Name = settings.Name
+	c.Rules = settings.Rules
+	c.CardTracker = settings.CardTracker
+	c.TargetLevel = level
+	c.TurnTimer = nil
+	if settings.ActionTime > 0 {
+		c.TurnTimer = &engine.TimerConfig{
+			ActionTime: time.Duration(settings.ActionTime) * time.Second,
+			TimeBank:   time.Duration(settings.TimeBank) * time.Second,
+		}
+	}
+	return nil
+}
+
+// parseLevel parses a level from "2" to "A"
+func parseLevel(text string) (domain.Rank, bool) {
+	for level := domain.Two; level <= domain.Ace; level++ {
+		if strings.EqualFold(text, level.String()) {
+			return level, true
+		}
+	}
+	return domain.Two, false
+}
+
+// Settings returns the room settings
+func (rk *RoomKernel) Settings() RoomSettings {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	return rk.config.Settings()
+}
+
+// SeatOf returns the seat of the player on a connection; players can change seats in the lobby
+func (rk *RoomKernel) SeatOf(conn *websocket.Conn) (domain.SeatID, bool) {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	for seat, player := range rk.players {
+		if player.Conn == conn {
+			return seat, true
+		}
+	}
+	return noHost, false
+}
+
+// handleLobby handles the lobby requests of a player. It reports false for other message types
+func (rk *RoomKernel) handleLobby(player *PlayerConn, msg WSMessage) bool {
+	handled, err := rk.lobbyRequest(player, msg)
+	if err != nil {
+		log.Printf("Lobby request %s from seat %s failed: %v", msg.Type, player.Seat, err)
+		player.Send(NewErrorMessage(err, player.Locale))
+	}
+	return handled
+}
+
+// lobbyRequest runs a lobby request. The caller must hold rk.mutex
+func (rk *RoomKernel) lobbyRequest(player *PlayerConn, msg WSMessage) (bool, error) {
+	switch msg.Type {
+	case "Ready":
+		return true, rk.handleReady(player, msg)
+	case "ChangeSeat":
+		return true, rk.handleChangeSeat(player, msg)
+	case "SwapSeat":
+		return true, rk.handleSwapSeat(player, msg)
+	case "Kick":
+		return true, rk.handleKick(player, msg)
+	case "AddBot":
+		return true, rk.handleAddBot(player, msg)
+	case "Settings":
+		return true, rk.handleSettings(player, msg)
+	case "Start":
+		return true, rk.handleStart(player)
+	}
+	return false, nil
+}
+
+func (rk *RoomKernel) handleReady(player *PlayerConn, msg WSMessage) error {
+	if rk.matchID != "" {
+		return ErrGameAlreadyStarted
+	}
+	var ready ReadyMessage
+	if err := decodeData(msg.Data, &ready); err != nil {
+		return err
+	}
+	
+	rk.ready[player.Seat] = ready.Ready == nil || *ready.Ready
+	rk.lobbyChanged()
+	return nil
+}
+
+func (rk *RoomKernel) handleChangeSeat(player *PlayerConn, msg WSMessage) error {
+	seat, err := rk.lobbySeat(msg)
+	if err != nil {
+		return err
+	}
+	if rk.seatTaken(seat) {
+		return ErrSeatTaken
+	}
+	
+	log.Printf("Player %s moved from seat %s to %s in room %s", player.PlayerID, player.Seat, seat, rk.roomID)
+	rk.swapSeats(player.Seat, seat)
+	rk.lobbyChanged()
+	return nil
+}
+
+// handleSwapSeat asks to swap seats with another player; the swap happens once both have asked.
+// Bots and free seats swap right away, and asking for the own seat withdraws the request
+func (rk *RoomKernel) handleSwapSeat(player *PlayerConn, msg WSMessage) error {
+	seat, err := rk.lobbySeat(msg)
+	if err != nil {
+		return err
+	}
+	
+	other, isPlayer := rk.players[seat]
+	asked, hasAsked := rk.swaps[seat]
+	switch {
+	case seat == player.Seat:
+		delete(rk.swaps, seat)
+	case isPlayer && (!hasAsked || asked != player.Seat):
+		rk.swaps[player.Seat] = seat
+		log.Printf("Player %s asked %s to swap seats in room %s", player.PlayerID, other.PlayerID, rk.roomID)
+	default:
+		log.Printf("Seats %s and %s swapped in room %s", player.Seat, seat, rk.roomID)
+		rk.swapSeats(player.Seat, seat)
+	}
+	rk.lobbyChanged()
+	return nil
+}
+
+func (rk *RoomKernel) handleKick(player *PlayerConn, msg WSMessage) error {
+	if err := rk.checkHost(player); err != nil {
+		return err
+	}
+	seat, err := rk.lobbySeat(msg)
+	if err != nil {
+		return err
+	}
+	if seat == player.Seat {
+		return ErrInvalidSeat
+	}
+	
+	if target, exists := rk.players[seat]; exists {
+		target.Send(map[string]interface{}{"t": "Kicked"})
+		target.Close()
+		delete(rk.players, seat)
+		if address := connAddress(target.Conn); address != "" {
+			rk.kicked[address] = true
+		}
+		log.Printf("Player %s was kicked from room %s", target.PlayerID, rk.roomID)
+	} else if _, exists := rk.bots[seat]; exists {
+		delete(rk.bots, seat)
+		log.Printf("Bot at seat %s was kicked from room %s", seat, rk.roomID)
+	} else {
+		return ErrPlayerNotFound
+	}
+	rk.leaveLobby(seat)
+	rk.lobbyChanged()
+	return nil
+}
+
+// connAddress returns the address a connection comes from, without the port; empty when unknown
+func connAddress(conn *websocket.Conn) string {
+	if conn == nil {
+		return ""
+	}
+	address := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// handleAddBot fills an empty seat with a bot; bots leave again through Kick
+func (rk *RoomKernel) handleAddBot(player *PlayerConn, msg WSMessage) error {
+	if err := rk.checkHost(player); err != nil {
+		return err
+	}
+	var add AddBotMessage
+	if err := decodeData(msg.Data, &add); err != nil {
+		return err
+	}
+	return rk.addBot(add.Seat, add.Agent)
+}
+
+// handleSettings changes the room settings. Everyone must confirm ready again afterwards
+func (rk *RoomKernel) handleSettings(player *PlayerConn, msg WSMessage) error {
+	if err := rk.checkHost(player); err != nil {
+		return err
+	}
+	var update SettingsUpdate
+	if err := decodeData(msg.Data, &update); err != nil {
+		return err
+	}
+	if err := rk.config.ApplySettings(update); err != nil {
+		return err
+	}
+	
+	clear(rk.ready)
+	log.Printf("Room %s settings changed to %+v", rk.roomID, rk.config.Settings())
+	rk.lobbyChanged()
+	return nil
+}
+
+// handleStart starts the match once every seat is taken and every player is ready
+func (rk *RoomKernel) handleStart(player *PlayerConn) error {
+	if err := rk.checkHost(player); err != nil {
+		return err
+	}
+	if rk.seatCount() < rk.config.MaxPlayers {
+		return ErrNotReady
+	}
+	for seat, p := range rk.players {
+		if !rk.ready[seat] || !p.IsConnected() {
+			return ErrNotReady
+		}
+	}
+	
+	log.Printf("Room %s is ready, creating match...", rk.roomID)
+	if err := rk.createMatch(); err != nil {
+		log.Printf("Failed to create match for room %s: %v", rk.roomID, err)
+		return err
+	}
+	log.Printf("Match created successfully for room %s", rk.roomID)
+	return nil
+}
+
+// checkHost rejects lobby requests from players other than the host or after the match started
+func (rk *RoomKernel) checkHost(player *PlayerConn) error {
+	if rk.matchID != "" {
+		return ErrGameAlreadyStarted
+	}
+	if rk.host != player.Seat {
+		return ErrNotHost
+	}
+	return nil
+}
+
+// lobbySeat reads the seat of a lobby request
+func (rk *RoomKernel) lobbySeat(msg WSMessage) (domain.SeatID, error) {
+	if rk.matchID != "" {
+		return noHost, ErrGameAlreadyStarted
+	}
+	var seat SeatMessage
+	if err := decodeData(msg.Data, &seat); err != nil {
+		return noHost, err
+	}
+	if !seat.Seat.IsValid() {
+		return noHost, ErrInvalidSeat
+	}
+	return seat.Seat, nil
+}
+
+// joinLobby seats a new player, who becomes the host of an empty room. The caller must hold rk.mutex
+func (rk *RoomKernel) joinLobby(seat domain.SeatID) {
+	if rk.host == noHost {
+		rk.host = seat
+	}
+	rk.ready[seat] = false
+	rk.lobbyChanged()
+}
+
+// leaveLobby clears a freed seat and hands the host to the next player if needed. The caller must hold rk.mutex
+func (rk *RoomKernel) leaveLobby(seat domain.SeatID) {
+	delete(rk.ready, seat)
+	rk.clearSwaps(seat)
+	rk.dropTickets(seat)
+	if rk.host != seat {
+		return
+	}
+	
+	rk.host = noHost
+	for next := domain.SeatEast; next <= domain.SeatNorth; next++ {
+		if _, exists := rk.players[next]; exists {
+			rk.host = next
+			break
+		}
+	}
+}
+
+// swapSeats swaps whoever sits in two seats, players or bots; either seat may be free.
+// Moved players must confirm ready again. The caller must hold rk.mutex
+func (rk *RoomKernel) swapSeats(a, b domain.SeatID) {
+	playerA, hasPlayerA := rk.players[a]
+	playerB, hasPlayerB := rk.players[b]
+	botA, hasBotA := rk.bots[a]
+	botB, hasBotB := rk.bots[b]
+	delete(rk.players, a)
+	delete(rk.players, b)
+	delete(rk.bots, a)
+	delete(rk.bots, b)
+	
+	if hasPlayerA {
+		playerA.Seat = b
+		rk.players[b] = playerA
+	}
+	if hasPlayerB {
+		playerB.Seat = a
+		rk.players[a] = playerB
+	}
+	if hasBotA {
+		rk.bots[b] = botA
+	}
+	if hasBotB {
+		rk.bots[a] = botB
+	}
+	
+	switch rk.host {
+	case a:
+		rk.host = b
+	case b:
+		rk.host = a
+	}
+	delete(rk.ready, a)
+	delete(rk.ready, b)
+	rk.clearSwaps(a)
+	rk.clearSwaps(b)
+	rk.moveTickets(a, b)
+}
+
+// clearSwaps drops the swap requests from and to a seat. The caller must hold rk.mutex
+func (rk *RoomKernel) clearSwaps(seat domain.SeatID) {
+	delete(rk.swaps, seat)
+	for from, to := range rk.swaps {
+		if to == seat {
+			delete(rk.swaps, from)
+		}
+	}
+}
+
+// lobbyState describes the lobby. The caller must hold rk.mutex
+func (rk *RoomKernel) lobbyState() *LobbyState {
+	state := &LobbyState{
+		Seats:    make([]LobbySeat, 0, rk.config.MaxPlayers),
+		Settings: rk.config.Settings(),
+	}
+	if rk.host != noHost {
+		host := rk.host
+		state.Host = &host
+	}
+	
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		info := LobbySeat{Seat: seat}
+		if player, exists := rk.players[seat]; exists {
+			info.PlayerID = player.PlayerID
+			info.Connected = player.IsConnected()
+			info.Ready = rk.ready[seat]
+			if to, asked := rk.swaps[seat]; asked {
+				info.SwapWith = &to
+			}
+		} else if agent, exists := rk.bots[seat]; exists {
+			info.Bot = agent
+			info.Connected = true
+			info.Ready = true
+		}
+		state.Seats = append(state.Seats, info)
+	}
+	return state
+}
+
+// lobbyOutbox sends LobbyUpdated messages in order, outside rk.mutex.
+// Each message carries the whole lobby, so a sender that falls behind only sends the latest one
+type lobbyOutbox struct {
+	mutex   sync.Mutex
+	pending *LobbyMessage
+	sending bool
+}
+
+// lobbyChanged queues the lobby for broadcast after a change. The caller must hold rk.mutex
+func (rk *RoomKernel) lobbyChanged() {
+	if rk.matchID != "" {
+		return
+	}
+	msg := LobbyMessage{Type: "LobbyUpdated", LobbyState: rk.lobbyState()}
+	
+	rk.lobbyOut.mutex.Lock()
+	defer rk.lobbyOut.mutex.Unlock()
+	rk.lobbyOut.pending = &msg
+	if !rk.lobbyOut.sending {
+		rk.lobbyOut.sending = true
+		go rk.sendLobbyUpdates()
+	}
+}
+
+// sendLobbyUpdates broadcasts queued lobby updates until none is left. Only one runs per room
+func (rk *RoomKernel) sendLobbyUpdates() {
+	for {
+		rk.lobbyOut.mutex.Lock()
+		msg := rk.lobbyOut.pending
+		rk.lobbyOut.pending = nil
+		if msg == nil {
+			rk.lobbyOut.sending = false
+		}
+		rk.lobbyOut.mutex.Unlock()
+		
+		if msg == nil {
+			return
+		}
+		rk.broadcastMessage(*msg)
+	}
+}
+
+// decodeData decodes the data of a WS message into a typed message
+func decodeData(data interface{}, v interface{}) error {
+	if data == nil {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAction, err)
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package room
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	
+	"github.com/gorilla/websocket"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/service"
+)
+
+// lobbyRequest sends a lobby request from a seat and returns its error
+func lobbyRequest(rk *RoomKernel, seat domain.SeatID, msgType string, data interface{}) error {
+	rk.mutex.Lock()
+	defer rk.mutex.Unlock()
+	
+	_, err := rk.lobbyRequest(rk.players[seat], WSMessage{Type: msgType, Data: data})
+	return err
+}
+
+func seatData(seat domain.SeatID) map[string]interface{} {
+	return map[string]interface{}{"seat": seat}
+}
+
+func TestLobbySeats(t *testing.T) {
+	rk := NewRoomKernel("room", service.NewGameService(), DefaultRoomConfig)
+	t.Cleanup(rk.Stop)
+	
+	east, inbox := newTestClient(t)
+	if err := rk.AddPlayer("p-East", domain.SeatEast, east, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := rk.AddPlayer("p-South", domain.SeatSouth, newTestConn(t), ""); err != nil {
+		t.Fatal(err)
+	}
+	if rk.host != domain.SeatEast {
+		t.Fatalf("Expected the first player to host, got %s", rk.host)
+	}
+	
+	if err := lobbyRequest(rk, domain.SeatSouth, "ChangeSeat", seatData(domain.SeatEast)); !errors.Is(err, ErrSeatTaken) {
+		t.Errorf("Expected ErrSeatTaken, got %v", err)
+	}
+	if err := lobbyRequest(rk, domain.SeatSouth, "ChangeSeat", seatData(domain.SeatWest)); err != nil {
+		t.Fatalf("Failed to change seats: %v", err)
+	}
+	if rk.players[domain.SeatWest] == nil || rk.players[domain.SeatWest].Seat != domain.SeatWest {
+		t.Fatal("The player did not move to West")
+	}
+	
+	// A swap with another player waits for both to ask
+	if err := lobbyRequest(rk, domain.SeatEast, "SwapSeat", seatData(domain.SeatWest)); err != nil {
+		t.Fatal(err)
+	}
+	rk.mutex.RLock()
+	swapWith := rk.lobbyState().Seats[domain.SeatEast].SwapWith
+	rk.mutex.RUnlock()
+	if swapWith == nil || *swapWith != domain.SeatWest {
+		t.Errorf("Expected East to ask West for a swap, got %v", swapWith)
+	}
+	if err := lobbyRequest(rk, domain.SeatWest, "SwapSeat", seatData(domain.SeatEast)); err != nil {
+		t.Fatal(err)
+	}
+	if rk.players[domain.SeatEast].PlayerID != "p-South" || rk.host != domain.SeatWest || len(rk.swaps) != 0 {
+		t.Errorf("Expected the players and the host to swap, host is %s", rk.host)
+	}
+	
+	// Only the host adds bots, and a swap with a bot happens right away
+	addBot := map[string]interface{}{"seat": domain.SeatNorth, "agent": "heuristic"}
+	if err := lobbyRequest(rk, domain.SeatEast, "AddBot", addBot); !errors.Is(err, ErrNotHost) {
+		t.Errorf("Expected ErrNotHost, got %v", err)
+	}
+	if err := lobbyRequest(rk, domain.SeatWest, "AddBot", addBot); err != nil {
+		t.Fatalf("Failed to add a bot: %v", err)
+	}
+	if err := lobbyRequest(rk, domain.SeatEast, "SwapSeat", seatData(domain.SeatNorth)); err != nil {
+		t.Fatal(err)
+	}
+	if rk.players[domain.SeatNorth] == nil || rk.bots[domain.SeatEast] != "heuristic" {
+		t.Error("Expected the player and the bot to swap")
+	}
+	
+	// Only the host kicks, and the host passes on when it leaves
+	if err := lobbyRequest(rk, domain.SeatNorth, "Kick", seatData(domain.SeatEast)); !errors.Is(err, ErrNotHost) {
+		t.Errorf("Expected ErrNotHost, got %v", err)
+	}
+	if err := lobbyRequest(rk, domain.SeatWest, "Kick", seatData(domain.SeatEast)); err != nil {
+		t.Fatalf("Failed to kick the bot: %v", err)
+	}
+	if rk.seatTaken(domain.SeatEast) {
+		t.Error("The kicked bot still has its seat")
+	}
+	rk.RemovePlayer(domain.SeatWest)
+	if rk.host != domain.SeatNorth {
+		t.Errorf("Expected North to host, got %s", rk.host)
+	}
+	
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case msgType := <-inbox:
+			if msgType == "LobbyUpdated" {
+				return
+			}
+		case <-deadline:
+			t.Fatal("The player did not receive a lobby update")
+		}
+	}
+}
+
+func TestLobbyKickedPlayerStaysOut(t *testing.T) {
+	rk := NewRoomKernel("room", service.NewGameService(), DefaultRoomConfig)
+	t.Cleanup(rk.Stop)
+	
+	rk.mutex.Lock()
+	rk.addBot(domain.SeatEast, "")
+	rk.mutex.Unlock()
+	if err := rk.AddPlayer("p-South", domain.SeatSouth, newTestConn(t), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := rk.AddPlayer("p-West", domain.SeatWest, newTestConn(t), ""); err != nil {
+		t.Fatal(err)
+	}
+	
+	if err := lobbyRequest(rk, domain.SeatSouth, "Kick", seatData(domain.SeatWest)); err != nil {
+		t.Fatalf("Failed to kick West: %v", err)
+	}
+	
+	// The test clients share an address, so the kicked player cannot come back on any seat
+	for _, seat := range []domain.SeatID{domain.SeatWest, domain.SeatNorth} {
+		if err := rk.AddPlayer("p-West", seat, newTestConn(t), ""); !errors.Is(err, ErrKicked) {
+			t.Errorf("Expected ErrKicked at %s, got %v", seat, err)
+		}
+	}
+	
+	// Kicking a bot does not shut anyone out
+	if err := lobbyRequest(rk, domain.SeatSouth, "Kick", seatData(domain.SeatEast)); err != nil {
+		t.Fatalf("Failed to kick the bot: %v", err)
+	}
+	rk.mutex.RLock()
+	kicked := len(rk.kicked)
+	rk.mutex.RUnlock()
+	if kicked != 1 {
+		t.Errorf("Expected one kicked address, got %d", kicked)
+	}
+}
+
+func TestLobbyUpdatesArriveInOrder(t *testing.T) {
+	rk := NewRoomKernel("room", service.NewGameService(), DefaultRoomConfig)
+	t.Cleanup(rk.Stop)
+	
+	conns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := rk.AddPlayer("p-East", domain.SeatEast, <-conns, ""); err != nil {
+		t.Fatal(err)
+	}
+	
+	// The last update must show the last change, however the sends interleave
+	for i := 0; i < 50; i++ {
+		if err := lobbyRequest(rk, domain.SeatEast, "Ready", map[string]interface{}{"ready": i%2 == 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	var last *LobbyMessage
+	for {
+		client.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		var msg LobbyMessage
+		if err := client.ReadJSON(&msg); err != nil {
+			break
+		}
+		if msg.Type == "LobbyUpdated" {
+			last = &msg
+		}
+	}
+	if last == nil {
+		t.Fatal("The player did not receive a lobby update")
+	}
+	if !last.Seats[domain.SeatEast].Ready {
+		t.Error("The last lobby update is out of date")
+	}
+}
+
+func TestLobbySettingsAndStart(t *testing.T) {
+	rk := NewRoomKernel("room", service.NewGameService(), DefaultRoomConfig)
+	t.Cleanup(rk.Stop)
+	
+	for seat := domain.SeatEast; seat <= domain.SeatWest; seat++ {
+		if err := rk.AddPlayer("p-"+seat.String(), seat, newTestConn(t), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	if err := lobbyRequest(rk, domain.SeatSouth, "Settings", map[string]interface{}{"rules": "standard"}); !errors.Is(err, ErrNotHost) {
+		t.Errorf("Expected ErrNotHost, got %v", err)
+	}
+	if err := lobbyRequest(rk, domain.SeatEast, "Settings", map[string]interface{}{"targetLevel": "1"}); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("Expected ErrInvalidSettings, got %v", err)
+	}
+	for seat := domain.SeatEast; seat <= domain.SeatWest; seat++ {
+		if err := lobbyRequest(rk, seat, "Ready", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	update := map[string]interface{}{"rules": "standard", "targetLevel": "5"}
+	if err := lobbyRequest(rk, domain.SeatEast, "Settings", update); err != nil {
+		t.Fatalf("Failed to change the settings: %v", err)
+	}
+	if rk.config.TurnTimer == nil || rk.config.TurnTimer.ActionTime != 20*time.Second || rk.config.TargetLevel != domain.Five {
+		t.Errorf("Unexpected settings %+v", rk.config.Settings())
+	}
+	if len(rk.ready) != 0 {
+		t.Error("Changing the settings should clear ready")
+	}
+	
+	if err := rk.AddBot(domain.SeatNorth, "heuristic"); err != nil {
+		t.Fatal(err)
+	}
+	if err := lobbyRequest(rk, domain.SeatEast, "Start", nil); !errors.Is(err, ErrNotReady) {
+		t.Errorf("Expected ErrNotReady, got %v", err)
+	}
+	for seat := domain.SeatEast; seat <= domain.SeatWest; seat++ {
+		if err := lobbyRequest(rk, seat, "Ready", map[string]interface{}{"ready": true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lobbyRequest(rk, domain.SeatSouth, "Start", nil); !errors.Is(err, ErrNotHost) {
+		t.Errorf("Expected ErrNotHost, got %v", err)
+	}
+	if err := lobbyRequest(rk, domain.SeatEast, "Start", nil); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	if rk.matchID == "" {
+		t.Fatal("The match did not start")
+	}
+	if err := lobbyRequest(rk, domain.SeatSouth, "Ready", nil); !errors.Is(err, ErrGameAlreadyStarted) {
+		t.Errorf("Expected ErrGameAlreadyStarted, got %v", err)
+	}
+}
+
+func TestEntropyWindowBeforeFirstDeal(t *testing.T) {
+	gs := service.NewGameService()
+	config := DefaultRoomConfig
+	config.EntropyWindow = 100 * time.Millisecond
+	rk := NewRoomKernel("room", gs, config)
+	t.Cleanup(rk.Stop)
+	
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if err := rk.AddPlayer("p-"+seat.String(), seat, newTestConn(t), ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := lobbyRequest(rk, seat, "Ready", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lobbyRequest(rk, domain.SeatEast, "Start", nil); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	
+	state, err := gs.GetMatchState(rk.matchID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CurrentDeal != 0 {
+		t.Fatal("The first deal should wait for the entropy window")
+	}
+	
+	// Entropy sent in the window goes into the shuffle; a second contribution is refused
+	rk.HandleMessage(domain.SeatSouth, WSMessage{Type: "Entropy", Data: map[string]interface{}{"entropy": "south"}})
+	if err := gs.ContributeEntropy(rk.matchID, domain.SeatSouth, []byte("again")); err == nil {
+		t.Error("South's entropy should have been accepted during the window")
+	}
+	
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rk.mutex.RLock()
+		state, err = gs.GetMatchState(rk.matchID)
+		rk.mutex.RUnlock()
+		if err == nil && state.CurrentDeal == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The first deal did not start after the entropy window")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	
+	if err := gs.ContributeEntropy(rk.matchID, domain.SeatNorth, []byte("late")); !errors.Is(err, &engine.ErrWrongPhase{}) {
+		t.Errorf("Entropy after the shuffle should be rejected, got %v", err)
+	}
+}
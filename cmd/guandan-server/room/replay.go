@@ -0,0 +1,194 @@
+package room
+
+import (
+	"log"
+	"sync"
+	
+	"guandan/sdk/analysis"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+	"guandan/sdk/notation"
+)
+
+// DealSummary describes a finished deal in the room's replay archive
+type DealSummary struct {
+	Deal     int      `json:"deal"`
+	Result   []string `json:"result"` // seat letters in finishing order
+	Tricks   int      `json:"tricks"`
+	Analysis string   `json:"analysis"` // "none", "running", "done" or "failed"
+}
+
+// Analysis job states reported in DealSummary
+const (
+	AnalysisNone    = "none"
+	AnalysisRunning = "running"
+	AnalysisDone    = "done"
+	AnalysisFailed  = "failed"
+)
+
+// dealArchive records every finished deal of the room's match from its events
+// and caches the analysis report of each deal once requested
+type dealArchive struct {
+	mutex    sync.Mutex
+	matchCtx *domain.MatchCtx
+	recorder *notation.Recorder
+	last     []domain.SeatID
+	records  []*notation.Record
+	jobs     map[int]*analysisJob
+	config   analysis.Config
+}
+
+// analysisJob is a background analysis of one deal; done is closed when it finishes
+type analysisJob struct {
+	done   chan struct{}
+	report *analysis.Report
+	err    error
+}
+
+func newDealArchive(matchID domain.MatchID, players []*domain.Player, config analysis.Config) *dealArchive {
+	return &dealArchive{
+		matchCtx: domain.NewMatchCtx(matchID, players, 0),
+		jobs:     make(map[int]*analysisJob),
+		config:   config,
+	}
+}
+
+// handle feeds a match event to the recorder of the current deal
+func (a *dealArchive) handle(e event.DomainEvent) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	switch ev := e.(type) {
+	case *event.DealStartedEvent:
+		a.recorder = notation.NewRecorder(a.matchCtx, a.last)
+	case *event.DealEndedEvent:
+		a.last = append([]domain.SeatID(nil), ev.RankList...)
+		if a.recorder == nil {
+			return
+		}
+		a.recorder.Apply(e)
+		a.records = append(a.records, a.recorder.Record())
+		a.recorder = nil
+		return
+	}
+	
+	if a.recorder != nil {
+		a.recorder.Apply(e)
+	}
+}
+
+// summaries lists the finished deals in order
+func (a *dealArchive) summaries() []DealSummary {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	summaries := make([]DealSummary, 0, len(a.records))
+	for _, rec := range a.records {
+		summary := DealSummary{Deal: rec.Deal, Result: []string{}, Tricks: len(rec.Tricks), Analysis: AnalysisNone}
+		for _, seat := range rec.Result {
+			summary.Result = append(summary.Result, notation.SeatLetter(seat))
+		}
+		if job, ok := a.jobs[rec.Deal]; ok {
+			summary.Analysis = job.status()
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// record returns the finished deal with the given number
+func (a *dealArchive) record(deal int) (*notation.Record, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	for _, rec := range a.records {
+		if rec.Deal == deal {
+			return rec, nil
+		}
+	}
+	return nil, ErrDealNotFound
+}
+
+// analyze starts the analysis of a finished deal unless it was already requested and returns its job
+func (a *dealArchive) analyze(deal int) (*analysisJob, error) {
+	rec, err := a.record(deal)
+	if err != nil {
+		return nil, err
+	}
+	
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	
+	if job, ok := a.jobs[deal]; ok {
+		return job, nil
+	}
+	job := &analysisJob{done: make(chan struct{})}
+	a.jobs[deal] = job
+	
+	go func() {
+		defer close(job.done)
+		job.report, job.err = analysis.Analyze(rec, a.config)
+		if job.err != nil {
+			log.Printf("Failed to analyze deal %d of match %s: %v", deal, rec.Match, job.err)
+		}
+	}()
+	return job, nil
+}
+
+// status reports whether the job is still running
+func (j *analysisJob) status() string {
+	select {
+	case <-j.done:
+		if j.err != nil {
+			return AnalysisFailed
+		}
+		return AnalysisDone
+	default:
+		return AnalysisRunning
+	}
+}
+
+// Deals lists the finished deals of the room's match
+func (rk *RoomKernel) Deals() ([]DealSummary, error) {
+	archive := rk.getArchive()
+	if archive == nil {
+		return nil, ErrGameNotStarted
+	}
+	return archive.summaries(), nil
+}
+
+// DealRecord returns the GDN record of a finished deal
+func (rk *RoomKernel) DealRecord(deal int) (*notation.Record, error) {
+	archive := rk.getArchive()
+	if archive == nil {
+		return nil, ErrGameNotStarted
+	}
+	return archive.record(deal)
+}
+
+// AnalyzeDeal returns the analysis report of a finished deal, starting the analysis on the first request.
+// The report is nil while the analysis is still running
+func (rk *RoomKernel) AnalyzeDeal(deal int) (*analysis.Report, error) {
+	archive := rk.getArchive()
+	if archive == nil {
+		return nil, ErrGameNotStarted
+	}
+	job, err := archive.analyze(deal)
+	if err != nil {
+		return nil, err
+	}
+	
+	select {
+	case <-job.done:
+		return job.report, job.err
+	default:
+		return nil, nil
+	}
+}
+
+func (rk *RoomKernel) getArchive() *dealArchive {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	return rk.archive
+}
@@ -0,0 +1,78 @@
+package room
+
+import (
+	"errors"
+	"testing"
+	"time"
+	
+	"guandan/sdk/analysis"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+)
+
+func TestDealArchiveRecordsAndAnalyzes(t *testing.T) {
+	eventBus := event.NewEventBus(1000)
+	eventBus.Start()
+	defer eventBus.Stop()
+	eventChan, unsubscribe := eventBus.Subscribe("archive")
+	defer unsubscribe()
+	
+	players := make([]*domain.Player, 4)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		players[seat] = domain.NewPlayer(seat.String(), seat.String(), seat)
+	}
+	ge := engine.NewGameEngine(eventBus)
+	if err := ge.Initialize(domain.NewMatchCtx("archive", players, 1)); err != nil {
+		t.Fatal(err)
+	}
+	archive := newDealArchive("archive", players, analysis.Config{Iterations: 20, Workers: 1, Seed: 1})
+	
+	if err := ge.StartDeal(1, nil); err != nil {
+		t.Fatal(err)
+	}
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	heuristic := bot.NewHeuristic()
+	if err := bot.PlayDeal(ge, [4]bot.Agent{heuristic, heuristic, heuristic, heuristic}); err != nil {
+		t.Fatal(err)
+	}
+	
+	timeout := time.After(2 * time.Second)
+	for len(archive.summaries()) == 0 {
+		select {
+		case e := <-eventChan:
+			archive.handle(e)
+		case <-timeout:
+			t.Fatal("timed out waiting for the deal to be archived")
+		}
+	}
+	
+	summary := archive.summaries()[0]
+	if summary.Deal != 1 || len(summary.Result) < 3 || summary.Analysis != AnalysisNone {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+	if _, err := archive.record(2); !errors.Is(err, ErrDealNotFound) {
+		t.Errorf("Expected ErrDealNotFound, got %v", err)
+	}
+	
+	job, err := archive.analyze(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := archive.analyze(1); again != job {
+		t.Error("A second request started another analysis")
+	}
+	<-job.done
+	if job.err != nil {
+		t.Fatal(job.err)
+	}
+	if job.report.Deal != 1 || len(job.report.Seats) != 4 {
+		t.Errorf("Unexpected report %+v", job.report)
+	}
+	if status := archive.summaries()[0].Analysis; status != AnalysisDone {
+		t.Errorf("Expected analysis status %q, got %q", AnalysisDone, status)
+	}
+}
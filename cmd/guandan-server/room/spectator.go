@@ -0,0 +1,274 @@
+package room
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	
+	"github.com/gorilla/websocket"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// Spectator modes
+const (
+	SpectatorsOff        = "off"
+	SpectatorsPublic     = "public"     // plays and public events; no hands or seeds
+	SpectatorsSeat       = "seat"       // public events plus the hand of one seat, chosen by the spectator; needs a delay
+	SpectatorsOmniscient = "omniscient" // every event; needs a delay
+)
+
+// SpectatorConfig sets what spectators of a room see and how late
+type SpectatorConfig struct {
+	Mode          string        `json:"mode"`
+	Delay         time.Duration `json:"delay"`         // events reach spectators this much later
+	DelayActions  int           `json:"delayActions"`  // events reach spectators after this many more plays, passes and tributes
+	MaxSpectators int           `json:"maxSpectators"`
+}
+
+// Validate checks the mode and delays; spectators who see a hand could pass it to players without a delay
+func (c SpectatorConfig) Validate() error {
+	switch c.Mode {
+	case SpectatorsOff, SpectatorsPublic, SpectatorsSeat, SpectatorsOmniscient:
+	default:
+		return fmt.Errorf("%w: unknown spectator mode %q", ErrInvalidSettings, c.Mode)
+	}
+	if c.Delay < 0 || c.DelayActions < 0 || c.MaxSpectators < 0 {
+		return fmt.Errorf("%w: spectator delays and limit cannot be negative", ErrInvalidSettings)
+	}
+	if (c.Mode == SpectatorsSeat || c.Mode == SpectatorsOmniscient) && c.Delay == 0 && c.DelayActions == 0 {
+		return fmt.Errorf("%w: %s spectators need a delay", ErrInvalidSettings, c.Mode)
+	}
+	return nil
+}
+
+// spectator is a read-only connection and the seat whose hand it sees in seat mode, noSeat otherwise
+type spectator struct {
+	conn *PlayerConn
+	seat domain.SeatID
+}
+
+// spectatorEntry is an event waiting for its delay
+type spectatorEntry struct {
+	event   event.DomainEvent
+	version int
+	at      time.Time
+	actions int // actions so far, this event included
+}
+
+// spectatorHub delays the room's events and sends each spectator its view of them
+type spectatorHub struct {
+	config     SpectatorConfig
+	spectators map[*websocket.Conn]*spectator
+	log        []spectatorEntry // events of the current deal
+	released   int              // events in log already sent
+	flushTo    int              // events in log to send regardless of the delay
+	actions    int
+	timer      *time.Timer
+	stopped    bool
+	mutex      sync.Mutex
+}
+
+func newSpectatorHub(config SpectatorConfig) *spectatorHub {
+	return &spectatorHub{
+		config:     config,
+		spectators: make(map[*websocket.Conn]*spectator),
+	}
+}
+
+// AddSpectator lets a connection watch the room. Seat mode needs the seat to watch
+func (rk *RoomKernel) AddSpectator(conn *websocket.Conn, seat *domain.SeatID) error {
+	h := rk.spectators
+	view := SpectatorView{
+		Mode:         h.config.Mode,
+		Delay:        int(h.config.Delay / time.Second),
+		DelayActions: h.config.DelayActions,
+	}
+	switch {
+	case h.config.Mode == SpectatorsOff:
+		return ErrSpectatorsOff
+	case h.config.Mode == SpectatorsSeat && (seat == nil || !seat.IsValid()):
+		return ErrInvalidSeat
+	case h.config.Mode == SpectatorsSeat:
+		view.Seat = seat
+	}
+	
+	rk.mutex.RLock()
+	welcome := SpectatingMessage{Type: "Spectating", View: view, Players: rk.getPlayersInfo(), Status: "waiting"}
+	if rk.matchID != "" {
+		welcome.Status = "playing"
+	}
+	rk.mutex.RUnlock()
+	
+	s := &spectator{conn: NewPlayerConn(fmt.Sprintf("%s_spectator", rk.roomID), noHost, conn), seat: noSeat}
+	if view.Seat != nil {
+		s.seat = *view.Seat
+	}
+	return h.add(s, welcome)
+}
+
+// RemoveSpectator stops sending events to a connection
+func (rk *RoomKernel) RemoveSpectator(conn *websocket.Conn) {
+	h := rk.spectators
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	if s, exists := h.spectators[conn]; exists {
+		s.conn.Close()
+		delete(h.spectators, conn)
+	}
+}
+
+// SpectatorCount returns the number of spectators watching the room
+func (rk *RoomKernel) SpectatorCount() int {
+	return rk.spectators.count()
+}
+
+// add sends the welcome and the released events of the current deal, then follows the stream
+func (h *spectatorHub) add(s *spectator, welcome SpectatingMessage) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	if h.stopped {
+		return ErrRoomNotFound
+	}
+	if h.config.MaxSpectators > 0 && len(h.spectators) >= h.config.MaxSpectators {
+		return ErrTooManySpectators
+	}
+	if err := s.conn.Send(welcome); err != nil {
+		return err
+	}
+	for _, entry := range h.log[:h.released] {
+		h.send(s, entry)
+	}
+	h.spectators[s.conn.Conn] = s
+	return nil
+}
+
+// count returns the number of spectators
+func (h *spectatorHub) count() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	return len(h.spectators)
+}
+
+// handle queues a room event. The end of a deal releases everything, as nothing is left to hide
+func (h *spectatorHub) handle(e event.DomainEvent, version int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	if h.config.Mode == SpectatorsOff || h.stopped {
+		return
+	}
+	switch e.(type) {
+	case *event.CardsPlayedEvent, *event.PlayerPassedEvent, *event.TributeGivenEvent, *event.TributeCardSelectedEvent:
+		h.actions++
+	}
+	h.log = append(h.log, spectatorEntry{event: e, version: version, at: time.Now(), actions: h.actions})
+	switch e.(type) {
+	case *event.DealEndedEvent, *event.MatchEndedEvent:
+		h.flushTo = len(h.log)
+	}
+	h.release()
+}
+
+// release sends the events whose delay has passed and waits for the next one. The caller must hold h.mutex
+func (h *spectatorHub) release() {
+	now := time.Now()
+	for h.released < len(h.log) {
+		entry := h.log[h.released]
+		if h.released >= h.flushTo {
+			if h.actions-entry.actions < h.config.DelayActions {
+				return
+			}
+			if wait := entry.at.Add(h.config.Delay).Sub(now); wait > 0 {
+				h.schedule(wait)
+				return
+			}
+		}
+		
+		// A new deal starts a new log, so joining spectators only replay the current deal
+		if _, ok := entry.event.(*event.DealStartedEvent); ok {
+			h.log = h.log[h.released:]
+			h.flushTo = max(h.flushTo-h.released, 0)
+			h.released = 0
+		}
+		for _, s := range h.spectators {
+			h.send(s, entry)
+		}
+		h.released++
+	}
+}
+
+// schedule runs release again once the next event is due. The caller must hold h.mutex
+func (h *spectatorHub) schedule(wait time.Duration) {
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	h.timer = time.AfterFunc(wait, func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		
+		if !h.stopped {
+			h.release()
+		}
+	})
+}
+
+// send sends a spectator its view of an event. The caller must hold h.mutex
+func (h *spectatorHub) send(s *spectator, entry spectatorEntry) {
+	view := spectatorEvent(entry.event, h.config.Mode, s.seat)
+	if view == nil {
+		return
+	}
+	msg := EventMessage{
+		Type:    "Event",
+		Event:   entry.event.EventType(),
+		Data:    view,
+		Version: entry.version,
+	}
+	if err := s.conn.Send(msg); err != nil {
+		log.Printf("Failed to send event to spectator: %v", err)
+	}
+}
+
+// ping keeps the spectator connections alive
+func (h *spectatorHub) ping() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	for _, s := range h.spectators {
+		if err := s.conn.Send(map[string]interface{}{"t": "ping"}); err != nil {
+			log.Printf("Failed to ping spectator: %v", err)
+		}
+	}
+}
+
+// stop closes every spectator connection
+func (h *spectatorHub) stop() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	h.stopped = true
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	for conn, s := range h.spectators {
+		s.conn.Close()
+		delete(h.spectators, conn)
+	}
+}
+
+// spectatorEvent returns the view of an event for a spectator mode: everything for omniscient
+// spectators, the public view plus the watched hand in seat mode and the public view otherwise
+func spectatorEvent(e event.DomainEvent, mode string, seat domain.SeatID) event.DomainEvent {
+	switch mode {
+	case SpectatorsOmniscient:
+		return e
+	case SpectatorsSeat:
+		return eventView(e, seat)
+	}
+	return eventView(e, noSeat)
+}
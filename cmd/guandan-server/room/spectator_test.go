@@ -0,0 +1,116 @@
+package room
+
+import (
+	"errors"
+	"testing"
+	"time"
+	
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+	"guandan/sdk/service"
+)
+
+func TestSpectatorEventViews(t *testing.T) {
+	hands := map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {domain.NewCard(domain.Hearts, domain.Two)},
+		domain.SeatSouth: {domain.NewCard(domain.Spades, domain.Ace)},
+	}
+	dealt := event.NewCardsDealtEvent("m", hands)
+	
+	if ev := spectatorEvent(dealt, SpectatorsPublic, domain.SeatEast).(*event.CardsDealtEvent); len(ev.Hands) != 0 {
+		t.Errorf("Public spectators should see no hands, got %v", ev.Hands)
+	}
+	if ev := spectatorEvent(dealt, SpectatorsSeat, domain.SeatSouth).(*event.CardsDealtEvent); len(ev.Hands) != 1 || len(ev.Hands[domain.SeatSouth]) != 1 {
+		t.Errorf("Seat spectators should see only their seat's hand, got %v", ev.Hands)
+	}
+	if spectatorEvent(dealt, SpectatorsOmniscient, domain.SeatEast) != dealt || len(dealt.Hands) != 2 {
+		t.Error("Omniscient spectators should see the event unchanged")
+	}
+}
+
+func TestSpectatorDelay(t *testing.T) {
+	released := func(h *spectatorHub) int {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		
+		return h.released
+	}
+	play := event.NewCardsPlayedEvent("m", domain.SeatEast, nil, nil)
+	
+	// Events wait for two more actions, and the end of the deal releases the rest
+	h := newSpectatorHub(SpectatorConfig{Mode: SpectatorsOmniscient, DelayActions: 2})
+	h.handle(event.NewDealStartedEvent("m", 1, domain.Two, domain.SeatEast, [2]domain.Rank{}), 1)
+	h.handle(play, 2)
+	h.handle(play, 3)
+	if got := released(h); got != 1 {
+		t.Errorf("Expected the deal start to be released alone, got %d events", got)
+	}
+	h.handle(play, 4)
+	if got := released(h); got != 2 {
+		t.Errorf("Expected the first play after two more, got %d events", got)
+	}
+	h.handle(event.NewDealEndedEvent("m", 1, nil, domain.TeamEastWest, nil, "", 0), 5)
+	if got := released(h); got != 5 {
+		t.Errorf("Expected the end of the deal to release everything, got %d events", got)
+	}
+	
+	// Events wait for the time delay
+	h = newSpectatorHub(SpectatorConfig{Mode: SpectatorsOmniscient, Delay: 20 * time.Millisecond})
+	t.Cleanup(h.stop)
+	h.handle(play, 1)
+	if got := released(h); got != 0 {
+		t.Errorf("Expected the play to wait, got %d events", got)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := released(h); got != 1 {
+		t.Errorf("Expected the play after the delay, got %d events", got)
+	}
+}
+
+func TestSpectators(t *testing.T) {
+	config := DefaultRoomConfig
+	config.Spectators = SpectatorConfig{Mode: SpectatorsOmniscient}
+	if err := config.Spectators.Validate(); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("Expected omniscient spectators without a delay to be refused, got %v", err)
+	}
+	config.Spectators.Mode = SpectatorsSeat
+	if err := config.Spectators.Validate(); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("Expected seat spectators without a delay to be refused, got %v", err)
+	}
+	config.Spectators.Mode = SpectatorsOff
+	off := NewRoomKernel("room", service.NewGameService(), config)
+	t.Cleanup(off.Stop)
+	if err := off.AddSpectator(newTestConn(t), nil); !errors.Is(err, ErrSpectatorsOff) {
+		t.Errorf("Expected ErrSpectatorsOff, got %v", err)
+	}
+	config.Spectators.Mode = SpectatorsSeat
+	seat := NewRoomKernel("room", service.NewGameService(), config)
+	t.Cleanup(seat.Stop)
+	if err := seat.AddSpectator(newTestConn(t), nil); !errors.Is(err, ErrInvalidSeat) {
+		t.Errorf("Expected seat spectators to need a seat, got %v", err)
+	}
+	
+	// Spectators take no seat and do not keep the room open
+	rk := NewRoomKernel("room", service.NewGameService(), DefaultRoomConfig)
+	t.Cleanup(rk.Stop)
+	conn, inbox := newTestClient(t)
+	if err := rk.AddSpectator(conn, nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msgType := <-inbox:
+		if msgType != "Spectating" {
+			t.Errorf("Expected the Spectating greeting, got %s", msgType)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The spectator was not greeted")
+	}
+	snapshot, _ := rk.GetSnapshot()
+	if snapshot.Spectators != 1 || rk.GetPlayerCount() != 0 || !rk.IsEmpty() {
+		t.Errorf("Expected one spectator in an empty room, got %d spectators and %d seats", snapshot.Spectators, rk.GetPlayerCount())
+	}
+	rk.RemoveSpectator(conn)
+	if rk.SpectatorCount() != 0 {
+		t.Error("Expected the spectator to leave")
+	}
+}
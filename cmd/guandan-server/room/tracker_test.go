@@ -0,0 +1,38 @@
+package room
+
+import (
+	"testing"
+
+	"guandan/sdk/domain"
+	"guandan/sdk/tracker"
+)
+
+func TestNewTrackerMessage(t *testing.T) {
+	report := &tracker.Report{
+		Seat:       domain.SeatEast,
+		Remaining:  map[domain.Rank]int{domain.Ace: 5, domain.BigJoker: 2},
+		HandCounts: [4]int{27, 26, 27, 28},
+		Known:      map[domain.SeatID][]domain.Card{domain.SeatNorth: {domain.NewJoker(domain.BigJoker)}},
+		Bombs:      []domain.Rank{domain.Ace},
+		StraightFlushes: []tracker.StraightFlush{
+			{Suit: domain.Spades, High: domain.Nine},
+		},
+	}
+	
+	msg := newTrackerMessage(report, 7)
+	if msg.Type != "Tracker" || msg.Version != 7 {
+		t.Errorf("Unexpected message header: %+v", msg)
+	}
+	if msg.Remaining["A"] != 5 || msg.Remaining["大王"] != 2 {
+		t.Errorf("Unexpected remaining counts: %v", msg.Remaining)
+	}
+	if known := msg.Known[domain.SeatNorth.String()]; len(known) != 1 || known[0] != "大王" {
+		t.Errorf("Unexpected known cards: %v", msg.Known)
+	}
+	if len(msg.Bombs) != 1 || msg.Bombs[0] != "A" || msg.JokerBomb {
+		t.Errorf("Unexpected bombs: %v, joker bomb %v", msg.Bombs, msg.JokerBomb)
+	}
+	if !msg.StraightFlush || len(msg.StraightFlushes) != 1 || msg.StraightFlushes[0] != "♠5-9" {
+		t.Errorf("Unexpected straight flushes: %v", msg.StraightFlushes)
+	}
+}
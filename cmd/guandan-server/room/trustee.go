@@ -0,0 +1,232 @@
+package room
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+	
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+	"guandan/sdk/protocol"
+)
+
+// trustee is the bot playing a seat for its player (托管)
+type trustee struct {
+	agent bot.Agent
+	busy  bool // an action is being chosen or applied
+}
+
+// trusteeAgents lists the bots a room can use for trustee seats
+var trusteeAgents = []string{"heuristic", "mc", "exec:<command>"}
+
+// newTrusteeAgent creates the bot named by spec; an empty spec means the heuristic bot
+func newTrusteeAgent(spec string) (bot.Agent, error) {
+	switch spec {
+	case "", "heuristic":
+		return bot.NewHeuristic(), nil
+	case "mc":
+		return bot.NewMonteCarlo(bot.SearchConfig{}), nil
+	}
+	
+	if command, ok := strings.CutPrefix(spec, "exec:"); ok {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("exec: needs a command")
+		}
+		return protocol.NewExternalAgent(protocol.ExternalConfig{Command: fields, Stderr: os.Stderr})
+	}
+	return nil, fmt.Errorf("unknown trustee agent %q (available: %s)", spec, strings.Join(trusteeAgents, ", "))
+}
+
+// ValidateTrusteeAgent checks a trustee agent name without starting it
+func ValidateTrusteeAgent(spec string) error {
+	switch spec {
+	case "", "heuristic", "mc":
+		return nil
+	}
+	if command, ok := strings.CutPrefix(spec, "exec:"); ok && len(strings.Fields(command)) > 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown trustee agent %q (available: %s)", spec, strings.Join(trusteeAgents, ", "))
+}
+
+// handleTrustee turns trustee on or off at the player's request
+func (rk *RoomKernel) handleTrustee(player *PlayerConn, msg WSMessage) {
+	if rk.matchID == "" {
+		player.Send(NewErrorMessage(ErrGameNotStarted, player.Locale))
+		return
+	}
+	
+	enabled := true
+	if data, ok := msg.Data.(map[string]interface{}); ok {
+		if value, ok := data["enabled"].(bool); ok {
+			enabled = value
+		}
+	}
+	
+	var err error
+	if enabled {
+		err = rk.startTrustee(player.Seat, event.ControlReasonRequest)
+	} else {
+		err = rk.stopTrustee(player.Seat, event.ControlReasonCancel)
+	}
+	if err != nil {
+		log.Printf("Failed to change trustee for seat %s: %v", player.Seat, err)
+		player.Send(NewErrorMessage(err, player.Locale))
+	}
+}
+
+// startTrustee hands a seat to the bot. The caller must hold rk.mutex
+func (rk *RoomKernel) startTrustee(seat domain.SeatID, reason string) error {
+	if _, exists := rk.trustees[seat]; exists {
+		return nil
+	}
+	
+	agent, err := newTrusteeAgent(rk.config.TrusteeAgent)
+	if err != nil {
+		return err
+	}
+	return rk.startAgent(seat, agent, reason)
+}
+
+// startAgent lets agent play a seat. The caller must hold rk.mutex
+func (rk *RoomKernel) startAgent(seat domain.SeatID, agent bot.Agent, reason string) error {
+	if err := rk.gameService.SetSeatControl(rk.matchID, seat, true, reason); err != nil {
+		closeAgent(agent)
+		return err
+	}
+	rk.trustees[seat] = &trustee{agent: agent}
+	log.Printf("Bot took over seat %s in room %s (%s)", seat, rk.roomID, reason)
+	
+	go rk.runTrustees()
+	return nil
+}
+
+// stopTrustee gives a seat back to its player. The caller must hold rk.mutex
+func (rk *RoomKernel) stopTrustee(seat domain.SeatID, reason string) error {
+	t, exists := rk.trustees[seat]
+	if !exists {
+		return nil
+	}
+	
+	delete(rk.trustees, seat)
+	closeAgent(t.agent)
+	log.Printf("Seat %s in room %s returned to its player (%s)", seat, rk.roomID, reason)
+	return rk.gameService.SetSeatControl(rk.matchID, seat, false, reason)
+}
+
+// scheduleTrustee starts the bot for a disconnected seat after the configured delay
+// unless the player reconnects first. The caller must hold rk.mutex
+func (rk *RoomKernel) scheduleTrustee(seat domain.SeatID, player *PlayerConn) {
+	if rk.config.TrusteeDelay <= 0 {
+		return
+	}
+	if timer, exists := rk.takeovers[seat]; exists {
+		timer.Stop()
+	}
+	
+	rk.takeovers[seat] = time.AfterFunc(rk.config.TrusteeDelay, func() {
+		rk.mutex.Lock()
+		defer rk.mutex.Unlock()
+		
+		if rk.players[seat] != player || player.IsConnected() {
+			return
+		}
+		delete(rk.takeovers, seat)
+		if err := rk.startTrustee(seat, event.ControlReasonDisconnect); err != nil {
+			log.Printf("Failed to start trustee for seat %s: %v", seat, err)
+		}
+	})
+}
+
+// runTrustees lets the bot act for every trustee seat that has a pending action.
+// Actions go through the game service like a player's, so they produce the usual events
+func (rk *RoomKernel) runTrustees() {
+	rk.mutex.Lock()
+	matchID := rk.matchID
+	idle := make(map[domain.SeatID]*trustee)
+	for seat, t := range rk.trustees {
+		if !t.busy {
+			idle[seat] = t
+		}
+	}
+	rk.mutex.Unlock()
+	
+	if matchID == "" || len(idle) == 0 {
+		return
+	}
+	pending, err := rk.gameService.GetPendingActions(matchID)
+	if err != nil {
+		return
+	}
+	
+	for seat, t := range idle {
+		if len(pending[seat]) == 0 {
+			continue
+		}
+		
+		rk.mutex.Lock()
+		if rk.trustees[seat] != t || t.busy {
+			rk.mutex.Unlock()
+			continue
+		}
+		t.busy = true
+		rk.mutex.Unlock()
+		
+		go rk.trusteeAct(matchID, seat, t)
+	}
+}
+
+// trusteeAct chooses and applies one action for a trustee seat
+func (rk *RoomKernel) trusteeAct(matchID domain.MatchID, seat domain.SeatID, t *trustee) {
+	acted := false
+	defer func() {
+		rk.mutex.Lock()
+		t.busy = false
+		rk.mutex.Unlock()
+		
+		// Events may be dropped under load, so look again rather than wait for the next one
+		if acted {
+			rk.runTrustees()
+		}
+	}()
+	
+	obs, err := rk.gameService.Observe(matchID, seat)
+	if err != nil {
+		log.Printf("Trustee for seat %s failed to observe: %v", seat, err)
+		return
+	}
+	action, err := t.agent.Act(obs)
+	if err != nil {
+		log.Printf("Trustee for seat %s failed to choose an action: %v", seat, err)
+		return
+	}
+	
+	rk.mutex.RLock()
+	active := rk.trustees[seat] == t
+	rk.mutex.RUnlock()
+	if !active {
+		return
+	}
+	ended, err := rk.applyAction(matchID, seat, action)
+	if err != nil {
+		log.Printf("Trustee for seat %s failed to apply %s: %v", seat, action, err)
+		return
+	}
+	acted = true
+	
+	rk.mutex.Lock()
+	rk.scheduleNextDeal(ended)
+	rk.mutex.Unlock()
+}
+
+// closeAgent stops agents that hold resources, such as external engines
+func closeAgent(agent bot.Agent) {
+	if closer, ok := agent.(io.Closer); ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,272 @@
+package room
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	
+	"github.com/gorilla/websocket"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+	"guandan/sdk/service"
+)
+
+// newTestConn returns the server side of a websocket connection whose client discards every message
+func newTestConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+	
+	conn, _ := newTestClient(t)
+	return conn
+}
+
+// newTestClient returns the server side of a websocket connection and the types of the messages its client receives
+func newTestClient(t *testing.T) (*websocket.Conn, <-chan string) {
+	t.Helper()
+	
+	conns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+	
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	received := make(chan string, 64)
+	go func() {
+		for {
+			var msg struct {
+				Type string `json:"t"`
+			}
+			if err := client.ReadJSON(&msg); err != nil {
+				return
+			}
+			select {
+			case received <- msg.Type:
+			default:
+			}
+		}
+	}()
+	return <-conns, received
+}
+
+// newTestRoom fills a room with four connected players and starts the match
+func newTestRoom(t *testing.T, gs service.GameService, config RoomConfig) (*RoomKernel, [4]*websocket.Conn) {
+	t.Helper()
+	
+	rk := NewRoomKernel("room", gs, config)
+	t.Cleanup(rk.Stop)
+	var conns [4]*websocket.Conn
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		conns[seat] = newTestConn(t)
+		if err := rk.AddPlayer("p-"+seat.String(), seat, conns[seat], ""); err != nil {
+			t.Fatalf("Failed to add %s: %v", seat, err)
+		}
+	}
+	startMatch(t, rk)
+	return rk, conns
+}
+
+// startMatch readies every player and lets the host start the match, dealing straight away
+func startMatch(t *testing.T, rk *RoomKernel) {
+	t.Helper()
+	
+	rk.config.EntropyWindow = 0
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if _, exists := rk.players[seat]; exists {
+			rk.HandleMessage(seat, WSMessage{Type: "Ready"})
+		}
+	}
+	rk.HandleMessage(rk.host, WSMessage{Type: "Start"})
+	if rk.matchID == "" {
+		t.Fatal("The host could not start the match")
+	}
+}
+
+func TestTrusteeTakeoverAndReturn(t *testing.T) {
+	gs := service.NewGameService()
+	config := DefaultRoomConfig
+	config.TrusteeDelay = 20 * time.Millisecond
+	rk, conns := newTestRoom(t, gs, config)
+	
+	changes := make(chan *event.SeatControlChangedEvent, 8)
+	unsubscribe, err := gs.Subscribe(rk.matchID, func(e event.DomainEvent) {
+		if ev, ok := e.(*event.SeatControlChangedEvent); ok {
+			changes <- ev
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer unsubscribe()
+	
+	expect := func(seat domain.SeatID, bot bool, reason string) {
+		t.Helper()
+		select {
+		case ev := <-changes:
+			if ev.Player != seat || ev.Bot != bot || ev.Reason != reason {
+				t.Fatalf("Expected %s bot=%v (%s), got %s bot=%v (%s)", seat, bot, reason, ev.Player, ev.Bot, ev.Reason)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %s bot=%v (%s)", seat, bot, reason)
+		}
+	}
+	
+	// A stale connection closing does not affect the seat
+	rk.DisconnectPlayer(domain.SeatEast, conns[domain.SeatSouth])
+	if rk.CanReconnect(domain.SeatEast, "") {
+		t.Fatal("East should still be connected")
+	}
+	
+	rk.mutex.RLock()
+	token := rk.players[domain.SeatEast].Reconnect
+	rk.mutex.RUnlock()
+	
+	rk.DisconnectPlayer(domain.SeatEast, conns[domain.SeatEast])
+	if !rk.CanReconnect(domain.SeatEast, token) {
+		t.Fatal("East's seat should be kept after the disconnect")
+	}
+	if rk.CanReconnect(domain.SeatEast, "") || rk.CanReconnect(domain.SeatEast, "wrong") {
+		t.Fatal("East's seat should only be kept for its reconnect token")
+	}
+	expect(domain.SeatEast, true, event.ControlReasonDisconnect)
+	
+	rk.HandleMessage(domain.SeatSouth, WSMessage{Type: "Trustee"})
+	expect(domain.SeatSouth, true, event.ControlReasonRequest)
+	if info := rk.getPlayersInfoLocked(); !info[domain.SeatSouth] {
+		t.Errorf("South should be reported as a bot, got %v", info)
+	}
+	
+	if err := rk.AddPlayer("intruder", domain.SeatEast, newTestConn(t), "wrong"); !errors.Is(err, ErrSeatTaken) {
+		t.Fatalf("A wrong reconnect token should not take East's seat, got %v", err)
+	}
+	if err := rk.AddPlayer("p-East", domain.SeatEast, newTestConn(t), token); err != nil {
+		t.Fatalf("Failed to reconnect East: %v", err)
+	}
+	expect(domain.SeatEast, false, event.ControlReasonReconnect)
+	
+	rk.HandleMessage(domain.SeatSouth, WSMessage{Type: "Trustee", Data: map[string]interface{}{"enabled": false}})
+	expect(domain.SeatSouth, false, event.ControlReasonCancel)
+	
+	state, _ := gs.GetMatchState(rk.matchID)
+	if state.BotSeats != [4]bool{} {
+		t.Errorf("Expected no bot seats, got %v", state.BotSeats)
+	}
+}
+
+func TestTrusteesPlayTheDeal(t *testing.T) {
+	gs := service.NewGameService()
+	rk, _ := newTestRoom(t, gs, DefaultRoomConfig)
+	
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		rk.HandleMessage(seat, WSMessage{Type: "Trustee"})
+	}
+	
+	// The room deals the next hand, played at the level of the team that won the first
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err := gs.GetMatchState(rk.matchID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.CurrentDeal >= 2 {
+			if state.Trump == domain.Two || (state.Trump != state.Teams[0].Level && state.Trump != state.Teams[1].Level) {
+				t.Errorf("Expected the second deal at the first winner's level, got trump %s with levels %s/%s",
+					state.Trump, state.Teams[0].Level, state.Teams[1].Level)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Bots did not finish the deal")
+}
+
+func TestNextDealDoesNotWaitForEvents(t *testing.T) {
+	gs := service.NewGameService()
+	rk, _ := newTestRoom(t, gs, DefaultRoomConfig)
+	
+	// Without the event subscription the bots keep playing and the room deals on from their moves
+	rk.mutex.Lock()
+	rk.eventSub()
+	rk.eventSub = nil
+	rk.mutex.Unlock()
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		rk.HandleMessage(seat, WSMessage{Type: "Trustee"})
+	}
+	
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err := gs.GetMatchState(rk.matchID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.CurrentDeal >= 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	
+	// A deal's end schedules the next deal only once
+	rk.mutex.Lock()
+	rk.scheduleNextDeal(1)
+	rk.mutex.Unlock()
+	state, _ := gs.GetMatchState(rk.matchID)
+	if state.CurrentDeal < 2 {
+		t.Fatal("The room should deal on without the DealEnded event")
+	}
+	if state.CurrentDeal > rk.dealsEnded+1 {
+		t.Errorf("Expected one deal per ended deal, got deal %d after %d ended", state.CurrentDeal, rk.dealsEnded)
+	}
+}
+
+// getPlayersInfoLocked reports which seats the room shows as played by the bot
+func (rk *RoomKernel) getPlayersInfoLocked() map[domain.SeatID]bool {
+	rk.mutex.RLock()
+	defer rk.mutex.RUnlock()
+	
+	bots := make(map[domain.SeatID]bool)
+	for _, info := range rk.getPlayersInfo() {
+		bots[info.Seat] = info.Bot
+	}
+	return bots
+}
+
+func TestPlayerMovesGoThroughActions(t *testing.T) {
+	gs := service.NewGameService()
+	rk, _ := newTestRoom(t, gs, DefaultRoomConfig)
+	
+	leader, err := gs.GetCurrentPlayer(rk.matchID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	// The leader cannot pass, and unparseable cards are rejected before reaching the engine
+	rk.HandleMessage(leader, WSMessage{Type: "Pass"})
+	rk.HandleMessage(leader, WSMessage{Type: "PlayCards", Data: map[string]interface{}{"cards": []interface{}{"not-a-card"}}})
+	if current, _ := gs.GetCurrentPlayer(rk.matchID); current != leader {
+		t.Fatalf("Rejected moves should not change the turn, %s is to play", current)
+	}
+	
+	plays, err := gs.GetValidPlays(rk.matchID, leader)
+	if err != nil || len(plays) == 0 {
+		t.Fatalf("Expected valid plays for %s: %v", leader, err)
+	}
+	cards := make([]interface{}, len(plays[0]))
+	for i, card := range plays[0] {
+		cards[i] = card.String()
+	}
+	rk.HandleMessage(leader, WSMessage{Type: "PlayCards", Data: map[string]interface{}{"cards": cards}})
+	if current, _ := gs.GetCurrentPlayer(rk.matchID); current == leader {
+		t.Errorf("%s's play should pass the turn", leader)
+	}
+}
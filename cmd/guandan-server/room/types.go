@@ -1,11 +1,14 @@
 package room
 
 import (
+	"crypto/subtle"
 	"sync"
 	"time"
-
+	
 	"github.com/gorilla/websocket"
+	"guandan/sdk/analysis"
 	"guandan/sdk/domain"
+	"guandan/sdk/engine"
 )
 
 // PlayerConn represents a player connection in a room
@@ -15,6 +18,8 @@ type PlayerConn struct {
 	Conn       *websocket.Conn
 	LastPing   time.Time
 	Connected  bool
+	Locale     string // language for error messages, e.g. "en" or "zh"
+	Reconnect  string // token the player needs to take the seat back after a disconnect
 	mutex      sync.RWMutex
 }
 
@@ -26,6 +31,7 @@ func NewPlayerConn(playerID string, seat domain.SeatID, conn *websocket.Conn) *P
 		Conn:      conn,
 		LastPing:  time.Now(),
 		Connected: true,
+		Locale:    DefaultLocale,
 	}
 }
 
@@ -52,6 +58,11 @@ func (pc *PlayerConn) Close() {
 	}
 }
 
+// canReconnect checks the token that takes the seat back after a disconnect
+func (pc *PlayerConn) canReconnect(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(pc.Reconnect)) == 1
+}
+
 // IsConnected checks if the player is connected
 func (pc *PlayerConn) IsConnected() bool {
 	pc.mutex.RLock()
@@ -72,7 +83,55 @@ type PlayCardsMessage struct {
 
 type PassMessage struct{}
 
+// EntropyMessage carries client entropy for the provably fair shuffle
+type EntropyMessage struct {
+	Entropy string `json:"entropy"`
+}
+
+// HintMessage asks for the next suggested play; repeated requests cycle through the list
+type HintMessage struct{}
+
+// TrusteeMessage hands the seat to the bot (托管), or takes it back when Enabled is false
+type TrusteeMessage struct {
+	Enabled *bool `json:"enabled,omitempty"` // omitted means true
+}
+
+// SeatMessage names a seat: the seat to move to, to swap with or to kick
+type SeatMessage struct {
+	Seat domain.SeatID `json:"seat"`
+}
+
+// AddBotMessage fills a seat with a bot from the lobby (host only)
+type AddBotMessage struct {
+	Seat  domain.SeatID `json:"seat"`
+	Agent string        `json:"agent,omitempty"` // heuristic when omitted
+}
+
+// ReadyMessage sets the player ready in the lobby, or not ready when Ready is false
+type ReadyMessage struct {
+	Ready *bool `json:"ready,omitempty"` // omitted means true
+}
+
+// SettingsUpdate changes the room settings from the lobby; omitted fields keep their value.
+// Choosing a rule preset first resets the timer and card tracker to the preset
+type SettingsUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Rules       *string `json:"rules,omitempty"`
+	ActionTime  *int    `json:"actionTime,omitempty"`
+	TimeBank    *int    `json:"timeBank,omitempty"`
+	CardTracker *bool   `json:"cardTracker,omitempty"`
+	TargetLevel *string `json:"targetLevel,omitempty"`
+}
+
 // Server to client messages
+
+// JoinedMessage tells a player which seat they took and the token that takes it back after a disconnect
+type JoinedMessage struct {
+	Type      string        `json:"t"`
+	Seat      domain.SeatID `json:"seat"`
+	Reconnect string        `json:"reconnect"`
+}
+
 type SnapshotMessage struct {
 	Type    string      `json:"t"`
 	Version int         `json:"version"`
@@ -86,6 +145,86 @@ type EventMessage struct {
 	Version int         `json:"version"`
 }
 
+// HintResultMessage answers a Hint request. Index points at the suggestion to
+// highlight and advances on each request until the game state changes.
+type HintResultMessage struct {
+	Type        string           `json:"t"`
+	Index       int              `json:"index"`
+	Suggestions []HintSuggestion `json:"suggestions"`
+	Version     int              `json:"version"`
+}
+
+type HintSuggestion struct {
+	Cards  []string `json:"cards"` // empty means pass
+	Pass   bool     `json:"pass"`
+	Reason string   `json:"reason"`
+	Score  int      `json:"score"`
+}
+
+// TrackerMessage is a seat's card tracker (记牌器) view, sent after each public
+// change when the room enables the tracker
+type TrackerMessage struct {
+	Type            string              `json:"t"`
+	Version         int                 `json:"version"`
+	Remaining       map[string]int      `json:"remaining"` // unseen cards by rank, e.g. "A" or "大王"
+	HandCounts      [4]int              `json:"handCounts"`
+	Known           map[string][]string `json:"known,omitempty"` // tribute cards known to be in a seat's hand
+	Bombs           []string            `json:"bombs"`           // ranks that can still form a bomb
+	JokerBomb       bool                `json:"jokerBomb"`
+	StraightFlush   bool                `json:"straightFlush"`
+	StraightFlushes []string            `json:"straightFlushes,omitempty"` // e.g. "♠5-9"
+}
+
+// LobbyMessage is broadcast as "LobbyUpdated" after every change in the lobby
+type LobbyMessage struct {
+	Type string `json:"t"`
+	*LobbyState
+}
+
+// LobbyState describes the room before the match starts
+type LobbyState struct {
+	Host     *domain.SeatID `json:"host"` // nil while no player is in the room
+	Seats    []LobbySeat    `json:"seats"`
+	Settings RoomSettings   `json:"settings"`
+}
+
+// LobbySeat is one of the four seats in the lobby
+type LobbySeat struct {
+	Seat      domain.SeatID  `json:"seat"`
+	PlayerID  string         `json:"playerId,omitempty"` // empty for a free seat
+	Bot       string         `json:"bot,omitempty"`      // agent of a bot seat
+	Connected bool           `json:"connected"`
+	Ready     bool           `json:"ready"`              // bots are always ready
+	SwapWith  *domain.SeatID `json:"swapWith,omitempty"` // seat this player asked to swap with
+}
+
+// RoomSettings are the settings the host chooses in the lobby
+type RoomSettings struct {
+	Name        string `json:"name"`
+	Rules       string `json:"rules"`       // rule preset: "casual", "standard" or "tournament"
+	ActionTime  int    `json:"actionTime"`  // seconds per action, 0 disables the turn timer
+	TimeBank    int    `json:"timeBank"`    // seconds of time bank per seat per deal
+	CardTracker bool   `json:"cardTracker"`
+	TargetLevel string `json:"targetLevel"` // levels rise up to this level; a team at it wins by winning a deal, "2" to "A"
+	Visibility  string `json:"visibility"`  // set when the room is created
+}
+
+// SpectatingMessage greets a spectator with its view of the room; the events follow
+type SpectatingMessage struct {
+	Type    string        `json:"t"`
+	View    SpectatorView `json:"view"`
+	Players []PlayerInfo  `json:"players"`
+	Status  string        `json:"status"`
+}
+
+// SpectatorView describes what a spectator sees
+type SpectatorView struct {
+	Mode         string         `json:"mode"`
+	Seat         *domain.SeatID `json:"seat,omitempty"` // watched seat in seat mode
+	Delay        int            `json:"delay"`          // seconds events are held back
+	DelayActions int            `json:"delayActions"`   // actions events are held back
+}
+
 // Match snapshot for synchronization
 type MatchSnapshot struct {
 	MatchID      string                     `json:"matchId"`
@@ -93,6 +232,8 @@ type MatchSnapshot struct {
 	CurrentDeal  *DealSnapshot              `json:"currentDeal"`
 	Status       string                     `json:"status"`
 	Version      int                        `json:"version"`
+	Lobby        *LobbyState                `json:"lobby,omitempty"` // set while the room is waiting
+	Spectators   int                        `json:"spectators"`
 }
 
 type PlayerInfo struct {
@@ -102,6 +243,8 @@ type PlayerInfo struct {
 	HandCount int           `json:"handCount"`
 	Level     int           `json:"level"`
 	Connected bool          `json:"connected"`
+	Bot       bool          `json:"bot"`             // the seat is played by the bot (托管)
+	Agent     string        `json:"agent,omitempty"` // bot added from the lobby to fill the seat
 }
 
 type DealSnapshot struct {
@@ -113,6 +256,7 @@ type DealSnapshot struct {
 	LastPlayer   domain.SeatID         `json:"lastPlayer"`
 	TrickHistory []TrickInfo           `json:"trickHistory"`
 	PlayerHands  map[domain.SeatID][]domain.Card `json:"playerHands"`
+	ShuffleCommitment string               `json:"shuffleCommitment,omitempty"`
 }
 
 type TrickInfo struct {
@@ -127,6 +271,18 @@ type RoomConfig struct {
 	IdleTimeout   time.Duration `json:"idleTimeout"`
 	PingInterval  time.Duration `json:"pingInterval"`
 	AllowReconnect bool         `json:"allowReconnect"`
+	TurnTimer     *engine.TimerConfig `json:"turnTimer,omitempty"` // nil means players have unlimited time
+	CardTracker   bool          `json:"cardTracker"`         // send each seat a card tracker feed; some tables forbid it
+	Analysis      analysis.Config `json:"-"`                 // search budget for post-game analysis; zero values use the defaults
+	TrusteeDelay  time.Duration `json:"trusteeDelay"`        // how long a disconnected seat waits before the bot takes over, 0 disables the takeover
+	TrusteeAgent  string        `json:"trusteeAgent"`        // bot for trustee seats: "heuristic", "mc" or "exec:<command>"
+	Name          string        `json:"name"`
+	Rules         string        `json:"rules"`               // rule preset the timer and card tracker were set from
+	TargetLevel   domain.Rank   `json:"targetLevel"`         // level at which the match ends
+	Visibility    string        `json:"visibility"`          // VisibilityPublic or VisibilityPrivate
+	Password      string        `json:"-"`                   // optional; the room keeps only its hash
+	Spectators    SpectatorConfig `json:"spectators"`
+	EntropyWindow time.Duration `json:"entropyWindow"`       // time between the shuffle commitment and the deal, for seats to add entropy
 }
 
 // Default room configuration
@@ -135,6 +291,13 @@ var DefaultRoomConfig = RoomConfig{
 	IdleTimeout:   30 * time.Minute,
 	PingInterval:  30 * time.Second,
 	AllowReconnect: true,
+	TrusteeDelay:  30 * time.Second,
+	TrusteeAgent:  "heuristic",
+	Rules:         "casual",
+	TargetLevel:   domain.Ace,
+	Visibility:    VisibilityPublic,
+	Spectators:    SpectatorConfig{Mode: SpectatorsPublic, MaxSpectators: 50},
+	EntropyWindow: 3 * time.Second,
 }
 
 // Room events
@@ -167,4 +330,18 @@ var (
 	ErrGameAlreadyStarted = RoomError{"GAME_ALREADY_STARTED", "Game already started"}
 	ErrInvalidAction      = RoomError{"INVALID_ACTION", "Invalid action"}
 	ErrNotPlayerTurn      = RoomError{"NOT_PLAYER_TURN", "Not player's turn"}
+	ErrDealNotFound       = RoomError{"DEAL_NOT_FOUND", "Deal not found"}
+	ErrSeatUnderBotControl = RoomError{"BOT_CONTROL", "Seat is played by the bot; cancel trustee first"}
+	ErrBotNotFound        = RoomError{"BOT_NOT_FOUND", "No bot in this seat"}
+	ErrInvalidBot         = RoomError{"INVALID_BOT", "Unknown bot; choose heuristic or mc"}
+	ErrBotsOnly           = RoomError{"BOTS_ONLY", "A room needs at least one player"}
+	ErrNotHost            = RoomError{"NOT_HOST", "Only the host can do this"}
+	ErrKicked             = RoomError{"KICKED", "You were kicked from this room"}
+	ErrNotReady           = RoomError{"NOT_READY", "All four seats must be taken and ready"}
+	ErrInvalidSettings    = RoomError{"INVALID_SETTINGS", "Invalid room settings"}
+	ErrAccessDenied       = RoomError{"ACCESS_DENIED", "Wrong password, invite code or ticket"}
+	ErrInviteNotFound     = RoomError{"INVITE_NOT_FOUND", "Invite code not found"}
+	ErrTooManyAttempts    = RoomError{"TOO_MANY_ATTEMPTS", "Too many failed attempts; try again later"}
+	ErrSpectatorsOff      = RoomError{"SPECTATORS_OFF", "This room does not allow spectators"}
+	ErrTooManySpectators  = RoomError{"TOO_MANY_SPECTATORS", "Too many spectators"}
 )
\ No newline at end of file
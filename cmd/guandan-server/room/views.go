@@ -0,0 +1,105 @@
+package room
+
+import (
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// noSeat is a viewer without a hand of its own, such as a public spectator or the REST snapshot
+const noSeat domain.SeatID = -1
+
+// eventView returns what a viewer may see of an event: the public fields of the events listed here
+// and the dealt hand of seat. Fields and events not listed are withheld, so new ones stay private
+// until they are added; nil means the viewer gets nothing
+func eventView(e event.DomainEvent, seat domain.SeatID) event.DomainEvent {
+	switch ev := e.(type) {
+	case *event.MatchCreatedEvent:
+		view := &event.MatchCreatedEvent{BaseEvent: ev.BaseEvent, SeedCommitment: ev.SeedCommitment}
+		for _, player := range ev.Players {
+			view.Players = append(view.Players, publicPlayer(&player))
+		}
+		for i, team := range ev.Teams {
+			view.Teams[i] = domain.Team{ID: team.ID, Level: team.Level}
+			for j, player := range team.Players {
+				if player != nil {
+					public := publicPlayer(player)
+					view.Teams[i].Players[j] = &public
+				}
+			}
+		}
+		return view
+	case *event.DealStartedEvent:
+		return &event.DealStartedEvent{BaseEvent: ev.BaseEvent, DealNumber: ev.DealNumber, Trump: ev.Trump,
+			FirstPlayer: ev.FirstPlayer, Levels: ev.Levels}
+	case *event.CardsDealtEvent:
+		return &event.CardsDealtEvent{BaseEvent: ev.BaseEvent, Hands: handsView(ev.Hands, seat)}
+	case *event.ShuffleRecordedEvent:
+		return &event.ShuffleRecordedEvent{BaseEvent: ev.BaseEvent, DealNumber: ev.DealNumber, Commitment: ev.Commitment}
+	case *event.ShuffleCommittedEvent:
+		return &event.ShuffleCommittedEvent{BaseEvent: ev.BaseEvent, DealNumber: ev.DealNumber, Commitment: ev.Commitment}
+	case *event.ShuffleRevealedEvent:
+		// Seeds are revealed once the deal is over
+		return &event.ShuffleRevealedEvent{BaseEvent: ev.BaseEvent, DealNumber: ev.DealNumber, Commitment: ev.Commitment,
+			ServerSeed: ev.ServerSeed, ClientSeeds: ev.ClientSeeds}
+	case *event.TrumpDeterminedEvent:
+		return &event.TrumpDeterminedEvent{BaseEvent: ev.BaseEvent, CurrentLevel: ev.CurrentLevel, Trump: ev.Trump}
+	case *event.TributeRequestedEvent:
+		return &event.TributeRequestedEvent{BaseEvent: ev.BaseEvent, RequiredTributes: ev.RequiredTributes}
+	case *event.TributeGivenEvent:
+		// Tribute cards are shown to the table
+		return &event.TributeGivenEvent{BaseEvent: ev.BaseEvent, From: ev.From, To: ev.To, Cards: ev.Cards}
+	case *event.TributeSelectionRequestedEvent:
+		return &event.TributeSelectionRequestedEvent{BaseEvent: ev.BaseEvent, Selector: ev.Selector, AvailableCards: ev.AvailableCards}
+	case *event.TributeCardSelectedEvent:
+		return &event.TributeCardSelectedEvent{BaseEvent: ev.BaseEvent, Selector: ev.Selector, SelectedFrom: ev.SelectedFrom,
+			SelectedCard: ev.SelectedCard, RemainingTo: ev.RemainingTo, RemainingCard: ev.RemainingCard}
+	case *event.FirstPlayerDeterminedEvent:
+		return &event.FirstPlayerDeterminedEvent{BaseEvent: ev.BaseEvent, FirstPlayer: ev.FirstPlayer}
+	case *event.CardsPlayedEvent:
+		return &event.CardsPlayedEvent{BaseEvent: ev.BaseEvent, Player: ev.Player, Cards: ev.Cards, CardGroup: ev.CardGroup}
+	case *event.PlayerPassedEvent:
+		return &event.PlayerPassedEvent{BaseEvent: ev.BaseEvent, Player: ev.Player}
+	case *event.TrickWonEvent:
+		return &event.TrickWonEvent{BaseEvent: ev.BaseEvent, Winner: ev.Winner, TrickNumber: ev.TrickNumber}
+	case *event.PlayerFinishedEvent:
+		return &event.PlayerFinishedEvent{BaseEvent: ev.BaseEvent, Player: ev.Player, Position: ev.Position}
+	case *event.DealEndedEvent:
+		// The dealt hands and the deal seed are revealed once the deal is over
+		return &event.DealEndedEvent{BaseEvent: ev.BaseEvent, DealNumber: ev.DealNumber, RankList: ev.RankList,
+			WinnerTeam: ev.WinnerTeam, DealtHands: ev.DealtHands, ShuffleCommitment: ev.ShuffleCommitment, DealSeed: ev.DealSeed}
+	case *event.MatchEndedEvent:
+		return &event.MatchEndedEvent{BaseEvent: ev.BaseEvent, WinnerTeam: ev.WinnerTeam, FinalScore: ev.FinalScore, Seed: ev.Seed}
+	case *event.TurnTimerStartedEvent:
+		return &event.TurnTimerStartedEvent{BaseEvent: ev.BaseEvent, Player: ev.Player, ActionTime: ev.ActionTime,
+			TimeBank: ev.TimeBank, Deadline: ev.Deadline}
+	case *event.TurnTimerTickedEvent:
+		return &event.TurnTimerTickedEvent{BaseEvent: ev.BaseEvent, Player: ev.Player, ActionRemaining: ev.ActionRemaining,
+			TimeBankRemaining: ev.TimeBankRemaining}
+	case *event.TurnTimerExpiredEvent:
+		return &event.TurnTimerExpiredEvent{BaseEvent: ev.BaseEvent, Player: ev.Player, Action: ev.Action}
+	case *event.SeatControlChangedEvent:
+		return &event.SeatControlChangedEvent{BaseEvent: ev.BaseEvent, Player: ev.Player, Bot: ev.Bot, Reason: ev.Reason}
+	}
+	return nil
+}
+
+// publicPlayer copies a player without their hand
+func publicPlayer(player *domain.Player) domain.Player {
+	return domain.Player{
+		ID:       player.ID,
+		Name:     player.Name,
+		SeatID:   player.SeatID,
+		TeamID:   player.TeamID,
+		Level:    player.Level,
+		IsOnline: player.IsOnline,
+	}
+}
+
+// handsView returns the hand of seat alone; other hands are left out
+func handsView(hands map[domain.SeatID][]domain.Card, seat domain.SeatID) map[domain.SeatID][]domain.Card {
+	view := make(map[domain.SeatID][]domain.Card)
+	if hand, exists := hands[seat]; exists {
+		view[seat] = hand
+	}
+	return view
+}
@@ -0,0 +1,78 @@
+package room
+
+import (
+	"testing"
+	
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+	"guandan/sdk/service"
+)
+
+// secretEvent is an event the views do not list
+type secretEvent struct {
+	event.BaseEvent
+	Hand []domain.Card
+}
+
+func TestEventViews(t *testing.T) {
+	card := domain.NewCard(domain.Hearts, domain.Two)
+	hands := map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {card},
+		domain.SeatSouth: {card},
+	}
+	
+	dealt := eventView(event.NewCardsDealtEvent("m", hands), domain.SeatEast).(*event.CardsDealtEvent)
+	if len(dealt.Hands) != 1 || len(dealt.Hands[domain.SeatEast]) != 1 {
+		t.Errorf("A player should see only their own hand, got %v", dealt.Hands)
+	}
+	
+	// Players listed in the match carry no hands, not even through their team
+	east := domain.NewPlayer("p-East", "East", domain.SeatEast)
+	east.Hand = []domain.Card{card}
+	team := domain.Team{ID: domain.TeamEastWest, Players: [2]*domain.Player{east, nil}}
+	created := eventView(event.NewMatchCreatedEvent("m", []domain.Player{*east}, [2]domain.Team{team}, 1), noSeat).(*event.MatchCreatedEvent)
+	if len(created.Players) != 1 || created.Players[0].ID != "p-East" || created.Players[0].Hand != nil {
+		t.Errorf("Expected the player without their hand, got %+v", created.Players)
+	}
+	if player := created.Teams[0].Players[0]; player == nil || player.Hand != nil || created.Teams[0].Players[1] != nil {
+		t.Errorf("Expected the team's players without their hands, got %+v", created.Teams[0].Players)
+	}
+	if created.SeedCommitment == "" {
+		t.Error("The seed commitment is public")
+	}
+	
+	// Events that are not listed are withheld from everyone but omniscient spectators
+	secret := &secretEvent{BaseEvent: event.BaseEvent{EventTypeName: "Secret"}, Hand: []domain.Card{card}}
+	if view := eventView(secret, domain.SeatEast); view != nil {
+		t.Errorf("Expected an unlisted event to be withheld, got %+v", view)
+	}
+	if view := spectatorEvent(secret, SpectatorsSeat, domain.SeatEast); view != nil {
+		t.Errorf("Expected an unlisted event to be withheld from seat spectators, got %+v", view)
+	}
+	if view := spectatorEvent(secret, SpectatorsOmniscient, domain.SeatEast); view != secret {
+		t.Errorf("Omniscient spectators should see every event, got %+v", view)
+	}
+}
+
+func TestSnapshotHands(t *testing.T) {
+	rk, _ := newTestRoom(t, service.NewGameService(), DefaultRoomConfig)
+	
+	snapshot, err := rk.GetSnapshot()
+	if err != nil || snapshot.CurrentDeal == nil {
+		t.Fatalf("Expected a snapshot of the deal, got %+v, %v", snapshot, err)
+	}
+	if len(snapshot.CurrentDeal.PlayerHands) != 0 {
+		t.Errorf("The public snapshot should carry no hands, got %d", len(snapshot.CurrentDeal.PlayerHands))
+	}
+	
+	rk.mutex.RLock()
+	snapshot, err = rk.snapshotFor(domain.SeatSouth)
+	rk.mutex.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hands := snapshot.CurrentDeal.PlayerHands
+	if len(hands) != 1 || len(hands[domain.SeatSouth]) != 27 {
+		t.Errorf("A player's snapshot should carry only their own hand, got %d hands", len(hands))
+	}
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"net/http"
-
+	
 	"github.com/gorilla/mux"
 	"guandan/cmd/guandan-server/handler"
 	"guandan/sdk/service"
@@ -33,9 +33,19 @@ func SetupRouter(gameService service.GameService) *mux.Router {
 	api.HandleFunc("/room/{id}/join", restHandler.JoinRoom).Methods("POST")
 	api.HandleFunc("/room/{id}", restHandler.GetRoomInfo).Methods("GET")
 	api.HandleFunc("/rooms", restHandler.ListRooms).Methods("GET")
+	api.HandleFunc("/room/{id}/bots", restHandler.AddBot).Methods("POST")
+	api.HandleFunc("/room/{id}/bots/{seat}", restHandler.RemoveBot).Methods("DELETE")
+	api.HandleFunc("/room/{id}/invites", restHandler.CreateInvite).Methods("POST")
+	api.HandleFunc("/room/{id}/invites/{code}", restHandler.RevokeInvite).Methods("DELETE")
+	
+	// Replay routes
+	api.HandleFunc("/room/{id}/deals", restHandler.ListDeals).Methods("GET")
+	api.HandleFunc("/room/{id}/deals/{deal}", restHandler.GetDealRecord).Methods("GET")
+	api.HandleFunc("/room/{id}/deals/{deal}/analysis", restHandler.GetDealAnalysis).Methods("GET")
 	
 	// WebSocket routes
 	api.HandleFunc("/room/{id}/ws", wsHandler.HandleWebSocket)
+	api.HandleFunc("/room/{id}/spectate", wsHandler.HandleSpectator)
 	
 	// Health check route
 	api.HandleFunc("/health", restHandler.Health).Methods("GET")
@@ -54,7 +64,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Room-Token")
 		
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/protocol"
+	"guandan/sdk/sim"
+)
+
+// agentNames lists the agents that can be assigned to a seat
+var agentNames = []string{"heuristic", "mc", "mc-belief", "exec:<command>"}
+
+// parseSeats turns a comma separated list of agent names in seat order
+// (East, South, West, North) into one factory per seat. A single name is
+// used for every seat.
+func parseSeats(spec string, iterations int) ([4]sim.AgentFactory, error) {
+	var factories [4]sim.AgentFactory
+	
+	names := strings.Split(spec, ",")
+	if len(names) == 1 {
+		names = []string{names[0], names[0], names[0], names[0]}
+	}
+	if len(names) != 4 {
+		return factories, fmt.Errorf("expected 1 or 4 agents, got %d", len(names))
+	}
+	
+	for seat, name := range names {
+		factory, err := newFactory(strings.TrimSpace(name), iterations)
+		if err != nil {
+			return factories, err
+		}
+		factories[seat] = factory
+	}
+	return factories, nil
+}
+
+func newFactory(name string, iterations int) (sim.AgentFactory, error) {
+	switch name {
+	case "heuristic":
+		return func(domain.SeatID, int64) bot.Agent {
+			return bot.NewHeuristic()
+		}, nil
+	case "mc", "mc-belief":
+		belief := name == "mc-belief"
+		return func(seat domain.SeatID, seed int64) bot.Agent {
+			// Matches already run in parallel, so each search stays on one goroutine
+			return bot.NewMonteCarlo(bot.SearchConfig{
+				Iterations: iterations,
+				Workers:    1,
+				Seed:       seed*4 + int64(seat) + 1,
+				Belief:     belief,
+			})
+		}, nil
+	}
+	
+	if command, ok := strings.CutPrefix(name, "exec:"); ok {
+		return externalFactory(strings.Fields(command))
+	}
+	return nil, fmt.Errorf("unknown agent %q (available: %s)", name, strings.Join(agentNames, ", "))
+}
+
+// externalFactory runs an engine that speaks the text protocol, one process
+// per seat and match. The simulator closes it when the match ends.
+func externalFactory(command []string) (sim.AgentFactory, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("exec: needs a command")
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, err
+	}
+	
+	return func(domain.SeatID, int64) bot.Agent {
+		agent, err := protocol.NewExternalAgent(protocol.ExternalConfig{
+			Command: command,
+			Stderr:  os.Stderr,
+		})
+		if err != nil {
+			// A nil agent fails the match, which reports the seed
+			log.Printf("Failed to start %s: %v", command[0], err)
+			return nil
+		}
+		return agent
+	}, nil
+}
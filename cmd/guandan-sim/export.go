@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	
+	"guandan/sdk/bot"
+	"guandan/sdk/dataset"
+	"guandan/sdk/domain"
+	"guandan/sdk/service"
+	"guandan/sdk/sim"
+)
+
+// exportConfig describes a self-play run whose decisions are written as
+// training data instead of being summarised in a report
+type exportConfig struct {
+	sim     sim.Config
+	dataset dataset.Config
+}
+
+// runExport plays the configured matches through a game service so that the
+// exporter sees them exactly as it would see matches on a server. Matches that
+// fail are reported by seed and do not stop the run.
+func runExport(cfg exportConfig) (dataset.Stats, []string, error) {
+	exporter, err := dataset.New(cfg.dataset)
+	if err != nil {
+		return dataset.Stats{}, nil, err
+	}
+	
+	workers := cfg.sim.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	gs := service.NewGameService()
+	seeds := make(chan int64)
+	var mu sync.Mutex
+	var failures []string
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range seeds {
+				var agents [4]bot.Agent
+				for seat, factory := range cfg.sim.Agents {
+					agents[seat] = factory(domain.SeatID(seat), seed)
+				}
+				_, err := dataset.SelfPlay(gs, exporter, seed, agents, cfg.sim.MaxDeals)
+				closeAgents(agents)
+				if err != nil {
+					mu.Lock()
+					failures = append(failures, fmt.Sprintf("seed %d: %v", seed, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < cfg.sim.Matches; i++ {
+		seed := cfg.sim.Seed
+		if !cfg.sim.FixedSeed {
+			seed += int64(i)
+		}
+		seeds <- seed
+	}
+	close(seeds)
+	wg.Wait()
+	
+	if err := exporter.Close(); err != nil {
+		return exporter.Stats(), failures, err
+	}
+	return exporter.Stats(), failures, nil
+}
+
+func closeAgents(agents [4]bot.Agent) {
+	for _, agent := range agents {
+		if closer, ok := agent.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+func writeExportStats(w io.Writer, stats dataset.Stats, cfg dataset.Config) error {
+	_, err := fmt.Fprintf(w, "Exported %d records from %d deals of %d matches to %d %s shards in %s (%d deals skipped)\n",
+		stats.Records, stats.Deals, stats.Matches, stats.Shards, cfg.Format, cfg.Dir, stats.Skipped)
+	return err
+}
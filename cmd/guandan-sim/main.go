@@ -0,0 +1,134 @@
+// Command guandan-sim runs many bot matches on the real engine and reports
+// aggregate statistics. Failed matches are listed with their seed so that
+// rule bugs found during a run can be reproduced.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	
+	"guandan/sdk/dataset"
+	"guandan/sdk/sim"
+)
+
+func main() {
+	matches := flag.Int("n", 100, "number of matches to play (pairs of tables in duplicate mode)")
+	seed := flag.Int64("seed", 1, "seed of the first match")
+	fixedSeed := flag.Bool("fixed-seed", false, "use the same seed for every match instead of seed, seed+1, ...")
+	workers := flag.Int("workers", 0, "matches played in parallel (0 = number of CPUs)")
+	maxDeals := flag.Int("max-deals", sim.DefaultMaxDeals, "deals after which an undecided match is stopped")
+	duplicate := flag.Bool("duplicate", false, "play each seed at two tables with the teams swapped and score East-West against South-North per board")
+	boards := flag.Int("boards", sim.DefaultBoards, "deals per table in duplicate mode")
+	seats := flag.String("seats", "heuristic", "agents in seat order East,South,West,North, or one agent for all seats ("+strings.Join(agentNames, ", ")+")")
+	iterations := flag.Int("mc-iterations", 200, "simulations per decision for Monte Carlo agents")
+	format := flag.String("format", "table", "output format: table, json or csv")
+	output := flag.String("o", "", "write the report to this file instead of stdout")
+	export := flag.String("export", "", "write every decision of the matches as training data to this directory instead of reporting")
+	exportFormat := flag.String("export-format", "jsonl", "training data format: jsonl or binary")
+	shardRecords := flag.Int("shard-records", dataset.DefaultShardRecords, "records per training data shard")
+	flag.Parse()
+	
+	agents, err := parseSeats(*seats, *iterations)
+	if err != nil {
+		log.Fatalf("Invalid -seats: %v", err)
+	}
+	
+	if *export != "" {
+		datasetFormat, err := dataset.ParseFormat(*exportFormat)
+		if err != nil {
+			log.Fatalf("Invalid -export-format: %v", err)
+		}
+		cfg := exportConfig{
+			sim: sim.Config{
+				Matches:   *matches,
+				Seed:      *seed,
+				FixedSeed: *fixedSeed,
+				Workers:   *workers,
+				MaxDeals:  *maxDeals,
+				Agents:    agents,
+			},
+			dataset: dataset.Config{Dir: *export, Format: datasetFormat, ShardRecords: *shardRecords},
+		}
+		stats, failures, err := runExport(cfg)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		writeExportStats(os.Stdout, stats, cfg.dataset)
+		for _, failure := range failures {
+			log.Printf("Match failed: %s", failure)
+		}
+		if len(failures) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	
+	write, err := writer(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	
+	cfg := sim.Config{
+		Matches:   *matches,
+		Seed:      *seed,
+		FixedSeed: *fixedSeed,
+		Workers:   *workers,
+		MaxDeals:  *maxDeals,
+		Boards:    *boards,
+		Agents:    agents,
+	}
+	
+	var result *report
+	if *duplicate {
+		summary, err := sim.RunDuplicate(cfg)
+		if err != nil {
+			log.Fatalf("Simulation failed: %v", err)
+		}
+		result = duplicateReport(summary)
+	} else {
+		summary, err := sim.Run(cfg)
+		if err != nil {
+			log.Fatalf("Simulation failed: %v", err)
+		}
+		result = matchReport(summary)
+	}
+	
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+	
+	if err := write(out, result); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	
+	// A non-zero exit status lets CI use the simulator as a soak test
+	if len(result.Failures) > 0 {
+		if *output != "" {
+			log.Printf("%d of %d matches failed, see %s", len(result.Failures), *matches, *output)
+		}
+		os.Exit(1)
+	}
+}
+
+func writer(format string) (func(io.Writer, *report) error, error) {
+	switch format {
+	case "table":
+		return writeTable, nil
+	case "json":
+		return writeJSON, nil
+	case "csv":
+		return writeCSV, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
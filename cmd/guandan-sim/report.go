@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	
+	"guandan/sdk/domain"
+	"guandan/sdk/sim"
+)
+
+// metric is one line of the report
+type metric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// report is what gets written in any of the output formats. Result is the
+// raw summary, included only in JSON output.
+type report struct {
+	Result   interface{}
+	Metrics  []metric
+	Failures []sim.Failure
+	Elapsed  time.Duration
+}
+
+func matchReport(summary *sim.Summary) *report {
+	return &report{
+		Result: summary,
+		Metrics: []metric{
+			{"matches", float64(summary.Matches)},
+			{"failed_matches", float64(len(summary.Failures))},
+			{"decided_matches", float64(summary.Decided)},
+			{"win_rate_east_west", summary.WinRate(domain.TeamEastWest)},
+			{"win_rate_south_north", summary.WinRate(domain.TeamSouthNorth)},
+			{"deals", float64(summary.Deals)},
+			{"deals_per_match", summary.DealsPerMatch()},
+			{"levels_per_deal_east_west", summary.LevelsPerDeal(domain.TeamEastWest)},
+			{"levels_per_deal_south_north", summary.LevelsPerDeal(domain.TeamSouthNorth)},
+			{"double_down_rate", summary.OutcomeRate(domain.TributeScenarioDoubleDown)},
+			{"single_last_rate", summary.OutcomeRate(domain.TributeScenarioSingleLast)},
+			{"partner_last_rate", summary.OutcomeRate(domain.TributeScenarioPartnerLast)},
+			{"tribute_rate", summary.TributeRate()},
+			{"immunity_rate", summary.ImmunityRate()},
+			{"bombs_per_deal", summary.BombsPerDeal()},
+			{"actions_per_deal", summary.DealLength()},
+			{"plays_per_deal", summary.PlaysPerDeal()},
+		},
+		Failures: summary.Failures,
+		Elapsed:  summary.Elapsed,
+	}
+}
+
+// duplicateReport scores the East-West agents of table A against the
+// South-North agents, per board
+func duplicateReport(summary *sim.DuplicateSummary) *report {
+	score := summary.Score
+	return &report{
+		Result: summary,
+		Metrics: []metric{
+			{"pairs", float64(summary.Pairs)},
+			{"failed_pairs", float64(len(summary.Failures))},
+			{"boards", float64(len(score.Boards))},
+			{"score_per_board", score.Mean},
+			{"score_stddev", score.StdDev},
+			{"score_ci95_low", score.Low},
+			{"score_ci95_high", score.High},
+		},
+		Failures: summary.Failures,
+		Elapsed:  summary.Elapsed,
+	}
+}
+
+func writeTable(w io.Writer, r *report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, m := range r.Metrics {
+		fmt.Fprintf(tw, "%s\t%s\n", m.Name, strconv.FormatFloat(m.Value, 'f', 4, 64))
+	}
+	fmt.Fprintf(tw, "elapsed\t%s\n", r.Elapsed)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	
+	for _, failure := range r.Failures {
+		fmt.Fprintf(w, "FAILED seed %d: %s\n", failure.Seed, failure.Error)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, r *report) error {
+	values := make(map[string]float64)
+	for _, m := range r.Metrics {
+		values[m.Name] = m.Value
+	}
+	
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Result  interface{}        `json:"result"`
+		Metrics map[string]float64 `json:"metrics"`
+	}{r.Result, values})
+}
+
+func writeCSV(w io.Writer, r *report) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"metric", "value"})
+	for _, m := range r.Metrics {
+		writer.Write([]string{m.Name, strconv.FormatFloat(m.Value, 'f', -1, 64)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
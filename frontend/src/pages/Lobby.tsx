@@ -7,12 +7,14 @@ interface Room {
   playerCount: number;
   maxPlayers: number;
   isEmpty: boolean;
+  password: boolean;
 }
 
 const Lobby: React.FC = () => {
   const navigate = useNavigate();
   const [roomName, setRoomName] = useState('');
   const [joinRoomId, setJoinRoomId] = useState('');
+  const [accessCode, setAccessCode] = useState('');
   const [selectedSeat, setSelectedSeat] = useState<number>(0);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState('');
@@ -103,6 +105,8 @@ const Lobby: React.FC = () => {
           },
           body: JSON.stringify({
             seat: seatUsed,
+            password: accessCode,
+            invite: accessCode,
           }),
         });
 
@@ -111,8 +115,9 @@ const Lobby: React.FC = () => {
           throw new Error(errorData.error || '加入房间失败');
         }
 
-        // Navigate to room with the actual seat used
-        navigate(`/room/${targetRoomId}?seat=${seatUsed}`);
+        // Navigate to room with the actual seat used; protected rooms hand out a ticket
+        const data = await response.json();
+        navigate(`/room/${targetRoomId}?seat=${seatUsed}${data.ticket ? `&ticket=${data.ticket}` : ''}`);
       } else {
         // Selected seat is available, use it
         const response = await fetch(`/api/room/${targetRoomId}/join`, {
@@ -122,6 +127,8 @@ const Lobby: React.FC = () => {
           },
           body: JSON.stringify({
             seat: selectedSeat,
+            password: accessCode,
+            invite: accessCode,
           }),
         });
 
@@ -130,8 +137,9 @@ const Lobby: React.FC = () => {
           throw new Error(errorData.error || '加入房间失败');
         }
 
-        // Navigate to room
-        navigate(`/room/${targetRoomId}?seat=${selectedSeat}`);
+        // Navigate to room; protected rooms hand out a ticket
+        const data = await response.json();
+        navigate(`/room/${targetRoomId}?seat=${selectedSeat}${data.ticket ? `&ticket=${data.ticket}` : ''}`);
       }
     } catch (err) {
       setError(err instanceof Error ? err.message : '加入房间失败');
@@ -250,6 +258,19 @@ const Lobby: React.FC = () => {
                   disabled={loading}
                 />
               </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-2">
+                  密码或邀请码
+                </label>
+                <input
+                  type="password"
+                  value={accessCode}
+                  onChange={(e) => setAccessCode(e.target.value)}
+                  placeholder="私密房间需要填写"
+                  className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-green-500"
+                  disabled={loading}
+                />
+              </div>
               <button
                 onClick={() => handleJoinRoom()}
                 disabled={loading}
@@ -293,7 +314,7 @@ const Lobby: React.FC = () => {
                         房间 {room.roomId}
                       </div>
                       <div className="text-sm text-gray-500">
-                        {room.playerCount}/{room.maxPlayers} 人
+                        {room.playerCount}/{room.maxPlayers} 人{room.password && ' · 需要密码'}
                       </div>
                     </div>
                   </div>
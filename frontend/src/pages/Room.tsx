@@ -13,10 +13,11 @@ import {
   useConnectionState, 
   useGameState, 
   usePlayerState, 
+  useLobbyState,
   useErrorState,
   initializePlayerSeat
 } from '../store';
-import { SeatID } from '../types';
+import { SeatID, SEATS } from '../types';
 
 const Room: React.FC = () => {
   const { roomId } = useParams<{ roomId: string }>();
@@ -26,6 +27,7 @@ const Room: React.FC = () => {
   const { isConnected, connectionStatus, connect, disconnect } = useConnectionState();
   const { players, status, currentDeal } = useGameState();
   const { mySeat, myHand } = usePlayerState();
+  const { lobby, setReady, startMatch } = useLobbyState();
   const { } = useErrorState();
 
   const [isInitialized, setIsInitialized] = useState(false);
@@ -58,7 +60,9 @@ const Room: React.FC = () => {
     initializePlayerSeat(seat);
     
     // Connect to WebSocket
-    const wsUrl = `ws://${window.location.host}/api/room/${roomId}/ws?seat=${seatNumber}`;
+    // Protected rooms need the ticket JoinRoom handed out
+    const ticket = searchParams.get('ticket');
+    const wsUrl = `ws://${window.location.host}/api/room/${roomId}/ws?seat=${seatNumber}${ticket ? `&ticket=${encodeURIComponent(ticket)}` : ''}`;
     connect(wsUrl);
     
     setIsInitialized(true);
@@ -116,6 +120,12 @@ const Room: React.FC = () => {
   const currentPlayer = getCurrentPlayer();
   const playersArrangement = arrangePlayersForDisplay();
 
+  // Lobby: the host starts the match once every seat is taken and ready
+  const mySeatNumber = mySeat ? SEATS.indexOf(mySeat) : -1;
+  const myLobbySeat = lobby?.seats.find(s => s.seat === mySeatNumber);
+  const isHost = lobby?.host === mySeatNumber;
+  const canStart = !!lobby && lobby.seats.every(s => (s.playerId || s.bot) && s.ready);
+
   if (!isInitialized) {
     return (
       <div className="min-h-screen bg-gray-100 flex items-center justify-center">
@@ -194,6 +204,39 @@ const Room: React.FC = () => {
           </div>
         )}
 
+        {/* Lobby */}
+        {status === 'waiting' && lobby && (
+          <div className="bg-white border-b border-gray-200 p-4">
+            <div className="max-w-6xl mx-auto flex items-center justify-between">
+              <div className="flex items-center space-x-4 text-sm text-gray-600">
+                {lobby.seats.map(s => (
+                  <span key={s.seat} className={s.ready ? 'text-green-600' : ''}>
+                    {SEATS[s.seat]}: {s.playerId || (s.bot ? `机器人 (${s.bot})` : '空位')}
+                    {(s.playerId || s.bot) && (s.ready ? ' ✓' : ' …')}
+                  </span>
+                ))}
+              </div>
+              <div className="flex items-center space-x-2">
+                <button
+                  onClick={() => setReady(!myLobbySeat?.ready)}
+                  className="px-4 py-2 rounded-lg bg-blue-500 text-white hover:bg-blue-600"
+                >
+                  {myLobbySeat?.ready ? '取消准备' : '准备'}
+                </button>
+                {isHost && (
+                  <button
+                    onClick={startMatch}
+                    disabled={!canStart}
+                    className="px-4 py-2 rounded-lg bg-green-500 text-white hover:bg-green-600 disabled:opacity-50"
+                  >
+                    开始游戏
+                  </button>
+                )}
+              </div>
+            </div>
+          </div>
+        )}
+
         {/* Game area */}
         <div className="max-w-6xl mx-auto p-4">
           <div className="grid grid-cols-12 grid-rows-8 gap-4 h-[calc(100vh-200px)]">
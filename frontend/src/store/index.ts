@@ -8,9 +8,18 @@ import {
   SeatID, 
   EventMessage, 
   WSMessage,
+  LobbyState,
   CONNECTION_STATUS 
 } from '../types';
-import { WSClient, isSnapshotMessage, isEventMessage, isErrorMessage } from '../utils/ws';
+import { 
+  WSClient, 
+  isSnapshotMessage, 
+  isEventMessage, 
+  isErrorMessage, 
+  isLobbyUpdatedMessage, 
+  createReadyMessage, 
+  createStartMessage 
+} from '../utils/ws';
 
 // Convert server seat ID (0,1,2,3) to frontend seat ID (east,south,west,north)
 function convertSeatIDToString(seatID: number): SeatID {
@@ -60,6 +69,9 @@ export const useRoomStore = create<RoomStore>()(
       mySeat: null,
       myHand: [],
       
+      // Lobby state
+      lobby: null,
+      
       // UI state
       selectedCards: [],
       isMyTurn: false,
@@ -95,6 +107,8 @@ export const useRoomStore = create<RoomStore>()(
                 actions.handleSnapshot(message.payload);
               } else if (isEventMessage(message)) {
                 actions.handleEvent(message);
+              } else if (isLobbyUpdatedMessage(message)) {
+                actions.handleLobby(message);
               } else if (isErrorMessage(message)) {
                 actions.setError(message.error);
               }
@@ -217,11 +231,41 @@ export const useRoomStore = create<RoomStore>()(
           });
         },
         
+        // Lobby actions
+        setReady: (ready: boolean) => {
+          const state = get();
+          if (!state.wsClient || !state.isConnected) {
+            return;
+          }
+          
+          state.wsClient.send(createReadyMessage(ready)).catch((_error) => {
+            set((draft) => {
+              draft.errorMessage = 'Failed to send ready';
+              draft.showValidationError = true;
+            });
+          });
+        },
+        
+        startMatch: () => {
+          const state = get();
+          if (!state.wsClient || !state.isConnected) {
+            return;
+          }
+          
+          state.wsClient.send(createStartMessage()).catch((_error) => {
+            set((draft) => {
+              draft.errorMessage = 'Failed to start the match';
+              draft.showValidationError = true;
+            });
+          });
+        },
+        
         // State management
         handleSnapshot: (snapshot: GameState) => {
           set((state) => {
             // Update game state
             state.matchId = snapshot.matchId;
+            state.lobby = snapshot.lobby ?? null;
             state.players = snapshot.players;
             state.currentDeal = snapshot.currentDeal;
             state.status = snapshot.status;
@@ -295,6 +339,16 @@ export const useRoomStore = create<RoomStore>()(
           });
         },
         
+        handleLobby: (lobby: LobbyState) => {
+          set((state) => {
+            state.lobby = {
+              host: lobby.host,
+              seats: lobby.seats,
+              settings: lobby.settings
+            };
+          });
+        },
+        
         setError: (message: string) => {
           set((state) => {
             state.errorMessage = message;
@@ -618,6 +672,12 @@ export const useGameActions = () => useRoomStore(state => ({
   pass: state.actions.pass
 }));
 
+export const useLobbyState = () => useRoomStore(state => ({
+  lobby: state.lobby,
+  setReady: state.actions.setReady,
+  startMatch: state.actions.startMatch
+}));
+
 export const useErrorState = () => useRoomStore(state => ({
   showValidationError: state.showValidationError,
   errorMessage: state.errorMessage,
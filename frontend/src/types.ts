@@ -23,6 +23,8 @@ export interface GameState {
   currentDeal?: DealState;
   status: 'waiting' | 'playing' | 'finished';
   version: number;
+  lobby?: LobbyState;
+  spectators?: number;
 }
 
 export interface DealState {
@@ -69,27 +71,128 @@ export interface PassMessage extends WSMessage {
   t: 'Pass';
 }
 
+export interface HintSuggestion {
+  cards: string[];
+  pass: boolean;
+  reason: string;
+  score: number;
+}
+
+export interface HintResultMessage extends WSMessage {
+  t: 'HintResult';
+  index: number;
+  suggestions: HintSuggestion[];
+  version: number;
+}
+
+export interface TrackerMessage extends WSMessage {
+  t: 'Tracker';
+  version: number;
+  remaining: Record<string, number>;
+  handCounts: number[];
+  known?: Record<string, string[]>;
+  bombs: string[];
+  jokerBomb: boolean;
+  straightFlush: boolean;
+  straightFlushes?: string[];
+}
+
+export interface LobbySeat {
+  seat: number;
+  playerId?: string;
+  bot?: string;
+  connected: boolean;
+  ready: boolean;
+  swapWith?: number;
+}
+
+export type RulePreset = 'casual' | 'standard' | 'tournament';
+
+export interface RoomSettings {
+  name: string;
+  rules: RulePreset;
+  actionTime: number;
+  timeBank: number;
+  cardTracker: boolean;
+  targetLevel: string;
+  visibility: 'public' | 'private';
+}
+
+export interface LobbyState {
+  host: number | null;
+  seats: LobbySeat[];
+  settings: RoomSettings;
+}
+
+export interface LobbyUpdatedMessage extends WSMessage, LobbyState {
+  t: 'LobbyUpdated';
+}
+
+export type SpectatorMode = 'off' | 'public' | 'seat' | 'omniscient';
+
+export interface SpectatorView {
+  mode: SpectatorMode;
+  seat?: number;
+  delay: number;
+  delayActions: number;
+}
+
+export interface SpectatingMessage extends WSMessage {
+  t: 'Spectating';
+  view: SpectatorView;
+  players: Player[];
+  status: 'waiting' | 'playing';
+}
+
 // API types
 export interface CreateRoomRequest {
   roomName: string;
+  rules?: RulePreset;
+  actionTime?: number;
+  timeBank?: number;
+  cardTracker?: boolean;
+  targetLevel?: string;
+  visibility?: 'public' | 'private';
+  password?: string;
+  spectators?: SpectatorOptions;
+}
+
+export interface SpectatorOptions {
+  mode: SpectatorMode;
+  delay?: number;
+  delayActions?: number;
 }
 
 export interface CreateRoomResponse {
   roomId: string;
+  ownerToken: string;
 }
 
 export interface JoinRoomRequest {
   seat: number;
+  password?: string;
+  invite?: string;
 }
 
 export interface JoinRoomResponse {
   wsUrl: string;
+  ticket?: string;
+}
+
+export interface Invite {
+  code: string;
+  maxUses: number;
+  uses: number;
+  expiresAt?: string;
 }
 
 export interface RoomInfo {
   roomId: string;
+  name: string;
+  password: boolean;
   playerCount: number;
   maxPlayers: number;
+  spectators: number;
   isEmpty: boolean;
 }
 
@@ -114,6 +217,9 @@ export interface RoomState extends GameState, UIState {
   mySeat: SeatID | null;
   myHand: Card[];
   
+  // Lobby state, set until the host starts the match
+  lobby: LobbyState | null;
+  
   // Actions
   actions: {
     // Connection actions
@@ -126,8 +232,13 @@ export interface RoomState extends GameState, UIState {
     playCards: (cards: Card[]) => void;
     pass: () => void;
     
+    // Lobby actions
+    setReady: (ready: boolean) => void;
+    startMatch: () => void;
+    
     // State management
     handleSnapshot: (snapshot: GameState) => void;
+    handleLobby: (lobby: LobbyState) => void;
     handleEvent: (event: EventMessage) => void;
     setError: (message: string) => void;
     clearError: () => void;
@@ -1,4 +1,4 @@
-import { WSMessage, SnapshotMessage, EventMessage, CONNECTION_STATUS } from '../types';
+import { WSMessage, SnapshotMessage, EventMessage, HintResultMessage, TrackerMessage, LobbyUpdatedMessage, SpectatingMessage, RoomSettings, CONNECTION_STATUS } from '../types';
 
 export interface WSClientOptions {
   url: string;
@@ -221,6 +221,39 @@ export const createPassMessage = (): WSMessage => ({
   t: 'Pass'
 });
 
+export const createHintMessage = (): WSMessage => ({
+  t: 'Hint'
+});
+
+export const createReadyMessage = (ready: boolean): WSMessage => ({
+  t: 'Ready',
+  data: { ready }
+});
+
+export const createChangeSeatMessage = (seat: number): WSMessage => ({
+  t: 'ChangeSeat',
+  data: { seat }
+});
+
+export const createSwapSeatMessage = (seat: number): WSMessage => ({
+  t: 'SwapSeat',
+  data: { seat }
+});
+
+export const createKickMessage = (seat: number): WSMessage => ({
+  t: 'Kick',
+  data: { seat }
+});
+
+export const createSettingsMessage = (settings: Partial<RoomSettings>): WSMessage => ({
+  t: 'Settings',
+  data: settings
+});
+
+export const createStartMessage = (): WSMessage => ({
+  t: 'Start'
+});
+
 // Message type guards
 export const isSnapshotMessage = (message: WSMessage): message is SnapshotMessage => {
   return message.t === 'Snapshot';
@@ -230,7 +263,23 @@ export const isEventMessage = (message: WSMessage): message is EventMessage => {
   return message.t === 'Event';
 };
 
-export const isErrorMessage = (message: WSMessage): message is WSMessage & { error: string } => {
+export const isHintResultMessage = (message: WSMessage): message is HintResultMessage => {
+  return message.t === 'HintResult';
+};
+
+export const isTrackerMessage = (message: WSMessage): message is TrackerMessage => {
+  return message.t === 'Tracker';
+};
+
+export const isLobbyUpdatedMessage = (message: WSMessage): message is LobbyUpdatedMessage => {
+  return message.t === 'LobbyUpdated';
+};
+
+export const isSpectatingMessage = (message: WSMessage): message is SpectatingMessage => {
+  return message.t === 'Spectating';
+};
+
+export const isErrorMessage = (message: WSMessage): message is WSMessage & { error: string; code?: string; detail?: string } => {
   return message.t === 'Error' && 'error' in message;
 };
 
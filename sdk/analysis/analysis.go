@@ -0,0 +1,315 @@
+// Package analysis 对局复盘：重放一个Deal的记录，在每个出牌决策点比较实际行动与最强机器人或残局求解器的选择，
+// 估计每一步损失的期望升级数，并按座位列出损失最大的失误及更好的出牌
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/notation"
+	"guandan/sdk/solver"
+)
+
+const (
+	DefaultIterations  = 1000 // 每个决策点的蒙特卡洛模拟次数
+	DefaultSolverCards = 20   // 四家剩余手牌合计不多于此数时改用求解器
+	DefaultThreshold   = 0.25 // 损失达到此值（升级数）才算失误
+	DefaultTopMistakes = 3    // 每个座位列出的失误数
+)
+
+// maxAlternatives 每个失误列出的更好出牌数
+const maxAlternatives = 3
+
+// 估值方法
+const (
+	MethodSolver     = "solver"
+	MethodMonteCarlo = "montecarlo"
+)
+
+// Config 分析参数，零值使用各项默认值
+type Config struct {
+	Iterations  int     // 每个决策点的模拟次数，0表示DefaultIterations
+	SolverCards int     // 改用求解器的剩余手牌数，0表示DefaultSolverCards，负数表示不用求解器
+	Threshold   float64 // 失误阈值，0表示DefaultThreshold
+	TopMistakes int     // 每个座位列出的失误数，0表示DefaultTopMistakes
+	Seed        int64   // 蒙特卡洛随机种子，0表示按时间
+	Workers     int     // 蒙特卡洛并行goroutine数，0表示runtime.NumCPU()
+}
+
+func (c Config) withDefaults() Config {
+	if c.Iterations <= 0 {
+		c.Iterations = DefaultIterations
+	}
+	if c.SolverCards == 0 {
+		c.SolverCards = DefaultSolverCards
+	}
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultThreshold
+	}
+	if c.TopMistakes <= 0 {
+		c.TopMistakes = DefaultTopMistakes
+	}
+	return c
+}
+
+// Report 一个Deal的分析报告
+type Report struct {
+	Match     string       `json:"match"`
+	Deal      int          `json:"deal"`
+	Result    []string     `json:"result"`    // 名次，座位字母
+	Decisions int          `json:"decisions"` // 分析过的决策数，只有一种选择的不计
+	Seats     []SeatReport `json:"seats"`
+}
+
+// SeatReport 一个座位的分析
+type SeatReport struct {
+	Seat      string    `json:"seat"`
+	Player    string    `json:"player"`
+	Decisions int       `json:"decisions"`
+	Loss      float64   `json:"loss"`     // 全部决策的损失之和
+	Mistakes  []Mistake `json:"mistakes"` // 损失不低于阈值的决策，按损失从大到小，最多TopMistakes个
+}
+
+// Mistake 一个失误
+type Mistake struct {
+	Step         int           `json:"step"`  // 本Deal的第几个出牌或Pass，从1开始
+	Trick        int           `json:"trick"` // 所在的轮次，从1开始
+	Played       string        `json:"played"`
+	Best         string        `json:"best"`
+	Loss         float64       `json:"loss"` // 本队期望升级数的损失
+	Method       string        `json:"method"`
+	Alternatives []Alternative `json:"alternatives"` // 比实际行动更好的出牌，从好到差
+}
+
+// Alternative 一个候选出牌的估值
+type Alternative struct {
+	Move   string  `json:"move"`
+	Gain   float64 `json:"gain"`             // 本队期望升级数，-3..3
+	Visits int     `json:"visits,omitempty"` // 蒙特卡洛模拟次数，求解器为0
+}
+
+// FormatMove 行动的GDN写法：牌以逗号分隔，Pass为"-"
+func FormatMove(action engine.Action) string {
+	if action.Kind == engine.ActionPass {
+		return "-"
+	}
+	names := make([]string, len(action.Cards))
+	for i, card := range action.Cards {
+		names[i] = notation.FormatCard(card)
+	}
+	return strings.Join(names, ",")
+}
+
+// decision 重放中记录的一个出牌决策点
+type decision struct {
+	step   int
+	trick  int
+	played engine.Action
+	obs    *bot.Observation
+	state  engine.State
+}
+
+// Analyze 重放记录并分析其中的出牌与Pass；贡牌和还贡不分析。
+// 剩余手牌较少时求解器按四家明手计算，结果是事后的最优解；其余决策点用蒙特卡洛搜索按该座位可见的信息估值
+func Analyze(rec *notation.Record, config Config) (*Report, error) {
+	config = config.withDefaults()
+	
+	var decisions []decision
+	var replayErr error
+	step := 0
+	_, err := notation.ReplayEach(rec, func(ge *engine.GameEngine, action engine.Action) {
+		if action.Kind != engine.ActionPlay && action.Kind != engine.ActionPass {
+			return
+		}
+		step++
+		
+		options := len(ge.GetValidPlays(action.Seat))
+		if ge.GetLastPlay() != nil {
+			options++
+		}
+		if options <= 1 || replayErr != nil {
+			return
+		}
+		state, err := ge.GetState()
+		if err != nil {
+			replayErr = err
+			return
+		}
+		decisions = append(decisions, decision{
+			step:   step,
+			trick:  ge.GetTrickCtx().TrickNumber,
+			played: action,
+			obs:    bot.Observe(ge, action.Seat),
+			state:  state,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	if replayErr != nil {
+		return nil, fmt.Errorf("replay: %w", replayErr)
+	}
+	
+	report := &Report{Match: rec.Match, Deal: rec.Deal, Decisions: len(decisions)}
+	for _, seat := range rec.Result {
+		report.Result = append(report.Result, notation.SeatLetter(seat))
+	}
+	seats := make([]SeatReport, 4)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		seats[seat] = SeatReport{Seat: notation.SeatLetter(seat), Player: rec.Players[seat], Mistakes: []Mistake{}}
+	}
+	
+	a := &analyzer{
+		config: config,
+		solver: solver.New(solver.Config{}),
+		search: bot.NewMonteCarlo(bot.SearchConfig{
+			Iterations: config.Iterations,
+			Workers:    config.Workers,
+			Seed:       config.Seed,
+			Belief:     true,
+		}),
+	}
+	for _, d := range decisions {
+		mistake, err := a.evaluate(d)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", d.step, notation.SeatLetter(d.played.Seat), err)
+		}
+		if mistake == nil {
+			continue
+		}
+		
+		seat := &seats[d.played.Seat]
+		seat.Decisions++
+		seat.Loss += mistake.Loss
+		if mistake.Loss >= config.Threshold {
+			seat.Mistakes = append(seat.Mistakes, *mistake)
+		}
+	}
+	
+	for i := range seats {
+		mistakes := seats[i].Mistakes
+		sort.SliceStable(mistakes, func(a, b int) bool { return mistakes[a].Loss > mistakes[b].Loss })
+		if len(mistakes) > config.TopMistakes {
+			seats[i].Mistakes = mistakes[:config.TopMistakes]
+		}
+	}
+	report.Seats = seats
+	return report, nil
+}
+
+// analyzer 一次分析共用的求解器与搜索机器人。求解器的置换表在同一Deal的各局面之间复用
+type analyzer struct {
+	config Config
+	solver *solver.Solver
+	search *bot.MonteCarlo
+}
+
+// evaluate 给一个决策点估值，返回的Mistake的Loss可能为0；实际行动不在候选中时返回nil
+func (a *analyzer) evaluate(d decision) (*Mistake, error) {
+	remaining := 0
+	for _, hand := range d.state.Hands {
+		remaining += len(hand)
+	}
+	if remaining <= a.config.SolverCards {
+		mistake, err := a.solve(d)
+		if !errors.Is(err, solver.ErrNodeLimit) {
+			return mistake, err
+		}
+	}
+	return a.sample(d)
+}
+
+// solve 用求解器为当前座位的每个出牌求出完美应对下的升级数
+func (a *analyzer) solve(d decision) (*Mistake, error) {
+	moves, err := a.solver.Moves(d.state)
+	if err != nil {
+		return nil, err
+	}
+	
+	team := domain.GetTeamFromSeat(d.played.Seat)
+	alternatives := make([]Alternative, len(moves))
+	played := -1
+	for i, move := range moves {
+		alternatives[i] = Alternative{Move: FormatMove(move.Action), Gain: float64(move.Outcome.Gain(team))}
+		if sameMove(move.Action, d.played) {
+			played = i
+		}
+	}
+	return newMistake(d, MethodSolver, alternatives, played), nil
+}
+
+// sample 用蒙特卡洛搜索按该座位可见的信息估值
+func (a *analyzer) sample(d decision) (*Mistake, error) {
+	evaluations, err := a.search.Evaluate(d.obs)
+	if err != nil {
+		return nil, err
+	}
+	
+	var key domain.ComparisonKey
+	if d.played.Kind == engine.ActionPlay {
+		key = domain.NewCardGroup(d.played.Cards).ComparisonKey()
+	}
+	alternatives := make([]Alternative, len(evaluations))
+	played := -1
+	for i, e := range evaluations {
+		action := engine.Action{Kind: engine.ActionPass, Seat: d.played.Seat}
+		if e.Action.Kind == bot.ActionPlay {
+			action = engine.Action{Kind: engine.ActionPlay, Seat: d.played.Seat, Cards: e.Action.Cards}
+		}
+		alternatives[i] = Alternative{Move: FormatMove(action), Gain: e.Gain, Visits: e.Visits}
+		
+		switch {
+		case d.played.Kind == engine.ActionPass && e.Action.Kind == bot.ActionPass:
+			played = i
+		case d.played.Kind == engine.ActionPlay && e.Action.Kind == bot.ActionPlay &&
+			domain.NewCardGroup(e.Action.Cards).ComparisonKey() == key:
+			played = i
+		}
+	}
+	return newMistake(d, MethodMonteCarlo, alternatives, played), nil
+}
+
+// newMistake alternatives已按从好到差排列，played为实际行动的下标
+func newMistake(d decision, method string, alternatives []Alternative, played int) *Mistake {
+	if played < 0 || len(alternatives) == 0 {
+		return nil
+	}
+	
+	better := []Alternative{}
+	for _, alternative := range alternatives[:played] {
+		if alternative.Gain > alternatives[played].Gain && len(better) < maxAlternatives {
+			better = append(better, alternative)
+		}
+	}
+	return &Mistake{
+		Step:         d.step,
+		Trick:        d.trick,
+		Played:       FormatMove(d.played),
+		Best:         alternatives[0].Move,
+		Loss:         alternatives[0].Gain - alternatives[played].Gain,
+		Method:       method,
+		Alternatives: better,
+	}
+}
+
+// sameMove 两个行动是否打出同样的牌（按牌面计数，与顺序无关）
+func sameMove(a, b engine.Action) bool {
+	if a.Kind != b.Kind || len(a.Cards) != len(b.Cards) {
+		return false
+	}
+	counts := make(map[domain.Card]int)
+	for _, card := range a.Cards {
+		counts[card]++
+	}
+	for _, card := range b.Cards {
+		if counts[card] == 0 {
+			return false
+		}
+		counts[card]--
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+	"guandan/sdk/notation"
+)
+
+// recordDeal 由规则机器人打完首Deal并返回记录
+func recordDeal(t *testing.T) *notation.Record {
+	t.Helper()
+	
+	eventBus := event.NewEventBus(1000)
+	eventBus.Start()
+	t.Cleanup(eventBus.Stop)
+	eventChan, unsubscribe := eventBus.Subscribe("analysis-1")
+	t.Cleanup(unsubscribe)
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Alice", domain.SeatEast),
+		domain.NewPlayer("p2", "Bob", domain.SeatSouth),
+		domain.NewPlayer("p3", "Carol", domain.SeatWest),
+		domain.NewPlayer("p4", "Dave", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("analysis-1", players, 20240501)
+	ge := engine.NewGameEngine(eventBus)
+	if err := ge.Initialize(matchCtx); err != nil {
+		t.Fatal(err)
+	}
+	recorder := notation.NewRecorder(matchCtx, nil)
+	
+	if err := ge.StartDeal(1, nil); err != nil {
+		t.Fatal(err)
+	}
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	recorder.SetStartingCard(*ge.GetStartingCard())
+	
+	heuristic := bot.NewHeuristic()
+	if err := bot.PlayDeal(ge, [4]bot.Agent{heuristic, heuristic, heuristic, heuristic}); err != nil {
+		t.Fatal(err)
+	}
+	
+	timeout := time.After(2 * time.Second)
+	for !recorder.IsFinished() {
+		select {
+		case e := <-eventChan:
+			recorder.Apply(e)
+		case <-timeout:
+			t.Fatal("timed out waiting for DealEnded event")
+		}
+	}
+	return recorder.Record()
+}
+
+func TestAnalyzeDeal(t *testing.T) {
+	rec := recordDeal(t)
+	config := Config{Iterations: 100, Workers: 2, Seed: 1, Threshold: 0.01, TopMistakes: 5}
+	report, err := Analyze(rec, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	if report.Deal != 1 || len(report.Result) != len(rec.Result) || len(report.Seats) != 4 {
+		t.Fatalf("unexpected report header %+v", report)
+	}
+	if report.Decisions == 0 {
+		t.Fatal("no decisions analyzed")
+	}
+	
+	decisions := 0
+	methods := make(map[string]int)
+	for _, seat := range report.Seats {
+		decisions += seat.Decisions
+		if seat.Loss < 0 {
+			t.Errorf("%s has negative loss %v", seat.Seat, seat.Loss)
+		}
+		if len(seat.Mistakes) > config.TopMistakes {
+			t.Errorf("%s lists %d mistakes", seat.Seat, len(seat.Mistakes))
+		}
+		for i, mistake := range seat.Mistakes {
+			methods[mistake.Method]++
+			if i > 0 && mistake.Loss > seat.Mistakes[i-1].Loss {
+				t.Errorf("%s mistakes are not sorted by loss", seat.Seat)
+			}
+			if mistake.Loss < config.Threshold || len(mistake.Alternatives) == 0 {
+				t.Errorf("%s step %d: loss %v with alternatives %v", seat.Seat, mistake.Step, mistake.Loss, mistake.Alternatives)
+			}
+			if mistake.Alternatives[0].Move != mistake.Best || mistake.Best == mistake.Played {
+				t.Errorf("%s step %d: best %s, played %s, alternatives %v", seat.Seat, mistake.Step, mistake.Best, mistake.Played, mistake.Alternatives)
+			}
+			if mistake.Method == MethodSolver && mistake.Loss != math.Trunc(mistake.Loss) {
+				t.Errorf("solver loss %v is not a whole number of levels", mistake.Loss)
+			}
+		}
+	}
+	if decisions != report.Decisions {
+		t.Errorf("seats cover %d of %d decisions", decisions, report.Decisions)
+	}
+	
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("methods %v, %d bytes", methods, len(data))
+}
+
+func TestAnalyzeRejectsInvalidRecord(t *testing.T) {
+	rec := recordDeal(t)
+	// 把第一手出牌换成不在手中的牌
+	first := &rec.Tricks[0].Actions[0]
+	hand := rec.Hands[first.Seat]
+	held := make(map[domain.Card]int)
+	for _, card := range hand {
+		held[card]++
+	}
+	for _, card := range domain.NewDeck().Cards {
+		if held[card] == 0 {
+			first.Cards = []domain.Card{card}
+			break
+		}
+	}
+	
+	if _, err := Analyze(rec, Config{Iterations: 10, Workers: 1, Seed: 1}); err == nil {
+		t.Fatal("expected a replay error")
+	}
+}
@@ -0,0 +1,256 @@
+package bot
+
+import (
+	"fmt"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// ActionKind 机器人行动类型，与引擎的行动类型相同
+type ActionKind = engine.ActionKind
+
+const (
+	ActionPlay          = engine.ActionPlay
+	ActionPass          = engine.ActionPass
+	ActionTribute       = engine.ActionTribute
+	ActionSelectTribute = engine.ActionSelectTribute
+	ActionReturnTribute = engine.ActionReturnTribute
+)
+
+// Action 机器人的一次行动。Agent不填Seat；贡牌、还贡的Target由EngineAction按贡牌信息确定，
+// SelectTribute的Target为所选贡牌的进贡者
+type Action = engine.Action
+
+func Play(cards []domain.Card) Action {
+	return Action{Kind: ActionPlay, Cards: cards}
+}
+
+func Pass() Action {
+	return Action{Kind: ActionPass}
+}
+
+func Tribute(card domain.Card) Action {
+	return Action{Kind: ActionTribute, Cards: []domain.Card{card}}
+}
+
+func SelectTribute(giver domain.SeatID) Action {
+	return Action{Kind: ActionSelectTribute, Target: giver}
+}
+
+func ReturnTribute(card domain.Card) Action {
+	return Action{Kind: ActionReturnTribute, Cards: []domain.Card{card}}
+}
+
+// TributeView 贡牌阶段的公开信息
+type TributeView struct {
+	Scenario    domain.TributeScenario
+	HasImmunity bool
+	Requests    map[domain.SeatID]domain.SeatID // 进贡 from -> to
+	Returns     map[domain.SeatID]domain.SeatID // 还贡 from -> to
+	Given       map[domain.SeatID]domain.Card   // 已进贡的牌
+	Receivers   map[domain.SeatID]domain.SeatID // 贡牌的实际接收者 (giver -> receiver)，Double Down在选择后确定
+	Available   map[domain.SeatID]domain.Card   // Double Down待选择的贡牌 (giver -> card)
+	Returned    map[domain.SeatID]domain.Card   // 已还贡的牌
+}
+
+// Observation 某个座位能看到的全部信息：自己的手牌与公开信息
+type Observation struct {
+	Seat         domain.SeatID
+	Phase        engine.DealPhase
+	DealNumber   int
+	Hand         []domain.Card
+	HandCounts   [4]int            // 按座位索引的剩余手牌数
+	Trump        domain.Rank
+	Levels       [2]domain.Rank    // 按TeamID索引的级数
+	LastRankings []domain.SeatID   // 上一Deal排名，首Deal为nil
+	RankList     []domain.SeatID   // 本Deal已出完牌的座位
+	Plays        []domain.TrickPlay // 本Deal所有出牌与Pass，按顺序，Pass的Cards为空
+	Trick        []domain.TrickPlay // 当前一轮的出牌与Pass
+	LastPlay     *domain.CardGroup  // 当前一轮需要压过的牌，首家出牌时为nil
+	LastPlayer   domain.SeatID
+	Tribute      *TributeView       // 无贡牌信息时为nil
+}
+
+// Partner 对家座位
+func (o *Observation) Partner() domain.SeatID {
+	return o.Seat.Opposite()
+}
+
+// IsLeading 是否由本座位首出
+func (o *Observation) IsLeading() bool {
+	return o.LastPlay == nil
+}
+
+// IsFinished 座位是否已出完手牌
+func (o *Observation) IsFinished(seat domain.SeatID) bool {
+	for _, finished := range o.RankList {
+		if finished == seat {
+			return true
+		}
+	}
+	return false
+}
+
+// Agent 机器人接口：根据座位的观察返回一个行动
+type Agent interface {
+	Act(obs *Observation) (Action, error)
+}
+
+// Observe 从引擎中构造seat的观察，只包含该座位可见的信息
+func Observe(ge *engine.GameEngine, seat domain.SeatID) *Observation {
+	obs := &Observation{
+		Seat:  seat,
+		Phase: ge.GetCurrentPhase(),
+		Hand:  ge.GetPlayerHand(seat),
+		Plays: ge.GetDealPlays(),
+	}
+	
+	if matchCtx := ge.GetMatchCtx(); matchCtx != nil {
+		for s := domain.SeatEast; s <= domain.SeatNorth; s++ {
+			if player := matchCtx.GetPlayer(s); player != nil {
+				obs.HandCounts[s] = player.HandSize()
+			}
+		}
+		for _, team := range matchCtx.Teams {
+			obs.Levels[team.ID] = team.Level
+		}
+	}
+	
+	if dealCtx := ge.GetDealCtx(); dealCtx != nil {
+		obs.DealNumber = dealCtx.DealNumber
+		obs.Trump = dealCtx.Trump
+		obs.LastRankings = append([]domain.SeatID(nil), dealCtx.LastRankings...)
+		obs.RankList = append([]domain.SeatID(nil), dealCtx.RankList...)
+		if dealCtx.TributeInfo != nil {
+			obs.Tribute = newTributeView(dealCtx.TributeInfo)
+		}
+	}
+	
+	if trickCtx := ge.GetTrickCtx(); trickCtx != nil {
+		obs.Trick = append([]domain.TrickPlay(nil), trickCtx.PlayHistory...)
+		obs.LastPlay = trickCtx.LastPlay
+		obs.LastPlayer = trickCtx.LastPlayer
+	}
+	
+	return obs
+}
+
+func newTributeView(info *domain.TributeInfo) *TributeView {
+	return &TributeView{
+		Scenario:    info.Scenario,
+		HasImmunity: info.HasImmunity,
+		Requests:    copySeatMap(info.TributeRequests),
+		Returns:     copySeatMap(info.ReturnRequests),
+		Given:       copyCardMap(info.GivenTributes),
+		Receivers:   tributeReceivers(info),
+		Available:   copyCardMap(info.AvailableCards),
+		Returned:    copyCardMap(info.ReturnedTributes),
+	}
+}
+
+func tributeReceivers(info *domain.TributeInfo) map[domain.SeatID]domain.SeatID {
+	if info.Scenario == domain.TributeScenarioDoubleDown {
+		return copySeatMap(info.ActualReceivers)
+	}
+	return copySeatMap(info.TributeRequests)
+}
+
+func copySeatMap(m map[domain.SeatID]domain.SeatID) map[domain.SeatID]domain.SeatID {
+	copied := make(map[domain.SeatID]domain.SeatID, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+func copyCardMap(m map[domain.SeatID]domain.Card) map[domain.SeatID]domain.Card {
+	copied := make(map[domain.SeatID]domain.Card, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// EngineAction 把seat的机器人行动转换为引擎行动，贡牌、还贡的接收者按当前贡牌信息确定
+func EngineAction(ge *engine.GameEngine, seat domain.SeatID, action Action) (engine.Action, error) {
+	switch action.Kind {
+	case ActionPlay:
+		return engine.Action{Kind: engine.ActionPlay, Seat: seat, Cards: action.Cards}, nil
+	case ActionPass:
+		return engine.Action{Kind: engine.ActionPass, Seat: seat}, nil
+	case ActionTribute:
+		dealCtx := ge.GetDealCtx()
+		if dealCtx == nil || dealCtx.TributeInfo == nil {
+			return engine.Action{}, fmt.Errorf("no tribute in progress")
+		}
+		to, required := dealCtx.TributeInfo.TributeRequests[seat]
+		if !required {
+			return engine.Action{}, fmt.Errorf("player %s is not required to give tribute", seat.String())
+		}
+		return engine.Action{Kind: engine.ActionTribute, Seat: seat, Cards: action.Cards, Target: to}, nil
+	case ActionSelectTribute:
+		return engine.Action{Kind: engine.ActionSelectTribute, Seat: seat, Target: action.Target}, nil
+	case ActionReturnTribute:
+		dealCtx := ge.GetDealCtx()
+		if dealCtx == nil || dealCtx.TributeInfo == nil {
+			return engine.Action{}, fmt.Errorf("no tribute in progress")
+		}
+		to, required := dealCtx.TributeInfo.ReturnRequests[seat]
+		if !required {
+			return engine.Action{}, fmt.Errorf("player %s is not required to give return tribute", seat.String())
+		}
+		return engine.Action{Kind: engine.ActionReturnTribute, Seat: seat, Cards: action.Cards, Target: to}, nil
+	default:
+		return engine.Action{}, fmt.Errorf("unknown action kind %d", action.Kind)
+	}
+}
+
+// Apply 在引擎上执行seat的行动
+func Apply(ge *engine.GameEngine, seat domain.SeatID, action Action) error {
+	resolved, err := EngineAction(ge, seat, action)
+	if err != nil {
+		return err
+	}
+	return ge.Apply(resolved)
+}
+
+// Step 让第一个需要行动的座位的agent行动一次，返回行动的座位与行动
+func Step(ge *engine.GameEngine, agents [4]Agent) (domain.SeatID, Action, error) {
+	pending := ge.GetPendingSeats()
+	if len(pending) == 0 {
+		return 0, Action{}, fmt.Errorf("no seat to act in phase %s", ge.GetCurrentPhase().String())
+	}
+	
+	seat := pending[0]
+	agent := agents[seat]
+	if agent == nil {
+		return seat, Action{}, fmt.Errorf("no agent for seat %s", seat.String())
+	}
+	
+	action, err := agent.Act(Observe(ge, seat))
+	if err != nil {
+		return seat, action, fmt.Errorf("agent for %s failed: %w", seat.String(), err)
+	}
+	if err := Apply(ge, seat, action); err != nil {
+		return seat, action, fmt.Errorf("%s %s rejected: %w", seat.String(), action, err)
+	}
+	return seat, action, nil
+}
+
+// maxDealSteps 一Deal行动次数上限，防止agent陷入死循环
+const maxDealSteps = 2000
+
+// PlayDeal 由agents替四个座位行动直到本Deal结束。调用前需已完成StartTribute
+func PlayDeal(ge *engine.GameEngine, agents [4]Agent) error {
+	for step := 0; step < maxDealSteps; step++ {
+		switch ge.GetCurrentPhase() {
+		case engine.PhaseRankList, engine.PhaseFinished:
+			return nil
+		}
+		
+		if _, _, err := Step(ge, agents); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("deal did not finish within %d actions", maxDealSteps)
+}
@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"math/rand"
+	"guandan/sdk/domain"
+)
+
+const (
+	DefaultPassPenalty = 0.1 // 手中有不拆炸弹就能压过的牌却Pass的相对似然
+	DefaultOversample  = 8   // 每个返回的样本先抽取的候选发牌数
+)
+
+// passEvidence 某座位在对手出牌后Pass，说明其当时手中多半没有不拆炸弹就能压过的牌
+type passEvidence struct {
+	seat  domain.SeatID
+	table *domain.CardGroup
+	later []domain.Card // 该座位Pass之后打出的牌，与抽样手牌合起来即Pass时的手牌
+}
+
+// Belief 对其他座位隐藏手牌的概率模型。张数、已出的牌和公开的贡牌是硬约束，
+// 出牌记录中的每次Pass是软证据；抽样时先均匀发牌，再按证据的似然重采样
+type Belief struct {
+	PassPenalty float64 // 0表示DefaultPassPenalty
+	Oversample  int     // 0表示DefaultOversample
+	
+	constraints *dealConstraints
+	evidence    []passEvidence
+	trump       domain.Rank
+}
+
+// NewBelief 用obs.Plays中本Deal的全部出牌与Pass构造seat视角的模型
+func NewBelief(obs *Observation) (*Belief, error) {
+	constraints, err := newDealConstraints(obs)
+	if err != nil {
+		return nil, err
+	}
+	
+	b := &Belief{constraints: constraints, trump: obs.Trump}
+	var table *domain.CardGroup
+	var tablePlayer domain.SeatID
+	for i, play := range obs.Plays {
+		if !play.IsPass() {
+			table = play.CardGroup
+			if table == nil {
+				table = domain.NewCardGroup(play.Cards)
+			}
+			tablePlayer = play.Player
+			continue
+		}
+		
+		// 自己的Pass不提供信息；对家领先时Pass是常规打法
+		if play.Player == obs.Seat || table == nil || tablePlayer == play.Player.Opposite() {
+			continue
+		}
+		evidence := passEvidence{seat: play.Player, table: table}
+		for _, later := range obs.Plays[i+1:] {
+			if later.Player == play.Player {
+				evidence.later = append(evidence.later, later.Cards...)
+			}
+		}
+		b.evidence = append(b.evidence, evidence)
+	}
+	return b, nil
+}
+
+func (b *Belief) passPenalty() float64 {
+	if b.PassPenalty == 0 {
+		return DefaultPassPenalty
+	}
+	return b.PassPenalty
+}
+
+func (b *Belief) oversample() int {
+	if b.Oversample <= 0 {
+		return DefaultOversample
+	}
+	return b.Oversample
+}
+
+// Likelihood 在hands下产生出牌记录中这些Pass的相对概率，1表示与所有Pass都相符
+func (b *Belief) Likelihood(hands [4][]domain.Card) float64 {
+	likelihood := 1.0
+	for _, evidence := range b.evidence {
+		hand := append(append([]domain.Card(nil), hands[evidence.seat]...), evidence.later...)
+		if canBeatWithoutBomb(hand, evidence.table, b.trump) {
+			likelihood *= b.passPenalty()
+		}
+	}
+	return likelihood
+}
+
+// Sample 抽样n副与出牌记录相符的发牌（重要性重采样），每副都包含本座位的手牌
+func (b *Belief) Sample(n int, rng *rand.Rand) [][4][]domain.Card {
+	if n <= 0 {
+		return nil
+	}
+	
+	candidates := make([][4][]domain.Card, n*b.oversample())
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i := range candidates {
+		candidates[i] = b.constraints.draw(rng)
+		weights[i] = b.Likelihood(candidates[i])
+		total += weights[i]
+	}
+	
+	// 系统重采样：权重越大的候选被选中的次数越多
+	samples := make([][4][]domain.Card, 0, n)
+	step := total / float64(n)
+	target := rng.Float64() * step
+	cumulative := 0.0
+	for i, weight := range weights {
+		cumulative += weight
+		for len(samples) < n && target < cumulative {
+			samples = append(samples, candidates[i])
+			target += step
+		}
+	}
+	for len(samples) < n {
+		// 浮点误差导致的缺口用最后一个候选补齐
+		samples = append(samples, candidates[len(candidates)-1])
+	}
+	return samples
+}
+
+// Marginals 用n个样本估计各座位持有每种牌的边缘概率
+func (b *Belief) Marginals(n int, rng *rand.Rand) *Marginals {
+	m := &Marginals{}
+	for seat := range m.holds {
+		m.holds[seat] = make(map[domain.Card]int)
+		m.counts[seat] = make(map[domain.Card]int)
+	}
+	
+	for _, hands := range b.Sample(n, rng) {
+		m.Samples++
+		for seat, hand := range hands {
+			held := make(map[domain.Card]bool)
+			for _, card := range hand {
+				m.counts[seat][card]++
+				if !held[card] {
+					held[card] = true
+					m.holds[seat][card]++
+				}
+			}
+		}
+	}
+	return m
+}
+
+// Marginals 边缘概率估计，例如Holds(SeatWest, NewJoker(BigJoker))即西家持有大王的概率
+type Marginals struct {
+	Samples int
+	holds   [4]map[domain.Card]int
+	counts  [4]map[domain.Card]int
+}
+
+// Holds seat手中至少有一张card的概率
+func (m *Marginals) Holds(seat domain.SeatID, card domain.Card) float64 {
+	if m.Samples == 0 {
+		return 0
+	}
+	return float64(m.holds[seat][card]) / float64(m.Samples)
+}
+
+// Expected seat手中card的期望张数（两副牌中最多2张）
+func (m *Marginals) Expected(seat domain.SeatID, card domain.Card) float64 {
+	if m.Samples == 0 {
+		return 0
+	}
+	return float64(m.counts[seat][card]) / float64(m.Samples)
+}
+
+// canBeatWithoutBomb hand中是否有不用炸弹、不拆炸弹就能压过table的牌
+func canBeatWithoutBomb(hand []domain.Card, table *domain.CardGroup, trump domain.Rank) bool {
+	if isBombLike(table) {
+		return false
+	}
+	protected := bombCards(hand)
+	
+	// 单张、对子、三同张按点数计数即可判断，不必枚举牌型
+	if table.Category == domain.Single || table.Category == domain.Pair || (table.Category == domain.Triple && table.Size == 3) {
+		for rank, count := range rankCounts(hand) {
+			if count < table.Size || protected[rank] {
+				continue
+			}
+			if domain.CompareCards(rankCard(rank), rankCard(table.Rank), trump) == domain.CmpGreater {
+				return true
+			}
+		}
+		return false
+	}
+	
+	for _, cards := range domain.GetPlayableCards(hand, table, trump) {
+		group := domain.NewCardGroup(cards)
+		if !isBombLike(group) && !usesAny(group, protected) {
+			return true
+		}
+	}
+	return false
+}
+
+func rankCard(rank domain.Rank) domain.Card {
+	if rank == domain.SmallJoker || rank == domain.BigJoker {
+		return domain.NewJoker(rank)
+	}
+	return domain.NewCard(domain.Spades, rank)
+}
@@ -0,0 +1,593 @@
+package bot
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+)
+
+func newTestEngine(t *testing.T, source engine.DealSource) (*engine.GameEngine, *domain.MatchCtx) {
+	t.Helper()
+	
+	eventBus := event.NewEventBus(1000)
+	eventBus.Start()
+	t.Cleanup(eventBus.Stop)
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Alice", domain.SeatEast),
+		domain.NewPlayer("p2", "Bob", domain.SeatSouth),
+		domain.NewPlayer("p3", "Carol", domain.SeatWest),
+		domain.NewPlayer("p4", "Dave", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("bot-match", players, 20240601)
+	ge := engine.NewGameEngine(eventBus)
+	if err := ge.Initialize(matchCtx); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	if source != nil {
+		if err := ge.SetDealSource(source); err != nil {
+			t.Fatalf("Failed to set deal source: %v", err)
+		}
+	}
+	return ge, matchCtx
+}
+
+func heuristicTable() [4]Agent {
+	return [4]Agent{NewHeuristic(), NewHeuristic(), NewHeuristic(), NewHeuristic()}
+}
+
+func card(suit domain.Suit, rank domain.Rank) domain.Card {
+	return domain.NewCard(suit, rank)
+}
+
+func TestHeuristicPlaysFullDeal(t *testing.T) {
+	ge, _ := newTestEngine(t, nil)
+	
+	if err := ge.StartDeal(1, nil); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	
+	if err := PlayDeal(ge, heuristicTable()); err != nil {
+		t.Fatalf("PlayDeal failed: %v", err)
+	}
+	
+	if rankList := ge.GetDealCtx().RankList; len(rankList) != 4 {
+		t.Errorf("Expected a full ranking, got %v", rankList)
+	}
+	if len(ge.GetDealPlays()) == 0 {
+		t.Error("Expected the deal play log to record plays")
+	}
+}
+
+func TestHeuristicDoubleDownTribute(t *testing.T) {
+	previous := []domain.SeatID{domain.SeatEast, domain.SeatWest, domain.SeatSouth, domain.SeatNorth}
+	source := engine.NewConstrainedDealSource(engine.HoldsCards(domain.SeatEast,
+		domain.NewJoker(domain.BigJoker),
+		domain.NewJoker(domain.BigJoker),
+	))
+	ge, _ := newTestEngine(t, source)
+	
+	if err := ge.StartDeal(2, previous); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	ge.DealCards()
+	ge.DetermineTrump()
+	if err := ge.StartTribute(); err != nil {
+		t.Fatalf("Failed to start tribute: %v", err)
+	}
+	
+	var kinds []ActionKind
+	for ge.GetCurrentPhase() != engine.PhaseFirstPlay {
+		_, action, err := Step(ge, heuristicTable())
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		kinds = append(kinds, action.Kind)
+	}
+	
+	expected := []ActionKind{ActionTribute, ActionTribute, ActionSelectTribute, ActionReturnTribute, ActionReturnTribute}
+	if len(kinds) != len(expected) {
+		t.Fatalf("Expected actions %v, got %v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Fatalf("Expected actions %v, got %v", expected, kinds)
+		}
+	}
+	
+	if err := PlayDeal(ge, heuristicTable()); err != nil {
+		t.Fatalf("PlayDeal failed: %v", err)
+	}
+}
+
+func TestObserveShowsOnlyOwnHand(t *testing.T) {
+	ge, matchCtx := newTestEngine(t, nil)
+	matchCtx.GetTeam(domain.TeamSouthNorth).Level = domain.Four
+	
+	ge.StartDeal(1, nil)
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	
+	leader := ge.GetCurrentPlayer()
+	if _, _, err := Step(ge, heuristicTable()); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	
+	obs := Observe(ge, leader.Next())
+	if len(obs.Hand) != 27 {
+		t.Errorf("Expected 27 cards in own hand, got %d", len(obs.Hand))
+	}
+	if obs.HandCounts[leader] == 27 {
+		t.Errorf("Expected leader's hand count to drop after leading, got %d", obs.HandCounts[leader])
+	}
+	if len(obs.Plays) != 1 || obs.Plays[0].Player != leader {
+		t.Errorf("Expected one public play by %s, got %v", leader, obs.Plays)
+	}
+	if obs.LastPlay == nil || obs.LastPlayer != leader {
+		t.Errorf("Expected %s's play on the table, got %v by %s", leader, obs.LastPlay, obs.LastPlayer)
+	}
+	if obs.Levels[domain.TeamSouthNorth] != domain.Four {
+		t.Errorf("Expected South/North level 4, got %s", obs.Levels[domain.TeamSouthNorth])
+	}
+}
+
+func TestHeuristicLeadsLowSetWithoutBreakingBombs(t *testing.T) {
+	obs := &Observation{
+		Seat:  domain.SeatEast,
+		Phase: engine.PhaseInProgress,
+		Trump: domain.Ten,
+		Hand: []domain.Card{
+			card(domain.Spades, domain.Two), card(domain.Hearts, domain.Two),
+			card(domain.Clubs, domain.Two), card(domain.Diamonds, domain.Two),
+			card(domain.Spades, domain.Three), card(domain.Hearts, domain.Three),
+			card(domain.Clubs, domain.Four),
+			card(domain.Spades, domain.King),
+		},
+	}
+	
+	action, err := NewHeuristic().Act(obs)
+	if err != nil {
+		t.Fatalf("Act failed: %v", err)
+	}
+	group := domain.NewCardGroup(action.Cards)
+	if action.Kind != ActionPlay || group.Category != domain.Pair || group.Rank != domain.Three {
+		t.Errorf("Expected to lead the pair of threes, got %s", action)
+	}
+}
+
+func TestHeuristicDoesNotOvertakePartner(t *testing.T) {
+	obs := &Observation{
+		Seat:       domain.SeatEast,
+		Phase:      engine.PhaseInProgress,
+		Trump:      domain.Two,
+		Hand:       []domain.Card{card(domain.Spades, domain.King), card(domain.Hearts, domain.Five)},
+		HandCounts: [4]int{2, 10, 10, 10},
+		LastPlay:   domain.NewCardGroup([]domain.Card{card(domain.Clubs, domain.Four)}),
+		LastPlayer: domain.SeatWest,
+	}
+	
+	action, err := NewHeuristic().Act(obs)
+	if err != nil {
+		t.Fatalf("Act failed: %v", err)
+	}
+	if action.Kind != ActionPass {
+		t.Errorf("Expected to pass on partner's play, got %s", action)
+	}
+	
+	obs.LastPlayer = domain.SeatSouth
+	action, _ = NewHeuristic().Act(obs)
+	if action.Kind != ActionPlay || action.Cards[0] != card(domain.Hearts, domain.Five) {
+		t.Errorf("Expected to beat the opponent with the smallest single, got %s", action)
+	}
+}
+
+func TestHeuristicKeepsBombsForEmergencies(t *testing.T) {
+	obs := &Observation{
+		Seat:  domain.SeatEast,
+		Phase: engine.PhaseInProgress,
+		Trump: domain.Two,
+		Hand: []domain.Card{
+			card(domain.Spades, domain.Nine), card(domain.Hearts, domain.Nine),
+			card(domain.Clubs, domain.Nine), card(domain.Diamonds, domain.Nine),
+			card(domain.Spades, domain.Three),
+		},
+		HandCounts: [4]int{5, 12, 10, 10},
+		LastPlay:   domain.NewCardGroup([]domain.Card{card(domain.Clubs, domain.Queen)}),
+		LastPlayer: domain.SeatSouth,
+	}
+	
+	action, _ := NewHeuristic().Act(obs)
+	if action.Kind != ActionPass {
+		t.Errorf("Expected to keep the bomb and pass, got %s", action)
+	}
+	
+	obs.HandCounts[domain.SeatSouth] = 2
+	action, _ = NewHeuristic().Act(obs)
+	if group := domain.NewCardGroup(action.Cards); action.Kind != ActionPlay || !group.IsBomb() {
+		t.Errorf("Expected to bomb an opponent about to finish, got %s", action)
+	}
+}
+
+func TestHeuristicReturnTribute(t *testing.T) {
+	obs := &Observation{
+		Seat:  domain.SeatEast,
+		Phase: engine.PhaseReturnTribute,
+		Trump: domain.Six,
+		Hand: []domain.Card{
+			card(domain.Spades, domain.Two), card(domain.Hearts, domain.Two),
+			card(domain.Clubs, domain.Two), card(domain.Diamonds, domain.Two),
+			card(domain.Spades, domain.Five), card(domain.Hearts, domain.Five),
+			card(domain.Hearts, domain.Six),
+			card(domain.Clubs, domain.Eight),
+			card(domain.Clubs, domain.Jack),
+		},
+	}
+	
+	action, err := NewHeuristic().Act(obs)
+	if err != nil {
+		t.Fatalf("Act failed: %v", err)
+	}
+	if action.Kind != ActionReturnTribute || action.Cards[0] != card(domain.Clubs, domain.Eight) {
+		t.Errorf("Expected to return the lone eight, got %s", action)
+	}
+}
+
+func TestSampleHandsMatchesUnseenCards(t *testing.T) {
+	ge, _ := newTestEngine(t, nil)
+	ge.StartDeal(1, nil)
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	
+	for i := 0; i < 30; i++ {
+		if _, _, err := Step(ge, heuristicTable()); err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+	}
+	
+	seat := ge.GetPendingSeats()[0]
+	obs := Observe(ge, seat)
+	hands, err := sampleHands(obs, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("sampleHands failed: %v", err)
+	}
+	
+	counts := make(map[domain.Card]int)
+	for s := domain.SeatEast; s <= domain.SeatNorth; s++ {
+		if len(hands[s]) != obs.HandCounts[s] {
+			t.Errorf("Expected %d sampled cards for %s, got %d", obs.HandCounts[s], s, len(hands[s]))
+		}
+		for _, card := range hands[s] {
+			counts[card]++
+		}
+	}
+	for _, play := range obs.Plays {
+		for _, card := range play.Cards {
+			counts[card]++
+		}
+	}
+	for _, card := range domain.NewDeck().Cards {
+		counts[card]--
+	}
+	for card, count := range counts {
+		if count != 0 {
+			t.Errorf("Card %s off by %d between sampled hands, plays and the deck", card, count)
+		}
+	}
+	if len(hands[seat]) != len(obs.Hand) {
+		t.Errorf("Own hand should be kept as is")
+	}
+}
+
+func TestMonteCarloLeadsSafePair(t *testing.T) {
+	// 两个对手各剩一张牌：先出对K再出3能头游，先出3很可能被对手压过出完
+	obs := &Observation{
+		Seat:       domain.SeatEast,
+		Phase:      engine.PhaseInProgress,
+		Trump:      domain.Two,
+		Hand:       []domain.Card{card(domain.Spades, domain.King), card(domain.Hearts, domain.King), card(domain.Clubs, domain.Three)},
+		HandCounts: [4]int{3, 1, 10, 1},
+	}
+	
+	if action, _ := NewHeuristic().Act(obs); action.Kind != ActionPlay || len(action.Cards) != 1 {
+		t.Fatalf("Heuristic is expected to lead a low single here, got %s", action)
+	}
+	
+	mc := NewMonteCarlo(SearchConfig{Iterations: 600, Workers: 2, Seed: 42})
+	action, err := mc.Act(obs)
+	if err != nil {
+		t.Fatalf("Act failed: %v", err)
+	}
+	if group := domain.NewCardGroup(action.Cards); action.Kind != ActionPlay || group.Category != domain.Pair {
+		t.Errorf("Expected search to lead the pair of kings, got %s", action)
+	}
+}
+
+func TestMonteCarloPlaysFullDeal(t *testing.T) {
+	ge, _ := newTestEngine(t, nil)
+	ge.StartDeal(1, nil)
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	
+	mc := NewMonteCarlo(SearchConfig{Iterations: 64, Workers: 2, Seed: 7})
+	agents := [4]Agent{mc, NewHeuristic(), mc, NewHeuristic()}
+	if err := PlayDeal(ge, agents); err != nil {
+		t.Fatalf("PlayDeal failed: %v", err)
+	}
+	if rankList := ge.GetDealCtx().RankList; len(rankList) != 4 {
+		t.Errorf("Expected a full ranking, got %v", rankList)
+	}
+}
+
+func TestMonteCarloRespectsDuration(t *testing.T) {
+	obs := &Observation{
+		Seat:       domain.SeatEast,
+		Phase:      engine.PhaseInProgress,
+		Trump:      domain.Two,
+		Hand:       []domain.Card{card(domain.Spades, domain.King), card(domain.Hearts, domain.King), card(domain.Clubs, domain.Three)},
+		HandCounts: [4]int{3, 1, 10, 1},
+	}
+	
+	mc := NewMonteCarlo(SearchConfig{Duration: 50 * time.Millisecond, Workers: 2})
+	start := time.Now()
+	if _, err := mc.Act(obs); err != nil {
+		t.Fatalf("Act failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Search should stop near its 50ms budget, took %s", elapsed)
+	}
+}
+
+func suggest(t *testing.T, obs *Observation) []Suggestion {
+	t.Helper()
+	
+	plays := domain.GetPlayableCards(obs.Hand, obs.LastPlay, obs.Trump)
+	suggestions, err := NewHeuristic().Suggest(obs, plays)
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	return suggestions
+}
+
+func TestSuggestRanksAndDeduplicates(t *testing.T) {
+	obs := &Observation{
+		Seat:  domain.SeatEast,
+		Phase: engine.PhaseInProgress,
+		Trump: domain.Two,
+		Hand: []domain.Card{
+			card(domain.Spades, domain.Five), card(domain.Spades, domain.Five),
+			card(domain.Hearts, domain.Five),
+			card(domain.Spades, domain.Nine), card(domain.Hearts, domain.Nine),
+			card(domain.Clubs, domain.Nine), card(domain.Diamonds, domain.Nine),
+			card(domain.Clubs, domain.King),
+		},
+		HandCounts: [4]int{8, 12, 10, 10},
+		LastPlay:   domain.NewCardGroup([]domain.Card{card(domain.Clubs, domain.Four)}),
+		LastPlayer: domain.SeatSouth,
+	}
+	
+	suggestions := suggest(t, obs)
+	first := suggestions[0]
+	if first.Reason != ReasonSmallestBeat || first.Action.Cards[0].Rank != domain.Five {
+		t.Fatalf("Expected the single five as smallest beat, got %s (%s)", first.Action, first.Reason)
+	}
+	if second := suggestions[1]; second.Reason != ReasonKeepsBombs || second.Action.Cards[0].Rank != domain.King {
+		t.Errorf("Expected the king next to keep the bomb intact, got %s (%s)", second.Action, second.Reason)
+	}
+	
+	singles := make(map[domain.Rank]int)
+	for i, suggestion := range suggestions {
+		if i > 0 && suggestion.Score >= suggestions[i-1].Score {
+			t.Errorf("Scores should be strictly decreasing, got %d after %d", suggestion.Score, suggestions[i-1].Score)
+		}
+		if len(suggestion.Action.Cards) == 1 {
+			singles[suggestion.Action.Cards[0].Rank]++
+		}
+	}
+	if singles[domain.Five] != 1 || singles[domain.Nine] != 1 {
+		t.Errorf("Expected one suggestion per single rank, got %v", singles)
+	}
+	
+	last := suggestions[len(suggestions)-1]
+	if group := domain.NewCardGroup(last.Action.Cards); !group.IsBomb() || last.Reason != ReasonBomb {
+		t.Errorf("Expected bombs to be suggested last, got %s (%s)", last.Action, last.Reason)
+	}
+}
+
+func TestSuggestPassWhenPartnerWinning(t *testing.T) {
+	obs := &Observation{
+		Seat:       domain.SeatEast,
+		Phase:      engine.PhaseInProgress,
+		Trump:      domain.Two,
+		Hand:       []domain.Card{card(domain.Spades, domain.King), card(domain.Hearts, domain.Five)},
+		HandCounts: [4]int{2, 10, 10, 10},
+		LastPlay:   domain.NewCardGroup([]domain.Card{card(domain.Clubs, domain.Four)}),
+		LastPlayer: domain.SeatWest,
+	}
+	
+	suggestions := suggest(t, obs)
+	if first := suggestions[0]; first.Action.Kind != ActionPass || first.Reason != ReasonPartnerWinning {
+		t.Errorf("Expected pass recommended on partner's play, got %s (%s)", first.Action, first.Reason)
+	}
+	if len(suggestions) != 3 {
+		t.Errorf("Expected pass and both singles, got %d suggestions", len(suggestions))
+	}
+}
+
+func TestSuggestSavesBombsUnlessEmergency(t *testing.T) {
+	obs := &Observation{
+		Seat:  domain.SeatEast,
+		Phase: engine.PhaseInProgress,
+		Trump: domain.Two,
+		Hand: []domain.Card{
+			card(domain.Spades, domain.Nine), card(domain.Hearts, domain.Nine),
+			card(domain.Clubs, domain.Nine), card(domain.Diamonds, domain.Nine),
+			card(domain.Spades, domain.Three),
+		},
+		HandCounts: [4]int{5, 12, 10, 10},
+		LastPlay:   domain.NewCardGroup([]domain.Card{card(domain.Clubs, domain.Queen)}),
+		LastPlayer: domain.SeatSouth,
+	}
+	
+	if first := suggest(t, obs)[0]; first.Action.Kind != ActionPass || first.Reason != ReasonSaveBombs {
+		t.Errorf("Expected pass to save the bomb, got %s (%s)", first.Action, first.Reason)
+	}
+	
+	obs.HandCounts[domain.SeatSouth] = 2
+	if first := suggest(t, obs)[0]; first.Reason != ReasonStopOpponent {
+		t.Errorf("Expected a bomb to stop the opponent, got %s (%s)", first.Action, first.Reason)
+	}
+	
+	obs.Phase = engine.PhaseTribute
+	if _, err := NewHeuristic().Suggest(obs, nil); err == nil {
+		t.Error("Expected an error outside the play phases")
+	}
+}
+
+func TestObservePlaysRecordPasses(t *testing.T) {
+	ge, _ := newTestEngine(t, nil)
+	ge.StartDeal(1, nil)
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	
+	passes := 0
+	for passes == 0 {
+		if _, action, err := Step(ge, heuristicTable()); err != nil {
+			t.Fatalf("Step failed: %v", err)
+		} else if action.Kind == ActionPass {
+			passes++
+		}
+	}
+	
+	obs := Observe(ge, domain.SeatEast)
+	last := obs.Plays[len(obs.Plays)-1]
+	if !last.IsPass() || len(obs.Trick) == 0 || !obs.Trick[len(obs.Trick)-1].IsPass() {
+		t.Errorf("Expected the pass in the deal and trick history, got %v", last)
+	}
+}
+
+// kingLeadObservation 东家首出单张K，南、西、北都Pass，又轮到东家
+func kingLeadObservation() *Observation {
+	king := []domain.Card{card(domain.Spades, domain.King)}
+	return &Observation{
+		Seat:       domain.SeatEast,
+		Phase:      engine.PhaseInProgress,
+		Trump:      domain.Two,
+		Hand:       []domain.Card{card(domain.Clubs, domain.Three), card(domain.Clubs, domain.Four)},
+		HandCounts: [4]int{2, 5, 5, 5},
+		Plays: []domain.TrickPlay{
+			{Player: domain.SeatEast, Cards: king, CardGroup: domain.NewCardGroup(king)},
+			{Player: domain.SeatSouth},
+			{Player: domain.SeatWest},
+			{Player: domain.SeatNorth},
+		},
+	}
+}
+
+func TestBeliefPassesLowerHighCardOdds(t *testing.T) {
+	obs := kingLeadObservation()
+	bigJoker := domain.NewJoker(domain.BigJoker)
+	
+	belief, err := NewBelief(obs)
+	if err != nil {
+		t.Fatalf("NewBelief failed: %v", err)
+	}
+	if len(belief.evidence) != 2 {
+		t.Fatalf("Expected evidence from both opponents only, got %d", len(belief.evidence))
+	}
+	posterior := belief.Marginals(400, rand.New(rand.NewSource(1)))
+	
+	belief.PassPenalty = 1
+	prior := belief.Marginals(400, rand.New(rand.NewSource(1)))
+	
+	for _, seat := range []domain.SeatID{domain.SeatSouth, domain.SeatNorth} {
+		if p, q := posterior.Holds(seat, bigJoker), prior.Holds(seat, bigJoker); p >= q/2 {
+			t.Errorf("Passing on a king should make a big joker unlikely for %s: %.3f vs prior %.3f", seat, p, q)
+		}
+	}
+	if p, q := posterior.Holds(domain.SeatWest, bigJoker), prior.Holds(domain.SeatWest, bigJoker); p < q {
+		t.Errorf("Partner's pass should not lower the odds: %.3f vs prior %.3f", p, q)
+	}
+	if posterior.Holds(domain.SeatEast, card(domain.Clubs, domain.Three)) != 1 || posterior.Expected(domain.SeatEast, bigJoker) != 0 {
+		t.Error("Own hand should be known exactly")
+	}
+}
+
+func TestBeliefSamplesAreConsistent(t *testing.T) {
+	obs := kingLeadObservation()
+	belief, err := NewBelief(obs)
+	if err != nil {
+		t.Fatalf("NewBelief failed: %v", err)
+	}
+	
+	samples := belief.Sample(20, rand.New(rand.NewSource(3)))
+	if len(samples) != 20 {
+		t.Fatalf("Expected 20 samples, got %d", len(samples))
+	}
+	for _, hands := range samples {
+		for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+			if len(hands[seat]) != obs.HandCounts[seat] {
+				t.Fatalf("Expected %d cards for %s, got %d", obs.HandCounts[seat], seat, len(hands[seat]))
+			}
+		}
+		// 两张黑桃K已打出一张，各家合计最多还有一张
+		kings := 0
+		for _, hand := range hands {
+			for _, held := range hand {
+				if held == card(domain.Spades, domain.King) {
+					kings++
+				}
+			}
+		}
+		if kings > 1 {
+			t.Fatalf("Sampled %d spade kings after one was played", kings)
+		}
+	}
+	
+	mc := NewMonteCarlo(SearchConfig{Iterations: 50, Workers: 2, Seed: 5, Belief: true})
+	if action, err := mc.Act(obs); err != nil || action.Kind != ActionPlay {
+		t.Errorf("Expected a play from belief-based search, got %s (%v)", action, err)
+	}
+}
+
+func TestSuggestMarksLikelyHolds(t *testing.T) {
+	obs := kingLeadObservation()
+	obs.Hand = []domain.Card{card(domain.Clubs, domain.Three), card(domain.Hearts, domain.Ace)}
+	obs.HandCounts = [4]int{2, 3, 5, 3}
+	plays := domain.GetPlayableCards(obs.Hand, nil, obs.Trump)
+	
+	reasons := func(h *Heuristic) map[domain.Rank]HintReason {
+		t.Helper()
+		suggestions, err := h.Suggest(obs, plays)
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		byRank := make(map[domain.Rank]HintReason)
+		for _, suggestion := range suggestions {
+			byRank[suggestion.Action.Cards[0].Rank] = suggestion.Reason
+		}
+		return byRank
+	}
+	
+	if got := reasons(NewHeuristic())[domain.Ace]; got != ReasonAlternative {
+		t.Errorf("Without belief the ace is an alternative lead, got %s", got)
+	}
+	
+	// 对手都没压K，多半也压不过A；小3总有人能压
+	got := reasons(&Heuristic{HintDeals: DefaultHintDeals})
+	if got[domain.Ace] != ReasonLikelyHolds {
+		t.Errorf("Expected the ace to likely hold after both opponents passed a king, got %s", got[domain.Ace])
+	}
+	if got[domain.Three] != ReasonSmallestLead {
+		t.Errorf("Expected the three to stay the smallest lead, got %s", got[domain.Three])
+	}
+}
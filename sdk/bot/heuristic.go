@@ -0,0 +1,264 @@
+package bot
+
+import (
+	"fmt"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+const (
+	DefaultEmergencyHandSize = 6   // 对手剩余手牌不多于该数量时才用炸弹拦截
+	DefaultHintDeals         = 32  // 提示时用Belief估计对手能否压过的抽样数
+	DefaultHoldProbability   = 0.8 // 对手不拆炸弹压不过的概率不低于此值时提示likely_holds
+)
+
+// Heuristic 基于规则的机器人：保留炸弹到紧急时刻，首出从最小的单张、对子开始，
+// 不压对家的牌，还贡时尽量还不影响牌型的小牌
+type Heuristic struct {
+	EmergencyHandSize int // 0表示DefaultEmergencyHandSize
+	HintDeals         int // Suggest按Belief抽样估计对手能否压过的发牌数，0表示不估计
+}
+
+func NewHeuristic() *Heuristic {
+	return &Heuristic{}
+}
+
+func (h *Heuristic) Act(obs *Observation) (Action, error) {
+	switch obs.Phase {
+	case engine.PhaseTribute:
+		card, ok := domain.SelectTributeCard(obs.Hand, obs.Trump)
+		if !ok {
+			return Action{}, fmt.Errorf("no legal tribute card")
+		}
+		return Tribute(card), nil
+	case engine.PhaseTributeSelection:
+		return h.selectTribute(obs)
+	case engine.PhaseReturnTribute:
+		return h.returnTribute(obs)
+	case engine.PhaseFirstPlay, engine.PhaseInProgress:
+		if obs.IsLeading() {
+			return h.lead(obs)
+		}
+		return h.follow(obs)
+	default:
+		return Action{}, fmt.Errorf("no action in phase %s", obs.Phase.String())
+	}
+}
+
+func (h *Heuristic) emergencyHandSize() int {
+	if h.EmergencyHandSize == 0 {
+		return DefaultEmergencyHandSize
+	}
+	return h.EmergencyHandSize
+}
+
+// selectTribute 选较大的贡牌，相同时取上局第三名的牌
+func (h *Heuristic) selectTribute(obs *Observation) (Action, error) {
+	if obs.Tribute == nil || len(obs.LastRankings) != 4 {
+		return Action{}, fmt.Errorf("no tribute selection pending")
+	}
+	
+	third := obs.LastRankings[2]
+	fourth := obs.LastRankings[3]
+	available := obs.Tribute.Available
+	if domain.CompareCards(available[fourth], available[third], obs.Trump) == domain.CmpGreater {
+		return SelectTribute(fourth), nil
+	}
+	return SelectTribute(third), nil
+}
+
+// returnTribute 优先还非主牌、不拆炸弹、同点数张数最少的最小牌
+func (h *Heuristic) returnTribute(obs *Observation) (Action, error) {
+	counts := rankCounts(obs.Hand)
+	
+	var best domain.Card
+	var bestScore []int
+	for _, card := range obs.Hand {
+		if !domain.IsValidReturnTributeCard(obs.Hand, card) {
+			continue
+		}
+		
+		score := []int{0, 0, counts[card.Rank], int(card.Rank)}
+		if card.Rank == obs.Trump {
+			score[0] = 1
+		}
+		if counts[card.Rank] >= 4 {
+			score[1] = 1
+		}
+		if bestScore == nil || lessScore(score, bestScore) {
+			best, bestScore = card, score
+		}
+	}
+	
+	if bestScore == nil {
+		return Action{}, fmt.Errorf("no legal return tribute card")
+	}
+	return ReturnTribute(best), nil
+}
+
+// lead 首出：能一手出完就出完；否则出点数最小的整组单张、对子或三同张，不拆炸弹
+func (h *Heuristic) lead(obs *Observation) (Action, error) {
+	groups := playableGroups(obs.Hand, nil, obs.Trump)
+	if len(groups) == 0 {
+		return Action{}, fmt.Errorf("no playable cards")
+	}
+	if group := wholeHand(groups, obs.Hand); group != nil {
+		return Play(group.Cards), nil
+	}
+	
+	protected := bombCards(obs.Hand)
+	counts := rankCounts(obs.Hand)
+	
+	var natural, others, bombs []*domain.CardGroup
+	for _, group := range groups {
+		switch {
+		case group.IsBomb():
+			bombs = append(bombs, group)
+		case usesAny(group, protected):
+			// 拆炸弹的牌型不考虑
+		case isNaturalSet(group, counts):
+			natural = append(natural, group)
+		default:
+			others = append(others, group)
+		}
+	}
+	
+	for _, candidates := range [][]*domain.CardGroup{natural, others} {
+		if len(candidates) > 0 {
+			return Play(lowestLead(candidates, obs.Trump).Cards), nil
+		}
+	}
+	return Play(smallestGroup(bombs, obs.Trump).Cards), nil
+}
+
+// follow 跟牌：对家领先时不压；用最小的不拆炸弹的牌压过；只有炸弹能压时，
+// 仅在对手快出完时使用
+func (h *Heuristic) follow(obs *Observation) (Action, error) {
+	groups := playableGroups(obs.Hand, obs.LastPlay, obs.Trump)
+	if group := wholeHand(groups, obs.Hand); group != nil {
+		return Play(group.Cards), nil
+	}
+	if obs.LastPlayer == obs.Partner() {
+		return Pass(), nil
+	}
+	
+	protected := bombCards(obs.Hand)
+	var plain, bombs []*domain.CardGroup
+	for _, group := range groups {
+		switch {
+		case group.IsBomb():
+			bombs = append(bombs, group)
+		case !usesAny(group, protected):
+			plain = append(plain, group)
+		}
+	}
+	
+	if len(plain) > 0 {
+		return Play(smallestGroup(plain, obs.Trump).Cards), nil
+	}
+	if len(bombs) > 0 && obs.HandCounts[obs.LastPlayer] <= h.emergencyHandSize() {
+		return Play(smallestGroup(bombs, obs.Trump).Cards), nil
+	}
+	return Pass(), nil
+}
+
+func playableGroups(hand []domain.Card, lastPlay *domain.CardGroup, trump domain.Rank) []*domain.CardGroup {
+	var groups []*domain.CardGroup
+	for _, cards := range domain.GetPlayableCards(hand, lastPlay, trump) {
+		groups = append(groups, domain.NewCardGroup(cards))
+	}
+	return groups
+}
+
+// wholeHand 能一次出完手牌的牌组
+func wholeHand(groups []*domain.CardGroup, hand []domain.Card) *domain.CardGroup {
+	for _, group := range groups {
+		if len(group.Cards) == len(hand) {
+			return group
+		}
+	}
+	return nil
+}
+
+func rankCounts(cards []domain.Card) map[domain.Rank]int {
+	counts := make(map[domain.Rank]int)
+	for _, card := range cards {
+		counts[card.Rank]++
+	}
+	return counts
+}
+
+// bombCards 手牌中属于炸弹的点数：四张及以上的同点数，以及两张及以上的王
+func bombCards(hand []domain.Card) map[domain.Rank]bool {
+	counts := rankCounts(hand)
+	protected := make(map[domain.Rank]bool)
+	for rank, count := range counts {
+		if rank != domain.SmallJoker && rank != domain.BigJoker && count >= 4 {
+			protected[rank] = true
+		}
+	}
+	if counts[domain.SmallJoker]+counts[domain.BigJoker] >= domain.MinJokerBomb {
+		protected[domain.SmallJoker] = true
+		protected[domain.BigJoker] = true
+	}
+	return protected
+}
+
+func usesAny(group *domain.CardGroup, ranks map[domain.Rank]bool) bool {
+	for _, card := range group.Cards {
+		if ranks[card.Rank] {
+			return true
+		}
+	}
+	return false
+}
+
+// isNaturalSet 单张、对子或三同张恰好用完该点数的所有牌
+func isNaturalSet(group *domain.CardGroup, counts map[domain.Rank]int) bool {
+	switch group.Category {
+	case domain.Single, domain.Pair, domain.Triple:
+		return counts[group.Cards[0].Rank] == len(group.Cards)
+	default:
+		return false
+	}
+}
+
+// lowestLead 最小的牌点数最小的牌组，相同时出牌较多的优先
+func lowestLead(groups []*domain.CardGroup, trump domain.Rank) *domain.CardGroup {
+	return sortLeads(groups, trump)[0]
+}
+
+func lowestCard(group *domain.CardGroup, trump domain.Rank) domain.Card {
+	lowest := group.Cards[0]
+	for _, card := range group.Cards[1:] {
+		if domain.CompareCards(card, lowest, trump) == domain.CmpLess {
+			lowest = card
+		}
+	}
+	return lowest
+}
+
+// smallestGroup 按CompareCardGroups取最小的牌组，无法比较时出牌较少的优先
+func smallestGroup(groups []*domain.CardGroup, trump domain.Rank) *domain.CardGroup {
+	smallest := groups[0]
+	for _, group := range groups[1:] {
+		switch domain.CompareCardGroups(group, smallest, trump) {
+		case domain.CmpLess:
+			smallest = group
+		case domain.CmpEqual:
+			if len(group.Cards) < len(smallest.Cards) {
+				smallest = group
+			}
+		}
+	}
+	return smallest
+}
+
+func lessScore(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
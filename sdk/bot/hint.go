@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// HintReason 提示的理由代码，客户端按代码显示本地化文案
+type HintReason string
+
+const (
+	ReasonPlayOut        HintReason = "play_out"        // 一手出完
+	ReasonPartnerWinning HintReason = "partner_winning" // 对家领先，建议不出
+	ReasonSmallestLead   HintReason = "smallest_lead"   // 最小的首出
+	ReasonSmallestBeat   HintReason = "smallest_beat"   // 最小的能压过的牌
+	ReasonKeepsBombs     HintReason = "keeps_bombs"     // 不拆炸弹
+	ReasonAlternative    HintReason = "alternative"     // 其他不拆炸弹的出法
+	ReasonStopOpponent   HintReason = "stop_opponent"   // 对手快出完，用炸弹拦截
+	ReasonSaveBombs      HintReason = "save_bombs"      // 只有炸弹能压，建议不出保留炸弹
+	ReasonCannotBeat     HintReason = "cannot_beat"     // 没有能压过的牌
+	ReasonBreaksBomb     HintReason = "breaks_bomb"     // 需要拆炸弹
+	ReasonBomb           HintReason = "bomb"            // 炸弹
+	ReasonLikelyHolds    HintReason = "likely_holds"    // 按出牌记录推断，对手多半不拆炸弹压不过
+)
+
+// Suggestion 一条出牌提示，Score越高越推荐
+type Suggestion struct {
+	Action Action
+	Score  int
+	Reason HintReason
+}
+
+// Suggest 按Heuristic的策略给plays（GameEngine.GetValidPlays的结果）及Pass排名，最推荐的在前。
+// 牌型与点数相同的出法只保留一个（两副牌中相同的牌、仅花色不同的牌组）。
+// HintDeals大于0时用Belief抽样对手手牌，对手多半压不过的普通出牌改用likely_holds作理由
+func (h *Heuristic) Suggest(obs *Observation, plays [][]domain.Card) ([]Suggestion, error) {
+	if obs.Phase != engine.PhaseFirstPlay && obs.Phase != engine.PhaseInProgress {
+		return nil, fmt.Errorf("no hint in phase %s", obs.Phase.String())
+	}
+	
+	var groups []*domain.CardGroup
+	for _, cards := range plays {
+		groups = append(groups, domain.NewCardGroup(cards))
+	}
+	groups = uniqueGroups(groups)
+	protected := bombCards(obs.Hand)
+	counts := rankCounts(obs.Hand)
+	
+	var playOut, natural, plain, breaking, bombs []*domain.CardGroup
+	for _, group := range groups {
+		switch {
+		case len(group.Cards) == len(obs.Hand):
+			playOut = append(playOut, group)
+		case isBombLike(group):
+			bombs = append(bombs, group)
+		case usesAny(group, protected):
+			breaking = append(breaking, group)
+		case obs.IsLeading() && isNaturalSet(group, counts):
+			natural = append(natural, group)
+		default:
+			plain = append(plain, group)
+		}
+	}
+	
+	var ranked []Suggestion
+	add := func(action Action, reason HintReason) {
+		ranked = append(ranked, Suggestion{Action: action, Reason: reason})
+	}
+	addGroups := func(groups []*domain.CardGroup, reason HintReason) {
+		for _, group := range groups {
+			add(Play(group.Cards), reason)
+		}
+	}
+	
+	addGroups(playOut, ReasonPlayOut)
+	if obs.IsLeading() {
+		plain = append(sortLeads(natural, obs.Trump), sortLeads(plain, obs.Trump)...)
+		addPlain(&ranked, plain, ReasonSmallestLead, len(protected) > 0)
+		addGroups(sortLeads(breaking, obs.Trump), ReasonBreaksBomb)
+		addGroups(sortBeats(bombs, obs.Trump), ReasonBomb)
+	} else {
+		partnerWinning := obs.LastPlayer == obs.Partner()
+		if partnerWinning {
+			add(Pass(), ReasonPartnerWinning)
+		}
+		addPlain(&ranked, sortBeats(plain, obs.Trump), ReasonSmallestBeat, len(protected) > 0)
+		
+		bombs = sortBeats(bombs, obs.Trump)
+		emergency := !partnerWinning && len(plain) == 0 &&
+			obs.HandCounts[obs.LastPlayer] <= h.emergencyHandSize()
+		if emergency {
+			addGroups(bombs, ReasonStopOpponent)
+		}
+		if !partnerWinning && len(plain) == 0 {
+			if len(bombs) > 0 && !emergency {
+				add(Pass(), ReasonSaveBombs)
+			} else {
+				add(Pass(), ReasonCannotBeat)
+			}
+		}
+		addGroups(sortBeats(breaking, obs.Trump), ReasonBreaksBomb)
+		if !emergency {
+			addGroups(bombs, ReasonBomb)
+		}
+	}
+	
+	for i := range ranked {
+		ranked[i].Score = len(ranked) - i
+	}
+	if h.HintDeals > 0 {
+		h.markLikelyHolds(obs, ranked)
+	}
+	return ranked, nil
+}
+
+// markLikelyHolds 用Belief的样本估计两名对手都无法不拆炸弹压过的概率，
+// 不低于DefaultHoldProbability的普通出牌改用ReasonLikelyHolds。种子取自出牌记录，同一局面的提示不变
+func (h *Heuristic) markLikelyHolds(obs *Observation, ranked []Suggestion) {
+	belief, err := NewBelief(obs)
+	if err != nil {
+		return
+	}
+	samples := belief.Sample(h.HintDeals, rand.New(rand.NewSource(int64(len(obs.Plays))*4+int64(obs.Seat))))
+	
+	for i := range ranked {
+		if reason := ranked[i].Reason; reason != ReasonSmallestLead && reason != ReasonSmallestBeat &&
+			reason != ReasonKeepsBombs && reason != ReasonAlternative {
+			continue
+		}
+		group := domain.NewCardGroup(ranked[i].Action.Cards)
+		holds := 0
+		for _, hands := range samples {
+			if !canBeatWithoutBomb(hands[obs.Seat.Next()], group, obs.Trump) &&
+				!canBeatWithoutBomb(hands[obs.Seat.Next().Opposite()], group, obs.Trump) {
+				holds++
+			}
+		}
+		if float64(holds) >= DefaultHoldProbability*float64(len(samples)) {
+			ranked[i].Reason = ReasonLikelyHolds
+		}
+	}
+}
+
+// addPlain 第一个不拆炸弹的牌组用first作理由，其余的按手中是否有炸弹标注
+func addPlain(ranked *[]Suggestion, groups []*domain.CardGroup, first HintReason, hasBombs bool) {
+	for i, group := range groups {
+		reason := first
+		if i > 0 && hasBombs {
+			reason = ReasonKeepsBombs
+		} else if i > 0 {
+			reason = ReasonAlternative
+		}
+		*ranked = append(*ranked, Suggestion{Action: Play(group.Cards), Reason: reason})
+	}
+}
+
+// isBombLike 炸弹与同花顺
+func isBombLike(group *domain.CardGroup) bool {
+	return group.GetCATValue() > 0
+}
+
+// uniqueGroups 按牌型与点数去重，保留第一次出现的牌组
+func uniqueGroups(groups []*domain.CardGroup) []*domain.CardGroup {
+	seen := make(map[string]bool)
+	var unique []*domain.CardGroup
+	for _, group := range groups {
+		key := groupKey(group)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, group)
+	}
+	return unique
+}
+
+func groupKey(group *domain.CardGroup) string {
+	ranks := make([]int, len(group.Cards))
+	for i, card := range group.Cards {
+		ranks[i] = int(card.Rank)
+	}
+	sort.Ints(ranks)
+	
+	var key strings.Builder
+	fmt.Fprintf(&key, "%d/%d:", group.Category, group.GetCATValue())
+	for _, rank := range ranks {
+		fmt.Fprintf(&key, "%d,", rank)
+	}
+	return key.String()
+}
+
+// sortLeads 按最小的牌从小到大排序，相同时出牌较多的优先
+func sortLeads(groups []*domain.CardGroup, trump domain.Rank) []*domain.CardGroup {
+	sorted := make([]*domain.CardGroup, len(groups))
+	copy(sorted, groups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := lowestCard(sorted[i], trump), lowestCard(sorted[j], trump)
+		if cmp := domain.CompareCards(a, b, trump); cmp != domain.CmpEqual {
+			return cmp == domain.CmpLess
+		}
+		return len(sorted[i].Cards) > len(sorted[j].Cards)
+	})
+	return sorted
+}
+
+// sortBeats 按CompareCardGroups从小到大排序，无法比较时出牌较少的优先
+func sortBeats(groups []*domain.CardGroup, trump domain.Rank) []*domain.CardGroup {
+	sorted := make([]*domain.CardGroup, len(groups))
+	copy(sorted, groups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		switch domain.CompareCardGroups(sorted[i], sorted[j], trump) {
+		case domain.CmpLess:
+			return true
+		case domain.CmpEqual:
+			return len(sorted[i].Cards) < len(sorted[j].Cards)
+		default:
+			return false
+		}
+	})
+	return sorted
+}
@@ -0,0 +1,407 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+const (
+	DefaultIterations  = 2000
+	DefaultExploration = 0.7
+	DefaultBeliefDeals = 64 // Belief开启时每次行动预先抽样的发牌数
+)
+
+// SearchConfig 搜索预算。Iterations与Duration同时设置时先到者为准，都为0时使用DefaultIterations
+type SearchConfig struct {
+	Iterations  int           // 总模拟次数
+	Duration    time.Duration // 每次行动的思考时间上限
+	Workers     int           // 并行goroutine数，0表示runtime.NumCPU()
+	Exploration float64       // UCB1探索系数，0表示DefaultExploration
+	Seed        int64         // 随机种子，0表示按时间
+	Belief      bool          // 按出牌记录中的Pass推断对手手牌（见Belief），而不是均匀抽样
+}
+
+func (c SearchConfig) workers() int {
+	if c.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return c.Workers
+}
+
+func (c SearchConfig) iterations() int {
+	if c.Iterations <= 0 && c.Duration <= 0 {
+		return DefaultIterations
+	}
+	return c.Iterations
+}
+
+func (c SearchConfig) exploration() float64 {
+	if c.Exploration == 0 {
+		return DefaultExploration
+	}
+	return c.Exploration
+}
+
+// MonteCarlo 确定化蒙特卡洛搜索机器人（PIMC）：每次模拟按已知信息为对手抽样一副手牌，
+// 用UCB1在本座位的候选出牌中分配模拟次数，以快速策略推演到Deal结束，
+// 选择本队期望升级数最高的出牌。贡牌、还贡等非出牌决策交给Heuristic
+type MonteCarlo struct {
+	config   SearchConfig
+	fallback *Heuristic
+	calls    int64
+}
+
+func NewMonteCarlo(config SearchConfig) *MonteCarlo {
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	return &MonteCarlo{
+		config:   config,
+		fallback: NewHeuristic(),
+	}
+}
+
+// armStats 一个候选出牌的模拟统计
+type armStats struct {
+	visits int
+	total  float64
+}
+
+func (a armStats) mean() float64 {
+	if a.visits == 0 {
+		return math.Inf(-1)
+	}
+	return a.total / float64(a.visits)
+}
+
+func (m *MonteCarlo) Act(obs *Observation) (Action, error) {
+	if obs.Phase != engine.PhaseFirstPlay && obs.Phase != engine.PhaseInProgress {
+		return m.fallback.Act(obs)
+	}
+	
+	candidates := searchCandidates(obs)
+	if len(candidates) == 0 {
+		return Action{}, fmt.Errorf("no playable cards")
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	
+	stats := m.search(obs, candidates)
+	best := -1
+	for i, arm := range stats {
+		if arm.visits == 0 {
+			continue
+		}
+		if best < 0 || arm.mean() > stats[best].mean() ||
+			(arm.mean() == stats[best].mean() && arm.visits > stats[best].visits) {
+			best = i
+		}
+	}
+	if best < 0 {
+		// 抽样失败时退回规则策略
+		return m.fallback.Act(obs)
+	}
+	return candidates[best], nil
+}
+
+// Evaluation 一个候选出牌的搜索估值
+type Evaluation struct {
+	Action Action
+	Gain   float64 // 本队期望升级数，-3..3
+	Visits int
+}
+
+// Evaluate 对出牌阶段的每个候选出牌（牌面相同的只算一种）做一次搜索，按期望升级数从高到低返回；
+// 其他阶段返回nil。未分到模拟次数的候选排在最后，Gain为-3
+func (m *MonteCarlo) Evaluate(obs *Observation) ([]Evaluation, error) {
+	if obs.Phase != engine.PhaseFirstPlay && obs.Phase != engine.PhaseInProgress {
+		return nil, nil
+	}
+	
+	candidates := searchCandidates(obs)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no playable cards")
+	}
+	
+	stats := m.search(obs, candidates)
+	evaluations := make([]Evaluation, len(candidates))
+	for i, arm := range stats {
+		evaluations[i] = Evaluation{Action: candidates[i], Gain: -3, Visits: arm.visits}
+		if arm.visits > 0 {
+			evaluations[i].Gain = arm.mean() * 3
+		}
+	}
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		if evaluations[i].Gain != evaluations[j].Gain {
+			return evaluations[i].Gain > evaluations[j].Gain
+		}
+		return evaluations[i].Visits > evaluations[j].Visits
+	})
+	return evaluations, nil
+}
+
+// search 各goroutine独立做UCB1并在结束后合并统计（根并行）
+func (m *MonteCarlo) search(obs *Observation, candidates []Action) []armStats {
+	workers := m.config.workers()
+	iterations := m.config.iterations()
+	
+	var deadline time.Time
+	if m.config.Duration > 0 {
+		deadline = time.Now().Add(m.config.Duration)
+	}
+	
+	call := atomic.AddInt64(&m.calls, 1)
+	var deals [][4][]domain.Card
+	if m.config.Belief {
+		if belief, err := NewBelief(obs); err == nil {
+			deals = belief.Sample(DefaultBeliefDeals, rand.New(rand.NewSource(m.config.Seed+call)))
+		}
+	}
+	
+	results := make([][]armStats, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		budget := 0
+		if iterations > 0 {
+			budget = iterations / workers
+			if w < iterations%workers {
+				budget++
+			}
+			if budget == 0 {
+				continue
+			}
+		}
+		
+		wg.Add(1)
+		go func(w, budget int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(m.config.Seed + call*1000003 + int64(w)))
+			results[w] = m.runWorker(obs, candidates, deals, budget, deadline, rng)
+		}(w, budget)
+	}
+	wg.Wait()
+	
+	merged := make([]armStats, len(candidates))
+	for _, stats := range results {
+		for i, arm := range stats {
+			merged[i].visits += arm.visits
+			merged[i].total += arm.total
+		}
+	}
+	return merged
+}
+
+// runWorker deals非空时从中随机取对手手牌，否则每次模拟均匀抽样
+func (m *MonteCarlo) runWorker(obs *Observation, candidates []Action, deals [][4][]domain.Card, budget int, deadline time.Time, rng *rand.Rand) []armStats {
+	stats := make([]armStats, len(candidates))
+	team := domain.GetTeamFromSeat(obs.Seat)
+	exploration := m.config.exploration()
+	
+	for n := 0; budget == 0 || n < budget; n++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		
+		var hands [4][]domain.Card
+		if len(deals) > 0 {
+			hands = deals[rng.Intn(len(deals))]
+		} else {
+			var err error
+			if hands, err = sampleHands(obs, rng); err != nil {
+				break
+			}
+		}
+		
+		arm := selectArm(stats, n, exploration)
+		candidate := candidates[arm]
+		candidate.Seat = obs.Seat
+		state, _, err := newSearchState(obs, hands).Apply(candidate)
+		if err != nil {
+			// 候选动作来自合法出牌，失败说明观察不一致
+			break
+		}
+		state = rollout(state)
+		if !state.IsOver() {
+			break
+		}
+		
+		stats[arm].visits++
+		stats[arm].total += float64(domain.TeamLevelGain(state.Deal.RankList, team)) / 3
+	}
+	return stats
+}
+
+// selectArm UCB1：先把每个候选各模拟一次
+func selectArm(stats []armStats, n int, exploration float64) int {
+	best, bestScore := 0, math.Inf(-1)
+	for i, arm := range stats {
+		if arm.visits == 0 {
+			return i
+		}
+		score := arm.mean() + exploration*math.Sqrt(math.Log(float64(n))/float64(arm.visits))
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// searchCandidates 本座位的候选行动：牌型、张数、点数相同的出牌只保留一种
+func searchCandidates(obs *Observation) []Action {
+	var candidates []Action
+	seen := make(map[domain.ComparisonKey]bool)
+	for _, group := range playableGroups(obs.Hand, obs.LastPlay, obs.Trump) {
+		key := group.ComparisonKey()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, Play(group.Cards))
+	}
+	if !obs.IsLeading() {
+		candidates = append(candidates, Pass())
+	}
+	return candidates
+}
+
+// rollout 所有座位按快速策略出牌直到Deal结束
+func rollout(state engine.State) engine.State {
+	for step := 0; step < maxDealSteps && !state.IsOver(); step++ {
+		seat := state.Trick.CurrentPlayer
+		if cards := rolloutPlay(state); cards != nil {
+			if next, _, err := state.Apply(engine.Action{Kind: engine.ActionPlay, Seat: seat, Cards: cards}); err == nil {
+				state = next
+				continue
+			}
+		}
+		
+		action := engine.Action{Kind: engine.ActionPass, Seat: seat}
+		if state.Trick.LastPlay == nil {
+			// 首家不能Pass，出一张最小的牌
+			card := lowestSingle(state.Hands[seat], state.Deal.Trump)
+			action = engine.Action{Kind: engine.ActionPlay, Seat: seat, Cards: []domain.Card{card}}
+		}
+		next, _, err := state.Apply(action)
+		if err != nil {
+			break
+		}
+		state = next
+	}
+	return state
+}
+
+// rolloutPlay 快速策略，只按点数张数决策不枚举牌型：首出最小的整组单张、对子或三同张；
+// 跟单张、对子、三同张时用不拆炸弹的最小同张数牌压过；对家领先时不压；
+// 对手剩余手牌不多于DefaultEmergencyHandSize时才用炸弹。返回nil表示Pass
+func rolloutPlay(state engine.State) []domain.Card {
+	seat := state.Trick.CurrentPlayer
+	hand := state.Hands[seat]
+	trump := state.Deal.Trump
+	lastPlay := state.Trick.LastPlay
+	lastPlayer := state.Trick.LastPlayer
+	
+	if whole := domain.NewCardGroup(hand); domain.CanFollow(whole, lastPlay, trump) {
+		return hand
+	}
+	
+	byRank := make(map[domain.Rank][]domain.Card)
+	for _, card := range hand {
+		byRank[card.Rank] = append(byRank[card.Rank], card)
+	}
+	jokers := len(byRank[domain.SmallJoker]) + len(byRank[domain.BigJoker])
+	isBombRank := func(rank domain.Rank) bool {
+		if rank == domain.SmallJoker || rank == domain.BigJoker {
+			return jokers >= domain.MinJokerBomb
+		}
+		return len(byRank[rank]) >= 4
+	}
+	
+	if lastPlay == nil {
+		var best []domain.Card
+		for rank, cards := range byRank {
+			if isBombRank(rank) {
+				continue
+			}
+			if best == nil || rankLess(rank, best[0].Rank, trump) {
+				best = cards
+			}
+		}
+		if best != nil {
+			return best
+		}
+		return smallestBomb(byRank, jokers, trump)
+	}
+	
+	if lastPlayer == seat.Opposite() {
+		return nil
+	}
+	
+	switch lastPlay.Category {
+	case domain.Single, domain.Pair, domain.Triple:
+		size := len(lastPlay.Cards)
+		var best []domain.Card
+		for rank, cards := range byRank {
+			if isBombRank(rank) || len(cards) < size {
+				continue
+			}
+			candidate := cards[:size]
+			if !domain.CanFollow(domain.NewCardGroup(candidate), lastPlay, trump) {
+				continue
+			}
+			if best == nil || rankLess(rank, best[0].Rank, trump) {
+				best = candidate
+			}
+		}
+		if best != nil {
+			return best
+		}
+	}
+	
+	if len(state.Hands[lastPlayer]) <= DefaultEmergencyHandSize {
+		if bomb := smallestBomb(byRank, jokers, trump); bomb != nil &&
+			domain.CanFollow(domain.NewCardGroup(bomb), lastPlay, trump) {
+			return bomb
+		}
+	}
+	return nil
+}
+
+// smallestBomb 最小的四张炸弹，没有时用王炸
+func smallestBomb(byRank map[domain.Rank][]domain.Card, jokers int, trump domain.Rank) []domain.Card {
+	var best []domain.Card
+	for rank, cards := range byRank {
+		if rank == domain.SmallJoker || rank == domain.BigJoker || len(cards) < 4 {
+			continue
+		}
+		if best == nil || rankLess(rank, best[0].Rank, trump) {
+			best = cards[:4]
+		}
+	}
+	if best == nil && jokers >= domain.MinJokerBomb {
+		best = append(append([]domain.Card(nil), byRank[domain.SmallJoker]...), byRank[domain.BigJoker]...)
+	}
+	return best
+}
+
+func rankLess(a, b domain.Rank, trump domain.Rank) bool {
+	return domain.CompareCards(domain.Card{Rank: a}, domain.Card{Rank: b}, trump) == domain.CmpLess
+}
+
+func lowestSingle(hand []domain.Card, trump domain.Rank) domain.Card {
+	lowest := hand[0]
+	for _, card := range hand[1:] {
+		if domain.CompareCards(card, lowest, trump) == domain.CmpLess {
+			lowest = card
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// newSearchState 用观察和为其他座位抽样的手牌构造出牌阶段的engine.State，
+// 出牌、Pass、接风等规则与引擎完全一致
+func newSearchState(obs *Observation, hands [4][]domain.Card) engine.State {
+	deal := domain.NewDealCtx(obs.DealNumber, obs.Trump, obs.Seat)
+	deal = deal.WithRankList(obs.RankList)
+	
+	trick := domain.NewTrickCtx(1, obs.Seat)
+	if obs.LastPlay != nil {
+		trick = trick.WithLastPlay(obs.LastPlay, obs.LastPlayer)
+		// 上一手出牌之后、轮到自己之前的座位都已Pass
+		for seat := nextActive(obs, obs.LastPlayer); seat != obs.Seat && seat != obs.LastPlayer; seat = nextActive(obs, seat) {
+			trick = trick.WithPlayerPassed(seat)
+		}
+	}
+	
+	return engine.State{
+		Phase: obs.Phase,
+		Levels: obs.Levels,
+		Hands: hands,
+		Deal:  deal,
+		Trick: trick,
+	}
+}
+
+func nextActive(obs *Observation, seat domain.SeatID) domain.SeatID {
+	next := seat.Next()
+	for i := 0; i < 3 && obs.IsFinished(next); i++ {
+		next = next.Next()
+	}
+	return next
+}
+
+func removeCards(hand []domain.Card, cards []domain.Card) ([]domain.Card, bool) {
+	remaining := append([]domain.Card(nil), hand...)
+	for _, card := range cards {
+		found := false
+		for i, held := range remaining {
+			if held == card {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return hand, false
+		}
+	}
+	return remaining, true
+}
+
+// sampleHands 按已知信息为其他座位随机分配未见过的牌：108张牌减去自己的手牌和已出的牌，
+// 公开的贡牌、还贡牌在接收者未打出前仍归接收者
+func sampleHands(obs *Observation, rng *rand.Rand) ([4][]domain.Card, error) {
+	constraints, err := newDealConstraints(obs)
+	if err != nil {
+		var hands [4][]domain.Card
+		hands[obs.Seat] = append([]domain.Card(nil), obs.Hand...)
+		return hands, err
+	}
+	return constraints.draw(rng), nil
+}
+
+// dealConstraints 为其他座位抽样手牌时的硬约束
+type dealConstraints struct {
+	seat  domain.SeatID
+	hand  []domain.Card
+	known [4][]domain.Card // 已知在该座位手中的贡牌、还贡牌
+	pool  []domain.Card    // 其余未见过的牌，已排序
+	need  [4]int           // 每个座位除已知牌外还需抽取的张数
+}
+
+func newDealConstraints(obs *Observation) (*dealConstraints, error) {
+	c := &dealConstraints{seat: obs.Seat, hand: obs.Hand}
+	
+	unseen := make(map[domain.Card]int)
+	for _, card := range domain.NewDeck().Cards {
+		unseen[card]++
+	}
+	for _, card := range obs.Hand {
+		unseen[card]--
+	}
+	for _, play := range obs.Plays {
+		for _, card := range play.Cards {
+			unseen[card]--
+		}
+	}
+	
+	var known [4][]domain.Card
+	if obs.Tribute != nil {
+		for giver, card := range obs.Tribute.Given {
+			if receiver, ok := obs.Tribute.Receivers[giver]; ok && receiver != obs.Seat {
+				known[receiver] = append(known[receiver], card)
+			}
+		}
+		for returner, card := range obs.Tribute.Returned {
+			if receiver, ok := obs.Tribute.Returns[returner]; ok && receiver != obs.Seat {
+				known[receiver] = append(known[receiver], card)
+			}
+		}
+	}
+	for _, play := range obs.Plays {
+		for _, card := range play.Cards {
+			known[play.Player], _ = removeCards(known[play.Player], []domain.Card{card})
+		}
+	}
+	
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if seat == obs.Seat {
+			continue
+		}
+		for _, card := range known[seat] {
+			if len(c.known[seat]) < obs.HandCounts[seat] && unseen[card] > 0 {
+				c.known[seat] = append(c.known[seat], card)
+				unseen[card]--
+			}
+		}
+	}
+	
+	for card, count := range unseen {
+		if count < 0 {
+			return nil, fmt.Errorf("card %s seen more often than it exists", card)
+		}
+		for i := 0; i < count; i++ {
+			c.pool = append(c.pool, card)
+		}
+	}
+	// map遍历顺序随机，先排序保证同一种子得到相同的抽样
+	sortCards(c.pool)
+	
+	total := 0
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if seat == obs.Seat {
+			continue
+		}
+		c.need[seat] = obs.HandCounts[seat] - len(c.known[seat])
+		total += c.need[seat]
+		if total > len(c.pool) {
+			return nil, fmt.Errorf("not enough unseen cards for %s", seat)
+		}
+	}
+	return c, nil
+}
+
+// draw 把未见过的牌随机分给其他座位
+func (c *dealConstraints) draw(rng *rand.Rand) [4][]domain.Card {
+	pool := append([]domain.Card(nil), c.pool...)
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	
+	var hands [4][]domain.Card
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if seat == c.seat {
+			hands[seat] = append([]domain.Card(nil), c.hand...)
+			continue
+		}
+		hands[seat] = append(append([]domain.Card(nil), c.known[seat]...), pool[:c.need[seat]]...)
+		pool = pool[c.need[seat]:]
+	}
+	return hands
+}
+
+func sortCards(cards []domain.Card) {
+	sort.Slice(cards, func(i, j int) bool { return cards[i].ID() < cards[j].ID() })
+}
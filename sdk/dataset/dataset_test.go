@@ -0,0 +1,172 @@
+package dataset
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/env"
+	"guandan/sdk/notation"
+	"guandan/sdk/service"
+)
+
+func heuristicAgents() [4]bot.Agent {
+	return [4]bot.Agent{bot.NewHeuristic(), bot.NewHeuristic(), bot.NewHeuristic(), bot.NewHeuristic()}
+}
+
+// readShards 读回目录中的所有分片
+func readShards(t *testing.T, dir string, format Format) []Record {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+format.extension()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		
+		if format == FormatBinary {
+			br, err := NewBinaryReader(gz)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			for {
+				r, err := br.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", path, err)
+				}
+				records = append(records, *r)
+			}
+		} else {
+			scanner := bufio.NewScanner(gz)
+			scanner.Buffer(nil, 1<<20)
+			for scanner.Scan() {
+				var r Record
+				if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+					t.Fatalf("%s: %v", path, err)
+				}
+				records = append(records, r)
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		file.Close()
+	}
+	return records
+}
+
+func TestExportSelfPlay(t *testing.T) {
+	for _, format := range []Format{FormatJSONL, FormatBinary} {
+		t.Run(format.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			exporter, err := New(Config{Dir: dir, Format: format, ShardRecords: 150})
+			if err != nil {
+				t.Fatal(err)
+			}
+			gs := service.NewGameService()
+			for seed := int64(1); seed <= 2; seed++ {
+				if _, err := SelfPlay(gs, exporter, seed, heuristicAgents(), 3); err != nil {
+					t.Fatalf("seed %d: %v", seed, err)
+				}
+			}
+			if err := exporter.Close(); err != nil {
+				t.Fatal(err)
+			}
+			
+			stats := exporter.Stats()
+			if stats.Matches != 2 || stats.Deals+stats.Skipped != 6 || stats.Records == 0 {
+				t.Fatalf("unexpected stats %+v", stats)
+			}
+			records := readShards(t, dir, format)
+			if len(records) != stats.Records {
+				t.Fatalf("read %d records, stats report %d", len(records), stats.Records)
+			}
+			if want := (stats.Records + 149) / 150; stats.Shards != want {
+				t.Errorf("expected %d shards, got %d", want, stats.Shards)
+			}
+			
+			for i, r := range records {
+				if len(r.Features) != env.ObservationSize {
+					t.Fatalf("record %d: %d features", i, len(r.Features))
+				}
+				legal := false
+				for _, index := range r.Legal {
+					legal = legal || index == r.Action
+				}
+				if !legal {
+					t.Fatalf("record %d: action %d (%s) not among legal %v", i, r.Action, r.Move, r.Legal)
+				}
+				if r.DealGain == 0 || r.MatchResult != 0 && r.MatchResult != 1 && r.MatchResult != -1 {
+					t.Fatalf("record %d: unexpected outcome %+v", i, r)
+				}
+				if (format == FormatJSONL) != (len(r.Observation) > 0) {
+					t.Fatalf("record %d: observation %v in %s", i, r.Observation, format)
+				}
+				if r.Human {
+					t.Fatalf("record %d: self-play marked as human", i)
+				}
+			}
+			
+			// 同一Deal中同队的记录结果相同，两队相反
+			for i := 1; i < len(records); i++ {
+				a, b := records[i-1], records[i]
+				if a.Match != b.Match || a.Deal != b.Deal {
+					continue
+				}
+				sa, _ := notation.ParseSeat(a.Seat)
+				sb, _ := notation.ParseSeat(b.Seat)
+				sign := 1
+				if domain.GetTeamFromSeat(sa) != domain.GetTeamFromSeat(sb) {
+					sign = -1
+				}
+				if a.DealGain != sign*b.DealGain || a.MatchGain != sign*b.MatchGain || a.MatchResult != sign*b.MatchResult {
+					t.Fatalf("records %d and %d disagree: %+v / %+v", i-1, i, a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestExcludeHuman(t *testing.T) {
+	exporter, err := New(Config{Dir: t.TempDir(), ExcludeHuman: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+	
+	gs := service.NewGameService()
+	players := make([]*domain.Player, 4)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		players[seat] = domain.NewPlayer(seat.String(), seat.String(), seat)
+	}
+	matchID, err := gs.CreateMatch(players, &service.MatchOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Watch(gs, matchID, [4]bool{true, false, false, false}); err != nil {
+		t.Fatal(err)
+	}
+	if stats := exporter.Stats(); stats.Ignored != 1 {
+		t.Fatalf("expected the human match to be ignored, got %+v", stats)
+	}
+	if err := exporter.Finish(matchID, 1); err != nil {
+		t.Fatalf("finishing an ignored match: %v", err)
+	}
+}
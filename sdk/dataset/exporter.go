@@ -0,0 +1,350 @@
+package dataset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/env"
+	"guandan/sdk/event"
+	"guandan/sdk/notation"
+	"guandan/sdk/protocol"
+	"guandan/sdk/service"
+)
+
+// DefaultWait Finish等待事件处理完的默认时长
+const DefaultWait = 5 * time.Second
+
+// Config 导出参数
+type Config struct {
+	Dir          string // 分片文件所在目录，不存在时创建
+	Prefix       string // 分片文件名前缀，空表示"records"
+	Format       Format
+	ShardRecords int  // 每个分片的记录数，0表示DefaultShardRecords
+	ExcludeHuman bool // 不导出有人类玩家的比赛
+	Wait         time.Duration // Finish等待事件的上限，0表示DefaultWait
+}
+
+// Stats 导出统计
+type Stats struct {
+	Matches int `json:"matches"` // 已写出的比赛数
+	Deals   int `json:"deals"`   // 已写出的Deal数
+	Skipped int `json:"skipped"` // 因事件丢失等原因无法重放而跳过的Deal数
+	Ignored int `json:"ignored"` // 按ExcludeHuman未跟踪的比赛数
+	Records int `json:"records"`
+	Shards  int `json:"shards"`
+}
+
+// Exporter 跟踪多场比赛并写出决策记录，可以并发使用。
+// 事件按Deal收集，Deal结束时用notation.ReplayEach重放得到每个决策点的观察；
+// 比赛结束（MatchEndedEvent）或调用Finish时写出该比赛的全部记录
+type Exporter struct {
+	config  Config
+	writer  *shardWriter
+	mu      sync.Mutex
+	matches map[domain.MatchID]*matchState
+	stats   Stats
+}
+
+// dealRecords 一个Deal的记录，结果在比赛结束时补全
+type dealRecords struct {
+	winner  domain.TeamID
+	gain    int
+	records []Record
+}
+
+// matchState 一场被跟踪的比赛
+type matchState struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	id          domain.MatchID
+	humans      [4]bool
+	matchCtx    *domain.MatchCtx
+	unsubscribe func()
+	recorder    *notation.Recorder
+	last        []domain.SeatID
+	deals       []dealRecords
+	processed   int // 已处理的DealEnded事件数
+	skipped     int
+	winner      *domain.TeamID
+	written     bool
+	err         error // 比赛分出胜负时写出记录的错误，由Finish返回
+}
+
+// New 创建导出器
+func New(config Config) (*Exporter, error) {
+	if config.Prefix == "" {
+		config.Prefix = "records"
+	}
+	if config.Wait <= 0 {
+		config.Wait = DefaultWait
+	}
+	writer, err := newShardWriter(config.Dir, config.Prefix, config.Format, config.ShardRecords)
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{config: config, writer: writer, matches: make(map[domain.MatchID]*matchState)}, nil
+}
+
+// Watch 订阅gs中的比赛，humans标记由人类操作的座位。应在第一个Deal开始前调用，
+// 否则从下一个Deal开始记录。按ExcludeHuman忽略的比赛返回nil
+func (x *Exporter) Watch(gs service.GameService, matchID domain.MatchID, humans [4]bool) error {
+	if x.config.ExcludeHuman && humans != [4]bool{} {
+		x.mu.Lock()
+		x.stats.Ignored++
+		x.mu.Unlock()
+		return nil
+	}
+	
+	state, err := gs.GetMatchState(matchID)
+	if err != nil {
+		return err
+	}
+	m := &matchState{
+		id:       matchID,
+		humans:   humans,
+		matchCtx: domain.NewMatchCtx(matchID, state.Players, 0),
+	}
+	m.cond = sync.NewCond(&m.mu)
+	
+	x.mu.Lock()
+	if _, exists := x.matches[matchID]; exists {
+		x.mu.Unlock()
+		return fmt.Errorf("match %s is already watched", matchID)
+	}
+	x.matches[matchID] = m
+	x.mu.Unlock()
+	
+	unsubscribe, err := gs.Subscribe(matchID, func(e event.DomainEvent) { x.handle(m, e) })
+	if err != nil {
+		x.mu.Lock()
+		delete(x.matches, matchID)
+		x.mu.Unlock()
+		return err
+	}
+	m.mu.Lock()
+	m.unsubscribe = unsubscribe
+	m.mu.Unlock()
+	return nil
+}
+
+// Finish 等待比赛的前deals个Deal处理完（最多Config.Wait），写出尚未写出的记录并停止跟踪。
+// 未分出胜负的比赛MatchResult为0。等待超时时仍写出已处理的Deal并返回错误
+func (x *Exporter) Finish(matchID domain.MatchID, deals int) error {
+	x.mu.Lock()
+	m, exists := x.matches[matchID]
+	delete(x.matches, matchID)
+	x.mu.Unlock()
+	if !exists {
+		return nil
+	}
+	
+	var waitErr error
+	m.mu.Lock()
+	deadline := time.Now().Add(x.config.Wait)
+	timer := time.AfterFunc(x.config.Wait, func() {
+		m.mu.Lock()
+		m.cond.Broadcast()
+		m.mu.Unlock()
+	})
+	for m.processed < deals && !m.written && time.Now().Before(deadline) {
+		m.cond.Wait()
+	}
+	timer.Stop()
+	if m.processed < deals && !m.written {
+		waitErr = fmt.Errorf("match %s: only %d of %d deals were received", matchID, m.processed, deals)
+	}
+	unsubscribe := m.unsubscribe
+	err := x.write(m)
+	if m.err != nil {
+		err = m.err
+	}
+	m.mu.Unlock()
+	
+	if unsubscribe != nil {
+		unsubscribe()
+	}
+	if err != nil {
+		return err
+	}
+	return waitErr
+}
+
+// Stats 目前为止的导出统计
+func (x *Exporter) Stats() Stats {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	stats := x.stats
+	stats.Shards = x.writer.shardCount()
+	return stats
+}
+
+// Close 不等待地结束所有仍在跟踪的比赛并关闭分片文件
+func (x *Exporter) Close() error {
+	x.mu.Lock()
+	var ids []domain.MatchID
+	for id := range x.matches {
+		ids = append(ids, id)
+	}
+	x.mu.Unlock()
+	
+	for _, id := range ids {
+		x.Finish(id, 0)
+	}
+	return x.writer.close()
+}
+
+// handle 在订阅的回调中处理一场比赛的事件，同一比赛的事件按顺序到达
+func (x *Exporter) handle(m *matchState, e event.DomainEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.written {
+		return
+	}
+	
+	switch ev := e.(type) {
+	case *event.DealStartedEvent:
+		m.recorder = notation.NewRecorder(m.matchCtx, m.last)
+	case *event.DealEndedEvent:
+		defer m.cond.Broadcast()
+		m.processed++
+		recorder := m.recorder
+		m.recorder = nil
+		if len(ev.RankList) != 4 {
+			m.skipped++
+			return
+		}
+		
+		var records []Record
+		replayed := false
+		if recorder != nil {
+			recorder.Apply(e)
+			records, replayed = x.replay(m, recorder.Record())
+		}
+		if !replayed {
+			m.skipped++
+		}
+		
+		// 跳过的Deal没有记录，但仍计入MatchGain
+		winner := domain.GetTeamFromSeat(ev.RankList[0])
+		outcome := domain.DetermineTributeScenario(ev.RankList)
+		m.deals = append(m.deals, dealRecords{winner: winner, gain: domain.LevelGain(outcome), records: records})
+		m.last = append([]domain.SeatID(nil), ev.RankList...)
+		return
+	case *event.MatchEndedEvent:
+		winner := ev.WinnerTeam
+		m.winner = &winner
+		m.err = x.write(m)
+		return
+	}
+	
+	if m.recorder != nil {
+		m.recorder.Apply(e)
+	}
+}
+
+// replay 重放一个Deal，为每个决策点生成记录；无法重放（如事件丢失）时返回false
+func (x *Exporter) replay(m *matchState, rec *notation.Record) ([]Record, bool) {
+	var records []Record
+	_, err := notation.ReplayEach(rec, func(ge *engine.GameEngine, action engine.Action) {
+		obs := bot.Observe(ge, action.Seat)
+		records = append(records, x.newRecord(m, rec.Deal, len(records), obs, botAction(action)))
+	})
+	if err != nil {
+		return nil, false
+	}
+	return records, true
+}
+
+func (x *Exporter) newRecord(m *matchState, deal, step int, obs *bot.Observation, action bot.Action) Record {
+	r := Record{
+		Match:    string(m.id),
+		Deal:     deal,
+		Step:     step,
+		Seat:     notation.SeatLetter(obs.Seat),
+		Human:    m.humans[obs.Seat],
+		Features: env.Encode(obs, nil),
+		Action:   -1,
+		Move:     protocol.FormatAction(action),
+	}
+	for index := range env.LegalActions(obs) {
+		r.Legal = append(r.Legal, index)
+	}
+	sort.Ints(r.Legal)
+	if index, ok := env.ActionIndex(obs, action); ok {
+		r.Action = index
+	}
+	if x.config.Format == FormatJSONL {
+		var text strings.Builder
+		protocol.WriteObservation(&text, obs)
+		lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+		r.Observation = lines[1 : len(lines)-1]
+	}
+	return r
+}
+
+// write 补全比赛结果后写出记录，每场比赛只写一次；调用时持有m.mu
+func (x *Exporter) write(m *matchState) error {
+	if m.written {
+		return nil
+	}
+	m.written = true
+	
+	var records []Record
+	matchGain := [2]int{}
+	for i := len(m.deals) - 1; i >= 0; i-- {
+		deal := m.deals[i]
+		matchGain[deal.winner] += deal.gain
+		matchGain[deal.winner.OpposingTeam()] -= deal.gain
+		for j := range deal.records {
+			r := &deal.records[j]
+			seat, _ := notation.ParseSeat(r.Seat)
+			team := domain.GetTeamFromSeat(seat)
+			r.DealGain = deal.gain
+			if team != deal.winner {
+				r.DealGain = -deal.gain
+			}
+			r.MatchGain = matchGain[team]
+			if m.winner != nil {
+				r.MatchResult = -1
+				if *m.winner == team {
+					r.MatchResult = 1
+				}
+			}
+		}
+	}
+	for _, deal := range m.deals {
+		records = append(records, deal.records...)
+	}
+	
+	x.mu.Lock()
+	x.stats.Skipped += m.skipped
+	if len(records) > 0 {
+		x.stats.Matches++
+		x.stats.Deals += len(m.deals) - m.skipped
+		x.stats.Records += len(records)
+	}
+	x.mu.Unlock()
+	m.deals = nil
+	return x.writer.write(records)
+}
+
+// botAction 把引擎行动转换为机器人行动
+func botAction(action engine.Action) bot.Action {
+	switch action.Kind {
+	case engine.ActionPass:
+		return bot.Pass()
+	case engine.ActionTribute:
+		return bot.Tribute(action.Cards[0])
+	case engine.ActionSelectTribute:
+		return bot.SelectTribute(action.Target)
+	case engine.ActionReturnTribute:
+		return bot.ReturnTribute(action.Cards[0])
+	default:
+		return bot.Play(action.Cards)
+	}
+}
@@ -0,0 +1,195 @@
+// Package dataset 训练数据导出：通过GameService.Subscribe跟踪比赛，把每个决策点连同行动座位的观察、
+// 合法行动、实际行动以及最终的Deal与比赛结果写成分片的gzip压缩JSONL或二进制记录，供模仿学习与价值模型训练
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"guandan/sdk/env"
+)
+
+// Format 记录的文件格式
+type Format int
+
+const (
+	FormatJSONL  Format = iota // 每行一个JSON对象
+	FormatBinary               // 紧凑的二进制记录，见BinaryReader
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSONL:
+		return "jsonl"
+	case FormatBinary:
+		return "binary"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseFormat 解析"jsonl"或"binary"
+func ParseFormat(text string) (Format, error) {
+	switch text {
+	case "jsonl":
+		return FormatJSONL, nil
+	case "binary":
+		return FormatBinary, nil
+	default:
+		return 0, fmt.Errorf("unknown format %q (available: jsonl, binary)", text)
+	}
+}
+
+// extension 分片文件的扩展名
+func (f Format) extension() string {
+	if f == FormatBinary {
+		return ".gdr.gz"
+	}
+	return ".jsonl.gz"
+}
+
+// Record 一个决策点。动作编号、合法动作与Features都使用env包的动作空间与观察编码
+type Record struct {
+	Match       string    `json:"match"`
+	Deal        int       `json:"deal"`
+	Step        int       `json:"step"` // 本Deal中的第几次决策，从0起
+	Seat        string    `json:"seat"` // E/S/W/N
+	Human       bool      `json:"human"`
+	Observation []string  `json:"observation,omitempty"` // protocol.WriteObservation的各行（不含observe与end），二进制格式不保存
+	Features    []float32 `json:"features"`
+	Legal       []int     `json:"legal"`
+	Action      int       `json:"action"` // 实际行动的编号，不在动作空间中时为-1
+	Move        string    `json:"move"`   // 实际行动，protocol.FormatAction的写法
+	DealGain    int       `json:"dealGain"`    // 行动座位所在队在本Deal的升级数，输掉为负
+	MatchGain   int       `json:"matchGain"`   // 行动座位所在队从本Deal到比赛结束的DealGain之和
+	MatchResult int       `json:"matchResult"` // 行动座位所在队赢得比赛为1，输掉为-1，未分出胜负为0
+}
+
+// binaryMagic 二进制分片的文件头，其后是观察向量长度与动作数（各uint16）
+var binaryMagic = []byte("GDR1")
+
+// 二进制记录，整数均为小端序：
+//
+//	uint16 len, match      uint32 deal     uint16 step     uint8 seat     uint8 human
+//	int16 action           uint16 len, move
+//	int8 dealGain          int16 matchGain int8 matchResult
+//	uint16 n, n×uint16 legal
+//	ObservationSize×float32 features
+func appendBinary(buf []byte, r *Record) []byte {
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(r.Match)))
+	buf = append(buf, r.Match...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(r.Deal))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(r.Step))
+	buf = append(buf, seatByte(r.Seat))
+	human := byte(0)
+	if r.Human {
+		human = 1
+	}
+	buf = append(buf, human)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(int16(r.Action)))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(r.Move)))
+	buf = append(buf, r.Move...)
+	buf = append(buf, byte(int8(r.DealGain)))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(int16(r.MatchGain)))
+	buf = append(buf, byte(int8(r.MatchResult)))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(r.Legal)))
+	for _, index := range r.Legal {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(index))
+	}
+	for _, value := range r.Features {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(value))
+	}
+	return buf
+}
+
+const seats = "ESWN"
+
+func seatByte(seat string) byte {
+	for i := 0; i < len(seats); i++ {
+		if seat == seats[i:i+1] {
+			return byte(i)
+		}
+	}
+	return 0xff
+}
+
+func appendBinaryHeader(buf []byte) []byte {
+	buf = append(buf, binaryMagic...)
+	buf = binary.LittleEndian.AppendUint16(buf, env.ObservationSize)
+	return binary.LittleEndian.AppendUint16(buf, env.NumActions)
+}
+
+// BinaryReader 读取一个解压后的二进制分片
+type BinaryReader struct {
+	r        *bufio.Reader
+	features int
+}
+
+// NewBinaryReader 读取并校验文件头。观察向量长度与当前env.ObservationSize不同时报错
+func NewBinaryReader(r io.Reader) (*BinaryReader, error) {
+	br := &BinaryReader{r: bufio.NewReader(r)}
+	header := make([]byte, len(binaryMagic)+4)
+	if _, err := io.ReadFull(br.r, header); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(header[:len(binaryMagic)]) != string(binaryMagic) {
+		return nil, fmt.Errorf("not a binary record file")
+	}
+	br.features = int(binary.LittleEndian.Uint16(header[4:]))
+	if br.features != env.ObservationSize || int(binary.LittleEndian.Uint16(header[6:])) != env.NumActions {
+		return nil, fmt.Errorf("records use %d features and %d actions, this build uses %d and %d",
+			br.features, binary.LittleEndian.Uint16(header[6:]), env.ObservationSize, env.NumActions)
+	}
+	return br, nil
+}
+
+// Next 读取下一条记录，没有更多记录时返回io.EOF
+func (br *BinaryReader) Next() (*Record, error) {
+	var err error
+	read := func(n int) []byte {
+		if err != nil {
+			return make([]byte, n)
+		}
+		buf := make([]byte, n)
+		_, err = io.ReadFull(br.r, buf)
+		return buf
+	}
+	u16 := func() uint16 { return binary.LittleEndian.Uint16(read(2)) }
+	
+	if _, peekErr := br.r.Peek(1); peekErr != nil {
+		return nil, peekErr
+	}
+	
+	r := &Record{}
+	r.Match = string(read(int(u16())))
+	r.Deal = int(binary.LittleEndian.Uint32(read(4)))
+	r.Step = int(u16())
+	if seat := read(1)[0]; int(seat) < len(seats) {
+		r.Seat = seats[seat : seat+1]
+	}
+	r.Human = read(1)[0] == 1
+	r.Action = int(int16(u16()))
+	r.Move = string(read(int(u16())))
+	r.DealGain = int(int8(read(1)[0]))
+	r.MatchGain = int(int16(u16()))
+	r.MatchResult = int(int8(read(1)[0]))
+	r.Legal = make([]int, u16())
+	for i := range r.Legal {
+		r.Legal[i] = int(u16())
+	}
+	r.Features = make([]float32, br.features)
+	for i := range r.Features {
+		r.Features[i] = math.Float32frombits(binary.LittleEndian.Uint32(read(4)))
+	}
+	
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("truncated record: %w", err)
+	}
+	return r, nil
+}
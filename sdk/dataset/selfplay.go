@@ -0,0 +1,96 @@
+package dataset
+
+import (
+	"fmt"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/service"
+	"guandan/sdk/sim"
+)
+
+// maxDealSteps 一Deal行动次数上限，防止Agent陷入死循环
+const maxDealSteps = 2000
+
+// SelfPlay 在gs中用agents进行一场自对弈比赛并交给exporter记录，比赛结束后删除。
+// 比赛从两队打2开始，到分出胜负或达到maxDeals（0表示sim.DefaultMaxDeals）结束，返回进行的Deal数
+func SelfPlay(gs service.GameService, exporter *Exporter, seed int64, agents [4]bot.Agent, maxDeals int) (int, error) {
+	if maxDeals <= 0 {
+		maxDeals = sim.DefaultMaxDeals
+	}
+	for seat, agent := range agents {
+		if agent == nil {
+			return 0, fmt.Errorf("no agent for seat %s", domain.SeatID(seat))
+		}
+	}
+	
+	players := make([]*domain.Player, 4)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		players[seat] = domain.NewPlayer(fmt.Sprintf("selfplay-%d-%s", seed, seat), seat.String(), seat)
+	}
+	matchID, err := gs.CreateMatch(players, &service.MatchOptions{Seed: seed})
+	if err != nil {
+		return 0, err
+	}
+	defer gs.DeleteMatch(matchID)
+	
+	if err := exporter.Watch(gs, matchID, [4]bool{}); err != nil {
+		return 0, err
+	}
+	
+	deals := 0
+	for deals < maxDeals {
+		if err := gs.StartNextDeal(matchID); err != nil {
+			exporter.Finish(matchID, deals)
+			return deals, fmt.Errorf("deal %d: %w", deals+1, err)
+		}
+		deals++
+		if _, err := playDeal(gs, matchID, agents); err != nil {
+			exporter.Finish(matchID, deals-1)
+			return deals, fmt.Errorf("deal %d: %w", deals, err)
+		}
+		state, err := gs.GetMatchState(matchID)
+		if err != nil {
+			exporter.Finish(matchID, deals)
+			return deals, err
+		}
+		if state.IsFinished {
+			break
+		}
+	}
+	return deals, exporter.Finish(matchID, deals)
+}
+
+// playDeal 通过服务让agents行动到本Deal结束，返回排名
+func playDeal(gs service.GameService, matchID domain.MatchID, agents [4]bot.Agent) ([]domain.SeatID, error) {
+	for step := 0; step < maxDealSteps; step++ {
+		pending, err := gs.GetPendingActions(matchID)
+		if err != nil {
+			return nil, err
+		}
+		seat, found := domain.SeatEast, false
+		for s := domain.SeatEast; s <= domain.SeatNorth && !found; s++ {
+			seat, found = s, len(pending[s]) > 0
+		}
+		
+		obs, err := gs.Observe(matchID, seat)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			if obs.Phase != engine.PhaseRankList && obs.Phase != engine.PhaseFinished || len(obs.RankList) != 4 {
+				return nil, fmt.Errorf("no seat to act in phase %s", obs.Phase)
+			}
+			return obs.RankList, nil
+		}
+		
+		action, err := agents[seat].Act(obs)
+		if err != nil {
+			return nil, fmt.Errorf("agent for %s failed: %w", seat, err)
+		}
+		if err := gs.ApplyBotAction(matchID, seat, action); err != nil {
+			return nil, fmt.Errorf("%s %s rejected: %w", seat, action, err)
+		}
+	}
+	return nil, fmt.Errorf("deal did not finish within %d actions", maxDealSteps)
+}
@@ -0,0 +1,121 @@
+package dataset
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultShardRecords 每个分片默认的记录数
+const DefaultShardRecords = 100000
+
+// shardWriter 按记录数轮换分片文件：<dir>/<prefix>-00000.jsonl.gz、-00001……
+type shardWriter struct {
+	mu       sync.Mutex
+	dir      string
+	prefix   string
+	format   Format
+	limit    int
+	shards   int
+	count    int // 当前分片中的记录数
+	file     *os.File
+	gz       *gzip.Writer
+	buf      *bufio.Writer
+	scratch  []byte
+}
+
+func newShardWriter(dir, prefix string, format Format, limit int) (*shardWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultShardRecords
+	}
+	return &shardWriter{dir: dir, prefix: prefix, format: format, limit: limit}, nil
+}
+
+// write 写入一批记录，需要时开始新的分片
+func (w *shardWriter) write(records []Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	
+	for i := range records {
+		if w.file == nil || w.count >= w.limit {
+			if err := w.rotate(); err != nil {
+				return err
+			}
+		}
+		if err := w.encode(&records[i]); err != nil {
+			return err
+		}
+		w.count++
+	}
+	return nil
+}
+
+func (w *shardWriter) encode(r *Record) error {
+	if w.format == FormatBinary {
+		w.scratch = appendBinary(w.scratch[:0], r)
+		_, err := w.buf.Write(w.scratch)
+		return err
+	}
+	
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	_, err = w.buf.Write(line)
+	return err
+}
+
+func (w *shardWriter) rotate() error {
+	if err := w.closeShard(); err != nil {
+		return err
+	}
+	
+	path := filepath.Join(w.dir, fmt.Sprintf("%s-%05d%s", w.prefix, w.shards, w.format.extension()))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w.file, w.gz = file, gzip.NewWriter(file)
+	w.buf = bufio.NewWriter(w.gz)
+	w.shards++
+	w.count = 0
+	if w.format == FormatBinary {
+		_, err = w.buf.Write(appendBinaryHeader(nil))
+	}
+	return err
+}
+
+func (w *shardWriter) closeShard() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.buf.Flush()
+	if closeErr := w.gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file, w.gz, w.buf = nil, nil, nil
+	return err
+}
+
+func (w *shardWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closeShard()
+}
+
+func (w *shardWriter) shardCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.shards
+}
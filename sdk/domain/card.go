@@ -3,6 +3,7 @@ package domain
 import (
 	"fmt"
 	"strconv"
+	"unicode/utf8"
 )
 
 type Suit int
@@ -133,7 +134,10 @@ func CardFromID(id CardID) Card {
 	return Card{Suit: Suit(suitVal), Rank: Rank(rankVal)}
 }
 
-// ParseCard parses a card from its string representation
+// ParseCard parses a card from its string representation.
+// Suits are ♥♦♣♠ or H/D/C/S; ranks are A, 2-10 (also T) and J/Q/K.
+// "SJ" and "BJ" are the jokers, so the Jack of Spades cannot be written "SJ":
+// write "♠J" or the alias "S11". "11" is accepted as a Jack in every suit.
 func ParseCard(cardStr string) (Card, error) {
 	if len(cardStr) == 0 {
 		return Card{}, fmt.Errorf("empty card string")
@@ -152,8 +156,10 @@ func ParseCard(cardStr string) (Card, error) {
 		return Card{}, fmt.Errorf("invalid card string: %s", cardStr)
 	}
 	
-	suitStr := cardStr[:1]
-	rankStr := cardStr[1:]
+	// 花色可能是多字节的Unicode符号（♥♦♣♠）
+	_, suitLen := utf8.DecodeRuneInString(cardStr)
+	suitStr := cardStr[:suitLen]
+	rankStr := cardStr[suitLen:]
 	
 	// Parse suit
 	var suit Suit
@@ -193,7 +199,7 @@ func ParseCard(cardStr string) (Card, error) {
 		rank = Nine
 	case "10", "T":
 		rank = Ten
-	case "J":
+	case "J", "11":
 		rank = Jack
 	case "Q":
 		rank = Queen
@@ -269,6 +269,9 @@ func TestParseCard(t *testing.T) {
 		{"Diamonds Queen Letter", "DQ", NewCard(Diamonds, Queen), false},
 		{"Clubs Jack Letter", "CJ", NewCard(Clubs, Jack), false},
 		{"Hearts Ten Letter", "HT", NewCard(Hearts, Ten), false},
+		{"Spades Jack Numeric", "S11", NewCard(Spades, Jack), false},
+		{"Hearts Ace Symbol", "♥A", NewCard(Hearts, Ace), false},
+		{"Spades Ten Symbol", "♠10", NewCard(Spades, Ten), false},
 		
 		// Jokers
 		{"Small Joker Chinese", "小王", NewJoker(SmallJoker), false},
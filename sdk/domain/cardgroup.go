@@ -18,6 +18,9 @@ const (
 	JokerBomb
 )
 
+// MinJokerBomb 王炸所需的最少王数，两张及以上的王（不分大小）都算王炸
+const MinJokerBomb = 2
+
 func (c CardCategory) String() string {
 	switch c {
 	case Single:
@@ -185,7 +188,7 @@ func (cg *CardGroup) isJokerBomb() bool {
 			jokerCount++
 		}
 	}
-	return jokerCount >= 2 && jokerCount == len(cg.Cards)
+	return jokerCount >= MinJokerBomb && jokerCount == len(cg.Cards)
 }
 
 func (cg *CardGroup) isStraight() bool {
@@ -195,6 +195,11 @@ func CanFollow(hand, tablePlay *CardGroup, trump Rank) bool {
 		return true
 	}
 	
+	// 炸弹之间不论张数都可以比较
+	if hand.IsBomb() && tablePlay.IsBomb() {
+		return CanBeat(hand, tablePlay, trump)
+	}
+	
 	if hand.Category != tablePlay.Category {
 		return false
 	}
@@ -206,50 +211,41 @@ func CanFollow(hand, tablePlay *CardGroup, trump Rank) bool {
 	return CanBeat(hand, tablePlay, trump)
 }
 
-func GetPlayableCards(hand []Card, tablePlay *CardGroup, trump Rank) [][]Card {
-	if len(hand) == 0 {
+// CheckFollow 与CanFollow规则相同，不能出时返回说明原因的错误：
+// ErrInvalidCombination、ErrCategoryMismatch、ErrSizeMismatch或ErrTooWeak。
+// 压不过桌面炸弹的炸弹返回ErrTooWeak，而不是张数或牌型不符
+func CheckFollow(hand, tablePlay *CardGroup, trump Rank) error {
+	if CanFollow(hand, tablePlay, trump) {
 		return nil
 	}
 	
-	var playable [][]Card
-	
-	for i := 1; i <= len(hand); i++ {
-		combinations := generateCombinations(hand, i)
-		for _, combo := range combinations {
-			group := NewCardGroup(combo)
-			if group.IsValid() && CanFollow(group, tablePlay, trump) {
-				playable = append(playable, combo)
-			}
-		}
+	switch {
+	case hand == nil || !hand.IsValid():
+		return &ErrInvalidCombination{Reason: invalidReason(hand)}
+	case hand.IsBomb() && tablePlay.IsBomb():
+		return &ErrTooWeak{TableKey: tablePlay.ComparisonKey(), YourKey: hand.ComparisonKey()}
+	case hand.Category != tablePlay.Category:
+		return &ErrCategoryMismatch{Want: tablePlay.Category, Got: hand.Category}
+	case hand.Size != tablePlay.Size:
+		return &ErrSizeMismatch{Want: tablePlay.Size, Got: hand.Size}
+	default:
+		return &ErrTooWeak{TableKey: tablePlay.ComparisonKey(), YourKey: hand.ComparisonKey()}
 	}
-	
-	return playable
 }
 
-func generateCombinations(cards []Card, size int) [][]Card {
-	if size == 0 {
-		return [][]Card{{}}
-	}
-	
-	if len(cards) == 0 {
+func GetPlayableCards(hand []Card, tablePlay *CardGroup, trump Rank) [][]Card {
+	if len(hand) == 0 {
 		return nil
 	}
 	
-	var result [][]Card
-	
-	first := cards[0]
-	rest := cards[1:]
-	
-	combosWithFirst := generateCombinations(rest, size-1)
-	for _, combo := range combosWithFirst {
-		newCombo := append([]Card{first}, combo...)
-		result = append(result, newCombo)
+	var playable [][]Card
+	for _, group := range EnumerateCardGroups(hand) {
+		if CanFollow(group, tablePlay, trump) {
+			playable = append(playable, group.Cards)
+		}
 	}
 	
-	combosWithoutFirst := generateCombinations(rest, size)
-	result = append(result, combosWithoutFirst...)
-	
-	return result
+	return playable
 }
 
 func IsTrump(card Card, trump Rank) bool {
@@ -298,30 +294,51 @@ func CountTrumps(cards []Card, trump Rank) int {
 }
 
 func HasBomb(cards []Card, trump Rank) bool {
-	for i := 1; i <= len(cards); i++ {
-		combinations := generateCombinations(cards, i)
-		for _, combo := range combinations {
-			group := NewCardGroup(combo)
-			if group.IsBomb() {
-				return true
-			}
+	rankCounts := make(map[Rank]int)
+	jokers := 0
+	for _, card := range cards {
+		if card.IsJoker() {
+			jokers++
+			continue
+		}
+		rankCounts[card.Rank]++
+		if rankCounts[card.Rank] >= 4 {
+			return true
 		}
 	}
-	return false
+	return jokers >= MinJokerBomb
 }
 
 func FindBombs(cards []Card, trump Rank) []*CardGroup {
 	var bombs []*CardGroup
-	
-	for i := 1; i <= len(cards); i++ {
-		combinations := generateCombinations(cards, i)
-		for _, combo := range combinations {
-			group := NewCardGroup(combo)
-			if group.IsBomb() {
-				bombs = append(bombs, group)
-			}
+	for _, group := range EnumerateCardGroups(cards) {
+		if group.IsBomb() {
+			bombs = append(bombs, group)
 		}
 	}
-	
 	return bombs
-}
\ No newline at end of file
+}
+
+// CountBombs 按点数统计手中的炸弹数（同点数4张及以上算一个，王炸算一个，不含同花顺）
+func CountBombs(cards []Card) int {
+	rankCounts := make(map[Rank]int)
+	jokers := 0
+	for _, card := range cards {
+		if card.IsJoker() {
+			jokers++
+			continue
+		}
+		rankCounts[card.Rank]++
+	}
+	
+	count := 0
+	for _, n := range rankCounts {
+		if n >= 4 {
+			count++
+		}
+	}
+	if jokers >= MinJokerBomb {
+		count++
+	}
+	return count
+}
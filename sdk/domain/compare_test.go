@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestCardCompare(t *testing.T) {
@@ -256,6 +259,102 @@ func TestCanFollow(t *testing.T) {
 	}
 }
 
+func TestCheckFollow(t *testing.T) {
+	kingPair := NewCardGroup([]Card{NewCard(Hearts, King), NewCard(Spades, King)})
+	jokerBomb := NewCardGroup([]Card{NewJoker(SmallJoker), NewJoker(BigJoker)})
+	fourBomb := NewCardGroup([]Card{NewCard(Hearts, Ace), NewCard(Spades, Ace), NewCard(Clubs, Ace),
+		NewCard(Diamonds, Ace)})
+	
+	testCases := []struct {
+		name      string
+		hand      *CardGroup
+		tablePlay *CardGroup
+		expected  error
+	}{
+		{
+			name:      "Higher pair follows",
+			hand:      NewCardGroup([]Card{NewCard(Hearts, Ace), NewCard(Spades, Ace)}),
+			tablePlay: kingPair,
+			expected:  nil,
+		},
+		{
+			name:      "Mixed ranks are not a combination",
+			hand:      NewCardGroup([]Card{NewCard(Hearts, Ace), NewCard(Spades, Three)}),
+			tablePlay: kingPair,
+			expected:  &ErrInvalidCombination{},
+		},
+		{
+			name:      "Single cannot follow pair",
+			hand:      NewCardGroup([]Card{NewCard(Hearts, Ace)}),
+			tablePlay: kingPair,
+			expected:  &ErrCategoryMismatch{},
+		},
+		{
+			name:      "Lower pair is too weak",
+			hand:      NewCardGroup([]Card{NewCard(Hearts, Queen), NewCard(Spades, Queen)}),
+			tablePlay: kingPair,
+			expected:  &ErrTooWeak{},
+		},
+		{
+			name: "Shorter straight cannot follow",
+			hand: NewCardGroup([]Card{NewCard(Hearts, Six), NewCard(Spades, Seven), NewCard(Clubs, Eight),
+				NewCard(Hearts, Nine), NewCard(Spades, Ten)}),
+			tablePlay: NewCardGroup([]Card{NewCard(Hearts, Three), NewCard(Spades, Four), NewCard(Clubs, Five),
+				NewCard(Hearts, Six), NewCard(Spades, Seven), NewCard(Clubs, Eight)}),
+			expected: &ErrSizeMismatch{},
+		},
+		{
+			name:      "Joker bomb follows four card bomb",
+			hand:      jokerBomb,
+			tablePlay: fourBomb,
+			expected:  nil,
+		},
+		{
+			name:      "Four card bomb is too weak for joker bomb",
+			hand:      fourBomb,
+			tablePlay: jokerBomb,
+			expected:  &ErrTooWeak{},
+		},
+	}
+	
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckFollow(tc.hand, tc.tablePlay, Two)
+			if tc.expected == nil {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %T, got %v", tc.expected, err)
+			}
+			if CanFollow(tc.hand, tc.tablePlay, Two) {
+				t.Error("CanFollow should agree with CheckFollow")
+			}
+		})
+	}
+	
+	var tooWeak *ErrTooWeak
+	err := CheckFollow(NewCardGroup([]Card{NewCard(Hearts, Queen), NewCard(Spades, Queen)}), kingPair, Two)
+	if !errors.As(err, &tooWeak) || tooWeak.TableKey.Rank != King || tooWeak.YourKey.Rank != Queen {
+		t.Errorf("Expected comparison keys in ErrTooWeak, got %v", err)
+	}
+}
+
+func TestMissingCards(t *testing.T) {
+	hand := []Card{NewCard(Hearts, Ace), NewCard(Spades, King)}
+	
+	if missing := MissingCards(hand, []Card{NewCard(Hearts, Ace)}); missing != nil {
+		t.Errorf("Expected no missing cards, got %v", missing)
+	}
+	
+	missing := MissingCards(hand, []Card{NewCard(Hearts, Ace), NewCard(Hearts, Ace), NewCard(Clubs, Two)})
+	if len(missing) != 2 || missing[0] != NewCard(Hearts, Ace) || missing[1] != NewCard(Clubs, Two) {
+		t.Errorf("Expected second Hearts Ace and Clubs Two missing, got %v", missing)
+	}
+}
+
 func TestIsTrump(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -974,4 +1073,73 @@ func BenchmarkGetPlayableCards(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = GetPlayableCards(hand, tablePlay, trump)
 	}
-}
\ No newline at end of file
+}
+
+func TestCountBombs(t *testing.T) {
+	cards := []Card{
+		NewCard(Hearts, Five),
+		NewCard(Spades, Five),
+		NewCard(Clubs, Five),
+		NewCard(Diamonds, Five),
+		NewCard(Hearts, Nine),
+		NewCard(Hearts, Nine),
+		NewCard(Spades, Nine),
+		NewJoker(BigJoker),
+		NewJoker(BigJoker),
+		NewJoker(SmallJoker),
+	}
+	
+	if count := CountBombs(cards); count != 2 {
+		t.Errorf("Expected 2 bombs, got %d", count)
+	}
+	
+	cards = append(cards, NewCard(Clubs, Nine), NewJoker(SmallJoker))
+	if count := CountBombs(cards); count != 3 {
+		t.Errorf("Expected 3 bombs, got %d", count)
+	}
+}
+
+func TestEnumerateCardGroups(t *testing.T) {
+	hand := []Card{
+		NewCard(Hearts, Three),
+		NewCard(Hearts, Three),
+		NewCard(Spades, Four),
+		NewCard(Clubs, Five),
+		NewCard(Clubs, Six),
+		NewCard(Diamonds, Seven),
+		NewJoker(SmallJoker),
+		NewJoker(BigJoker),
+	}
+	
+	counts := make(map[CardCategory]int)
+	seen := make(map[string]bool)
+	for _, group := range EnumerateCardGroups(hand) {
+		if !group.IsValid() {
+			t.Fatalf("Enumerated invalid group %v", group.Cards)
+		}
+		key := fmt.Sprint(group.Cards)
+		if seen[key] {
+			t.Errorf("Group %s enumerated twice", key)
+		}
+		seen[key] = true
+		counts[group.Category]++
+	}
+	
+	// 7种不同的单张，一对3，一个王炸，一个顺子（两张红桃3相同只算一种）
+	expected := map[CardCategory]int{Single: 7, Pair: 1, JokerBomb: 1, Straight: 1}
+	for category, count := range expected {
+		if counts[category] != count {
+			t.Errorf("Expected %d groups of %s, got %d", count, category, counts[category])
+		}
+	}
+	
+	// 完整的27张手牌也应快速完成
+	hands, _ := DealFromSeed(7)
+	start := time.Now()
+	if len(GetPlayableCards(hands[SeatEast], nil, Two)) == 0 {
+		t.Error("Expected playable cards for a full hand")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Enumerating a full hand took %s", elapsed)
+	}
+}
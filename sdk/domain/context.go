@@ -38,6 +38,7 @@ type MatchCtx struct {
 	MaxDeals    int
 	Winner      *TeamID
 	Seed        int64
+	TargetLevel Rank // 目标级数，级数最多升到该级，到该级的一队赢得Deal且对家不是末游时赢得比赛，默认为A
 }
 
 func NewMatchCtx(id MatchID, players []*Player, seed int64) *MatchCtx {
@@ -61,6 +62,7 @@ func NewMatchCtx(id MatchID, players []*Player, seed int64) *MatchCtx {
 		CurrentDeal: 0,
 		MaxDeals:    0,
 		Seed:        seed,
+		TargetLevel: Ace,
 	}
 }
 
@@ -88,6 +90,21 @@ func (m *MatchCtx) WithCurrentDeal(deal int) *MatchCtx {
 	return &newCtx
 }
 
+func (m *MatchCtx) WithTargetLevel(level Rank) *MatchCtx {
+	newCtx := *m
+	newCtx.TargetLevel = level
+	return &newCtx
+}
+
+// WithTeamLevel 返回teamID的级数为level的副本，复制该Team而不修改原来的Team
+func (m *MatchCtx) WithTeamLevel(teamID TeamID, level Rank) *MatchCtx {
+	newCtx := *m
+	team := *m.Teams[teamID]
+	team.Level = level
+	newCtx.Teams[teamID] = &team
+	return &newCtx
+}
+
 func (m *MatchCtx) WithWinner(winner TeamID) *MatchCtx {
 	newCtx := *m
 	newCtx.Winner = &winner
@@ -145,6 +162,8 @@ type DealCtx struct {
 	TributeCards   map[SeatID][]Card
 	LastRankings   []SeatID      // 上局排名，用于计算贡牌
 	TributeInfo    *TributeInfo  // 新的贡牌信息
+	DealSeed       int64         `json:"-"` // 由比赛种子派生的本Deal洗牌种子，Deal结束前不对外公开
+	ShuffleCommitment string     // 公平洗牌模式下的服务器种子承诺
 }
 
 func NewDealCtx(dealNumber int, trump Rank, firstPlayer SeatID) *DealCtx {
@@ -216,6 +235,18 @@ func (d *DealCtx) WithTributeGiven(given bool) *DealCtx {
 	return &newCtx
 }
 
+func (d *DealCtx) WithDealSeed(seed int64) *DealCtx {
+	newCtx := *d
+	newCtx.DealSeed = seed
+	return &newCtx
+}
+
+func (d *DealCtx) WithShuffleCommitment(commitment string) *DealCtx {
+	newCtx := *d
+	newCtx.ShuffleCommitment = commitment
+	return &newCtx
+}
+
 func (d *DealCtx) WithTrump(trump Rank) *DealCtx {
 	newCtx := *d
 	newCtx.Trump = trump
@@ -330,10 +361,11 @@ type TrickCtx struct {
 	LastPlay      *CardGroup
 	LastPlayer    SeatID
 	PassedPlayers map[SeatID]bool
-	PlayHistory   []TrickPlay
+	PlayHistory   []TrickPlay // 本轮的出牌与Pass，按顺序
 	Winner        SeatID
 }
 
+// TrickPlay 一次出牌；Cards为空表示Pass
 type TrickPlay struct {
 	Player    SeatID
 	Cards     []Card
@@ -341,6 +373,11 @@ type TrickPlay struct {
 	Timestamp time.Time
 }
 
+// IsPass 是否为Pass记录
+func (p TrickPlay) IsPass() bool {
+	return len(p.Cards) == 0
+}
+
 func NewTrickCtx(trickNumber int, startPlayer SeatID) *TrickCtx {
 	return &TrickCtx{
 		TrickNumber:   trickNumber,
@@ -375,6 +412,13 @@ func (t *TrickCtx) WithPlayerPassed(player SeatID) *TrickCtx {
 	return &newCtx
 }
 
+// WithPassesCleared 有人出牌后清空Pass记录，之前Pass的玩家可以继续压牌
+func (t *TrickCtx) WithPassesCleared() *TrickCtx {
+	newCtx := *t
+	newCtx.PassedPlayers = make(map[SeatID]bool)
+	return &newCtx
+}
+
 func (t *TrickCtx) WithPlayHistory(play TrickPlay) *TrickCtx {
 	newCtx := *t
 	newCtx.PlayHistory = make([]TrickPlay, len(t.PlayHistory)+1)
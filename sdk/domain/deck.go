@@ -11,18 +11,19 @@ type Deck struct {
 }
 
 func NewDeck() *Deck {
-	deck := &Deck{
-		Cards: make([]Card, 0, 108),
-		rng:   rand.New(rand.NewSource(time.Now().UnixNano())),
-	}
-	deck.initialize()
-	return deck
+	return NewDeckWithSource(rand.NewSource(time.Now().UnixNano()))
 }
 
 func NewDeckWithSeed(seed int64) *Deck {
+	return NewDeckWithSource(rand.NewSource(seed))
+}
+
+// NewDeckWithSource creates a deck whose shuffles draw from src, so callers
+// (and tests) control the exact card order produced by Shuffle.
+func NewDeckWithSource(src rand.Source) *Deck {
 	deck := &Deck{
 		Cards: make([]Card, 0, 108),
-		rng:   rand.New(rand.NewSource(seed)),
+		rng:   rand.New(src),
 	}
 	deck.initialize()
 	return deck
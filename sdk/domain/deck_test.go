@@ -508,4 +508,31 @@ func BenchmarkDeckDealToHands(b *testing.B) {
 		deck := NewDeck()
 		_ = deck.DealToHands(4)
 	}
-}
\ No newline at end of file
+}
+
+// maxSource always yields the largest Int63 value, which makes rand.Shuffle
+// pick j == i at every step and leaves the deck in its initial order.
+type maxSource struct{}
+
+func (maxSource) Int63() int64 { return 1<<63 - 1 }
+func (maxSource) Seed(int64)   {}
+
+func TestDeckWithSource(t *testing.T) {
+	deck := NewDeckWithSource(maxSource{})
+	
+	expected := make([]Card, len(deck.Cards))
+	copy(expected, deck.Cards)
+	
+	deck.Shuffle()
+	
+	for i, card := range deck.Cards {
+		if card != expected[i] {
+			t.Fatalf("Card at position %d: expected %v, got %v", i, expected[i], card)
+		}
+	}
+	
+	hands := deck.DealToHands(4)
+	if hands[0][0] != NewCard(Hearts, Ace) {
+		t.Errorf("Injected order should deal the first initialized card to East, got %v", hands[0][0])
+	}
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"sort"
+)
+
+// EnumerateCardGroups 列出手牌能组成的所有合法牌组（按牌面去重，两张相同的牌只算一种组合）。
+// 按牌型逐点数构造而不是枚举子集，27张手牌也能快速完成
+func EnumerateCardGroups(hand []Card) []*CardGroup {
+	if len(hand) == 0 {
+		return nil
+	}
+	
+	byRank := make(map[Rank][]Card)
+	var jokers []Card
+	for _, card := range hand {
+		if card.IsJoker() {
+			jokers = append(jokers, card)
+			continue
+		}
+		byRank[card.Rank] = append(byRank[card.Rank], card)
+	}
+	
+	var groups []*CardGroup
+	add := func(cards []Card) {
+		group := NewCardGroup(cards)
+		if group.IsValid() {
+			groups = append(groups, group)
+		}
+	}
+	
+	// 单张
+	for _, card := range distinctCards(hand) {
+		add([]Card{card})
+	}
+	
+	// 对子、三同张、炸弹
+	for rank := Two; rank <= Ace; rank++ {
+		for size := 2; size <= 4 && size <= len(byRank[rank]); size++ {
+			for _, cards := range cardMultisets(byRank[rank], size) {
+				add(cards)
+			}
+		}
+	}
+	
+	// 王炸（两张及以上的王）
+	for size := MinJokerBomb; size <= len(jokers); size++ {
+		for _, cards := range cardMultisets(jokers, size) {
+			add(cards)
+		}
+	}
+	
+	// 顺子、连对、钢板：每个点数取width张，连续length个点数
+	sequences := []struct {
+		width     int
+		minLength int
+	}{
+		{1, 5},
+		{2, 3},
+		{3, 2},
+	}
+	for _, seq := range sequences {
+		for start := Two; start <= Ace; start++ {
+			var choices [][][]Card
+			for rank := start; rank <= Ace && len(byRank[rank]) >= seq.width; rank++ {
+				choices = append(choices, cardMultisets(byRank[rank], seq.width))
+				if len(choices) >= seq.minLength {
+					for _, cards := range cartesianCards(choices) {
+						add(cards)
+					}
+				}
+			}
+		}
+	}
+	
+	return groups
+}
+
+// distinctCards 去重并排序
+func distinctCards(cards []Card) []Card {
+	seen := make(map[Card]bool)
+	var distinct []Card
+	for _, card := range cards {
+		if !seen[card] {
+			seen[card] = true
+			distinct = append(distinct, card)
+		}
+	}
+	sort.Slice(distinct, func(i, j int) bool {
+		if distinct[i].Rank != distinct[j].Rank {
+			return distinct[i].Rank < distinct[j].Rank
+		}
+		return distinct[i].Suit < distinct[j].Suit
+	})
+	return distinct
+}
+
+// cardMultisets 从cards中取size张的所有不同组合（相同的牌不区分）
+func cardMultisets(cards []Card, size int) [][]Card {
+	distinct := distinctCards(cards)
+	counts := make(map[Card]int)
+	for _, card := range cards {
+		counts[card]++
+	}
+	
+	var result [][]Card
+	var current []Card
+	var build func(index, remaining int)
+	build = func(index, remaining int) {
+		if remaining == 0 {
+			result = append(result, append([]Card(nil), current...))
+			return
+		}
+		if index == len(distinct) {
+			return
+		}
+		
+		card := distinct[index]
+		for n := counts[card]; n >= 0; n-- {
+			if n > remaining {
+				continue
+			}
+			for i := 0; i < n; i++ {
+				current = append(current, card)
+			}
+			build(index+1, remaining-n)
+			current = current[:len(current)-n]
+		}
+	}
+	build(0, size)
+	
+	return result
+}
+
+// cartesianCards 每组选一个组合拼接起来
+func cartesianCards(choices [][][]Card) [][]Card {
+	result := [][]Card{nil}
+	for _, options := range choices {
+		next := make([][]Card, 0, len(result)*len(options))
+		for _, prefix := range result {
+			for _, option := range options {
+				cards := make([]Card, 0, len(prefix)+len(option))
+				cards = append(cards, prefix...)
+				cards = append(cards, option...)
+				next = append(next, cards)
+			}
+		}
+		result = next
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 出牌违反规则时返回的错误类型，可用errors.Is匹配类型、errors.As取出细节。
+// 各类型的Is方法按类型匹配，不比较字段，例如errors.Is(err, &ErrTooWeak{})
+
+// ErrCardsNotInHand 出的牌不在手中（同一张牌按张数计算）
+type ErrCardsNotInHand struct {
+	Missing []Card
+}
+
+func (e *ErrCardsNotInHand) Error() string {
+	names := make([]string, len(e.Missing))
+	for i, card := range e.Missing {
+		names[i] = card.String()
+	}
+	return fmt.Sprintf("cards not in hand: %s", strings.Join(names, " "))
+}
+
+func (e *ErrCardsNotInHand) Is(target error) bool {
+	_, ok := target.(*ErrCardsNotInHand)
+	return ok
+}
+
+// ErrInvalidCombination 出的牌不构成任何牌型
+type ErrInvalidCombination struct {
+	Reason string
+}
+
+func (e *ErrInvalidCombination) Error() string {
+	return fmt.Sprintf("invalid card combination: %s", e.Reason)
+}
+
+func (e *ErrInvalidCombination) Is(target error) bool {
+	_, ok := target.(*ErrInvalidCombination)
+	return ok
+}
+
+// ErrCategoryMismatch 跟牌的牌型与桌面牌型不同，且不是炸弹
+type ErrCategoryMismatch struct {
+	Want CardCategory
+	Got  CardCategory
+}
+
+func (e *ErrCategoryMismatch) Error() string {
+	return fmt.Sprintf("must follow with %s, got %s", e.Want, e.Got)
+}
+
+func (e *ErrCategoryMismatch) Is(target error) bool {
+	_, ok := target.(*ErrCategoryMismatch)
+	return ok
+}
+
+// ErrSizeMismatch 跟牌的张数与桌面张数不同
+type ErrSizeMismatch struct {
+	Want int
+	Got  int
+}
+
+func (e *ErrSizeMismatch) Error() string {
+	return fmt.Sprintf("must follow with %d cards, got %d", e.Want, e.Got)
+}
+
+func (e *ErrSizeMismatch) Is(target error) bool {
+	_, ok := target.(*ErrSizeMismatch)
+	return ok
+}
+
+// ErrTooWeak 跟牌与桌面同型但压不过
+type ErrTooWeak struct {
+	TableKey ComparisonKey
+	YourKey  ComparisonKey
+}
+
+func (e *ErrTooWeak) Error() string {
+	return fmt.Sprintf("%s %s cannot beat %s %s", e.YourKey.Category, e.YourKey.Rank, e.TableKey.Category, e.TableKey.Rank)
+}
+
+func (e *ErrTooWeak) Is(target error) bool {
+	_, ok := target.(*ErrTooWeak)
+	return ok
+}
+
+// MissingCards 返回cards中hand不足的牌（按多重集合计数），全部持有时为nil
+func MissingCards(hand []Card, cards []Card) []Card {
+	counts := make(map[Card]int, len(hand))
+	for _, card := range hand {
+		counts[card]++
+	}
+
+	var missing []Card
+	for _, card := range cards {
+		if counts[card] == 0 {
+			missing = append(missing, card)
+			continue
+		}
+		counts[card]--
+	}
+	return missing
+}
+
+// invalidReason 说明牌组为什么不构成牌型
+func invalidReason(group *CardGroup) string {
+	switch {
+	case group == nil || len(group.Cards) == 0:
+		return "no cards"
+	case len(group.Cards) <= 4:
+		return "cards must share one rank"
+	default:
+		return "not a straight, pair straight, triple straight or bomb"
+	}
+}
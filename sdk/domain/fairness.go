@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+// Provably fair shuffle (commit-reveal)
+//
+//  1. Before the deal the server draws a random ServerSeed and publishes
+//     CommitServerSeed(ServerSeed) = hex(SHA-256(ServerSeed)).
+//  2. Each seat may add client entropy after seeing the commitment.
+//  3. The shuffle key is
+//     SHA-256(ServerSeed || for seat in East, South, West, North: BE32(len(c)) || c)
+//     where c is that seat's entropy (empty if none was sent).
+//  4. The key feeds a byte stream block_k = SHA-256(key || BE64(k)), k = 0, 1, ...
+//     read 8 bytes at a time as big-endian uint64 values.
+//  5. The canonical deck (NewDeck order before shuffling) is shuffled with
+//     Fisher-Yates: for i = n-1 down to 1, j = uniform(i+1), swap(i, j), where
+//     uniform(m) rejects draws >= MaxUint64 - MaxUint64%m and returns draw % m.
+//     The next uniform(n) draw picks the starting card index.
+//  6. Cards are dealt in consecutive blocks of 27 to East, South, West, North.
+//  7. After the deal the server reveals ServerSeed so anyone can repeat 1-6.
+const (
+	ServerSeedSize   = 32
+	MaxClientEntropy = 64
+)
+
+// GenerateServerSeed 生成随机服务器种子
+func GenerateServerSeed() ([]byte, error) {
+	seed := make([]byte, ServerSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("failed to generate server seed: %w", err)
+	}
+	return seed, nil
+}
+
+// CommitServerSeed 返回服务器种子的承诺值（十六进制SHA-256）
+func CommitServerSeed(serverSeed []byte) string {
+	sum := sha256.Sum256(serverSeed)
+	return hex.EncodeToString(sum[:])
+}
+
+// CombineSeeds 将服务器种子与各座位的客户端熵合成洗牌密钥
+func CombineSeeds(serverSeed []byte, clientSeeds map[SeatID][]byte) [32]byte {
+	h := sha256.New()
+	h.Write(serverSeed)
+	
+	var lenBuf [4]byte
+	for seat := SeatEast; seat <= SeatNorth; seat++ {
+		entropy := clientSeeds[seat]
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(entropy)))
+		h.Write(lenBuf[:])
+		h.Write(entropy)
+	}
+	
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// keyStream 由洗牌密钥派生的确定性随机流
+type keyStream struct {
+	key     [32]byte
+	counter uint64
+	block   [32]byte
+	offset  int
+}
+
+func newKeyStream(key [32]byte) *keyStream {
+	return &keyStream{key: key, offset: sha256.Size}
+}
+
+func (ks *keyStream) next() uint64 {
+	if ks.offset >= len(ks.block) {
+		var buf [40]byte
+		copy(buf[:32], ks.key[:])
+		binary.BigEndian.PutUint64(buf[32:], ks.counter)
+		ks.block = sha256.Sum256(buf[:])
+		ks.counter++
+		ks.offset = 0
+	}
+	
+	value := binary.BigEndian.Uint64(ks.block[ks.offset : ks.offset+8])
+	ks.offset += 8
+	return value
+}
+
+func (ks *keyStream) uniform(n int) int {
+	bound := uint64(n)
+	limit := math.MaxUint64 - math.MaxUint64%bound
+	for {
+		value := ks.next()
+		if value < limit {
+			return int(value % bound)
+		}
+	}
+}
+
+// ShuffleWithKey 使用洗牌密钥对牌组做确定性Fisher-Yates洗牌
+func (d *Deck) ShuffleWithKey(key [32]byte) {
+	fisherYates(d.Cards, newKeyStream(key))
+}
+
+func fisherYates(cards []Card, stream *keyStream) {
+	for i := len(cards) - 1; i > 0; i-- {
+		j := stream.uniform(i + 1)
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+}
+
+// DealFair 按承诺-揭示协议重新发牌，返回四家手牌（按座位顺序）和Starting Card
+func DealFair(serverSeed []byte, clientSeeds map[SeatID][]byte) ([][]Card, Card) {
+	deck := NewDeck()
+	stream := newKeyStream(CombineSeeds(serverSeed, clientSeeds))
+	
+	fisherYates(deck.Cards, stream)
+	startingCard := deck.GetCard(stream.uniform(deck.Size()))
+	hands := deck.DealToHands(4)
+	
+	return hands, startingCard
+}
+
+// VerifyFairDeal 校验揭示的种子与承诺一致，且能重现实际发出的手牌
+func VerifyFairDeal(dealtHands map[SeatID][]Card, commitment string, serverSeed []byte, clientSeeds map[SeatID][]byte) error {
+	if CommitServerSeed(serverSeed) != commitment {
+		return fmt.Errorf("server seed does not match commitment %s", commitment)
+	}
+	
+	hands, _ := DealFair(serverSeed, clientSeeds)
+	for seat := SeatEast; seat <= SeatNorth; seat++ {
+		dealt := dealtHands[seat]
+		expected := hands[seat]
+		if len(dealt) != len(expected) {
+			return fmt.Errorf("hand for %s has %d cards, expected %d", seat, len(dealt), len(expected))
+		}
+		for i := range expected {
+			if dealt[i] != expected[i] {
+				return fmt.Errorf("hand for %s differs at position %d: dealt %v, expected %v", seat, i, dealt[i], expected[i])
+			}
+		}
+	}
+	
+	return nil
+}
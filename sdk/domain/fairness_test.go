@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCommitServerSeed(t *testing.T) {
+	seed, err := GenerateServerSeed()
+	if err != nil {
+		t.Fatalf("Failed to generate server seed: %v", err)
+	}
+	
+	if len(seed) != ServerSeedSize {
+		t.Errorf("Expected %d byte seed, got %d", ServerSeedSize, len(seed))
+	}
+	
+	other, _ := GenerateServerSeed()
+	if bytes.Equal(seed, other) {
+		t.Error("Two generated server seeds should differ")
+	}
+	
+	if CommitServerSeed(seed) != CommitServerSeed(seed) {
+		t.Error("Commitment should be deterministic")
+	}
+	
+	if CommitServerSeed(seed) == CommitServerSeed(other) {
+		t.Error("Different seeds should have different commitments")
+	}
+}
+
+func TestCombineSeedsUsesClientEntropy(t *testing.T) {
+	serverSeed := []byte("server-seed")
+	
+	base := CombineSeeds(serverSeed, nil)
+	withEast := CombineSeeds(serverSeed, map[SeatID][]byte{SeatEast: []byte("abc")})
+	withSouth := CombineSeeds(serverSeed, map[SeatID][]byte{SeatSouth: []byte("abc")})
+	
+	if base == withEast {
+		t.Error("Client entropy should change the shuffle key")
+	}
+	
+	if withEast == withSouth {
+		t.Error("The seat contributing entropy should affect the shuffle key")
+	}
+	
+	// Length prefixes keep concatenations unambiguous
+	split := CombineSeeds(serverSeed, map[SeatID][]byte{SeatEast: []byte("ab"), SeatSouth: []byte("c")})
+	joined := CombineSeeds(serverSeed, map[SeatID][]byte{SeatEast: []byte("a"), SeatSouth: []byte("bc")})
+	if split == joined {
+		t.Error("Different entropy splits should produce different keys")
+	}
+}
+
+func TestShuffleWithKeyIsPermutation(t *testing.T) {
+	deck := NewDeck()
+	original := make(map[Card]int)
+	for _, card := range deck.Cards {
+		original[card]++
+	}
+	
+	deck.ShuffleWithKey(CombineSeeds([]byte("seed"), nil))
+	
+	shuffled := make(map[Card]int)
+	for _, card := range deck.Cards {
+		shuffled[card]++
+	}
+	
+	if len(original) != len(shuffled) {
+		t.Fatalf("Shuffle changed the set of cards")
+	}
+	for card, count := range original {
+		if shuffled[card] != count {
+			t.Errorf("Card %v: expected %d copies, got %d", card, count, shuffled[card])
+		}
+	}
+	
+	again := NewDeck()
+	again.ShuffleWithKey(CombineSeeds([]byte("seed"), nil))
+	for i := range deck.Cards {
+		if deck.Cards[i] != again.Cards[i] {
+			t.Fatalf("Same key should give the same order, differs at %d", i)
+		}
+	}
+}
+
+func TestVerifyFairDeal(t *testing.T) {
+	serverSeed := []byte("0123456789abcdef0123456789abcdef")
+	clientSeeds := map[SeatID][]byte{SeatWest: []byte("west-entropy")}
+	commitment := CommitServerSeed(serverSeed)
+	
+	hands, _ := DealFair(serverSeed, clientSeeds)
+	dealt := make(map[SeatID][]Card)
+	for i, hand := range hands {
+		dealt[SeatID(i)] = hand
+	}
+	
+	if err := VerifyFairDeal(dealt, commitment, serverSeed, clientSeeds); err != nil {
+		t.Errorf("Honest deal should verify: %v", err)
+	}
+	
+	if err := VerifyFairDeal(dealt, commitment, []byte("another seed"), clientSeeds); err == nil {
+		t.Error("Wrong server seed should fail commitment check")
+	}
+	
+	if err := VerifyFairDeal(dealt, commitment, serverSeed, nil); err == nil {
+		t.Error("Missing client entropy should fail hand check")
+	}
+	
+	stacked := make(map[SeatID][]Card)
+	for seat, hand := range dealt {
+		stacked[seat] = append([]Card(nil), hand...)
+	}
+	stacked[SeatEast][0], stacked[SeatSouth][0] = stacked[SeatSouth][0], stacked[SeatEast][0]
+	if err := VerifyFairDeal(stacked, commitment, serverSeed, clientSeeds); err == nil {
+		t.Error("Swapped cards should fail verification")
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// Seed hierarchy
+//
+// A match owns a single seed (MatchCtx.Seed). Every deal derives its own seed
+// from it so that deals differ from each other while staying reproducible:
+//
+//	dealSeed = int64(BE64(SHA-256("guandan/deal-seed/v1" || BE64(matchSeed) || BE32(dealNumber))[0:8]))
+//
+// The deal seed drives the shuffle (math/rand source) and, through a second
+// derivation, the index of the starting card used on the first deal:
+//
+//	startIndex = BE64(SHA-256("guandan/starting-card/v1" || BE64(dealSeed))[0:8]) mod deckSize
+//
+// Publishing SeedCommitment(dealSeed) before the deal and the seed afterwards
+// lets anyone re-deal that single deal with DealFromSeed. The match seed yields
+// every deal seed, so only MatchSeedCommitment(matchSeed) is published while the
+// match runs; the match seed itself is revealed once the match has ended.
+const (
+	dealSeedLabel        = "guandan/deal-seed/v1"
+	startingCardLabel    = "guandan/starting-card/v1"
+	commitmentLabel      = "guandan/deal-commit/v1"
+	matchCommitmentLabel = "guandan/match-commit/v1"
+)
+
+// DeriveDealSeed 由比赛种子派生指定Deal的种子
+func DeriveDealSeed(matchSeed int64, dealNumber int) int64 {
+	buf := make([]byte, 0, len(dealSeedLabel)+12)
+	buf = append(buf, dealSeedLabel...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(matchSeed))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(dealNumber))
+	
+	sum := sha256.Sum256(buf)
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+// StartingCardIndex 由Deal种子确定首局Starting Card在洗好的牌中的位置
+func StartingCardIndex(dealSeed int64, deckSize int) int {
+	if deckSize <= 0 {
+		return 0
+	}
+	
+	buf := make([]byte, 0, len(startingCardLabel)+8)
+	buf = append(buf, startingCardLabel...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(dealSeed))
+	
+	sum := sha256.Sum256(buf)
+	return int(binary.BigEndian.Uint64(sum[:8]) % uint64(deckSize))
+}
+
+// SeedCommitment 返回Deal种子的承诺值（十六进制SHA-256）
+func SeedCommitment(dealSeed int64) string {
+	buf := make([]byte, 0, len(commitmentLabel)+8)
+	buf = append(buf, commitmentLabel...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(dealSeed))
+	
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// MatchSeedCommitment 返回比赛种子的承诺值（十六进制SHA-256），比赛结束前只公开该值
+func MatchSeedCommitment(matchSeed int64) string {
+	buf := make([]byte, 0, len(matchCommitmentLabel)+8)
+	buf = append(buf, matchCommitmentLabel...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(matchSeed))
+	
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySeedCommitment 检查种子是否与之前公布的承诺一致
+func VerifySeedCommitment(dealSeed int64, commitment string) bool {
+	return SeedCommitment(dealSeed) == commitment
+}
+
+// DealFromSeed 根据Deal种子重新发牌，返回四家手牌（按座位顺序）和Starting Card
+func DealFromSeed(dealSeed int64) ([][]Card, Card) {
+	deck := NewDeckWithSeed(dealSeed)
+	deck.Shuffle()
+	
+	startingCard := deck.GetCard(StartingCardIndex(dealSeed, deck.Size()))
+	hands := deck.DealToHands(4)
+	
+	return hands, startingCard
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestDeriveDealSeedDeterministic(t *testing.T) {
+	if DeriveDealSeed(12345, 1) != DeriveDealSeed(12345, 1) {
+		t.Error("Same match seed and deal number should derive the same deal seed")
+	}
+	
+	seen := make(map[int64]int)
+	for deal := 1; deal <= 20; deal++ {
+		seed := DeriveDealSeed(12345, deal)
+		if prev, exists := seen[seed]; exists {
+			t.Errorf("Deal %d and deal %d derived the same seed", prev, deal)
+		}
+		seen[seed] = deal
+	}
+	
+	if DeriveDealSeed(12345, 1) == DeriveDealSeed(12346, 1) {
+		t.Error("Different match seeds should derive different deal seeds")
+	}
+}
+
+func TestStartingCardIndexInRange(t *testing.T) {
+	for _, seed := range []int64{0, 1, -1, -9223372036854775808, 9223372036854775807} {
+		index := StartingCardIndex(seed, 108)
+		if index < 0 || index >= 108 {
+			t.Errorf("Starting card index %d out of range for seed %d", index, seed)
+		}
+	}
+	
+	if StartingCardIndex(42, 0) != 0 {
+		t.Error("Empty deck should yield index 0")
+	}
+}
+
+func TestSeedCommitment(t *testing.T) {
+	seed := DeriveDealSeed(777, 3)
+	commitment := SeedCommitment(seed)
+	
+	if len(commitment) != 64 {
+		t.Errorf("Expected 64 hex characters, got %d", len(commitment))
+	}
+	
+	if !VerifySeedCommitment(seed, commitment) {
+		t.Error("Commitment should verify against its own seed")
+	}
+	
+	if VerifySeedCommitment(seed+1, commitment) {
+		t.Error("Commitment should not verify against a different seed")
+	}
+}
+
+func TestDealFromSeed(t *testing.T) {
+	seed := DeriveDealSeed(2024, 2)
+	hands1, start1 := DealFromSeed(seed)
+	hands2, start2 := DealFromSeed(seed)
+	
+	if len(hands1) != 4 {
+		t.Fatalf("Expected 4 hands, got %d", len(hands1))
+	}
+	
+	if start1 != start2 {
+		t.Errorf("Starting card should be reproducible: %v vs %v", start1, start2)
+	}
+	
+	for seat := range hands1 {
+		if len(hands1[seat]) != 27 {
+			t.Errorf("Hand %d: expected 27 cards, got %d", seat, len(hands1[seat]))
+		}
+		for i := range hands1[seat] {
+			if hands1[seat][i] != hands2[seat][i] {
+				t.Fatalf("Hand %d differs at position %d", seat, i)
+			}
+		}
+	}
+	
+	otherHands, _ := DealFromSeed(DeriveDealSeed(2024, 3))
+	same := true
+	for i := range hands1[0] {
+		if hands1[0][i] != otherHands[0][i] {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Error("Consecutive deals should not be dealt identically")
+	}
+}
+
+func TestMatchSeedCommitment(t *testing.T) {
+	commitment := MatchSeedCommitment(777)
+	
+	if len(commitment) != 64 || commitment != MatchSeedCommitment(777) {
+		t.Errorf("Expected a stable 64 character commitment, got %q", commitment)
+	}
+	if commitment == MatchSeedCommitment(778) || commitment == SeedCommitment(777) {
+		t.Error("The commitment should differ for other seeds and from the deal seed commitment")
+	}
+}
@@ -93,6 +93,35 @@ func NewTributeInfo(scenario TributeScenario, hasImmunity bool) *TributeInfo {
 	}
 }
 
+// Clone 深拷贝贡牌信息，修改副本不影响原值
+func (ti *TributeInfo) Clone() *TributeInfo {
+	clone := *ti
+	clone.TributeRequests = copySeatMap(ti.TributeRequests)
+	clone.ReturnRequests = copySeatMap(ti.ReturnRequests)
+	clone.GivenTributes = copyCardMap(ti.GivenTributes)
+	clone.ReturnedTributes = copyCardMap(ti.ReturnedTributes)
+	clone.AvailableCards = copyCardMap(ti.AvailableCards)
+	clone.SelectedCards = copyCardMap(ti.SelectedCards)
+	clone.ActualReceivers = copySeatMap(ti.ActualReceivers)
+	return &clone
+}
+
+func copySeatMap(m map[SeatID]SeatID) map[SeatID]SeatID {
+	copied := make(map[SeatID]SeatID, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+func copyCardMap(m map[SeatID]Card) map[SeatID]Card {
+	copied := make(map[SeatID]Card, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
 // DetermineTributeScenario 根据上局排名确定贡牌场景
 func DetermineTributeScenario(lastRankings []SeatID) TributeScenario {
 	if len(lastRankings) != 4 {
@@ -132,6 +161,43 @@ func DetermineTributeScenario(lastRankings []SeatID) TributeScenario {
 	return TributeScenarioNone // 不应该发生的情况
 }
 
+// LevelGain 头游队的升级数：对家二游升3级，三游升2级，末游升1级
+func LevelGain(outcome TributeScenario) int {
+	switch outcome {
+	case TributeScenarioDoubleDown:
+		return 3
+	case TributeScenarioSingleLast:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// TeamLevelGain team在ranking下的升级数，对方头游时为对方升级数的相反数；排名不完整时为0
+func TeamLevelGain(ranking []SeatID, team TeamID) int {
+	if len(ranking) != 4 {
+		return 0
+	}
+	gain := LevelGain(DetermineTributeScenario(ranking))
+	if GetTeamFromSeat(ranking[0]) != team {
+		return -gain
+	}
+	return gain
+}
+
+// AdvanceLevel 头游队赢得一个Deal后的级数：按outcome升级，最多升到target。
+// 该队已到target且对家不是末游时返回true，表示赢得比赛，此时级数不变
+func AdvanceLevel(level Rank, outcome TributeScenario, target Rank) (Rank, bool) {
+	if level >= target && outcome != TributeScenarioPartnerLast {
+		return level, true
+	}
+	level += Rank(LevelGain(outcome))
+	if level > target {
+		level = target
+	}
+	return level, false
+}
+
 // CheckTributeImmunity 检查是否有贡牌免疫
 func CheckTributeImmunity(scenario TributeScenario, playerBigJokers map[SeatID]int, lastRankings []SeatID) bool {
 	if len(lastRankings) != 4 {
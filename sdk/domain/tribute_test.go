@@ -543,4 +543,53 @@ func TestP3DifferentSeatArrangements(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestTeamLevelGain(t *testing.T) {
+	e, s, w, n := SeatEast, SeatSouth, SeatWest, SeatNorth
+	tests := []struct {
+		ranking []SeatID
+		want    int
+	}{
+		{[]SeatID{e, w, s, n}, 3},
+		{[]SeatID{e, s, w, n}, 2},
+		{[]SeatID{e, s, n, w}, 1},
+		{[]SeatID{s, e, n, w}, -2},
+		{[]SeatID{e, w}, 0},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := TeamLevelGain(tt.ranking, TeamEastWest); got != tt.want {
+			t.Errorf("TeamLevelGain(%v) = %d, want %d", tt.ranking, got, tt.want)
+		}
+	}
+}
+
+func TestAdvanceLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   Rank
+		outcome TributeScenario
+		target  Rank
+		want    Rank
+		won     bool
+	}{
+		{"Double down gains three", Two, TributeScenarioDoubleDown, Ace, Five, false},
+		{"Single last gains two", Two, TributeScenarioSingleLast, Ace, Four, false},
+		{"Partner last gains one", Two, TributeScenarioPartnerLast, Ace, Three, false},
+		{"Gain stops at the target", Queen, TributeScenarioDoubleDown, Ace, Ace, false},
+		{"Reaching the target does not win yet", King, TributeScenarioPartnerLast, Ace, Ace, false},
+		{"Winning at the target wins the match", Ace, TributeScenarioSingleLast, Ace, Ace, true},
+		{"Partner last at the target does not win", Ace, TributeScenarioPartnerLast, Ace, Ace, false},
+		{"Lower target", Five, TributeScenarioDoubleDown, Five, Five, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, won := AdvanceLevel(tt.level, tt.outcome, tt.target)
+			if level != tt.want || won != tt.won {
+				t.Errorf("AdvanceLevel(%s, %s, %s) = %s, %v; want %s, %v",
+					tt.level, tt.outcome, tt.target, level, won, tt.want, tt.won)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"fmt"
+	"guandan/sdk/domain"
+)
+
+// ActionKind 座位在一个Deal中可以执行的行动类型
+type ActionKind int
+
+const (
+	ActionPlay ActionKind = iota
+	ActionPass
+	ActionTribute
+	ActionSelectTribute // Double Down中第一名选择贡牌
+	ActionReturnTribute
+)
+
+func (k ActionKind) String() string {
+	switch k {
+	case ActionPlay:
+		return "Play"
+	case ActionPass:
+		return "Pass"
+	case ActionTribute:
+		return "Tribute"
+	case ActionSelectTribute:
+		return "SelectTribute"
+	case ActionReturnTribute:
+		return "ReturnTribute"
+	default:
+		return "Unknown"
+	}
+}
+
+// Action 某个座位的一次行动
+type Action struct {
+	Kind   ActionKind
+	Seat   domain.SeatID
+	Cards  []domain.Card // 出牌、贡牌、还贡的牌
+	Target domain.SeatID // 贡牌、还贡的接收者；SelectTribute时为所选贡牌的进贡者
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionPass:
+		return fmt.Sprintf("%s %s", a.Seat, a.Kind)
+	case ActionSelectTribute:
+		return fmt.Sprintf("%s %s(%s)", a.Seat, a.Kind, a.Target)
+	case ActionPlay:
+		return fmt.Sprintf("%s %s%v", a.Seat, a.Kind, a.Cards)
+	default:
+		return fmt.Sprintf("%s %s%v->%s", a.Seat, a.Kind, a.Cards, a.Target)
+	}
+}
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+	"guandan/sdk/domain"
+)
+
+// DefaultTickInterval 未配置TickInterval时检查超时的间隔
+const DefaultTickInterval = time.Second
+
+// TimerConfig 回合计时配置
+type TimerConfig struct {
+	ActionTime   time.Duration `json:"action_time"`   // 每次行动的基础时间
+	TimeBank     time.Duration `json:"time_bank"`     // 每个座位每Deal的备用时间，基础时间用完后开始消耗；0表示不使用
+	TickInterval time.Duration `json:"tick_interval"` // 检查超时并发布TurnTimerTicked事件的间隔，0表示DefaultTickInterval
+}
+
+func (c TimerConfig) Validate() error {
+	if c.ActionTime <= 0 {
+		return fmt.Errorf("action time must be positive, got %s", c.ActionTime)
+	}
+	if c.TimeBank < 0 {
+		return fmt.Errorf("time bank cannot be negative, got %s", c.TimeBank)
+	}
+	if c.TickInterval < 0 {
+		return fmt.Errorf("tick interval cannot be negative, got %s", c.TickInterval)
+	}
+	return nil
+}
+
+func (c TimerConfig) tickInterval() time.Duration {
+	if c.TickInterval == 0 {
+		return DefaultTickInterval
+	}
+	return c.TickInterval
+}
+
+// TurnClock 各座位的回合计时。只保存状态，当前时间由调用方传入，
+// 贡牌阶段可能有多个座位同时计时
+type TurnClock struct {
+	config  TimerConfig
+	banks   [4]time.Duration
+	running map[domain.SeatID]time.Time // 计时中的座位 -> 本次开始时间
+}
+
+func NewTurnClock(config TimerConfig) *TurnClock {
+	clock := &TurnClock{
+		config:  config,
+		running: make(map[domain.SeatID]time.Time),
+	}
+	clock.ResetBanks()
+	return clock
+}
+
+func (c *TurnClock) Config() TimerConfig {
+	return c.config
+}
+
+// ResetBanks 新Deal开始时恢复所有座位的备用时间
+func (c *TurnClock) ResetBanks() {
+	for seat := range c.banks {
+		c.banks[seat] = c.config.TimeBank
+	}
+}
+
+func (c *TurnClock) IsRunning(seat domain.SeatID) bool {
+	_, exists := c.running[seat]
+	return exists
+}
+
+// RunningSeats 按座位顺序返回计时中的座位
+func (c *TurnClock) RunningSeats() []domain.SeatID {
+	var seats []domain.SeatID
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if c.IsRunning(seat) {
+			seats = append(seats, seat)
+		}
+	}
+	return seats
+}
+
+func (c *TurnClock) Start(seat domain.SeatID, now time.Time) {
+	c.running[seat] = now
+}
+
+// Stop 停止座位计时，超出基础时间的部分从备用时间中扣除
+func (c *TurnClock) Stop(seat domain.SeatID, now time.Time) {
+	startedAt, exists := c.running[seat]
+	if !exists {
+		return
+	}
+	delete(c.running, seat)
+	
+	if over := now.Sub(startedAt) - c.config.ActionTime; over > 0 {
+		c.banks[seat] -= over
+		if c.banks[seat] < 0 {
+			c.banks[seat] = 0
+		}
+	}
+}
+
+// Remaining 返回座位本次剩余的基础时间和备用时间
+func (c *TurnClock) Remaining(seat domain.SeatID, now time.Time) (time.Duration, time.Duration) {
+	startedAt, exists := c.running[seat]
+	if !exists {
+		return 0, c.banks[seat]
+	}
+	
+	elapsed := now.Sub(startedAt)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if elapsed <= c.config.ActionTime {
+		return c.config.ActionTime - elapsed, c.banks[seat]
+	}
+	
+	bank := c.banks[seat] - (elapsed - c.config.ActionTime)
+	if bank < 0 {
+		bank = 0
+	}
+	return 0, bank
+}
+
+// Deadline 座位基础时间与备用时间都用完的时刻
+func (c *TurnClock) Deadline(seat domain.SeatID) time.Time {
+	return c.running[seat].Add(c.config.ActionTime + c.banks[seat])
+}
+
+func (c *TurnClock) Expired(seat domain.SeatID, now time.Time) bool {
+	return c.IsRunning(seat) && !now.Before(c.Deadline(seat))
+}
+
+// TurnClockState 可序列化的计时状态，用于快照与恢复；
+// 计时中的座位只记录已用时间，恢复后从恢复时刻继续计时
+type TurnClockState struct {
+	Config  TimerConfig                     `json:"config"`
+	Banks   [4]time.Duration                `json:"banks"`
+	Elapsed map[domain.SeatID]time.Duration `json:"elapsed"`
+}
+
+func (c *TurnClock) State(now time.Time) *TurnClockState {
+	state := &TurnClockState{
+		Config:  c.config,
+		Banks:   c.banks,
+		Elapsed: make(map[domain.SeatID]time.Duration),
+	}
+	for seat, startedAt := range c.running {
+		state.Elapsed[seat] = now.Sub(startedAt)
+	}
+	return state
+}
+
+// RestoreTurnClock 从快照状态恢复计时
+func RestoreTurnClock(state *TurnClockState, now time.Time) (*TurnClock, error) {
+	if state == nil {
+		return nil, fmt.Errorf("turn clock state is nil")
+	}
+	if err := state.Config.Validate(); err != nil {
+		return nil, err
+	}
+	
+	clock := &TurnClock{
+		config:  state.Config,
+		banks:   state.Banks,
+		running: make(map[domain.SeatID]time.Time),
+	}
+	for seat, elapsed := range state.Elapsed {
+		if seat < domain.SeatEast || seat > domain.SeatNorth {
+			return nil, fmt.Errorf("invalid seat in turn clock state: %d", seat)
+		}
+		clock.running[seat] = now.Add(-elapsed)
+	}
+	return clock, nil
+}
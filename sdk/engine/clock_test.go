@@ -0,0 +1,333 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+func TestTurnClockTimeBank(t *testing.T) {
+	clock := NewTurnClock(TimerConfig{ActionTime: 10 * time.Second, TimeBank: 30 * time.Second})
+	start := time.Unix(1000, 0)
+	
+	clock.Start(domain.SeatSouth, start)
+	action, bank := clock.Remaining(domain.SeatSouth, start.Add(4*time.Second))
+	if action != 6*time.Second || bank != 30*time.Second {
+		t.Errorf("Expected 6s action and 30s bank, got %s and %s", action, bank)
+	}
+	
+	if want := start.Add(40 * time.Second); !clock.Deadline(domain.SeatSouth).Equal(want) {
+		t.Errorf("Expected deadline %v, got %v", want, clock.Deadline(domain.SeatSouth))
+	}
+	
+	// 用时25秒，超出的15秒从备用时间扣除
+	clock.Stop(domain.SeatSouth, start.Add(25*time.Second))
+	if _, bank := clock.Remaining(domain.SeatSouth, start); bank != 15*time.Second {
+		t.Errorf("Expected 15s bank left, got %s", bank)
+	}
+	
+	next := start.Add(time.Minute)
+	clock.Start(domain.SeatSouth, next)
+	if clock.Expired(domain.SeatSouth, next.Add(24*time.Second)) {
+		t.Error("Clock should not expire before action time plus remaining bank")
+	}
+	if !clock.Expired(domain.SeatSouth, next.Add(25*time.Second)) {
+		t.Error("Clock should expire once the bank is used up")
+	}
+	
+	clock.ResetBanks()
+	if _, bank := clock.Remaining(domain.SeatNorth, next); bank != 30*time.Second {
+		t.Errorf("Expected bank to be reset to 30s, got %s", bank)
+	}
+}
+
+func TestTurnClockStateRestore(t *testing.T) {
+	clock := NewTurnClock(TimerConfig{ActionTime: 20 * time.Second, TimeBank: 60 * time.Second})
+	start := time.Unix(1000, 0)
+	clock.Start(domain.SeatEast, start.Add(-50*time.Second))
+	clock.Stop(domain.SeatEast, start.Add(-20*time.Second))
+	clock.Start(domain.SeatWest, start)
+	
+	data, err := json.Marshal(clock.State(start.Add(8 * time.Second)))
+	if err != nil {
+		t.Fatalf("Failed to marshal clock state: %v", err)
+	}
+	
+	var state TurnClockState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Failed to unmarshal clock state: %v", err)
+	}
+	
+	// 恢复时间与快照时间无关，已用时间从恢复时刻继续累计
+	restoredAt := start.Add(time.Hour)
+	restored, err := RestoreTurnClock(&state, restoredAt)
+	if err != nil {
+		t.Fatalf("Failed to restore clock: %v", err)
+	}
+	
+	if !restored.IsRunning(domain.SeatWest) || restored.IsRunning(domain.SeatEast) {
+		t.Errorf("Expected only West to be running, got %v", restored.RunningSeats())
+	}
+	action, bank := restored.Remaining(domain.SeatWest, restoredAt)
+	if action != 12*time.Second || bank != 60*time.Second {
+		t.Errorf("Expected West to have 12s action and 60s bank, got %s and %s", action, bank)
+	}
+	if _, bank := restored.Remaining(domain.SeatEast, restoredAt); bank != 50*time.Second {
+		t.Errorf("Expected East to keep 50s bank, got %s", bank)
+	}
+	
+	if _, err := RestoreTurnClock(&TurnClockState{}, restoredAt); err == nil {
+		t.Error("Restoring a state without action time should fail")
+	}
+}
+
+func newTimedEngine(t *testing.T, source DealSource, config TimerConfig) (*GameEngine, <-chan event.DomainEvent) {
+	t.Helper()
+	
+	eventBus := event.NewEventBus(1000)
+	eventBus.Start()
+	t.Cleanup(eventBus.Stop)
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("timer-match", players, 12345)
+	
+	eventChan, unsubscribe := eventBus.Subscribe(matchCtx.ID)
+	t.Cleanup(unsubscribe)
+	
+	engine := NewGameEngine(eventBus)
+	if err := engine.Initialize(matchCtx); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	t.Cleanup(engine.DisableTurnTimer)
+	
+	if err := engine.SetDealSource(source); err != nil {
+		t.Fatalf("Failed to set deal source: %v", err)
+	}
+	if err := engine.EnableTurnTimer(config); err != nil {
+		t.Fatalf("Failed to enable turn timer: %v", err)
+	}
+	return engine, eventChan
+}
+
+func expiredActions(events []event.DomainEvent) map[domain.SeatID][]string {
+	actions := make(map[domain.SeatID][]string)
+	for _, e := range events {
+		if expired, ok := e.(*event.TurnTimerExpiredEvent); ok {
+			actions[expired.Player] = append(actions[expired.Player], expired.Action)
+		}
+	}
+	return actions
+}
+
+func TestGameEngineTurnTimerPlay(t *testing.T) {
+	engine, eventChan := newTimedEngine(t, eastLeadsDealSource(t), TimerConfig{
+		ActionTime: 10 * time.Second,
+		TimeBank:   20 * time.Second,
+	})
+	
+	start := time.Now()
+	engine.StartDeal(1, nil)
+	engine.DealCards()
+	engine.DetermineTrump()
+	engine.StartTribute()
+	
+	if state := engine.GetTurnClockState(start); state == nil || len(state.Elapsed) != 1 {
+		t.Fatalf("Expected exactly one seat on the clock, got %+v", state)
+	}
+	
+	// 基础时间用完后仍有备用时间
+	if err := engine.CheckTurnTimer(start.Add(15 * time.Second)); err != nil {
+		t.Fatalf("CheckTurnTimer failed: %v", err)
+	}
+	if len(engine.GetPlayerHand(domain.SeatEast)) != 27 {
+		t.Fatal("East should not be acted for while the time bank lasts")
+	}
+	
+	// 首出超时：出最小的单张
+	trump := engine.GetDealCtx().Trump
+	smallest := smallestSingle(engine.GetPlayerHand(domain.SeatEast), trump)
+	expiredAt := start.Add(31 * time.Second)
+	if err := engine.CheckTurnTimer(expiredAt); err != nil {
+		t.Fatalf("CheckTurnTimer failed: %v", err)
+	}
+	lastPlay := engine.GetLastPlay()
+	if lastPlay == nil || len(lastPlay.Cards) != 1 || lastPlay.Cards[0] != smallest {
+		t.Fatalf("Expected East to lead %v, got %+v", smallest, lastPlay)
+	}
+	if engine.GetCurrentPlayer() != domain.SeatSouth {
+		t.Fatalf("Expected South to be next, got %s", engine.GetCurrentPlayer())
+	}
+	
+	// 跟牌超时：Pass
+	if err := engine.CheckTurnTimer(expiredAt.Add(31 * time.Second)); err != nil {
+		t.Fatalf("CheckTurnTimer failed: %v", err)
+	}
+	if passed := engine.GetPassedPlayers(); len(passed) != 1 || passed[0] != domain.SeatSouth {
+		t.Errorf("Expected South to have passed, got %v", passed)
+	}
+	
+	// South Pass后West开始计时
+	events := collectEventsUntil(eventChan, nil, func(e event.DomainEvent) bool {
+		started, ok := e.(*event.TurnTimerStartedEvent)
+		return ok && started.Player == domain.SeatWest
+	})
+	actions := expiredActions(events)
+	if len(actions[domain.SeatEast]) != 1 || actions[domain.SeatEast][0] != "play" {
+		t.Errorf("Expected East to time out with play, got %v", actions[domain.SeatEast])
+	}
+	if len(actions[domain.SeatSouth]) != 1 || actions[domain.SeatSouth][0] != "pass" {
+		t.Errorf("Expected South to time out with pass, got %v", actions[domain.SeatSouth])
+	}
+	
+	ticked := false
+	for _, e := range events {
+		if ev, ok := e.(*event.TurnTimerTickedEvent); ok && ev.Player == domain.SeatEast && ev.ActionRemaining == 0 && ev.TimeBankRemaining > 0 {
+			ticked = true
+		}
+	}
+	if !ticked {
+		t.Error("Expected a tick for East while using the time bank")
+	}
+	
+	// East超时已用完备用时间
+	state := engine.GetTurnClockState(time.Now())
+	if state.Banks[domain.SeatEast] != 0 {
+		t.Errorf("Expected East's time bank to be used up, got %s", state.Banks[domain.SeatEast])
+	}
+	if _, running := state.Elapsed[domain.SeatWest]; !running {
+		t.Errorf("Expected West on the clock, got %v", state.Elapsed)
+	}
+}
+
+func TestGameEngineTurnTimerTribute(t *testing.T) {
+	// East持有大王，North不可能抗贡
+	source := NewConstrainedDealSource(HoldsCards(domain.SeatEast, domain.NewJoker(domain.BigJoker)))
+	engine, eventChan := newTimedEngine(t, source, TimerConfig{ActionTime: 10 * time.Second})
+	
+	// 上局East第一、North末游：North向East进贡
+	previous := []domain.SeatID{domain.SeatEast, domain.SeatSouth, domain.SeatWest, domain.SeatNorth}
+	start := time.Now()
+	engine.StartDeal(2, previous)
+	engine.DealCards()
+	engine.DetermineTrump()
+	if err := engine.StartTribute(); err != nil {
+		t.Fatalf("Failed to start tribute: %v", err)
+	}
+	if engine.GetCurrentPhase() != PhaseTribute {
+		t.Fatalf("Expected tribute phase, got %s", engine.GetCurrentPhase())
+	}
+	
+	trump := engine.GetDealCtx().Trump
+	tribute, _ := domain.SelectTributeCard(engine.GetPlayerHand(domain.SeatNorth), trump)
+	
+	if err := engine.CheckTurnTimer(start.Add(11 * time.Second)); err != nil {
+		t.Fatalf("CheckTurnTimer failed: %v", err)
+	}
+	if engine.GetCurrentPhase() != PhaseReturnTribute {
+		t.Fatalf("Expected return tribute phase after forced tribute, got %s", engine.GetCurrentPhase())
+	}
+	if given := engine.GetDealCtx().TributeInfo.GivenTributes[domain.SeatNorth]; given != tribute {
+		t.Errorf("Expected North to give %v, got %v", tribute, given)
+	}
+	
+	returned, _ := smallestReturnCard(engine.GetPlayerHand(domain.SeatEast))
+	if err := engine.CheckTurnTimer(start.Add(22 * time.Second)); err != nil {
+		t.Fatalf("CheckTurnTimer failed: %v", err)
+	}
+	if engine.GetCurrentPhase() != PhaseFirstPlay {
+		t.Fatalf("Expected first play after forced return, got %s", engine.GetCurrentPhase())
+	}
+	if got := engine.GetDealCtx().TributeInfo.ReturnedTributes[domain.SeatEast]; got != returned {
+		t.Errorf("Expected East to return %v, got %v", returned, got)
+	}
+	
+	events := collectEventsUntil(eventChan, nil, func(e event.DomainEvent) bool {
+		expired, ok := e.(*event.TurnTimerExpiredEvent)
+		return ok && expired.Player == domain.SeatEast
+	})
+	actions := expiredActions(events)
+	if len(actions[domain.SeatNorth]) != 1 || actions[domain.SeatNorth][0] != "tribute" {
+		t.Errorf("Expected North to time out with tribute, got %v", actions[domain.SeatNorth])
+	}
+	if len(actions[domain.SeatEast]) != 1 || actions[domain.SeatEast][0] != "return" {
+		t.Errorf("Expected East to time out with return, got %v", actions[domain.SeatEast])
+	}
+}
+
+func TestGameEngineRestoreTurnClock(t *testing.T) {
+	engine, _ := newTimedEngine(t, eastLeadsDealSource(t), TimerConfig{ActionTime: 10 * time.Second})
+	engine.StartDeal(1, nil)
+	engine.DealCards()
+	engine.DetermineTrump()
+	engine.StartTribute()
+	
+	state := engine.GetTurnClockState(time.Now().Add(7 * time.Second))
+	engine.DisableTurnTimer()
+	if engine.GetTurnClockState(time.Now()) != nil {
+		t.Fatal("Clock state should be nil once the timer is disabled")
+	}
+	
+	if err := engine.RestoreTurnClock(state); err != nil {
+		t.Fatalf("Failed to restore turn clock: %v", err)
+	}
+	
+	// 恢复后East仅剩约3秒
+	if err := engine.CheckTurnTimer(time.Now().Add(4 * time.Second)); err != nil {
+		t.Fatalf("CheckTurnTimer failed: %v", err)
+	}
+	if engine.GetLastPlay() == nil {
+		t.Error("East should have been acted for after the restored clock expired")
+	}
+}
+
+func TestTimerConfigValidate(t *testing.T) {
+	if err := (TimerConfig{ActionTime: 0}).Validate(); err == nil {
+		t.Error("Zero action time should be invalid")
+	}
+	if err := (TimerConfig{ActionTime: time.Second, TimeBank: -time.Second}).Validate(); err == nil {
+		t.Error("Negative time bank should be invalid")
+	}
+	if err := (TimerConfig{ActionTime: time.Second}).Validate(); err != nil {
+		t.Errorf("Valid config rejected: %v", err)
+	}
+}
+
+func TestGameEngineTurnTimerActionFails(t *testing.T) {
+	source := NewConstrainedDealSource(HoldsCards(domain.SeatEast, domain.NewJoker(domain.BigJoker)))
+	engine, _ := newTimedEngine(t, source, TimerConfig{ActionTime: 10 * time.Second})
+	
+	previous := []domain.SeatID{domain.SeatEast, domain.SeatSouth, domain.SeatWest, domain.SeatNorth}
+	start := time.Now()
+	engine.StartDeal(2, previous)
+	engine.DealCards()
+	engine.DetermineTrump()
+	engine.StartTribute()
+	if err := engine.CheckTurnTimer(start.Add(11 * time.Second)); err != nil {
+		t.Fatalf("CheckTurnTimer failed: %v", err)
+	}
+	if engine.GetCurrentPhase() != PhaseReturnTribute {
+		t.Fatalf("Expected return tribute phase, got %s", engine.GetCurrentPhase())
+	}
+	
+	// East只剩大于10的牌，既没有默认还贡也没有其他合法行动
+	east := engine.GetMatchCtx().GetPlayer(domain.SeatEast)
+	east.ClearHand()
+	east.AddCards([]domain.Card{domain.NewCard(domain.Spades, domain.Ace), domain.NewJoker(domain.BigJoker)})
+	
+	if err := engine.CheckTurnTimer(start.Add(22 * time.Second)); err == nil {
+		t.Fatal("Expected the failed automatic action to be reported")
+	}
+	if state := engine.GetTurnClockState(start.Add(22 * time.Second)); len(state.Elapsed) != 0 {
+		t.Errorf("The failed seat's clock should be stopped, got %v", state.Elapsed)
+	}
+	if err := engine.CheckTurnTimer(start.Add(23 * time.Second)); err != nil {
+		t.Errorf("A stopped clock should not be retried, got %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"guandan/sdk/domain"
+)
+
+// Deal 一次发牌的结果：四家手牌（按座位）与首局用于确定首出者的Starting Card
+type Deal struct {
+	Hands        [4][]domain.Card
+	StartingCard domain.Card
+	Seed         int64 // 产生该Deal的种子，仅在Seeded为true时有意义
+	Seeded       bool
+}
+
+// DealSource 引擎获取四家手牌的来源
+type DealSource interface {
+	NextDeal(matchCtx *domain.MatchCtx, dealNumber int) (*Deal, error)
+}
+
+// ValidateDeal 检查发牌是否恰好用掉两副牌，每家27张
+func ValidateDeal(deal *Deal) error {
+	if deal == nil {
+		return fmt.Errorf("deal is nil")
+	}
+	
+	counts := make(map[domain.Card]int)
+	for seat, hand := range deal.Hands {
+		if len(hand) != 27 {
+			return fmt.Errorf("%s holds %d cards, expected 27", domain.SeatID(seat), len(hand))
+		}
+		for _, card := range hand {
+			counts[card]++
+		}
+	}
+	
+	for _, card := range domain.NewDeck().Cards {
+		if counts[card] != 2 {
+			return fmt.Errorf("card %v appears %d times, expected 2", card, counts[card])
+		}
+	}
+	
+	return nil
+}
+
+// SeededDealSource 默认随机发牌：由比赛种子派生每个Deal的种子
+type SeededDealSource struct{}
+
+func NewSeededDealSource() *SeededDealSource {
+	return &SeededDealSource{}
+}
+
+func (s *SeededDealSource) NextDeal(matchCtx *domain.MatchCtx, dealNumber int) (*Deal, error) {
+	dealSeed := domain.DeriveDealSeed(matchCtx.Seed, dealNumber)
+	hands, startingCard := domain.DealFromSeed(dealSeed)
+	
+	deal := &Deal{
+		StartingCard: startingCard,
+		Seed:         dealSeed,
+		Seeded:       true,
+	}
+	copy(deal.Hands[:], hands)
+	return deal, nil
+}
+
+// FixedDealSource 按顺序返回预设的发牌，用于测试、残局和教学
+type FixedDealSource struct {
+	deals []*Deal
+}
+
+func NewFixedDealSource(deals ...*Deal) (*FixedDealSource, error) {
+	if len(deals) == 0 {
+		return nil, fmt.Errorf("at least one preset deal is required")
+	}
+	
+	for i, deal := range deals {
+		if err := ValidateDeal(deal); err != nil {
+			return nil, fmt.Errorf("preset deal %d: %w", i+1, err)
+		}
+	}
+	
+	return &FixedDealSource{deals: deals}, nil
+}
+
+func (s *FixedDealSource) NextDeal(matchCtx *domain.MatchCtx, dealNumber int) (*Deal, error) {
+	if dealNumber < 1 || dealNumber > len(s.deals) {
+		return nil, fmt.Errorf("no preset deal for deal %d (have %d)", dealNumber, len(s.deals))
+	}
+	
+	preset := s.deals[dealNumber-1]
+	deal := &Deal{StartingCard: preset.StartingCard}
+	for seat, hand := range preset.Hands {
+		deal.Hands[seat] = make([]domain.Card, len(hand))
+		copy(deal.Hands[seat], hand)
+	}
+	return deal, nil
+}
+
+// LoadHandsFile 从手牌文件读取预设发牌
+func LoadHandsFile(path string) (*FixedDealSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hands file: %w", err)
+	}
+	defer file.Close()
+	
+	deals, err := ParseHands(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	
+	return NewFixedDealSource(deals...)
+}
+
+// ParseHands 解析手牌文件。每个Deal由四行"座位: 牌..."组成，可选"Start: 牌"
+// 指定Starting Card（缺省为East的第一张牌），Deal之间用"---"分隔，"#"开头为注释：
+//
+//	# deal 1
+//	East:  HA H2 H3 ... SJ
+//	South: ...
+//	West:  ...
+//	North: ...
+//	Start: D5
+//	---
+func ParseHands(r io.Reader) ([]*Deal, error) {
+	var deals []*Deal
+	var current *Deal
+	var start *domain.Card
+	
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if start != nil {
+			current.StartingCard = *start
+		} else if len(current.Hands[domain.SeatEast]) > 0 {
+			current.StartingCard = current.Hands[domain.SeatEast][0]
+		}
+		deals = append(deals, current)
+		current = nil
+		start = nil
+	}
+	
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		
+		if line == "---" {
+			flush()
+			continue
+		}
+		
+		label, rest, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected \"<seat>: <cards>\"", lineNumber)
+		}
+		
+		cards, err := parseCardList(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		
+		if current == nil {
+			current = &Deal{}
+		}
+		
+		label = strings.TrimSpace(label)
+		if strings.EqualFold(label, "Start") {
+			if len(cards) != 1 {
+				return nil, fmt.Errorf("line %d: starting card must be a single card", lineNumber)
+			}
+			start = &cards[0]
+			continue
+		}
+		
+		seat, err := parseSeatName(label)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if current.Hands[seat] != nil {
+			return nil, fmt.Errorf("line %d: duplicate hand for %s", lineNumber, seat)
+		}
+		current.Hands[seat] = cards
+	}
+	
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	
+	flush()
+	return deals, nil
+}
+
+func parseCardList(text string) ([]domain.Card, error) {
+	fields := strings.Fields(text)
+	cards := make([]domain.Card, 0, len(fields))
+	for _, field := range fields {
+		card, err := domain.ParseCard(field)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+func parseSeatName(name string) (domain.SeatID, error) {
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if strings.EqualFold(name, seat.String()) || strings.EqualFold(name, seat.String()[:1]) {
+			return seat, nil
+		}
+	}
+	return domain.SeatEast, fmt.Errorf("unknown seat %q", name)
+}
+
+// DealConstraint 约束发牌结果，例如"South至少两个炸弹"
+type DealConstraint interface {
+	Description() string
+	Satisfied(hands [4][]domain.Card) bool
+}
+
+// cardPlacer 可直接把指定牌放到某一家的约束，生成时先放置再随机补齐
+type cardPlacer interface {
+	placements() (domain.SeatID, []domain.Card)
+}
+
+type holdsCardsConstraint struct {
+	seat  domain.SeatID
+	cards []domain.Card
+}
+
+// HoldsCards 要求某座位持有指定的牌（可重复，例如两张大王）
+func HoldsCards(seat domain.SeatID, cards ...domain.Card) DealConstraint {
+	return &holdsCardsConstraint{seat: seat, cards: cards}
+}
+
+func (c *holdsCardsConstraint) Description() string {
+	names := make([]string, len(c.cards))
+	for i, card := range c.cards {
+		names[i] = card.String()
+	}
+	return fmt.Sprintf("%s holds %s", c.seat, strings.Join(names, " "))
+}
+
+func (c *holdsCardsConstraint) Satisfied(hands [4][]domain.Card) bool {
+	player := &domain.Player{Hand: append([]domain.Card(nil), hands[c.seat]...)}
+	return player.RemoveCards(c.cards)
+}
+
+func (c *holdsCardsConstraint) placements() (domain.SeatID, []domain.Card) {
+	return c.seat, c.cards
+}
+
+type minBombsConstraint struct {
+	seat  domain.SeatID
+	count int
+}
+
+// MinBombs 要求某座位至少持有count个炸弹
+func MinBombs(seat domain.SeatID, count int) DealConstraint {
+	return &minBombsConstraint{seat: seat, count: count}
+}
+
+func (c *minBombsConstraint) Description() string {
+	return fmt.Sprintf("%s holds at least %d bombs", c.seat, c.count)
+}
+
+func (c *minBombsConstraint) Satisfied(hands [4][]domain.Card) bool {
+	return domain.CountBombs(hands[c.seat]) >= c.count
+}
+
+type predicateConstraint struct {
+	description string
+	predicate   func(hands [4][]domain.Card) bool
+}
+
+// DealPredicate 以任意函数表达的约束
+func DealPredicate(description string, predicate func(hands [4][]domain.Card) bool) DealConstraint {
+	return &predicateConstraint{description: description, predicate: predicate}
+}
+
+func (c *predicateConstraint) Description() string {
+	return c.description
+}
+
+func (c *predicateConstraint) Satisfied(hands [4][]domain.Card) bool {
+	return c.predicate(hands)
+}
+
+// ConstrainedDealSource 随机生成满足全部约束的发牌（拒绝采样）
+type ConstrainedDealSource struct {
+	constraints []DealConstraint
+	maxAttempts int
+}
+
+const DefaultMaxDealAttempts = 10000
+
+func NewConstrainedDealSource(constraints ...DealConstraint) *ConstrainedDealSource {
+	return &ConstrainedDealSource{
+		constraints: constraints,
+		maxAttempts: DefaultMaxDealAttempts,
+	}
+}
+
+// WithMaxAttempts 设置放弃前最多尝试的发牌次数
+func (s *ConstrainedDealSource) WithMaxAttempts(attempts int) *ConstrainedDealSource {
+	newSource := *s
+	newSource.maxAttempts = attempts
+	return &newSource
+}
+
+func (s *ConstrainedDealSource) NextDeal(matchCtx *domain.MatchCtx, dealNumber int) (*Deal, error) {
+	dealSeed := domain.DeriveDealSeed(matchCtx.Seed, dealNumber)
+	rng := rand.New(rand.NewSource(dealSeed))
+	
+	for attempt := 0; attempt < s.maxAttempts; attempt++ {
+		hands, err := s.generate(rng)
+		if err != nil {
+			return nil, err
+		}
+		
+		if s.satisfied(hands) {
+			deal := &Deal{Hands: hands}
+			allCards := make([]domain.Card, 0, 108)
+			for _, hand := range hands {
+				allCards = append(allCards, hand...)
+			}
+			deal.StartingCard = allCards[rng.Intn(len(allCards))]
+			return deal, nil
+		}
+	}
+	
+	descriptions := make([]string, len(s.constraints))
+	for i, constraint := range s.constraints {
+		descriptions[i] = constraint.Description()
+	}
+	return nil, fmt.Errorf("no deal satisfying [%s] after %d attempts", strings.Join(descriptions, "; "), s.maxAttempts)
+}
+
+func (s *ConstrainedDealSource) generate(rng *rand.Rand) ([4][]domain.Card, error) {
+	var hands [4][]domain.Card
+	remaining := &domain.Player{Hand: domain.NewDeck().Cards}
+	
+	// 先放置必须持有的牌
+	for _, constraint := range s.constraints {
+		placer, ok := constraint.(cardPlacer)
+		if !ok {
+			continue
+		}
+		seat, cards := placer.placements()
+		if !remaining.RemoveCards(cards) {
+			return hands, fmt.Errorf("constraint %q requires cards that are not available", constraint.Description())
+		}
+		hands[seat] = append(hands[seat], cards...)
+		if len(hands[seat]) > 27 {
+			return hands, fmt.Errorf("constraints place %d cards with %s", len(hands[seat]), seat)
+		}
+	}
+	
+	// 剩余的牌洗匀后补齐每家27张
+	rest := remaining.Hand
+	rng.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+	for seat := range hands {
+		need := 27 - len(hands[seat])
+		hands[seat] = append(hands[seat], rest[:need]...)
+		rest = rest[need:]
+	}
+	
+	return hands, nil
+}
+
+func (s *ConstrainedDealSource) satisfied(hands [4][]domain.Card) bool {
+	for _, constraint := range s.constraints {
+		if !constraint.Satisfied(hands) {
+			return false
+		}
+	}
+	return true
+}
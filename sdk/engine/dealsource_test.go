@@ -0,0 +1,401 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// eastLeadsDealSource 返回一个固定发牌：Starting Card在East手中，使首出者确定为East；
+// East不持有♥A，测试中用它作为"不在手中的牌"
+func eastLeadsDealSource(t *testing.T) *FixedDealSource {
+	t.Helper()
+	
+	hands, _ := domain.DealFromSeed(domain.DeriveDealSeed(12345, 1))
+	deal := &Deal{}
+	copy(deal.Hands[:], hands)
+	
+	heartAce := domain.NewCard(domain.Hearts, domain.Ace)
+	east, north := deal.Hands[domain.SeatEast], deal.Hands[domain.SeatNorth]
+	for i := range east {
+		if east[i] != heartAce {
+			continue
+		}
+		for j := range north {
+			if north[j] != heartAce {
+				east[i], north[j] = north[j], east[i]
+				break
+			}
+		}
+	}
+	deal.StartingCard = east[0]
+	
+	source, err := NewFixedDealSource(deal)
+	if err != nil {
+		t.Fatalf("Failed to create fixed deal source: %v", err)
+	}
+	return source
+}
+
+func formatHand(cards []domain.Card) string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = card.String()
+	}
+	return strings.Join(names, " ")
+}
+
+func TestValidateDeal(t *testing.T) {
+	hands, startingCard := domain.DealFromSeed(42)
+	deal := &Deal{StartingCard: startingCard}
+	copy(deal.Hands[:], hands)
+	
+	if err := ValidateDeal(deal); err != nil {
+		t.Errorf("Seeded deal should be valid: %v", err)
+	}
+	
+	short := &Deal{}
+	copy(short.Hands[:], hands)
+	short.Hands[domain.SeatNorth] = short.Hands[domain.SeatNorth][1:]
+	if err := ValidateDeal(short); err == nil {
+		t.Error("Deal with a 26-card hand should be invalid")
+	}
+	
+	duplicated := &Deal{}
+	for seat := range hands {
+		duplicated.Hands[seat] = append([]domain.Card(nil), hands[seat]...)
+	}
+	duplicated.Hands[domain.SeatEast][0] = duplicated.Hands[domain.SeatSouth][0]
+	if duplicated.Hands[domain.SeatEast][0] != hands[domain.SeatEast][0] {
+		if err := ValidateDeal(duplicated); err == nil {
+			t.Error("Deal using a card three times should be invalid")
+		}
+	}
+}
+
+func TestSeededDealSourceMatchesDerivedSeed(t *testing.T) {
+	matchCtx := domain.NewMatchCtx("test-match", nil, 12345)
+	
+	deal, err := NewSeededDealSource().NextDeal(matchCtx, 2)
+	if err != nil {
+		t.Fatalf("Failed to deal: %v", err)
+	}
+	
+	expectedSeed := domain.DeriveDealSeed(12345, 2)
+	if !deal.Seeded || deal.Seed != expectedSeed {
+		t.Errorf("Expected seeded deal with seed %d, got %d (seeded=%v)", expectedSeed, deal.Seed, deal.Seeded)
+	}
+	
+	hands, startingCard := domain.DealFromSeed(expectedSeed)
+	if deal.StartingCard != startingCard {
+		t.Errorf("Expected starting card %v, got %v", startingCard, deal.StartingCard)
+	}
+	for seat := range hands {
+		if formatHand(deal.Hands[seat]) != formatHand(hands[seat]) {
+			t.Errorf("Hand %d differs from DealFromSeed", seat)
+		}
+	}
+}
+
+func TestParseHands(t *testing.T) {
+	hands, _ := domain.DealFromSeed(7)
+	
+	var text strings.Builder
+	text.WriteString("# preset deal\n")
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		text.WriteString(seat.String() + ": " + formatHand(hands[seat]) + "\n")
+	}
+	text.WriteString("Start: " + hands[domain.SeatWest][3].String() + "\n")
+	text.WriteString("---\n")
+	for seat := domain.SeatNorth; seat >= domain.SeatEast && seat <= domain.SeatNorth; seat-- {
+		text.WriteString(seat.String()[:1] + ": " + formatHand(hands[3-seat]) + "\n")
+	}
+	
+	deals, err := ParseHands(strings.NewReader(text.String()))
+	if err != nil {
+		t.Fatalf("Failed to parse hands: %v", err)
+	}
+	
+	if len(deals) != 2 {
+		t.Fatalf("Expected 2 deals, got %d", len(deals))
+	}
+	
+	if deals[0].StartingCard != hands[domain.SeatWest][3] {
+		t.Errorf("Expected explicit starting card %v, got %v", hands[domain.SeatWest][3], deals[0].StartingCard)
+	}
+	
+	if formatHand(deals[1].Hands[domain.SeatEast]) != formatHand(hands[domain.SeatNorth]) {
+		t.Error("Second deal should give North's cards to East")
+	}
+	
+	if deals[1].StartingCard != deals[1].Hands[domain.SeatEast][0] {
+		t.Error("Starting card should default to East's first card")
+	}
+	
+	if _, err := NewFixedDealSource(deals...); err != nil {
+		t.Errorf("Parsed deals should be valid: %v", err)
+	}
+}
+
+func TestParseHandsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing colon", "East HA H2\n"},
+		{"unknown seat", "Center: HA\n"},
+		{"bad card", "East: HA XX\n"},
+		{"duplicate seat", "East: HA\nEast: H2\n"},
+		{"multiple starting cards", "East: HA\nStart: HA H2\n"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseHands(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Expected error for input %q", tt.input)
+			}
+		})
+	}
+}
+
+func TestLoadHandsFile(t *testing.T) {
+	hands, _ := domain.DealFromSeed(99)
+	
+	var text strings.Builder
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		text.WriteString(seat.String() + ": " + formatHand(hands[seat]) + "\n")
+	}
+	
+	path := filepath.Join(t.TempDir(), "deal.hands")
+	if err := os.WriteFile(path, []byte(text.String()), 0o644); err != nil {
+		t.Fatalf("Failed to write hands file: %v", err)
+	}
+	
+	source, err := LoadHandsFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load hands file: %v", err)
+	}
+	
+	matchCtx := domain.NewMatchCtx("test-match", nil, 1)
+	deal, err := source.NextDeal(matchCtx, 1)
+	if err != nil {
+		t.Fatalf("Failed to get preset deal: %v", err)
+	}
+	if formatHand(deal.Hands[domain.SeatWest]) != formatHand(hands[domain.SeatWest]) {
+		t.Error("Loaded West hand differs from file")
+	}
+	
+	if _, err := source.NextDeal(matchCtx, 2); err == nil {
+		t.Error("Expected error when preset deals are exhausted")
+	}
+	
+	if _, err := LoadHandsFile(filepath.Join(t.TempDir(), "missing.hands")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestConstrainedDealSource(t *testing.T) {
+	jokers := []domain.Card{
+		domain.NewJoker(domain.BigJoker),
+		domain.NewJoker(domain.BigJoker),
+		domain.NewJoker(domain.SmallJoker),
+		domain.NewJoker(domain.SmallJoker),
+	}
+	source := NewConstrainedDealSource(
+		HoldsCards(domain.SeatSouth, jokers...),
+		MinBombs(domain.SeatSouth, 2),
+		DealPredicate("North holds no jokers", func(hands [4][]domain.Card) bool {
+			for _, card := range hands[domain.SeatNorth] {
+				if card.IsJoker() {
+					return false
+				}
+			}
+			return true
+		}),
+	)
+	
+	matchCtx := domain.NewMatchCtx("test-match", nil, 2024)
+	deal, err := source.NextDeal(matchCtx, 1)
+	if err != nil {
+		t.Fatalf("Failed to generate constrained deal: %v", err)
+	}
+	
+	if err := ValidateDeal(deal); err != nil {
+		t.Fatalf("Constrained deal should be valid: %v", err)
+	}
+	
+	south := &domain.Player{Hand: deal.Hands[domain.SeatSouth]}
+	if !south.HasCards(jokers) {
+		t.Error("South should hold all four jokers")
+	}
+	
+	if domain.CountBombs(deal.Hands[domain.SeatSouth]) < 2 {
+		t.Error("South should hold at least two bombs")
+	}
+	
+	again, _ := source.NextDeal(matchCtx, 1)
+	if formatHand(again.Hands[domain.SeatEast]) != formatHand(deal.Hands[domain.SeatEast]) {
+		t.Error("Constrained deal should be reproducible from the match seed")
+	}
+}
+
+func TestConstrainedDealSourceGivesUp(t *testing.T) {
+	source := NewConstrainedDealSource(
+		DealPredicate("impossible", func(hands [4][]domain.Card) bool { return false }),
+	).WithMaxAttempts(5)
+	
+	matchCtx := domain.NewMatchCtx("test-match", nil, 1)
+	if _, err := source.NextDeal(matchCtx, 1); err == nil {
+		t.Error("Expected error when no deal satisfies the constraints")
+	}
+	
+	overfull := NewConstrainedDealSource(HoldsCards(domain.SeatEast,
+		domain.NewJoker(domain.BigJoker),
+		domain.NewJoker(domain.BigJoker),
+		domain.NewJoker(domain.BigJoker),
+	))
+	if _, err := overfull.NextDeal(matchCtx, 1); err == nil {
+		t.Error("Expected error when a constraint needs a third copy of a card")
+	}
+}
+
+func TestEngineUsesDealSource(t *testing.T) {
+	eventBus := event.NewEventBus(100)
+	engine := NewGameEngine(eventBus)
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("test-match", players, 12345)
+	
+	if err := engine.SetDealSource(eastLeadsDealSource(t)); err == nil {
+		t.Error("Should not be able to set deal source before initialization")
+	}
+	
+	if err := engine.Initialize(matchCtx); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	
+	source := eastLeadsDealSource(t)
+	if err := engine.SetDealSource(source); err != nil {
+		t.Fatalf("Failed to set deal source: %v", err)
+	}
+	
+	if err := engine.EnableFairShuffle(); err == nil {
+		t.Error("Fair shuffle should not be combined with a custom deal source")
+	}
+	
+	engine.StartDeal(1, nil)
+	if err := engine.DealCards(); err != nil {
+		t.Fatalf("Failed to deal cards: %v", err)
+	}
+	
+	if err := engine.SetDealSource(source); err == nil {
+		t.Error("Should not be able to change deal source after cards are dealt")
+	}
+	
+	preset, _ := source.NextDeal(matchCtx, 1)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if formatHand(engine.GetPlayerHand(seat)) != formatHand(preset.Hands[seat]) {
+			t.Errorf("Hand for %s differs from preset deal", seat)
+		}
+	}
+	
+	engine.DetermineTrump()
+	engine.StartTribute()
+	
+	if engine.GetCurrentPlayer() != domain.SeatEast {
+		t.Errorf("Expected starting card holder %s to lead, got %s", domain.SeatEast, engine.GetCurrentPlayer())
+	}
+}
+
+func TestDealSeedRevealedOnlyAtDealEnd(t *testing.T) {
+	eventBus := event.NewEventBus(100)
+	eventBus.Start()
+	defer eventBus.Stop()
+	
+	eventChan, unsubscribe := eventBus.Subscribe("test-match")
+	defer unsubscribe()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("test-match", players, 12345)
+	sm := NewDealStateMachine(matchCtx, eventBus)
+	
+	if err := sm.StartDeal(1, nil); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	if err := sm.DealCards(); err != nil {
+		t.Fatalf("Failed to deal cards: %v", err)
+	}
+	if err := sm.DetermineTrump(); err != nil {
+		t.Fatalf("Failed to determine trump: %v", err)
+	}
+	if err := sm.StartTribute(); err != nil {
+		t.Fatalf("Failed to start tribute: %v", err)
+	}
+	
+	// 首出者和对家各留一张牌，双上结束本Deal
+	leader := sm.GetTrickCtx().CurrentPlayer
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		player := matchCtx.GetPlayer(seat)
+		hand := player.GetHand()
+		keep := 2
+		if seat == leader || seat == leader.Opposite() {
+			keep = 1
+		}
+		player.RemoveCards(hand[keep:])
+	}
+	if err := sm.PlayCards(leader, matchCtx.GetPlayer(leader).GetHand()); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	for _, seat := range []domain.SeatID{leader.Next(), leader.Opposite(), leader.Previous()} {
+		if err := sm.Pass(seat); err != nil {
+			t.Fatalf("Failed to pass for %s: %v", seat, err)
+		}
+	}
+	partner := leader.Opposite()
+	if err := sm.PlayCards(partner, matchCtx.GetPlayer(partner).GetHand()); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	
+	events := collectEventsUntil(eventChan, nil, func(e event.DomainEvent) bool {
+		_, ok := e.(*event.DealEndedEvent)
+		return ok
+	})
+	
+	dealSeed := domain.DeriveDealSeed(12345, 1)
+	var recorded *event.ShuffleRecordedEvent
+	var ended *event.DealEndedEvent
+	for _, e := range events {
+		switch ev := e.(type) {
+		case *event.ShuffleRecordedEvent:
+			recorded = ev
+		case *event.DealEndedEvent:
+			ended = ev
+		}
+	}
+	
+	if recorded == nil || recorded.Commitment != domain.SeedCommitment(dealSeed) {
+		t.Fatalf("Expected ShuffleRecordedEvent with the seed commitment, got %+v", recorded)
+	}
+	if ended == nil {
+		t.Fatal("Expected DealEndedEvent")
+	}
+	if ended.DealSeed != dealSeed {
+		t.Errorf("Expected deal seed %d revealed at deal end, got %d", dealSeed, ended.DealSeed)
+	}
+	if !domain.VerifySeedCommitment(ended.DealSeed, recorded.Commitment) {
+		t.Error("Revealed deal seed should match the commitment")
+	}
+}
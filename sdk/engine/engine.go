@@ -3,6 +3,7 @@ package engine
 import (
 	"fmt"
 	"sync"
+	"time"
 	"guandan/sdk/domain"
 	"guandan/sdk/event"
 )
@@ -12,13 +13,16 @@ type GameEngine struct {
 	stateMachine    *DealStateMachine
 	eventBus        *event.EventBus
 	isInitialized   bool
-	allowedActions  map[domain.SeatID][]string
+	allowedActions  map[domain.SeatID][]ActionKind
+	clock           *TurnClock
+	clockStop       chan struct{}
+	timerCheck      func(now time.Time) error // 后台计时检查，nil表示CheckTurnTimer
 }
 
 func NewGameEngine(eventBus *event.EventBus) *GameEngine {
 	return &GameEngine{
 		eventBus:       eventBus,
-		allowedActions: make(map[domain.SeatID][]string),
+		allowedActions: make(map[domain.SeatID][]ActionKind),
 	}
 }
 
@@ -86,6 +90,17 @@ func (ge *GameEngine) GetTrickCtx() *domain.TrickCtx {
 	return ge.stateMachine.GetTrickCtx()
 }
 
+func (ge *GameEngine) GetStartingCard() *domain.Card {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+	
+	if !ge.isInitialized {
+		return nil
+	}
+	
+	return ge.stateMachine.GetStartingCard()
+}
+
 func (ge *GameEngine) StartDeal(dealNumber int, lastRankings []domain.SeatID) error {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
@@ -94,7 +109,16 @@ func (ge *GameEngine) StartDeal(dealNumber int, lastRankings []domain.SeatID) er
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	return ge.stateMachine.StartDeal(dealNumber, lastRankings)
+	if err := ge.stateMachine.StartDeal(dealNumber, lastRankings); err != nil {
+		return err
+	}
+	
+	if ge.clock != nil {
+		ge.clock.ResetBanks()
+	}
+	
+	ge.syncClock(time.Now())
+	return nil
 }
 
 func (ge *GameEngine) DealCards() error {
@@ -127,10 +151,16 @@ func (ge *GameEngine) StartTribute() error {
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	return ge.stateMachine.StartTribute()
+	if err := ge.stateMachine.StartTribute(); err != nil {
+		return err
+	}
+	
+	ge.syncClock(time.Now())
+	return nil
 }
 
-func (ge *GameEngine) GiveTribute(from, to domain.SeatID, cards []domain.Card) error {
+// Apply 执行一个座位的行动：出牌、Pass、贡牌、选择贡牌或还贡，取代按阶段调用不同方法
+func (ge *GameEngine) Apply(action Action) error {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 	
@@ -138,13 +168,29 @@ func (ge *GameEngine) GiveTribute(from, to domain.SeatID, cards []domain.Card) e
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	if !ge.isActionAllowed(from, "tribute") {
-		return fmt.Errorf("tribute action not allowed for player %s", from.String())
+	return ge.apply(action)
+}
+
+// apply 校验并执行行动（调用方持有锁）。违反规则时返回ErrNotYourTurn、ErrWrongPhase
+// 或domain中的出牌错误，调用方可用errors.As取得原因
+func (ge *GameEngine) apply(action Action) error {
+	if !ge.isActionAllowed(action.Seat, action.Kind) {
+		return fmt.Errorf("%s action not allowed for player %s", action.Kind, action.Seat.String())
 	}
 	
-	return ge.stateMachine.GiveTribute(from, to, cards)
+	if err := ge.stateMachine.Apply(action); err != nil {
+		return err
+	}
+	
+	ge.afterAction(action.Seat)
+	return nil
+}
+
+func (ge *GameEngine) GiveTribute(from, to domain.SeatID, cards []domain.Card) error {
+	return ge.Apply(Action{Kind: ActionTribute, Seat: from, Cards: cards, Target: to})
 }
 
+// SelectTributeCard 上局第一名在Double Down场景中选择giver的贡牌
 func (ge *GameEngine) SelectTributeCard(giver domain.SeatID) error {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
@@ -153,10 +199,27 @@ func (ge *GameEngine) SelectTributeCard(giver domain.SeatID) error {
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	return ge.stateMachine.SelectTributeCard(giver)
+	dealCtx := ge.stateMachine.GetDealCtx()
+	if ge.stateMachine.GetCurrentPhase() != PhaseTributeSelection || len(dealCtx.LastRankings) != 4 {
+		return fmt.Errorf("cannot select tribute card from phase %s", ge.stateMachine.GetCurrentPhase().String())
+	}
+	
+	return ge.apply(Action{Kind: ActionSelectTribute, Seat: dealCtx.LastRankings[0], Target: giver})
 }
 
 func (ge *GameEngine) GiveReturnTribute(from, to domain.SeatID, cards []domain.Card) error {
+	return ge.Apply(Action{Kind: ActionReturnTribute, Seat: from, Cards: cards, Target: to})
+}
+
+func (ge *GameEngine) PlayCards(seat domain.SeatID, cards []domain.Card) error {
+	return ge.Apply(Action{Kind: ActionPlay, Seat: seat, Cards: cards})
+}
+
+func (ge *GameEngine) Pass(seat domain.SeatID) error {
+	return ge.Apply(Action{Kind: ActionPass, Seat: seat})
+}
+
+func (ge *GameEngine) StartFirstPlay() error {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 	
@@ -164,14 +227,15 @@ func (ge *GameEngine) GiveReturnTribute(from, to domain.SeatID, cards []domain.C
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	if !ge.isActionAllowed(from, "return_tribute") {
-		return fmt.Errorf("return tribute action not allowed for player %s", from.String())
+	if err := ge.stateMachine.StartFirstPlay(); err != nil {
+		return err
 	}
 	
-	return ge.stateMachine.GiveReturnTribute(from, to, cards)
+	ge.syncClock(time.Now())
+	return nil
 }
 
-func (ge *GameEngine) PlayCards(seat domain.SeatID, cards []domain.Card) error {
+func (ge *GameEngine) SetDealSource(source DealSource) error {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 	
@@ -179,18 +243,10 @@ func (ge *GameEngine) PlayCards(seat domain.SeatID, cards []domain.Card) error {
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	if !ge.isActionAllowed(seat, "play") {
-		return fmt.Errorf("play action not allowed for player %s", seat.String())
-	}
-	
-	if !ge.isValidPlay(seat, cards) {
-		return fmt.Errorf("invalid play for player %s", seat.String())
-	}
-	
-	return ge.stateMachine.PlayCards(seat, cards)
+	return ge.stateMachine.SetDealSource(source)
 }
 
-func (ge *GameEngine) Pass(seat domain.SeatID) error {
+func (ge *GameEngine) EnableFairShuffle() error {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 	
@@ -198,14 +254,10 @@ func (ge *GameEngine) Pass(seat domain.SeatID) error {
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	if !ge.isActionAllowed(seat, "pass") {
-		return fmt.Errorf("pass action not allowed for player %s", seat.String())
-	}
-	
-	return ge.stateMachine.Pass(seat)
+	return ge.stateMachine.EnableFairShuffle()
 }
 
-func (ge *GameEngine) StartFirstPlay() error {
+func (ge *GameEngine) ContributeEntropy(seat domain.SeatID, entropy []byte) error {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 	
@@ -213,7 +265,18 @@ func (ge *GameEngine) StartFirstPlay() error {
 		return fmt.Errorf("engine not initialized")
 	}
 	
-	return ge.stateMachine.StartFirstPlay()
+	return ge.stateMachine.ContributeEntropy(seat, entropy)
+}
+
+func (ge *GameEngine) GetShuffleCommitment() (int, string) {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+	
+	if !ge.isInitialized {
+		return 0, ""
+	}
+	
+	return ge.stateMachine.GetShuffleCommitment()
 }
 
 func (ge *GameEngine) GetValidPlays(seat domain.SeatID) [][]domain.Card {
@@ -245,12 +308,14 @@ func (ge *GameEngine) GetValidPlays(seat domain.SeatID) [][]domain.Card {
 	return domain.GetPlayableCards(player.GetHand(), tablePlay, dealCtx.Trump)
 }
 
-func (ge *GameEngine) CanPlayCards(seat domain.SeatID, cards []domain.Card) bool {
+// CanPlayCards 检查seat能否出cards（不检查是否轮到seat），不能出时返回原因：
+// domain.ErrCardsNotInHand或CheckFollow的错误
+func (ge *GameEngine) CanPlayCards(seat domain.SeatID, cards []domain.Card) error {
 	ge.mu.RLock()
 	defer ge.mu.RUnlock()
 	
 	if !ge.isInitialized {
-		return false
+		return fmt.Errorf("engine not initialized")
 	}
 	
 	return ge.isValidPlay(seat, cards)
@@ -336,14 +401,73 @@ func (ge *GameEngine) GetPassedPlayers() []domain.SeatID {
 	return passedPlayers
 }
 
-func (ge *GameEngine) isActionAllowed(seat domain.SeatID, action string) bool {
+// GetDealPlays 返回本Deal至今所有出牌与Pass，按顺序；Pass记录的Cards为空
+func (ge *GameEngine) GetDealPlays() []domain.TrickPlay {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+	
+	if !ge.isInitialized {
+		return nil
+	}
+	
+	return ge.stateMachine.GetDealPlays()
+}
+
+// GetPendingSeats 返回当前阶段需要行动的座位：贡牌、还贡阶段可能有多个
+func (ge *GameEngine) GetPendingSeats() []domain.SeatID {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+	
+	if !ge.isInitialized {
+		return nil
+	}
+	
+	return ge.pendingSeats()
+}
+
+// PendingActions 返回每个需要行动的座位当前可以执行的行动类型，已按SetAllowedActions的限制过滤
+func (ge *GameEngine) PendingActions() map[domain.SeatID][]ActionKind {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+	
+	if !ge.isInitialized || ge.stateMachine.GetDealCtx() == nil {
+		return nil
+	}
+	
+	pending := make(map[domain.SeatID][]ActionKind)
+	for seat, kinds := range ge.stateMachine.GetState().PendingActions() {
+		for _, kind := range kinds {
+			if ge.isActionAllowed(seat, kind) {
+				pending[seat] = append(pending[seat], kind)
+			}
+		}
+	}
+	return pending
+}
+
+// GetState 返回当前Deal的State快照，供搜索、模拟在副本上推演，不影响引擎
+func (ge *GameEngine) GetState() (State, error) {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+	
+	if !ge.isInitialized {
+		return State{}, fmt.Errorf("engine not initialized")
+	}
+	if ge.stateMachine.GetDealCtx() == nil {
+		return State{}, fmt.Errorf("no deal in progress")
+	}
+	
+	return ge.stateMachine.GetState(), nil
+}
+
+func (ge *GameEngine) isActionAllowed(seat domain.SeatID, kind ActionKind) bool {
 	allowedActions, exists := ge.allowedActions[seat]
 	if !exists {
 		return true
 	}
 	
 	for _, allowedAction := range allowedActions {
-		if allowedAction == action {
+		if allowedAction == kind {
 			return true
 		}
 	}
@@ -351,27 +475,22 @@ func (ge *GameEngine) isActionAllowed(seat domain.SeatID, action string) bool {
 	return false
 }
 
-func (ge *GameEngine) isValidPlay(seat domain.SeatID, cards []domain.Card) bool {
+func (ge *GameEngine) isValidPlay(seat domain.SeatID, cards []domain.Card) error {
 	matchCtx := ge.stateMachine.GetMatchCtx()
 	dealCtx := ge.stateMachine.GetDealCtx()
 	trickCtx := ge.stateMachine.GetTrickCtx()
 	
 	if matchCtx == nil || dealCtx == nil {
-		return false
+		return &ErrWrongPhase{Phase: ge.stateMachine.GetCurrentPhase(), Op: "play cards"}
 	}
 	
 	player := matchCtx.GetPlayer(seat)
 	if player == nil {
-		return false
-	}
-	
-	if !player.HasCards(cards) {
-		return false
+		return fmt.Errorf("invalid seat: %d", seat)
 	}
 	
-	cardGroup := domain.NewCardGroup(cards)
-	if !cardGroup.IsValid() {
-		return false
+	if missing := domain.MissingCards(player.GetHand(), cards); missing != nil {
+		return &domain.ErrCardsNotInHand{Missing: missing}
 	}
 	
 	var tablePlay *domain.CardGroup
@@ -379,10 +498,11 @@ func (ge *GameEngine) isValidPlay(seat domain.SeatID, cards []domain.Card) bool
 		tablePlay = trickCtx.LastPlay
 	}
 	
-	return domain.CanFollow(cardGroup, tablePlay, dealCtx.Trump)
+	return domain.CheckFollow(domain.NewCardGroup(cards), tablePlay, dealCtx.Trump)
 }
 
-func (ge *GameEngine) SetAllowedActions(seat domain.SeatID, actions []string) {
+// SetAllowedActions 限制seat只能执行actions中的行动类型，ClearAllowedActions取消限制
+func (ge *GameEngine) SetAllowedActions(seat domain.SeatID, actions []ActionKind) {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 	
@@ -432,12 +552,14 @@ func (ge *GameEngine) Reset() {
 	ge.mu.Lock()
 	defer ge.mu.Unlock()
 	
+	ge.stopClock()
+	
 	if ge.stateMachine != nil {
 		ge.stateMachine.Reset()
 	}
 	
 	ge.isInitialized = false
-	ge.allowedActions = make(map[domain.SeatID][]string)
+	ge.allowedActions = make(map[domain.SeatID][]ActionKind)
 }
 
 func (ge *GameEngine) GetStateMachine() *DealStateMachine {
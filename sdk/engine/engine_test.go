@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"errors"
 	"testing"
 	"time"
 	"guandan/sdk/domain"
@@ -104,7 +105,12 @@ func TestGameEngineFullDealFlow(t *testing.T) {
 		t.Errorf("Failed to initialize engine: %v", err)
 	}
 	
-	err = engine.StartDeal(1, domain.SeatEast)
+	err = engine.SetDealSource(eastLeadsDealSource(t))
+	if err != nil {
+		t.Errorf("Failed to set deal source: %v", err)
+	}
+	
+	err = engine.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -172,7 +178,12 @@ func TestGameEnginePlayCards(t *testing.T) {
 		t.Errorf("Failed to initialize engine: %v", err)
 	}
 	
-	err = engine.StartDeal(1, domain.SeatEast)
+	err = engine.SetDealSource(eastLeadsDealSource(t))
+	if err != nil {
+		t.Errorf("Failed to set deal source: %v", err)
+	}
+	
+	err = engine.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -244,7 +255,9 @@ func TestGameEnginePass(t *testing.T) {
 		t.Errorf("Failed to initialize engine: %v", err)
 	}
 	
-	err = engine.StartDeal(1, domain.SeatEast)
+	engine.SetDealSource(eastLeadsDealSource(t))
+	
+	err = engine.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -295,7 +308,9 @@ func TestGameEngineInvalidActions(t *testing.T) {
 	eventBus := event.NewEventBus(100)
 	engine := NewGameEngine(eventBus)
 	
-	err := engine.StartDeal(1, domain.SeatEast)
+	engine.SetDealSource(eastLeadsDealSource(t))
+	
+	err := engine.StartDeal(1, nil)
 	if err == nil {
 		t.Error("Should not be able to start deal on uninitialized engine")
 	}
@@ -361,7 +376,9 @@ func TestGameEngineValidPlays(t *testing.T) {
 		t.Errorf("Failed to initialize engine: %v", err)
 	}
 	
-	err = engine.StartDeal(1, domain.SeatEast)
+	engine.SetDealSource(eastLeadsDealSource(t))
+	
+	err = engine.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -388,11 +405,15 @@ func TestGameEngineValidPlays(t *testing.T) {
 	
 	hand := engine.GetPlayerHand(domain.SeatEast)
 	if len(hand) > 0 {
-		canPlay := engine.CanPlayCards(domain.SeatEast, []domain.Card{hand[0]})
-		if !canPlay {
-			t.Error("Should be able to play a single card in first play")
+		if err := engine.CanPlayCards(domain.SeatEast, []domain.Card{hand[0]}); err != nil {
+			t.Errorf("Should be able to play a single card in first play: %v", err)
 		}
 	}
+	
+	// 两副牌中同一张牌最多两张
+	if err := engine.CanPlayCards(domain.SeatEast, []domain.Card{hand[0], hand[0], hand[0]}); !errors.Is(err, &domain.ErrCardsNotInHand{}) {
+		t.Errorf("Expected ErrCardsNotInHand, got %v", err)
+	}
 }
 
 func TestGameEnginePlayerTurn(t *testing.T) {
@@ -412,7 +433,9 @@ func TestGameEnginePlayerTurn(t *testing.T) {
 		t.Errorf("Failed to initialize engine: %v", err)
 	}
 	
-	err = engine.StartDeal(1, domain.SeatEast)
+	engine.SetDealSource(eastLeadsDealSource(t))
+	
+	err = engine.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -474,7 +497,9 @@ func TestGameEngineActionPermissions(t *testing.T) {
 		t.Errorf("Failed to initialize engine: %v", err)
 	}
 	
-	err = engine.StartDeal(1, domain.SeatEast)
+	engine.SetDealSource(eastLeadsDealSource(t))
+	
+	err = engine.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -494,7 +519,7 @@ func TestGameEngineActionPermissions(t *testing.T) {
 		t.Errorf("Failed to start tribute: %v", err)
 	}
 	
-	engine.SetAllowedActions(domain.SeatEast, []string{"play"})
+	engine.SetAllowedActions(domain.SeatEast, []ActionKind{ActionPlay})
 	
 	hand := engine.GetPlayerHand(domain.SeatEast)
 	if len(hand) > 0 {
@@ -504,7 +529,7 @@ func TestGameEngineActionPermissions(t *testing.T) {
 		}
 	}
 	
-	engine.SetAllowedActions(domain.SeatSouth, []string{"tribute"})
+	engine.SetAllowedActions(domain.SeatSouth, []ActionKind{ActionTribute})
 	
 	hand = engine.GetPlayerHand(domain.SeatSouth)
 	if len(hand) > 0 {
@@ -516,9 +541,16 @@ func TestGameEngineActionPermissions(t *testing.T) {
 	
 	engine.ClearAllowedActions(domain.SeatSouth)
 	
-	hand = engine.GetPlayerHand(domain.SeatSouth)
-	if len(hand) > 0 {
-		err = engine.PlayCards(domain.SeatSouth, []domain.Card{hand[0]})
+	// 选择一张能压过East出牌的单张
+	var beating []domain.Card
+	for _, card := range engine.GetPlayerHand(domain.SeatSouth) {
+		if engine.CanPlayCards(domain.SeatSouth, []domain.Card{card}) == nil {
+			beating = []domain.Card{card}
+			break
+		}
+	}
+	if len(beating) > 0 {
+		err = engine.PlayCards(domain.SeatSouth, beating)
 		if err != nil {
 			t.Errorf("Should be able to play cards when actions are cleared: %v", err)
 		}
@@ -536,12 +568,6 @@ func TestGameEngineEventPublishing(t *testing.T) {
 	eventChan, unsubscribe := eventBus.Subscribe("test-match")
 	defer unsubscribe()
 	
-	go func() {
-		for e := range eventChan {
-			receivedEvents = append(receivedEvents, e)
-		}
-	}()
-	
 	players := []*domain.Player{
 		domain.NewPlayer("p1", "Player1", domain.SeatEast),
 		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
@@ -555,11 +581,14 @@ func TestGameEngineEventPublishing(t *testing.T) {
 		t.Errorf("Failed to initialize engine: %v", err)
 	}
 	
-	err = engine.StartDeal(1, domain.SeatEast)
+	engine.SetDealSource(eastLeadsDealSource(t))
+	
+	err = engine.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
 	
+	receivedEvents = collectEvents(eventChan, receivedEvents, 1)
 	if len(receivedEvents) < 1 {
 		t.Error("Should have received DealStarted event")
 	}
@@ -569,10 +598,16 @@ func TestGameEngineEventPublishing(t *testing.T) {
 		t.Errorf("Failed to deal cards: %v", err)
 	}
 	
+	receivedEvents = collectEvents(eventChan, receivedEvents, 2)
 	if len(receivedEvents) < 2 {
 		t.Error("Should have received CardsDealt event")
 	}
 	
+	err = engine.DetermineTrump()
+	if err != nil {
+		t.Errorf("Failed to determine trump: %v", err)
+	}
+	
 	err = engine.StartTribute()
 	if err != nil {
 		t.Errorf("Failed to start tribute: %v", err)
@@ -586,6 +621,7 @@ func TestGameEngineEventPublishing(t *testing.T) {
 		}
 	}
 	
+	receivedEvents = collectEvents(eventChan, receivedEvents, 3)
 	if len(receivedEvents) < 3 {
 		t.Error("Should have received CardsPlayed event")
 	}
@@ -595,7 +631,48 @@ func TestGameEngineEventPublishing(t *testing.T) {
 		t.Errorf("Failed to pass: %v", err)
 	}
 	
+	receivedEvents = collectEvents(eventChan, receivedEvents, 4)
 	if len(receivedEvents) < 4 {
 		t.Error("Should have received PlayerPassed event")
 	}
-}
\ No newline at end of file
+}
+
+func TestGameEngineApplyAndPendingActions(t *testing.T) {
+	engine, _ := newPlayingState(t)
+	
+	pending := engine.PendingActions()
+	if len(pending) != 1 || len(pending[domain.SeatEast]) != 1 || pending[domain.SeatEast][0] != ActionPlay {
+		t.Fatalf("Expected East to lead with Play only, got %v", pending)
+	}
+	
+	hand := engine.GetPlayerHand(domain.SeatEast)
+	if err := engine.Apply(Action{Kind: ActionPass, Seat: domain.SeatEast}); err == nil {
+		t.Error("Leader should not be able to pass")
+	}
+	if err := engine.Apply(Action{Kind: ActionTribute, Seat: domain.SeatEast, Cards: hand[:1], Target: domain.SeatWest}); err == nil {
+		t.Error("Tribute should be rejected during play")
+	}
+	
+	if err := engine.Apply(Action{Kind: ActionPlay, Seat: domain.SeatEast, Cards: hand[:1]}); err != nil {
+		t.Fatalf("Failed to apply play: %v", err)
+	}
+	
+	pending = engine.PendingActions()
+	kinds := pending[domain.SeatSouth]
+	if len(pending) != 1 || len(kinds) != 2 || kinds[0] != ActionPlay || kinds[1] != ActionPass {
+		t.Fatalf("Expected South to follow with Play or Pass, got %v", pending)
+	}
+	
+	engine.SetAllowedActions(domain.SeatSouth, []ActionKind{ActionPass})
+	kinds = engine.PendingActions()[domain.SeatSouth]
+	if len(kinds) != 1 || kinds[0] != ActionPass {
+		t.Errorf("Allowed actions should restrict pending actions, got %v", kinds)
+	}
+	
+	if err := engine.Apply(Action{Kind: ActionPass, Seat: domain.SeatSouth}); err != nil {
+		t.Fatalf("Failed to apply pass: %v", err)
+	}
+	if engine.GetCurrentPlayer() != domain.SeatWest {
+		t.Errorf("Expected West to act after South passed, got %s", engine.GetCurrentPlayer())
+	}
+}
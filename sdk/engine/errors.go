@@ -0,0 +1,27 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotYourTurn 非当前行动座位尝试出牌或Pass
+var ErrNotYourTurn = errors.New("not player's turn")
+
+// ErrLeaderCannotPass 一轮的首家必须出牌
+var ErrLeaderCannotPass = errors.New("trick leader cannot pass")
+
+// ErrWrongPhase 当前阶段不允许该操作。Is按类型匹配，例如errors.Is(err, &ErrWrongPhase{})
+type ErrWrongPhase struct {
+	Phase DealPhase
+	Op    string // 被拒绝的操作，如"play cards"
+}
+
+func (e *ErrWrongPhase) Error() string {
+	return fmt.Sprintf("cannot %s from phase %s", e.Op, e.Phase.String())
+}
+
+func (e *ErrWrongPhase) Is(target error) bool {
+	_, ok := target.(*ErrWrongPhase)
+	return ok
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"fmt"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// fairShuffle 保存已承诺但尚未揭示的服务器种子，以及各座位提交的客户端熵
+type fairShuffle struct {
+	dealNumber  int
+	serverSeed  []byte
+	commitment  string
+	clientSeeds map[domain.SeatID][]byte
+	shuffled    bool // 已用于发牌，之后提交的熵不会进入洗牌
+}
+
+// EnableFairShuffle 开启承诺-揭示洗牌，并立即为下一Deal公布服务器种子承诺
+func (sm *DealStateMachine) EnableFairShuffle() error {
+	if sm.fairShuffle != nil {
+		return nil
+	}
+	
+	if _, seeded := sm.dealSource.(*SeededDealSource); !seeded {
+		return fmt.Errorf("cannot enable fair shuffle with a custom deal source")
+	}
+	
+	switch sm.currentPhase {
+	case PhaseIdle, PhaseFinished:
+		nextDeal := 1
+		if sm.dealCtx != nil {
+			nextDeal = sm.dealCtx.DealNumber + 1
+		}
+		return sm.commitShuffle(nextDeal)
+	case PhaseCreated:
+		return sm.commitShuffle(sm.dealCtx.DealNumber)
+	default:
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "enable fair shuffle"}
+	}
+}
+
+// IsFairShuffle 是否处于承诺-揭示洗牌模式
+func (sm *DealStateMachine) IsFairShuffle() bool {
+	return sm.fairShuffle != nil
+}
+
+// ContributeEntropy 座位为下一次洗牌提交客户端熵（每Deal每座位一次，只能在发牌前提交）
+func (sm *DealStateMachine) ContributeEntropy(seat domain.SeatID, entropy []byte) error {
+	if sm.fairShuffle == nil {
+		return fmt.Errorf("fair shuffle not enabled")
+	}
+	
+	// 洗牌后提交的熵不会被使用，揭示时反而会让诚实的发牌无法通过校验
+	if sm.fairShuffle.shuffled {
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "contribute entropy"}
+	}
+	
+	if !seat.IsValid() {
+		return fmt.Errorf("invalid seat %d", seat)
+	}
+	
+	if len(entropy) == 0 || len(entropy) > domain.MaxClientEntropy {
+		return fmt.Errorf("entropy must be 1-%d bytes, got %d", domain.MaxClientEntropy, len(entropy))
+	}
+	
+	if _, exists := sm.fairShuffle.clientSeeds[seat]; exists {
+		return fmt.Errorf("player %s already contributed entropy for deal %d", seat.String(), sm.fairShuffle.dealNumber)
+	}
+	
+	contributed := make([]byte, len(entropy))
+	copy(contributed, entropy)
+	sm.fairShuffle.clientSeeds[seat] = contributed
+	
+	return nil
+}
+
+// GetShuffleCommitment 返回当前待用的服务器种子承诺及其对应的Deal编号
+func (sm *DealStateMachine) GetShuffleCommitment() (int, string) {
+	if sm.fairShuffle == nil {
+		return 0, ""
+	}
+	return sm.fairShuffle.dealNumber, sm.fairShuffle.commitment
+}
+
+func (sm *DealStateMachine) commitShuffle(dealNumber int) error {
+	serverSeed, err := domain.GenerateServerSeed()
+	if err != nil {
+		return err
+	}
+	
+	sm.fairShuffle = &fairShuffle{
+		dealNumber:  dealNumber,
+		serverSeed:  serverSeed,
+		commitment:  domain.CommitServerSeed(serverSeed),
+		clientSeeds: make(map[domain.SeatID][]byte),
+	}
+	
+	sm.eventBus.Publish(event.NewShuffleCommittedEvent(
+		sm.matchCtx.ID,
+		dealNumber,
+		sm.fairShuffle.commitment,
+	))
+	
+	return nil
+}
+
+func (sm *DealStateMachine) revealShuffle() {
+	sm.eventBus.Publish(event.NewShuffleRevealedEvent(
+		sm.matchCtx.ID,
+		sm.fairShuffle.dealNumber,
+		sm.fairShuffle.commitment,
+		sm.fairShuffle.serverSeed,
+		sm.fairShuffle.clientSeeds,
+	))
+}
+
+// VerifyDeal 用揭示的种子重算手牌，校验DealEndedEvent中记录的发牌是否真实
+func VerifyDeal(ended *event.DealEndedEvent, revealed *event.ShuffleRevealedEvent) error {
+	if ended == nil || revealed == nil {
+		return fmt.Errorf("deal ended and shuffle revealed events are both required")
+	}
+	
+	if ended.DealNumber != revealed.DealNumber {
+		return fmt.Errorf("deal number mismatch: ended %d, revealed %d", ended.DealNumber, revealed.DealNumber)
+	}
+	
+	if ended.ShuffleCommitment != revealed.Commitment {
+		return fmt.Errorf("commitment mismatch: ended %s, revealed %s", ended.ShuffleCommitment, revealed.Commitment)
+	}
+	
+	return domain.VerifyFairDeal(ended.DealtHands, ended.ShuffleCommitment, revealed.ServerSeed, revealed.ClientSeeds)
+}
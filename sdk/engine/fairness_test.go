@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+func TestFairShuffleVerifiesAfterDeal(t *testing.T) {
+	eventBus := event.NewEventBus(100)
+	eventBus.Start()
+	defer eventBus.Stop()
+
+	eventChan, unsubscribe := eventBus.Subscribe("test-match")
+	defer unsubscribe()
+
+	engine := NewGameEngine(eventBus)
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	if err := engine.Initialize(domain.NewMatchCtx("test-match", players, 12345)); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	if err := engine.EnableFairShuffle(); err != nil {
+		t.Fatalf("Failed to enable fair shuffle: %v", err)
+	}
+
+	// 发牌前的熵都会进入洗牌
+	if err := engine.ContributeEntropy(domain.SeatSouth, []byte("south")); err != nil {
+		t.Fatalf("Failed to contribute entropy before the deal: %v", err)
+	}
+	if err := engine.StartDeal(1, nil); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	if err := engine.ContributeEntropy(domain.SeatWest, []byte("west")); err != nil {
+		t.Fatalf("Failed to contribute entropy before the shuffle: %v", err)
+	}
+	if err := engine.DealCards(); err != nil {
+		t.Fatalf("Failed to deal cards: %v", err)
+	}
+
+	// 洗牌之后的熵被拒绝
+	if err := engine.ContributeEntropy(domain.SeatNorth, []byte("north")); !errors.Is(err, &ErrWrongPhase{}) {
+		t.Fatalf("Entropy after the shuffle should be rejected with ErrWrongPhase, got %v", err)
+	}
+
+	if err := engine.DetermineTrump(); err != nil {
+		t.Fatalf("Failed to determine trump: %v", err)
+	}
+	if err := engine.StartTribute(); err != nil {
+		t.Fatalf("Failed to start tribute: %v", err)
+	}
+
+	for step := 0; step < 1000 && engine.GetCurrentPhase() != PhaseFinished; step++ {
+		state, err := engine.GetState()
+		if err != nil {
+			t.Fatalf("Failed to get state: %v", err)
+		}
+		actions := state.LegalActions()
+		if len(actions) == 0 {
+			t.Fatalf("No legal actions in phase %s", state.Phase)
+		}
+		action := actions[0]
+		if last := actions[len(actions)-1]; last.Kind == ActionPass {
+			action = last
+		}
+		if err := engine.Apply(action); err != nil {
+			t.Fatalf("Legal action %s was rejected: %v", action, err)
+		}
+	}
+	if engine.GetCurrentPhase() != PhaseFinished {
+		t.Fatal("Deal should end")
+	}
+
+	// 下一Deal已重新承诺，可以继续提交熵
+	if err := engine.ContributeEntropy(domain.SeatNorth, []byte("north")); err != nil {
+		t.Errorf("Failed to contribute entropy for the next deal: %v", err)
+	}
+
+	var ended *event.DealEndedEvent
+	var revealed *event.ShuffleRevealedEvent
+	collectEventsUntil(eventChan, nil, func(e event.DomainEvent) bool {
+		switch ev := e.(type) {
+		case *event.DealEndedEvent:
+			ended = ev
+		case *event.ShuffleRevealedEvent:
+			revealed = ev
+		}
+		return ended != nil && revealed != nil
+	})
+	if ended == nil || revealed == nil {
+		t.Fatal("Expected DealEndedEvent and ShuffleRevealedEvent")
+	}
+
+	if len(revealed.ClientSeeds) != 2 {
+		t.Errorf("Expected the two pre-shuffle contributions to be revealed, got %d", len(revealed.ClientSeeds))
+	}
+	if err := VerifyDeal(ended, revealed); err != nil {
+		t.Errorf("Honest deal should verify: %v", err)
+	}
+}
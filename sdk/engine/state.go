@@ -0,0 +1,684 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// State 一个Deal从定主之后到排名确定的完整规则状态：四家手牌、级数、贡牌信息、当前一轮与出牌记录。
+// State是值类型，Apply不修改接收者，也不发布事件，而是返回新的State和应发布的事件，
+// 因此可以在搜索、模拟中廉价地复制和回溯。
+// 所有转换都只替换切片、map和上下文指针而不原地修改，Clone只需复制手牌
+type State struct {
+	MatchID            domain.MatchID
+	Phase              DealPhase
+	Levels             [2]domain.Rank    // 按TeamID索引的级数
+	Hands              [4][]domain.Card  // 按座位索引的手牌
+	Deal               *domain.DealCtx
+	Trick              *domain.TrickCtx  // 出牌阶段之前为nil
+	Plays              []domain.TrickPlay // 本Deal所有出牌与Pass，按顺序
+	DealtHands         map[domain.SeatID][]domain.Card // 发牌时的手牌，随DealEnded事件公开
+	StartingCardHolder domain.SeatID     // 首Deal中持有Starting Card的座位
+}
+
+// Clone 复制State，副本的手牌可以独立修改
+func (s State) Clone() State {
+	clone := s
+	for seat := range s.Hands {
+		clone.Hands[seat] = append([]domain.Card(nil), s.Hands[seat]...)
+	}
+	return clone
+}
+
+// IsOver 本Deal排名是否已确定
+func (s State) IsOver() bool {
+	return s.Phase == PhaseRankList || s.Phase == PhaseFinished
+}
+
+// PendingSeats 当前阶段需要行动的座位
+func (s State) PendingSeats() []domain.SeatID {
+	var seats []domain.SeatID
+	switch s.Phase {
+	case PhaseTribute:
+		for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+			_, required := s.Deal.TributeInfo.TributeRequests[seat]
+			_, given := s.Deal.TributeInfo.GivenTributes[seat]
+			if required && !given {
+				seats = append(seats, seat)
+			}
+		}
+	case PhaseTributeSelection:
+		if len(s.Deal.LastRankings) == 4 {
+			seats = append(seats, s.Deal.LastRankings[0])
+		}
+	case PhaseReturnTribute:
+		for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+			_, required := s.Deal.TributeInfo.ReturnRequests[seat]
+			_, returned := s.Deal.TributeInfo.ReturnedTributes[seat]
+			if required && !returned {
+				seats = append(seats, seat)
+			}
+		}
+	case PhaseFirstPlay, PhaseInProgress:
+		if s.Trick != nil {
+			seats = append(seats, s.Trick.CurrentPlayer)
+		}
+	}
+	return seats
+}
+
+// PendingActions 每个需要行动的座位当前可以执行的行动类型，跟牌时出牌与Pass均可
+func (s State) PendingActions() map[domain.SeatID][]ActionKind {
+	pending := make(map[domain.SeatID][]ActionKind)
+	for _, seat := range s.PendingSeats() {
+		switch s.Phase {
+		case PhaseTribute:
+			pending[seat] = []ActionKind{ActionTribute}
+		case PhaseTributeSelection:
+			pending[seat] = []ActionKind{ActionSelectTribute}
+		case PhaseReturnTribute:
+			pending[seat] = []ActionKind{ActionReturnTribute}
+		case PhaseFirstPlay, PhaseInProgress:
+			pending[seat] = []ActionKind{ActionPlay}
+			if s.Trick.LastPlay != nil {
+				pending[seat] = append(pending[seat], ActionPass)
+			}
+		}
+	}
+	return pending
+}
+
+// LegalActions 所有需要行动的座位当前可以执行的行动。
+// 出牌时每种可出的牌组为一个行动（花色不同的同型牌分别列出），跟牌时包含Pass
+func (s State) LegalActions() []Action {
+	var actions []Action
+	switch s.Phase {
+	case PhaseTribute:
+		for _, seat := range s.PendingSeats() {
+			if card, ok := domain.SelectTributeCard(s.Hands[seat], s.Deal.Trump); ok {
+				to := s.Deal.TributeInfo.TributeRequests[seat]
+				actions = append(actions, Action{Kind: ActionTribute, Seat: seat, Cards: []domain.Card{card}, Target: to})
+			}
+		}
+	case PhaseTributeSelection:
+		for _, seat := range s.PendingSeats() {
+			for _, giver := range s.Deal.LastRankings[2:] {
+				if _, ok := s.Deal.TributeInfo.AvailableCards[giver]; ok {
+					actions = append(actions, Action{Kind: ActionSelectTribute, Seat: seat, Target: giver})
+				}
+			}
+		}
+	case PhaseReturnTribute:
+		for _, seat := range s.PendingSeats() {
+			to := s.Deal.TributeInfo.ReturnRequests[seat]
+			seen := make(map[domain.Card]bool)
+			for _, card := range s.Hands[seat] {
+				if seen[card] || !domain.IsValidReturnTributeCard(s.Hands[seat], card) {
+					continue
+				}
+				seen[card] = true
+				actions = append(actions, Action{Kind: ActionReturnTribute, Seat: seat, Cards: []domain.Card{card}, Target: to})
+			}
+		}
+	case PhaseFirstPlay, PhaseInProgress:
+		seat := s.Trick.CurrentPlayer
+		for _, cards := range domain.GetPlayableCards(s.Hands[seat], s.Trick.LastPlay, s.Deal.Trump) {
+			actions = append(actions, Action{Kind: ActionPlay, Seat: seat, Cards: cards})
+		}
+		if s.Trick.LastPlay != nil {
+			actions = append(actions, Action{Kind: ActionPass, Seat: seat})
+		}
+	}
+	return actions
+}
+
+// Apply 执行一次行动，返回新的State与按发生顺序排列的事件。
+// 行动非法时返回原State和错误；接收者在任何情况下都不会被修改
+func (s State) Apply(action Action) (State, []event.DomainEvent, error) {
+	next := s
+	var events []event.DomainEvent
+	var err error
+	
+	switch action.Kind {
+	case ActionPlay:
+		events, err = next.playCards(action.Seat, action.Cards)
+	case ActionPass:
+		events, err = next.pass(action.Seat)
+	case ActionTribute:
+		events, err = next.giveTribute(action.Seat, action.Target, action.Cards)
+	case ActionSelectTribute:
+		events, err = next.selectTributeCard(action.Seat, action.Target)
+	case ActionReturnTribute:
+		events, err = next.giveReturnTribute(action.Seat, action.Target, action.Cards)
+	default:
+		err = fmt.Errorf("unknown action kind %d", action.Kind)
+	}
+	
+	if err != nil {
+		return s, nil, err
+	}
+	return next, events, nil
+}
+
+// 以下转换方法只在Apply得到的副本上调用，规则与原DealStateMachine一致
+
+// startTribute 定主之后开始贡牌，首Deal或抗贡时直接进入首次出牌
+func (s *State) startTribute() ([]event.DomainEvent, error) {
+	if s.Phase != PhaseTrumpDecision {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "start tribute"}
+	}
+	
+	if s.Deal.IsFirstDeal {
+		return s.skipTribute()
+	}
+	
+	// 计算每个玩家的大王数量
+	playerBigJokers := make(map[domain.SeatID]int)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		playerBigJokers[seat] = domain.CountBigJokers(s.Hands[seat])
+	}
+	
+	s.Deal = s.Deal.InitializeTribute(playerBigJokers)
+	if s.Deal.TributeInfo.HasImmunity {
+		return s.skipTribute()
+	}
+	
+	tributeRequirements := make(map[domain.SeatID]int)
+	for from := range s.Deal.TributeInfo.TributeRequests {
+		tributeRequirements[from] = 1 // 每人贡1张牌
+	}
+	
+	s.Deal = s.Deal.WithState(domain.DealStateTribute)
+	s.Phase = PhaseTribute
+	
+	return []event.DomainEvent{event.NewTributeRequestedEvent(s.MatchID, tributeRequirements)}, nil
+}
+
+func (s *State) skipTribute() ([]event.DomainEvent, error) {
+	s.Deal = s.Deal.WithTributeGiven(true)
+	return s.startFirstPlay()
+}
+
+// withTributeInfo 修改贡牌信息的副本，保证不影响之前的State
+func (s *State) withTributeInfo(modify func(info *domain.TributeInfo) error) error {
+	info := s.Deal.TributeInfo.Clone()
+	if err := modify(info); err != nil {
+		return err
+	}
+	s.Deal = s.Deal.WithTributeInfo(info)
+	return nil
+}
+
+func (s *State) giveTribute(from, to domain.SeatID, cards []domain.Card) ([]event.DomainEvent, error) {
+	if s.Phase != PhaseTribute {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "give tribute"}
+	}
+	
+	if len(cards) != 1 {
+		return nil, fmt.Errorf("must tribute exactly one card")
+	}
+	if !from.IsValid() || !to.IsValid() {
+		return nil, fmt.Errorf("invalid player seats")
+	}
+	
+	// 验证贡牌关系
+	info := s.Deal.TributeInfo
+	expectedTo, exists := info.TributeRequests[from]
+	if !exists {
+		return nil, fmt.Errorf("player %s is not required to give tribute", from.String())
+	}
+	if expectedTo != to {
+		return nil, fmt.Errorf("player %s should give tribute to %s, not %s", from.String(), expectedTo.String(), to.String())
+	}
+	if _, given := info.GivenTributes[from]; given {
+		return nil, fmt.Errorf("player %s has already given tribute", from.String())
+	}
+	
+	// 验证贡牌是否符合规则（除了红桃trump外最大的牌）
+	card := cards[0]
+	if err := domain.ValidateTributeCard(s.Hands[from], card, s.Deal.Trump); err != nil {
+		return nil, fmt.Errorf("invalid tribute card: %w", err)
+	}
+	
+	// Double Down的贡牌先进入待选池，由第一名选择后再分配
+	s.Hands[from], _ = removeCards(s.Hands[from], cards)
+	if info.Scenario != domain.TributeScenarioDoubleDown {
+		s.Hands[to] = addCards(s.Hands[to], cards)
+	}
+	
+	deal := *s.Deal
+	deal.TributeCards = make(map[domain.SeatID][]domain.Card, len(s.Deal.TributeCards)+1)
+	for seat, given := range s.Deal.TributeCards {
+		deal.TributeCards[seat] = given
+	}
+	deal.TributeCards[from] = cards
+	s.Deal = &deal
+	
+	var complete bool
+	s.withTributeInfo(func(info *domain.TributeInfo) error {
+		info.GivenTributes[from] = card
+		if complete = info.IsTributeComplete(); complete {
+			info.Phase = domain.TributePhaseGiving
+		}
+		return nil
+	})
+	
+	events := []event.DomainEvent{event.NewTributeGivenEvent(s.MatchID, from, to, cards)}
+	if !complete {
+		return events, nil
+	}
+	
+	// Double Down场景需要进入选择阶段
+	var more []event.DomainEvent
+	var err error
+	if s.Deal.TributeInfo.Scenario == domain.TributeScenarioDoubleDown {
+		more, err = s.startTributeSelection()
+	} else {
+		more, err = s.startReturnTribute()
+	}
+	return append(events, more...), err
+}
+
+// startTributeSelection 开始Double Down贡牌选择阶段
+func (s *State) startTributeSelection() ([]event.DomainEvent, error) {
+	if s.Phase != PhaseTribute {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "start tribute selection"}
+	}
+	if s.Deal.TributeInfo.Scenario != domain.TributeScenarioDoubleDown {
+		return nil, fmt.Errorf("tribute selection only available for Double Down scenario")
+	}
+	if len(s.Deal.LastRankings) != 4 {
+		return nil, fmt.Errorf("tribute selection requires complete last rankings")
+	}
+	
+	s.withTributeInfo(func(info *domain.TributeInfo) error {
+		info.PrepareDoubleDownSelection(s.Deal.LastRankings)
+		return nil
+	})
+	s.Phase = PhaseTributeSelection
+	
+	first := s.Deal.LastRankings[0]
+	return []event.DomainEvent{event.NewTributeSelectionRequestedEvent(
+		s.MatchID,
+		first,
+		s.Deal.TributeInfo.AvailableCards,
+	)}, nil
+}
+
+// selectTributeCard 第一名在Double Down场景中选择giver的贡牌，余下的贡牌归第二名
+func (s *State) selectTributeCard(selector, giver domain.SeatID) ([]event.DomainEvent, error) {
+	if s.Phase != PhaseTributeSelection {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "select tribute card"}
+	}
+	if s.Deal.TributeInfo.Scenario != domain.TributeScenarioDoubleDown {
+		return nil, fmt.Errorf("tribute selection only available for Double Down scenario")
+	}
+	
+	lastRankings := s.Deal.LastRankings
+	first, second, third, fourth := lastRankings[0], lastRankings[1], lastRankings[2], lastRankings[3]
+	if selector != first {
+		return nil, fmt.Errorf("player %s cannot select tribute card, %s selects", selector.String(), first.String())
+	}
+	
+	available := s.Deal.TributeInfo.AvailableCards
+	selectedCard := available[giver]
+	remainingGiver := third
+	if giver == third {
+		remainingGiver = fourth
+	}
+	remainingCard := available[remainingGiver]
+	
+	err := s.withTributeInfo(func(info *domain.TributeInfo) error {
+		return info.SelectTributeCardForDoubleDown(giver, lastRankings)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to select tribute card: %w", err)
+	}
+	
+	s.Hands[first] = addCards(s.Hands[first], []domain.Card{selectedCard})
+	s.Hands[second] = addCards(s.Hands[second], []domain.Card{remainingCard})
+	
+	events := []event.DomainEvent{event.NewTributeCardSelectedEvent(
+		s.MatchID,
+		first,
+		giver,
+		selectedCard,
+		second,
+		remainingCard,
+	)}
+	
+	more, err := s.startReturnTribute()
+	return append(events, more...), err
+}
+
+// startReturnTribute 开始还贡阶段，无需还贡时直接开始首次出牌
+func (s *State) startReturnTribute() ([]event.DomainEvent, error) {
+	if s.Phase != PhaseTribute && s.Phase != PhaseTributeSelection {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "start return tribute"}
+	}
+	
+	info := s.Deal.TributeInfo
+	if info.HasImmunity || len(info.ReturnRequests) == 0 {
+		s.withTributeInfo(func(info *domain.TributeInfo) error {
+			info.Phase = domain.TributePhaseCompleted
+			return nil
+		})
+		s.Deal = s.Deal.WithTributeGiven(true)
+		return s.startFirstPlay()
+	}
+	
+	s.Deal = s.Deal.WithState(domain.DealStateReturnTribute)
+	s.Phase = PhaseReturnTribute
+	s.withTributeInfo(func(info *domain.TributeInfo) error {
+		info.Phase = domain.TributePhaseReturning
+		return nil
+	})
+	return nil, nil
+}
+
+func (s *State) giveReturnTribute(from, to domain.SeatID, cards []domain.Card) ([]event.DomainEvent, error) {
+	if s.Phase != PhaseReturnTribute {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "give return tribute"}
+	}
+	
+	if len(cards) != 1 {
+		return nil, fmt.Errorf("must return exactly one card")
+	}
+	if !from.IsValid() || !to.IsValid() {
+		return nil, fmt.Errorf("invalid player seats")
+	}
+	
+	// 验证还贡关系
+	info := s.Deal.TributeInfo
+	expectedTo, exists := info.ReturnRequests[from]
+	if !exists {
+		return nil, fmt.Errorf("player %s is not required to give return tribute", from.String())
+	}
+	if expectedTo != to {
+		return nil, fmt.Errorf("player %s should give return tribute to %s, not %s", from.String(), expectedTo.String(), to.String())
+	}
+	if _, returned := info.ReturnedTributes[from]; returned {
+		return nil, fmt.Errorf("player %s has already given return tribute", from.String())
+	}
+	
+	// 验证还贡牌是否符合规则（点数<=10）
+	card := cards[0]
+	if !domain.IsValidReturnTributeCard(s.Hands[from], card) {
+		return nil, fmt.Errorf("invalid return tribute card: must be <= 10 points")
+	}
+	
+	s.Hands[from], _ = removeCards(s.Hands[from], cards)
+	s.Hands[to] = addCards(s.Hands[to], cards)
+	
+	var complete bool
+	s.withTributeInfo(func(info *domain.TributeInfo) error {
+		info.ReturnedTributes[from] = card
+		if complete = info.IsReturnComplete(); complete {
+			info.Phase = domain.TributePhaseCompleted
+		}
+		return nil
+	})
+	
+	events := []event.DomainEvent{event.NewTributeGivenEvent(s.MatchID, from, to, cards)}
+	if !complete {
+		return events, nil
+	}
+	
+	s.Deal = s.Deal.WithTributeGiven(true)
+	more, err := s.startFirstPlay()
+	return append(events, more...), err
+}
+
+func (s *State) startFirstPlay() ([]event.DomainEvent, error) {
+	if s.Phase != PhaseTribute && s.Phase != PhaseReturnTribute && s.Phase != PhaseTrumpDecision &&
+		s.Phase != PhaseTributeSelection {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "start first play"}
+	}
+	
+	// 确定首出者并更新DealCtx中的FirstPlayer
+	firstPlayer := domain.DetermineFirstPlayer(nil, s.Deal, s.StartingCardHolder)
+	deal := *s.Deal
+	deal.FirstPlayer = firstPlayer
+	s.Deal = &deal
+	
+	s.Deal = s.Deal.WithState(domain.DealStateFirstPlay)
+	s.Phase = PhaseFirstPlay
+	
+	s.Deal = s.Deal.WithTrickCount(1)
+	s.Trick = domain.NewTrickCtx(1, firstPlayer)
+	
+	return []event.DomainEvent{event.NewFirstPlayerDeterminedEvent(s.MatchID, firstPlayer)}, nil
+}
+
+func (s *State) transitionToInProgress() error {
+	if s.Phase != PhaseFirstPlay {
+		return &ErrWrongPhase{Phase: s.Phase, Op: "transition to in progress"}
+	}
+	
+	s.Deal = s.Deal.WithState(domain.DealStateInProgress)
+	s.Phase = PhaseInProgress
+	return nil
+}
+
+func (s *State) startNewTrick(startPlayer domain.SeatID) error {
+	if s.Phase != PhaseInProgress {
+		return &ErrWrongPhase{Phase: s.Phase, Op: "start new trick"}
+	}
+	
+	s.Deal = s.Deal.WithTrickCount(s.Deal.TrickCount + 1)
+	s.Trick = domain.NewTrickCtx(s.Deal.TrickCount, startPlayer)
+	return nil
+}
+
+func (s *State) playCards(seat domain.SeatID, cards []domain.Card) ([]event.DomainEvent, error) {
+	if s.Phase != PhaseFirstPlay && s.Phase != PhaseInProgress {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "play cards"}
+	}
+	
+	if s.Trick.CurrentPlayer != seat {
+		return nil, ErrNotYourTurn
+	}
+	
+	hand, ok := removeCards(s.Hands[seat], cards)
+	if !ok {
+		return nil, &domain.ErrCardsNotInHand{Missing: domain.MissingCards(s.Hands[seat], cards)}
+	}
+	
+	cardGroup := domain.NewCardGroup(cards)
+	if err := domain.CheckFollow(cardGroup, s.Trick.LastPlay, s.Deal.Trump); err != nil {
+		return nil, err
+	}
+	
+	s.Hands[seat] = hand
+	s.Trick = s.Trick.WithLastPlay(cardGroup, seat)
+	s.Trick = s.Trick.WithPassesCleared()
+	
+	trickPlay := domain.TrickPlay{
+		Player:    seat,
+		Cards:     cards,
+		CardGroup: cardGroup,
+		Timestamp: time.Now(),
+	}
+	s.Trick = s.Trick.WithPlayHistory(trickPlay)
+	s.Plays = append(s.Plays[:len(s.Plays):len(s.Plays)], trickPlay)
+	
+	events := []event.DomainEvent{event.NewCardsPlayedEvent(s.MatchID, seat, cards, cardGroup)}
+	
+	if s.Phase == PhaseFirstPlay {
+		s.transitionToInProgress()
+	}
+	
+	if len(hand) == 0 {
+		events = append(events, s.handlePlayerFinished(seat)...)
+		if s.Phase != PhaseInProgress {
+			return events, nil
+		}
+	}
+	
+	s.Trick = s.Trick.WithCurrentPlayer(s.nextActiveSeat(seat))
+	return events, nil
+}
+
+func (s *State) pass(seat domain.SeatID) ([]event.DomainEvent, error) {
+	if s.Phase != PhaseInProgress {
+		return nil, &ErrWrongPhase{Phase: s.Phase, Op: "pass"}
+	}
+	
+	if s.Trick.CurrentPlayer != seat {
+		return nil, ErrNotYourTurn
+	}
+	
+	// 首家必须出牌
+	if s.Trick.LastPlay == nil {
+		return nil, ErrLeaderCannotPass
+	}
+	
+	s.Trick = s.Trick.WithPlayerPassed(seat)
+	
+	// Pass也记入出牌记录，供推断手牌使用
+	trickPlay := domain.TrickPlay{Player: seat, Timestamp: time.Now()}
+	s.Trick = s.Trick.WithPlayHistory(trickPlay)
+	s.Plays = append(s.Plays[:len(s.Plays):len(s.Plays)], trickPlay)
+	
+	events := []event.DomainEvent{event.NewPlayerPassedEvent(s.MatchID, seat)}
+	
+	if s.trickShouldFinish() {
+		more, err := s.finishTrick()
+		return append(events, more...), err
+	}
+	
+	s.Trick = s.Trick.WithCurrentPlayer(s.nextActiveSeat(seat))
+	return events, nil
+}
+
+// trickShouldFinish 最后出牌者之外仍有手牌的玩家都已Pass时本轮结束
+func (s *State) trickShouldFinish() bool {
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if seat == s.Trick.LastPlayer || s.isSeatFinished(seat) {
+			continue
+		}
+		if !s.Trick.HasPlayerPassed(seat) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSeatFinished 座位是否已出完手牌
+func (s *State) isSeatFinished(seat domain.SeatID) bool {
+	for _, finished := range s.Deal.RankList {
+		if finished == seat {
+			return true
+		}
+	}
+	return false
+}
+
+// nextActiveSeat 按出牌顺序返回seat之后第一个仍有手牌的座位
+func (s *State) nextActiveSeat(seat domain.SeatID) domain.SeatID {
+	next := seat.Next()
+	for i := 0; i < 3 && s.isSeatFinished(next); i++ {
+		next = next.Next()
+	}
+	return next
+}
+
+func (s *State) finishTrick() ([]event.DomainEvent, error) {
+	winner := s.Trick.LastPlayer
+	s.Trick = s.Trick.WithWinner(winner)
+	
+	events := []event.DomainEvent{event.NewTrickWonEvent(s.MatchID, winner, s.Trick.TrickNumber)}
+	
+	if s.shouldFinishDeal() {
+		return append(events, s.finishDeal()...), nil
+	}
+	
+	// 赢家已出完牌时由对家接风，对家也已出完则由下家出牌
+	leader := winner
+	if s.isSeatFinished(winner) {
+		leader = winner.Opposite()
+		if s.isSeatFinished(leader) {
+			leader = s.nextActiveSeat(winner)
+		}
+	}
+	
+	return events, s.startNewTrick(leader)
+}
+
+func (s *State) handlePlayerFinished(seat domain.SeatID) []event.DomainEvent {
+	position := len(s.Deal.RankList) + 1
+	s.Deal = s.Deal.AddToRankList(seat)
+	
+	events := []event.DomainEvent{event.NewPlayerFinishedEvent(s.MatchID, seat, position)}
+	
+	if s.shouldFinishDeal() {
+		events = append(events, s.finishDeal()...)
+	}
+	return events
+}
+
+// shouldFinishDeal 三家出完，或同一队两家率先出完（双上）时本Deal结束
+func (s *State) shouldFinishDeal() bool {
+	rankList := s.Deal.RankList
+	if len(rankList) >= 3 {
+		return true
+	}
+	return len(rankList) == 2 && domain.GetTeamFromSeat(rankList[0]) == domain.GetTeamFromSeat(rankList[1])
+}
+
+// finishDeal 进入RankList阶段。比赛是否结束、公平洗牌的公开与承诺由DealStateMachine处理
+func (s *State) finishDeal() []event.DomainEvent {
+	s.Phase = PhaseRankList
+	
+	// 未出完的玩家按出牌顺序补齐排名，保证下一Deal的贡牌判定有完整的四家排名
+	for len(s.Deal.RankList) < 4 {
+		s.Deal = s.Deal.AddToRankList(s.nextActiveSeat(s.Deal.RankList[len(s.Deal.RankList)-1]))
+	}
+	
+	return []event.DomainEvent{event.NewDealEndedEvent(
+		s.MatchID,
+		s.Deal.DealNumber,
+		s.Deal.RankList,
+		s.WinnerTeam(),
+		s.DealtHands,
+		s.Deal.ShuffleCommitment,
+		s.Deal.DealSeed,
+	)}
+}
+
+// WinnerTeam 本Deal头游所在的队，排名为空时返回TeamEastWest
+func (s State) WinnerTeam() domain.TeamID {
+	if s.Deal == nil || len(s.Deal.RankList) == 0 {
+		return domain.TeamEastWest
+	}
+	return domain.GetTeamFromSeat(s.Deal.RankList[0])
+}
+
+// removeCards 从hand中逐张移除cards（按多重集合计数），返回新切片；缺牌时返回原hand和false
+func removeCards(hand []domain.Card, cards []domain.Card) ([]domain.Card, bool) {
+	remaining := make([]domain.Card, len(hand))
+	copy(remaining, hand)
+	for _, card := range cards {
+		found := false
+		for i, held := range remaining {
+			if held == card {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return hand, false
+		}
+	}
+	return remaining, true
+}
+
+// addCards 返回追加了cards的新切片，不修改hand
+func addCards(hand []domain.Card, cards []domain.Card) []domain.Card {
+	added := make([]domain.Card, 0, len(hand)+len(cards))
+	added = append(added, hand...)
+	return append(added, cards...)
+}
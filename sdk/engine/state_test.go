@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// newPlayingState 初始化引擎并进入首次出牌阶段，返回引擎当前的State
+func newPlayingState(t *testing.T) (*GameEngine, State) {
+	t.Helper()
+
+	eventBus := event.NewEventBus(100)
+	engine := NewGameEngine(eventBus)
+
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("test-match", players, 12345)
+
+	if err := engine.Initialize(matchCtx); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	if err := engine.SetDealSource(eastLeadsDealSource(t)); err != nil {
+		t.Fatalf("Failed to set deal source: %v", err)
+	}
+	engine.StartDeal(1, nil)
+	engine.DealCards()
+	engine.DetermineTrump()
+	engine.StartTribute()
+
+	state, err := engine.GetState()
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	return engine, state
+}
+
+func TestStateApplyDoesNotModifyReceiver(t *testing.T) {
+	engine, state := newPlayingState(t)
+
+	if state.Phase != PhaseFirstPlay {
+		t.Fatalf("Expected phase %s, got %s", PhaseFirstPlay, state.Phase)
+	}
+
+	actions := state.LegalActions()
+	if len(actions) == 0 {
+		t.Fatal("Leader should have legal actions")
+	}
+	for _, action := range actions {
+		if action.Kind == ActionPass {
+			t.Error("Leader should not be able to pass")
+		}
+	}
+
+	before := formatHand(state.Hands[domain.SeatEast])
+	next, events, err := state.Apply(actions[0])
+	if err != nil {
+		t.Fatalf("Failed to apply %s: %v", actions[0], err)
+	}
+
+	if formatHand(state.Hands[domain.SeatEast]) != before {
+		t.Error("Apply should not modify the receiver's hands")
+	}
+	if state.Phase != PhaseFirstPlay || state.Trick.LastPlay != nil || len(state.Plays) != 0 {
+		t.Error("Apply should not modify the receiver's trick")
+	}
+	if len(next.Hands[domain.SeatEast]) != len(state.Hands[domain.SeatEast])-len(actions[0].Cards) {
+		t.Error("Played cards should be removed from the new state")
+	}
+	if next.Phase != PhaseInProgress || next.Trick.CurrentPlayer != domain.SeatSouth {
+		t.Errorf("Expected South to act in progress, got %s in %s", next.Trick.CurrentPlayer, next.Phase)
+	}
+	if len(events) != 1 || events[0].EventType() != "CardsPlayed" {
+		t.Errorf("Expected a single CardsPlayed event, got %v", events)
+	}
+
+	// 引擎的状态不受State推演影响
+	if engine.GetCurrentPhase() != PhaseFirstPlay || len(engine.GetPlayerHand(domain.SeatEast)) != 27 {
+		t.Error("Applying a state copy should not affect the engine")
+	}
+
+	if _, _, err := state.Apply(Action{Kind: ActionPlay, Seat: domain.SeatSouth, Cards: actions[0].Cards}); err == nil {
+		t.Error("Out of turn play should be rejected")
+	}
+}
+
+func TestStateCloneIsIndependent(t *testing.T) {
+	_, state := newPlayingState(t)
+
+	before := formatHand(state.Hands[domain.SeatEast])
+	clone := state.Clone()
+	clone.Hands[domain.SeatEast][0] = domain.NewCard(domain.Hearts, domain.Ace)
+	if formatHand(state.Hands[domain.SeatEast]) != before {
+		t.Error("Modifying a clone's hand should not affect the original")
+	}
+}
+
+func TestStatePlaysDealToEnd(t *testing.T) {
+	_, state := newPlayingState(t)
+
+	for step := 0; step < 1000 && !state.IsOver(); step++ {
+		actions := state.LegalActions()
+		if len(actions) == 0 {
+			t.Fatalf("No legal actions in phase %s", state.Phase)
+		}
+		// 有Pass时优先Pass，否则出第一个合法牌组
+		action := actions[0]
+		if last := actions[len(actions)-1]; last.Kind == ActionPass {
+			action = last
+		}
+
+		next, _, err := state.Apply(action)
+		if err != nil {
+			t.Fatalf("Legal action %s was rejected: %v", action, err)
+		}
+		state = next
+	}
+
+	if !state.IsOver() {
+		t.Fatal("Deal should end")
+	}
+	if len(state.Deal.RankList) != 4 {
+		t.Errorf("Expected complete rank list, got %v", state.Deal.RankList)
+	}
+	if state.WinnerTeam() != domain.GetTeamFromSeat(state.Deal.RankList[0]) {
+		t.Error("Winner team should be the first finisher's team")
+	}
+}
+
+func TestStateApplyRuleErrors(t *testing.T) {
+	_, state := newPlayingState(t)
+	
+	east := state.Hands[domain.SeatEast]
+	heartAce := domain.NewCard(domain.Hearts, domain.Ace) // East不持有♥A
+	
+	_, _, err := state.Apply(Action{Kind: ActionPlay, Seat: domain.SeatSouth, Cards: state.Hands[domain.SeatSouth][:1]})
+	if !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("Expected ErrNotYourTurn, got %v", err)
+	}
+	
+	_, _, err = state.Apply(Action{Kind: ActionPlay, Seat: domain.SeatEast, Cards: []domain.Card{heartAce}})
+	var notInHand *domain.ErrCardsNotInHand
+	if !errors.As(err, &notInHand) || len(notInHand.Missing) != 1 || notInHand.Missing[0] != heartAce {
+		t.Errorf("Expected ErrCardsNotInHand for %s, got %v", heartAce, err)
+	}
+	
+	_, _, err = state.Apply(Action{Kind: ActionTribute, Seat: domain.SeatEast, Cards: east[:1], Target: domain.SeatWest})
+	var wrongPhase *ErrWrongPhase
+	if !errors.As(err, &wrongPhase) || wrongPhase.Phase != PhaseFirstPlay {
+		t.Errorf("Expected ErrWrongPhase in %s, got %v", PhaseFirstPlay, err)
+	}
+	
+	if _, _, err := state.Apply(Action{Kind: ActionPass, Seat: domain.SeatEast}); !errors.Is(err, &ErrWrongPhase{}) {
+		t.Errorf("Expected ErrWrongPhase for passing before the first play, got %v", err)
+	}
+}
@@ -2,7 +2,6 @@ package engine
 
 import (
 	"fmt"
-	"time"
 	"guandan/sdk/domain"
 	"guandan/sdk/event"
 )
@@ -58,9 +57,12 @@ type DealStateMachine struct {
 	dealCtx      *domain.DealCtx
 	trickCtx     *domain.TrickCtx
 	eventBus     *event.EventBus
-	deck         *domain.Deck
 	startingCard *domain.Card
 	startingCardHolder domain.SeatID
+	dealtHands   map[domain.SeatID][]domain.Card
+	fairShuffle  *fairShuffle
+	dealSource   DealSource
+	dealPlays    []domain.TrickPlay // 本Deal所有出牌与Pass，按顺序
 }
 
 func NewDealStateMachine(matchCtx *domain.MatchCtx, eventBus *event.EventBus) *DealStateMachine {
@@ -68,6 +70,26 @@ func NewDealStateMachine(matchCtx *domain.MatchCtx, eventBus *event.EventBus) *D
 		currentPhase: PhaseIdle,
 		matchCtx:     matchCtx,
 		eventBus:     eventBus,
+		dealSource:   NewSeededDealSource(),
+	}
+}
+
+// SetDealSource 替换发牌来源（预设牌局、约束发牌等），只能在Deal开始发牌前设置
+func (sm *DealStateMachine) SetDealSource(source DealSource) error {
+	if source == nil {
+		return fmt.Errorf("deal source is nil")
+	}
+	
+	if sm.fairShuffle != nil {
+		return fmt.Errorf("cannot set deal source while fair shuffle is enabled")
+	}
+	
+	switch sm.currentPhase {
+	case PhaseIdle, PhaseCreated, PhaseFinished:
+		sm.dealSource = source
+		return nil
+	default:
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "set deal source"}
 	}
 }
 
@@ -87,21 +109,40 @@ func (sm *DealStateMachine) GetTrickCtx() *domain.TrickCtx {
 	return sm.trickCtx
 }
 
+// GetDealPlays 本Deal至今所有出牌与Pass（公开信息），按顺序
+func (sm *DealStateMachine) GetDealPlays() []domain.TrickPlay {
+	plays := make([]domain.TrickPlay, len(sm.dealPlays))
+	copy(plays, sm.dealPlays)
+	return plays
+}
+
+// GetStartingCard 首Deal用于确定首出者的Starting Card（尚未发牌时为nil）
+func (sm *DealStateMachine) GetStartingCard() *domain.Card {
+	return sm.startingCard
+}
+
 func (sm *DealStateMachine) StartDeal(dealNumber int, lastRankings []domain.SeatID) error {
-	if sm.currentPhase != PhaseIdle {
-		return fmt.Errorf("cannot start deal from phase %s", sm.currentPhase.String())
+	// 上一Deal结束（PhaseFinished）后可直接开始下一Deal，比赛已结束时除外
+	if sm.currentPhase != PhaseIdle && (sm.currentPhase != PhaseFinished || sm.matchCtx.IsFinished()) {
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "start deal"}
 	}
 	
 	// Create deal context without first player - will be determined after cards are dealt
 	// For now, use a temporary first player that will be updated in StartFirstPlay
 	sm.dealCtx = domain.NewDealCtxWithHistory(dealNumber, domain.Two, domain.SeatEast, lastRankings) // Temporary trump and first player
+	sm.dealPlays = nil
 	sm.currentPhase = PhaseCreated
 	
+	var levels [2]domain.Rank
+	for _, team := range sm.matchCtx.Teams {
+		levels[team.ID] = team.Level
+	}
 	sm.eventBus.Publish(event.NewDealStartedEvent(
 		sm.matchCtx.ID,
 		dealNumber,
 		domain.Two, // Temporary trump
 		domain.SeatEast, // Temporary first player
+		levels,
 	))
 	
 	return nil
@@ -109,23 +150,49 @@ func (sm *DealStateMachine) StartDeal(dealNumber int, lastRankings []domain.Seat
 
 func (sm *DealStateMachine) DealCards() error {
 	if sm.currentPhase != PhaseCreated {
-		return fmt.Errorf("cannot deal cards from phase %s", sm.currentPhase.String())
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "deal cards"}
 	}
 	
-	sm.deck = domain.NewDeckWithSeed(sm.matchCtx.Seed)
-	sm.deck.Shuffle()
+	// P1 Step 1: Shuffle, either committed (provably fair) or taken from the deal source
+	var hands [][]domain.Card
+	var startingCard domain.Card
+	if sm.fairShuffle != nil {
+		if sm.fairShuffle.dealNumber != sm.dealCtx.DealNumber {
+			return fmt.Errorf("shuffle committed for deal %d, not deal %d", sm.fairShuffle.dealNumber, sm.dealCtx.DealNumber)
+		}
+		hands, startingCard = domain.DealFair(sm.fairShuffle.serverSeed, sm.fairShuffle.clientSeeds)
+		sm.fairShuffle.shuffled = true
+		sm.dealCtx = sm.dealCtx.WithShuffleCommitment(sm.fairShuffle.commitment)
+	} else {
+		deal, err := sm.dealSource.NextDeal(sm.matchCtx, sm.dealCtx.DealNumber)
+		if err != nil {
+			return fmt.Errorf("failed to deal cards: %w", err)
+		}
+		if err := ValidateDeal(deal); err != nil {
+			return fmt.Errorf("invalid deal: %w", err)
+		}
+		hands = deal.Hands[:]
+		startingCard = deal.StartingCard
+		
+		if deal.Seeded {
+			sm.dealCtx = sm.dealCtx.WithDealSeed(deal.Seed)
+			sm.eventBus.Publish(event.NewShuffleRecordedEvent(
+				sm.matchCtx.ID,
+				sm.dealCtx.DealNumber,
+				domain.SeedCommitment(deal.Seed),
+			))
+		}
+	}
 	
 	// P1 Step 2: Select starting card for first deal
 	if sm.dealCtx.IsFirstDeal {
-		// Randomly select a starting card from the deck
-		allCards := sm.deck.Cards
-		startingCardIndex := sm.matchCtx.Seed % int64(len(allCards))
-		sm.startingCard = &allCards[startingCardIndex]
+		sm.startingCard = &startingCard
 	}
 	
 	// P1 Step 3: Deal cards to each player (27 cards each)
-	hands := sm.deck.DealToHands(4)
 	handMap := make(map[domain.SeatID][]domain.Card)
+	sm.dealtHands = make(map[domain.SeatID][]domain.Card)
+	holderFound := false
 	
 	for i, hand := range hands {
 		seat := domain.SeatID(i)
@@ -134,12 +201,15 @@ func (sm *DealStateMachine) DealCards() error {
 			player.ClearHand()
 			player.AddCards(hand)
 			handMap[seat] = hand
+			sm.dealtHands[seat] = player.GetHand()
 			
 			// P1 Step 4: Record starting card holder for first deal
-			if sm.dealCtx.IsFirstDeal && sm.startingCard != nil {
+			// (两张相同的牌分在不同座位时，按East→North顺序取第一个持有者)
+			if sm.dealCtx.IsFirstDeal && sm.startingCard != nil && !holderFound {
 				for _, card := range hand {
 					if card.Rank == sm.startingCard.Rank && card.Suit == sm.startingCard.Suit {
 						sm.startingCardHolder = seat
+						holderFound = true
 						break
 					}
 				}
@@ -161,20 +231,16 @@ func (sm *DealStateMachine) DealCards() error {
 // DetermineTrump implements P2 phase - Determine Level & Trump
 func (sm *DealStateMachine) DetermineTrump() error {
 	if sm.currentPhase != PhaseCardsDealt {
-		return fmt.Errorf("cannot determine trump from phase %s", sm.currentPhase.String())
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "determine trump"}
 	}
 
-	// P2 Step 1: Read previous deal winner team's level, default to 2 if none
-	var currentLevel domain.Rank = domain.Two
-	if sm.dealCtx.DealNumber > 1 {
-		// Get previous deal winner team's current level
-		// For now, we'll use team1's level as placeholder
-		// This should be updated based on previous deal results
-		team := sm.matchCtx.GetTeam(domain.TeamEastWest)
-		if team != nil {
-			currentLevel = team.Level
-		}
+	// P2 Step 1: Play the level of the team that won the previous deal;
+	// without a previous ranking (the first deal) East-West's level, both teams start at 2
+	declarer := domain.TeamEastWest
+	if len(sm.dealCtx.LastRankings) > 0 {
+		declarer = domain.GetTeamFromSeat(sm.dealCtx.LastRankings[0])
 	}
+	currentLevel := sm.matchCtx.GetTeam(declarer).Level
 
 	// P2 Step 2: Set 8 cards equal to Level as Trump
 	trump := currentLevel
@@ -194,462 +260,154 @@ func (sm *DealStateMachine) DetermineTrump() error {
 	return nil
 }
 
-func (sm *DealStateMachine) StartTribute() error {
-	if sm.currentPhase != PhaseTrumpDecision {
-		return fmt.Errorf("cannot start tribute from phase %s", sm.currentPhase.String())
+// GetState 由当前上下文与玩家手牌组装的State快照，手牌为副本
+func (sm *DealStateMachine) GetState() State {
+	state := State{
+		MatchID:            sm.matchCtx.ID,
+		Phase:              sm.currentPhase,
+		Deal:               sm.dealCtx,
+		Trick:              sm.trickCtx,
+		Plays:              sm.dealPlays[:len(sm.dealPlays):len(sm.dealPlays)],
+		DealtHands:         sm.dealtHands,
+		StartingCardHolder: sm.startingCardHolder,
 	}
-	
-	if sm.dealCtx.IsFirstDeal {
-		return sm.skipTribute()
+	for _, team := range sm.matchCtx.Teams {
+		state.Levels[team.ID] = team.Level
 	}
-	
-	// 计算每个玩家的大王数量
-	playerBigJokers := make(map[domain.SeatID]int)
 	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
-		player := sm.matchCtx.GetPlayer(seat)
-		if player != nil {
-			playerBigJokers[seat] = domain.CountBigJokers(player.GetHand())
+		if player := sm.matchCtx.GetPlayer(seat); player != nil {
+			state.Hands[seat] = player.GetHand()
 		}
 	}
-	
-	// 初始化贡牌系统
-	sm.dealCtx = sm.dealCtx.InitializeTribute(playerBigJokers)
-	
-	if sm.dealCtx.TributeInfo.HasImmunity {
-		// 有免疫，直接跳过贡牌
-		return sm.skipTribute()
-	}
-	
-	// 转换贡牌要求格式用于事件
-	tributeRequirements := make(map[domain.SeatID]int)
-	for from := range sm.dealCtx.TributeInfo.TributeRequests {
-		tributeRequirements[from] = 1 // 每人贡1张牌
+	return state
+}
+
+// load 把State写回状态机的上下文与玩家手牌
+func (sm *DealStateMachine) load(state State) {
+	sm.currentPhase = state.Phase
+	sm.dealCtx = state.Deal
+	sm.trickCtx = state.Trick
+	sm.dealPlays = state.Plays
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if player := sm.matchCtx.GetPlayer(seat); player != nil {
+			player.ClearHand()
+			player.AddCards(state.Hands[seat])
+		}
 	}
-	
-	sm.dealCtx = sm.dealCtx.WithState(domain.DealStateTribute)
-	sm.currentPhase = PhaseTribute
-	
-	sm.eventBus.Publish(event.NewTributeRequestedEvent(
-		sm.matchCtx.ID,
-		tributeRequirements,
-	))
-	
-	return nil
 }
 
-func (sm *DealStateMachine) skipTribute() error {
-	sm.dealCtx = sm.dealCtx.WithTributeGiven(true)
-	return sm.StartFirstPlay()
+// Apply 执行一次行动：规则由State.Apply完成，状态机负责写回、发布事件，
+// 并在Deal结束后处理公平洗牌与比赛结束
+func (sm *DealStateMachine) Apply(action Action) error {
+	return sm.transition(func(state *State) ([]event.DomainEvent, error) {
+		next, events, err := state.Apply(action)
+		*state = next
+		return events, err
+	})
 }
 
-func (sm *DealStateMachine) GiveTribute(from, to domain.SeatID, cards []domain.Card) error {
-	if sm.currentPhase != PhaseTribute {
-		return fmt.Errorf("cannot give tribute from phase %s", sm.currentPhase.String())
-	}
-	
-	// 验证贡牌数量
-	if len(cards) != 1 {
-		return fmt.Errorf("must tribute exactly one card")
+// transition 在当前State的副本上执行转换，成功后写回并发布事件
+func (sm *DealStateMachine) transition(fn func(state *State) ([]event.DomainEvent, error)) error {
+	if sm.dealCtx == nil {
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "act"}
 	}
 	
-	card := cards[0]
-	
-	fromPlayer := sm.matchCtx.GetPlayer(from)
-	toPlayer := sm.matchCtx.GetPlayer(to)
-	
-	if fromPlayer == nil || toPlayer == nil {
-		return fmt.Errorf("invalid player seats")
+	state := sm.GetState()
+	events, err := fn(&state)
+	if err != nil {
+		return err
 	}
 	
-	// 验证贡牌关系
-	expectedTo, exists := sm.dealCtx.TributeInfo.TributeRequests[from]
-	if !exists {
-		return fmt.Errorf("player %s is not required to give tribute", from.String())
-	}
-	if expectedTo != to {
-		return fmt.Errorf("player %s should give tribute to %s, not %s", from.String(), expectedTo.String(), to.String())
+	sm.load(state)
+	for _, e := range events {
+		sm.eventBus.Publish(e)
 	}
 	
-	// 验证贡牌是否符合规则（除了红桃trump外最大的牌）
-	if err := domain.ValidateTributeCard(fromPlayer.GetHand(), card, sm.dealCtx.Trump); err != nil {
-		return fmt.Errorf("invalid tribute card: %w", err)
+	if sm.currentPhase == PhaseRankList {
+		return sm.finishDeal(state.WinnerTeam())
 	}
-	
-	// 执行贡牌
-	fromPlayer.RemoveCards(cards)
-	toPlayer.AddCards(cards)
-	
-	// 记录贡牌
-	sm.dealCtx.TributeCards[from] = cards
-	sm.dealCtx.TributeInfo.GivenTributes[from] = card
-	
-	sm.eventBus.Publish(event.NewTributeGivenEvent(
-		sm.matchCtx.ID,
-		from,
-		to,
-		cards,
-	))
-	
-	// 检查是否所有贡牌都已完成
-	if sm.dealCtx.TributeInfo.IsTributeComplete() {
-		sm.dealCtx.TributeInfo.Phase = domain.TributePhaseGiving
-		
-		// Double Down场景需要进入选择阶段
-		if sm.dealCtx.TributeInfo.Scenario == domain.TributeScenarioDoubleDown {
-			return sm.StartTributeSelection()
-		}
-		
-		return sm.StartReturnTribute()
-	}
-	
 	return nil
 }
 
+func (sm *DealStateMachine) StartTribute() error {
+	return sm.transition((*State).startTribute)
+}
+
+func (sm *DealStateMachine) GiveTribute(from, to domain.SeatID, cards []domain.Card) error {
+	return sm.Apply(Action{Kind: ActionTribute, Seat: from, Cards: cards, Target: to})
+}
+
 // StartTributeSelection 开始Double Down贡牌选择阶段
 func (sm *DealStateMachine) StartTributeSelection() error {
-	if sm.currentPhase != PhaseTribute {
-		return fmt.Errorf("cannot start tribute selection from phase %s", sm.currentPhase.String())
-	}
-	
-	if sm.dealCtx.TributeInfo.Scenario != domain.TributeScenarioDoubleDown {
-		return fmt.Errorf("tribute selection only available for Double Down scenario")
-	}
-	
-	// 准备选择阶段
-	sm.dealCtx.TributeInfo.PrepareDoubleDownSelection(sm.dealCtx.LastRankings)
-	sm.currentPhase = PhaseTributeSelection
-	
-	// 发布选择请求事件
-	first := sm.dealCtx.LastRankings[0]
-	sm.eventBus.Publish(event.NewTributeSelectionRequestedEvent(
-		sm.matchCtx.ID,
-		first,
-		sm.dealCtx.TributeInfo.AvailableCards,
-	))
-	
-	return nil
+	return sm.transition((*State).startTributeSelection)
 }
 
 // SelectTributeCard Player 1在Double Down场景中选择贡牌
 func (sm *DealStateMachine) SelectTributeCard(giver domain.SeatID) error {
 	if sm.currentPhase != PhaseTributeSelection {
-		return fmt.Errorf("cannot select tribute card from phase %s", sm.currentPhase.String())
+		return &ErrWrongPhase{Phase: sm.currentPhase, Op: "select tribute card"}
 	}
 	
-	if sm.dealCtx.TributeInfo.Scenario != domain.TributeScenarioDoubleDown {
-		return fmt.Errorf("tribute selection only available for Double Down scenario")
+	if len(sm.dealCtx.LastRankings) != 4 {
+		return fmt.Errorf("tribute selection requires complete last rankings")
 	}
 	
-	// 获取选择前的信息，用于事件发布
-	selectedCard := sm.dealCtx.TributeInfo.AvailableCards[giver]
-	first := sm.dealCtx.LastRankings[0]
-	second := sm.dealCtx.LastRankings[1]
-	third := sm.dealCtx.LastRankings[2]
-	fourth := sm.dealCtx.LastRankings[3]
-	
-	var remainingGiver domain.SeatID
-	if giver == third {
-		remainingGiver = fourth
-	} else {
-		remainingGiver = third
-	}
-	remainingCard := sm.dealCtx.TributeInfo.AvailableCards[remainingGiver]
-	
-	// 执行选择
-	err := sm.dealCtx.TributeInfo.SelectTributeCardForDoubleDown(giver, sm.dealCtx.LastRankings)
-	if err != nil {
-		return fmt.Errorf("failed to select tribute card: %w", err)
-	}
-	
-	// 实际进行卡牌交换
-	firstPlayer := sm.matchCtx.GetPlayer(first)
-	secondPlayer := sm.matchCtx.GetPlayer(second)
-	
-	if firstPlayer != nil {
-		firstPlayer.AddCards([]domain.Card{selectedCard})
-	}
-	if secondPlayer != nil {
-		secondPlayer.AddCards([]domain.Card{remainingCard})
-	}
-	
-	// 发布选择完成事件
-	sm.eventBus.Publish(event.NewTributeCardSelectedEvent(
-		sm.matchCtx.ID,
-		first,
-		giver,
-		selectedCard,
-		second,
-		remainingCard,
-	))
-	
-	// 转到还贡阶段
-	return sm.StartReturnTribute()
+	return sm.Apply(Action{Kind: ActionSelectTribute, Seat: sm.dealCtx.LastRankings[0], Target: giver})
 }
 
 // StartReturnTribute 开始还贡阶段
 func (sm *DealStateMachine) StartReturnTribute() error {
-	if sm.currentPhase != PhaseTribute && sm.currentPhase != PhaseTributeSelection {
-		return fmt.Errorf("cannot start return tribute from phase %s", sm.currentPhase.String())
-	}
-	
-	if sm.dealCtx.TributeInfo.HasImmunity || len(sm.dealCtx.TributeInfo.ReturnRequests) == 0 {
-		// 无需还贡，直接开始首次出牌
-		sm.dealCtx.TributeInfo.Phase = domain.TributePhaseCompleted
-		sm.dealCtx = sm.dealCtx.WithTributeGiven(true)
-		return sm.StartFirstPlay()
-	}
-	
-	sm.dealCtx = sm.dealCtx.WithState(domain.DealStateReturnTribute)
-	sm.currentPhase = PhaseReturnTribute
-	sm.dealCtx.TributeInfo.Phase = domain.TributePhaseReturning
-	
-	return nil
+	return sm.transition((*State).startReturnTribute)
 }
 
 // GiveReturnTribute 执行还贡
 func (sm *DealStateMachine) GiveReturnTribute(from, to domain.SeatID, cards []domain.Card) error {
-	if sm.currentPhase != PhaseReturnTribute {
-		return fmt.Errorf("cannot give return tribute from phase %s", sm.currentPhase.String())
-	}
-	
-	// 验证还贡数量
-	if len(cards) != 1 {
-		return fmt.Errorf("must return exactly one card")
-	}
-	
-	card := cards[0]
-	
-	fromPlayer := sm.matchCtx.GetPlayer(from)
-	toPlayer := sm.matchCtx.GetPlayer(to)
-	
-	if fromPlayer == nil || toPlayer == nil {
-		return fmt.Errorf("invalid player seats")
-	}
-	
-	// 验证还贡关系
-	expectedTo, exists := sm.dealCtx.TributeInfo.ReturnRequests[from]
-	if !exists {
-		return fmt.Errorf("player %s is not required to give return tribute", from.String())
-	}
-	if expectedTo != to {
-		return fmt.Errorf("player %s should give return tribute to %s, not %s", from.String(), expectedTo.String(), to.String())
-	}
-	
-	// 验证还贡牌是否符合规则（点数<=10）
-	if !domain.IsValidReturnTributeCard(fromPlayer.GetHand(), card) {
-		return fmt.Errorf("invalid return tribute card: must be <= 10 points")
-	}
-	
-	// 执行还贡
-	fromPlayer.RemoveCards(cards)
-	toPlayer.AddCards(cards)
-	
-	// 记录还贡
-	sm.dealCtx.TributeInfo.ReturnedTributes[from] = card
-	
-	sm.eventBus.Publish(event.NewTributeGivenEvent(
-		sm.matchCtx.ID,
-		from,
-		to,
-		cards,
-	))
-	
-	// 检查是否所有还贡都已完成
-	if sm.dealCtx.TributeInfo.IsReturnComplete() {
-		sm.dealCtx.TributeInfo.Phase = domain.TributePhaseCompleted
-		sm.dealCtx = sm.dealCtx.WithTributeGiven(true)
-		return sm.StartFirstPlay()
-	}
-	
-	return nil
+	return sm.Apply(Action{Kind: ActionReturnTribute, Seat: from, Cards: cards, Target: to})
 }
 
 func (sm *DealStateMachine) StartFirstPlay() error {
-	if sm.currentPhase != PhaseTribute && sm.currentPhase != PhaseReturnTribute && sm.currentPhase != PhaseTrumpDecision {
-		return fmt.Errorf("cannot start first play from phase %s", sm.currentPhase.String())
-	}
-	
-	// 确定首出者
-	firstPlayer := domain.DetermineFirstPlayer(sm.matchCtx, sm.dealCtx, sm.startingCardHolder)
-	
-	// 更新DealCtx中的FirstPlayer
-	newDealCtx := *sm.dealCtx
-	newDealCtx.FirstPlayer = firstPlayer
-	sm.dealCtx = &newDealCtx
-	
-	sm.dealCtx = sm.dealCtx.WithState(domain.DealStateFirstPlay)
-	sm.currentPhase = PhaseFirstPlay
-	
-	sm.trickCtx = domain.NewTrickCtx(1, firstPlayer)
-	
-	// 发布首出者确定事件
-	sm.eventBus.Publish(event.NewFirstPlayerDeterminedEvent(
-		sm.matchCtx.ID,
-		firstPlayer,
-	))
-	
-	return nil
+	return sm.transition((*State).startFirstPlay)
 }
 
 func (sm *DealStateMachine) TransitionToInProgress() error {
-	if sm.currentPhase != PhaseFirstPlay {
-		return fmt.Errorf("cannot transition to in progress from phase %s", sm.currentPhase.String())
-	}
-	
-	sm.dealCtx = sm.dealCtx.WithState(domain.DealStateInProgress)
-	sm.currentPhase = PhaseInProgress
-	
-	return nil
+	return sm.transition(func(state *State) ([]event.DomainEvent, error) {
+		return nil, state.transitionToInProgress()
+	})
 }
 
 func (sm *DealStateMachine) StartNewTrick(startPlayer domain.SeatID) error {
-	if sm.currentPhase != PhaseInProgress {
-		return fmt.Errorf("cannot start new trick from phase %s", sm.currentPhase.String())
-	}
-	
-	sm.dealCtx = sm.dealCtx.WithTrickCount(sm.dealCtx.TrickCount + 1)
-	sm.trickCtx = domain.NewTrickCtx(sm.dealCtx.TrickCount, startPlayer)
-	
-	return nil
+	return sm.transition(func(state *State) ([]event.DomainEvent, error) {
+		return nil, state.startNewTrick(startPlayer)
+	})
 }
 
 func (sm *DealStateMachine) PlayCards(seat domain.SeatID, cards []domain.Card) error {
-	if sm.currentPhase != PhaseFirstPlay && sm.currentPhase != PhaseInProgress {
-		return fmt.Errorf("cannot play cards from phase %s", sm.currentPhase.String())
-	}
-	
-	if sm.trickCtx.CurrentPlayer != seat {
-		return fmt.Errorf("not player's turn")
-	}
-	
-	player := sm.matchCtx.GetPlayer(seat)
-	if player == nil {
-		return fmt.Errorf("player not found")
-	}
-	
-	if !player.HasCards(cards) {
-		return fmt.Errorf("player does not have required cards")
-	}
-	
-	cardGroup := domain.NewCardGroup(cards)
-	if !cardGroup.IsValid() {
-		return fmt.Errorf("invalid card combination")
-	}
-	
-	if !domain.CanFollow(cardGroup, sm.trickCtx.LastPlay, sm.dealCtx.Trump) {
-		return fmt.Errorf("cannot beat current play")
-	}
-	
-	player.RemoveCards(cards)
-	
-	sm.trickCtx = sm.trickCtx.WithLastPlay(cardGroup, seat)
-	sm.trickCtx = sm.trickCtx.WithCurrentPlayer(seat.Next())
-	
-	trickPlay := domain.TrickPlay{
-		Player:    seat,
-		Cards:     cards,
-		CardGroup: cardGroup,
-		Timestamp: time.Now(),
-	}
-	sm.trickCtx = sm.trickCtx.WithPlayHistory(trickPlay)
-	
-	sm.eventBus.Publish(event.NewCardsPlayedEvent(
-		sm.matchCtx.ID,
-		seat,
-		cards,
-		cardGroup,
-	))
-	
-	if sm.currentPhase == PhaseFirstPlay {
-		sm.TransitionToInProgress()
-	}
-	
-	if player.IsHandEmpty() {
-		return sm.handlePlayerFinished(seat)
-	}
-	
-	return nil
+	return sm.Apply(Action{Kind: ActionPlay, Seat: seat, Cards: cards})
 }
 
 func (sm *DealStateMachine) Pass(seat domain.SeatID) error {
-	if sm.currentPhase != PhaseInProgress {
-		return fmt.Errorf("cannot pass from phase %s", sm.currentPhase.String())
-	}
-	
-	if sm.trickCtx.CurrentPlayer != seat {
-		return fmt.Errorf("not player's turn")
-	}
-	
-	sm.trickCtx = sm.trickCtx.WithPlayerPassed(seat)
-	sm.trickCtx = sm.trickCtx.WithCurrentPlayer(seat.Next())
-	
-	sm.eventBus.Publish(event.NewPlayerPassedEvent(
-		sm.matchCtx.ID,
-		seat,
-	))
-	
-	if sm.trickCtx.ShouldFinish() {
-		return sm.finishTrick()
-	}
-	
-	return nil
+	return sm.Apply(Action{Kind: ActionPass, Seat: seat})
 }
 
-func (sm *DealStateMachine) finishTrick() error {
-	winner := sm.trickCtx.LastPlayer
-	sm.trickCtx = sm.trickCtx.WithWinner(winner)
-	
-	sm.eventBus.Publish(event.NewTrickWonEvent(
-		sm.matchCtx.ID,
-		winner,
-		sm.trickCtx.TrickNumber,
-	))
-	
-	if sm.shouldFinishDeal() {
-		return sm.finishDeal()
+// finishDeal 排名确定后公开本Deal的洗牌种子并给头游队升级；比赛未结束时为下一Deal做出承诺
+func (sm *DealStateMachine) finishDeal(winnerTeam domain.TeamID) error {
+	if sm.fairShuffle != nil {
+		sm.revealShuffle()
 	}
 	
-	return sm.StartNewTrick(winner)
-}
-
-func (sm *DealStateMachine) handlePlayerFinished(seat domain.SeatID) error {
-	position := len(sm.dealCtx.RankList) + 1
-	sm.dealCtx = sm.dealCtx.WithRankList(append(sm.dealCtx.RankList, seat))
-	
-	sm.eventBus.Publish(event.NewPlayerFinishedEvent(
-		sm.matchCtx.ID,
-		seat,
-		position,
-	))
-	
-	if sm.shouldFinishDeal() {
-		return sm.finishDeal()
-	}
-	
-	return nil
-}
-
-func (sm *DealStateMachine) shouldFinishDeal() bool {
-	return len(sm.dealCtx.RankList) >= 3
-}
-
-func (sm *DealStateMachine) finishDeal() error {
-	sm.currentPhase = PhaseRankList
-	
-	winnerTeam := sm.determineWinnerTeam()
-	
-	sm.eventBus.Publish(event.NewDealEndedEvent(
-		sm.matchCtx.ID,
-		sm.dealCtx.DealNumber,
-		sm.dealCtx.RankList,
-		winnerTeam,
-	))
-	
-	if sm.shouldFinishMatch() {
+	team := sm.matchCtx.GetTeam(winnerTeam)
+	outcome := domain.DetermineTributeScenario(sm.dealCtx.RankList)
+	level, won := domain.AdvanceLevel(team.Level, outcome, sm.matchCtx.TargetLevel)
+	sm.matchCtx = sm.matchCtx.WithTeamLevel(winnerTeam, level)
+	if won {
 		return sm.finishMatch(winnerTeam)
 	}
 	
 	sm.currentPhase = PhaseFinished
+	
+	if sm.fairShuffle != nil {
+		return sm.commitShuffle(sm.dealCtx.DealNumber + 1)
+	}
 	return nil
 }
 
@@ -664,6 +422,7 @@ func (sm *DealStateMachine) finishMatch(winnerTeam domain.TeamID) error {
 		sm.matchCtx.ID,
 		winnerTeam,
 		finalScore,
+		sm.matchCtx.Seed,
 	))
 	
 	sm.matchCtx = sm.matchCtx.WithWinner(winnerTeam)
@@ -671,23 +430,6 @@ func (sm *DealStateMachine) finishMatch(winnerTeam domain.TeamID) error {
 	return nil
 }
 
-func (sm *DealStateMachine) determineWinnerTeam() domain.TeamID {
-	if len(sm.dealCtx.RankList) >= 2 {
-		first := sm.dealCtx.RankList[0]
-		second := sm.dealCtx.RankList[1]
-		
-		if domain.GetTeamFromSeat(first) == domain.GetTeamFromSeat(second) {
-			return domain.GetTeamFromSeat(first)
-		}
-	}
-	
-	return domain.GetTeamFromSeat(sm.dealCtx.RankList[0])
-}
-
-func (sm *DealStateMachine) shouldFinishMatch() bool {
-	return sm.dealCtx.CurrentLevel >= domain.Ace
-}
-
 // GetTributeCardOptions 获取贡牌选项（调试用）
 func (sm *DealStateMachine) GetTributeCardOptions(seat domain.SeatID) []domain.Card {
 	player := sm.matchCtx.GetPlayer(seat)
@@ -712,7 +454,8 @@ func (sm *DealStateMachine) Reset() {
 	sm.currentPhase = PhaseIdle
 	sm.dealCtx = nil
 	sm.trickCtx = nil
-	sm.deck = nil
 	sm.startingCard = nil
+	sm.dealtHands = nil
+	sm.dealPlays = nil
 	sm.startingCardHolder = domain.SeatEast
 }
\ No newline at end of file
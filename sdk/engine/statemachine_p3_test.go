@@ -24,7 +24,7 @@ func TestP3StateMachineDoubleDownFlow(t *testing.T) {
 		sm := NewDealStateMachine(matchCtx, eventBus)
 		
 		// 开始第二局
-		err := sm.StartDeal(2, domain.SeatEast)
+		err := sm.StartDeal(2, nil)
 		if err != nil {
 			t.Fatalf("Failed to start deal: %v", err)
 		}
@@ -177,7 +177,7 @@ func TestP3StateMachineImmunityFlow(t *testing.T) {
 		sm := NewDealStateMachine(matchCtx, eventBus)
 		
 		// 开始第二局
-		err := sm.StartDeal(2, domain.SeatEast)
+		err := sm.StartDeal(2, nil)
 		if err != nil {
 			t.Fatalf("Failed to start deal: %v", err)
 		}
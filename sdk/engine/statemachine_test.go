@@ -2,6 +2,7 @@ package engine
 
 import (
 	"testing"
+	"time"
 	"guandan/sdk/domain"
 	"guandan/sdk/event"
 )
@@ -22,7 +23,9 @@ func TestDealStateMachinePhaseTransitions(t *testing.T) {
 		t.Errorf("Expected initial phase %s, got %s", PhaseIdle, sm.GetCurrentPhase())
 	}
 	
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -91,12 +94,14 @@ func TestDealStateMachineInvalidTransitions(t *testing.T) {
 		t.Error("Should not be able to pass from idle phase")
 	}
 	
-	err = sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err = sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
 	
-	err = sm.StartDeal(2, domain.SeatSouth)
+	err = sm.StartDeal(2, nil)
 	if err == nil {
 		t.Error("Should not be able to start deal twice")
 	}
@@ -119,7 +124,9 @@ func TestDealStateMachineCardDealing(t *testing.T) {
 	
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -175,7 +182,9 @@ func TestDealStateMachinePlayCardsValidation(t *testing.T) {
 	
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -271,7 +280,9 @@ func TestDealStateMachinePassMechanism(t *testing.T) {
 	
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -333,8 +344,9 @@ func TestDealStateMachinePassMechanism(t *testing.T) {
 		t.Errorf("Expected trick winner %s, got %s", domain.SeatEast, updatedTrickCtx.Winner)
 	}
 	
-	if updatedTrickCtx.TrickNumber != 1 {
-		t.Errorf("Expected trick number 1, got %d", updatedTrickCtx.TrickNumber)
+	// 首轮编号为1，赢家领出的下一轮编号为2
+	if updatedTrickCtx.TrickNumber != 2 {
+		t.Errorf("Expected trick number 2, got %d", updatedTrickCtx.TrickNumber)
 	}
 }
 
@@ -355,24 +367,21 @@ func TestDealStateMachineEventPublishing(t *testing.T) {
 	eventChan, unsubscribe := eventBus.Subscribe("test-match")
 	defer unsubscribe()
 	
-	go func() {
-		for e := range eventChan {
-			receivedEvents = append(receivedEvents, e)
-		}
-	}()
-	
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
 	
+	receivedEvents = collectEvents(eventChan, receivedEvents, 1)
 	if len(receivedEvents) < 1 {
 		t.Error("Should have received DealStarted event")
 	}
 	
-	dealStartedEvent, ok := receivedEvents[0].(event.DealStartedEvent)
+	dealStartedEvent, ok := receivedEvents[0].(*event.DealStartedEvent)
 	if !ok {
 		t.Error("First event should be DealStartedEvent")
 	} else {
@@ -390,11 +399,12 @@ func TestDealStateMachineEventPublishing(t *testing.T) {
 		t.Errorf("Failed to deal cards: %v", err)
 	}
 	
+	receivedEvents = collectEvents(eventChan, receivedEvents, 2)
 	if len(receivedEvents) < 2 {
 		t.Error("Should have received CardsDealt event")
 	}
 	
-	cardsDealtEvent, ok := receivedEvents[1].(event.CardsDealtEvent)
+	cardsDealtEvent, ok := receivedEvents[1].(*event.CardsDealtEvent)
 	if !ok {
 		t.Error("Second event should be CardsDealtEvent")
 	} else {
@@ -408,6 +418,11 @@ func TestDealStateMachineEventPublishing(t *testing.T) {
 		}
 	}
 	
+	err = sm.DetermineTrump()
+	if err != nil {
+		t.Errorf("Failed to determine trump: %v", err)
+	}
+	
 	err = sm.StartTribute()
 	if err != nil {
 		t.Errorf("Failed to start tribute: %v", err)
@@ -421,10 +436,14 @@ func TestDealStateMachineEventPublishing(t *testing.T) {
 		t.Errorf("Failed to play cards: %v", err)
 	}
 	
-	var cardsPlayedEvent event.CardsPlayedEvent
+	receivedEvents = collectEventsUntil(eventChan, receivedEvents, func(e event.DomainEvent) bool {
+		_, ok := e.(*event.CardsPlayedEvent)
+		return ok
+	})
+	var cardsPlayedEvent *event.CardsPlayedEvent
 	found := false
 	for _, e := range receivedEvents {
-		if cpe, ok := e.(event.CardsPlayedEvent); ok {
+		if cpe, ok := e.(*event.CardsPlayedEvent); ok {
 			cardsPlayedEvent = cpe
 			found = true
 			break
@@ -450,10 +469,14 @@ func TestDealStateMachineEventPublishing(t *testing.T) {
 		t.Errorf("Failed to pass: %v", err)
 	}
 	
-	var playerPassedEvent event.PlayerPassedEvent
+	receivedEvents = collectEventsUntil(eventChan, receivedEvents, func(e event.DomainEvent) bool {
+		_, ok := e.(*event.PlayerPassedEvent)
+		return ok
+	})
+	var playerPassedEvent *event.PlayerPassedEvent
 	found = false
 	for _, e := range receivedEvents {
-		if ppe, ok := e.(event.PlayerPassedEvent); ok {
+		if ppe, ok := e.(*event.PlayerPassedEvent); ok {
 			playerPassedEvent = ppe
 			found = true
 			break
@@ -481,7 +504,9 @@ func TestDealStateMachineReset(t *testing.T) {
 	
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -597,7 +622,9 @@ func TestP1DealStart(t *testing.T) {
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
 	// P1 Entry condition: Each Deal starts
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -608,9 +635,9 @@ func TestP1DealStart(t *testing.T) {
 		t.Errorf("Failed to deal cards: %v", err)
 	}
 	
-	// Verify deck has 108 cards
-	if sm.deck == nil {
-		t.Error("Deck should not be nil after dealing")
+	// Verify all 108 cards were dealt and recorded
+	if len(sm.dealtHands) != 4 {
+		t.Errorf("Expected dealt hands for 4 seats, got %d", len(sm.dealtHands))
 	}
 	
 	// P1 Step 2: First deal should select starting card
@@ -670,7 +697,9 @@ func TestP2DetermineLevelAndTrump(t *testing.T) {
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
 	// Setup for P2 phase
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -745,7 +774,7 @@ func TestP2DefaultTrumpLevel(t *testing.T) {
 	sm := NewDealStateMachine(matchCtx, eventBus)
 	
 	// Start first deal
-	err := sm.StartDeal(1, domain.SeatEast)
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -773,7 +802,7 @@ func TestP2DefaultTrumpLevel(t *testing.T) {
 	
 	// Test second deal uses team level (which should still be 2 for this test)
 	sm.Reset()
-	err = sm.StartDeal(2, domain.SeatEast)
+	err = sm.StartDeal(2, nil)
 	if err != nil {
 		t.Errorf("Failed to start second deal: %v", err)
 	}
@@ -815,7 +844,9 @@ func TestP0ToP2StateFlow(t *testing.T) {
 	}
 	
 	// P1: Deal Start
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -885,7 +916,9 @@ func TestDealStateMachineContextAccess(t *testing.T) {
 		t.Error("TrickCtx should be nil initially")
 	}
 	
-	err := sm.StartDeal(1, domain.SeatEast)
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	err := sm.StartDeal(1, nil)
 	if err != nil {
 		t.Errorf("Failed to start deal: %v", err)
 	}
@@ -926,4 +959,369 @@ func TestDealStateMachineContextAccess(t *testing.T) {
 	if trickCtx.CurrentPlayer != domain.SeatEast {
 		t.Errorf("Expected current player %s, got %s", domain.SeatEast, trickCtx.CurrentPlayer)
 	}
-}
\ No newline at end of file
+}
+// collectEvents 从订阅通道读取事件，直到累计count个或等待超时（事件总线异步分发）
+func collectEvents(eventChan <-chan event.DomainEvent, events []event.DomainEvent, count int) []event.DomainEvent {
+	timeout := time.After(time.Second)
+	for len(events) < count {
+		select {
+		case e := <-eventChan:
+			events = append(events, e)
+		case <-timeout:
+			return events
+		}
+	}
+	return events
+}
+
+// collectEventsUntil 从订阅通道读取事件，直到出现满足条件的事件或等待超时
+func collectEventsUntil(eventChan <-chan event.DomainEvent, events []event.DomainEvent, match func(event.DomainEvent) bool) []event.DomainEvent {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case e := <-eventChan:
+			events = append(events, e)
+			if match(e) {
+				return events
+			}
+		case <-timeout:
+			return events
+		}
+	}
+}
+
+// newPlayingStateMachine 首Deal由East首出，并把四家手牌替换为给定的牌
+func newPlayingStateMachine(t *testing.T, hands map[domain.SeatID][]domain.Card) *DealStateMachine {
+	t.Helper()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("test-match", players, 12345)
+	sm := NewDealStateMachine(matchCtx, event.NewEventBus(100))
+	sm.SetDealSource(eastLeadsDealSource(t))
+	
+	if err := sm.StartDeal(1, nil); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	if err := sm.DealCards(); err != nil {
+		t.Fatalf("Failed to deal cards: %v", err)
+	}
+	if err := sm.DetermineTrump(); err != nil {
+		t.Fatalf("Failed to determine trump: %v", err)
+	}
+	if err := sm.StartTribute(); err != nil {
+		t.Fatalf("Failed to start tribute: %v", err)
+	}
+	
+	for seat, hand := range hands {
+		player := matchCtx.GetPlayer(seat)
+		player.ClearHand()
+		player.AddCards(hand)
+	}
+	
+	return sm
+}
+
+func TestDealStateMachineLeaderCannotPass(t *testing.T) {
+	sm := newPlayingStateMachine(t, map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {domain.NewCard(domain.Spades, domain.Three), domain.NewCard(domain.Spades, domain.Four)},
+		domain.SeatSouth: {domain.NewCard(domain.Clubs, domain.Three), domain.NewCard(domain.Clubs, domain.Four)},
+		domain.SeatWest:  {domain.NewCard(domain.Hearts, domain.Three), domain.NewCard(domain.Hearts, domain.Four)},
+		domain.SeatNorth: {domain.NewCard(domain.Diamonds, domain.Three), domain.NewCard(domain.Diamonds, domain.Four)},
+	})
+	
+	if err := sm.PlayCards(domain.SeatEast, []domain.Card{domain.NewCard(domain.Spades, domain.Four)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	for _, seat := range []domain.SeatID{domain.SeatSouth, domain.SeatWest, domain.SeatNorth} {
+		if err := sm.Pass(seat); err != nil {
+			t.Fatalf("Failed to pass for %s: %v", seat, err)
+		}
+	}
+	
+	if sm.GetTrickCtx().CurrentPlayer != domain.SeatEast {
+		t.Fatalf("Expected East to lead, got %s", sm.GetTrickCtx().CurrentPlayer)
+	}
+	if err := sm.Pass(domain.SeatEast); err == nil {
+		t.Error("Trick leader should not be allowed to pass")
+	}
+}
+
+func TestDealStateMachinePassesResetAfterPlay(t *testing.T) {
+	sm := newPlayingStateMachine(t, map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {domain.NewCard(domain.Spades, domain.Three), domain.NewCard(domain.Spades, domain.Four)},
+		domain.SeatSouth: {domain.NewCard(domain.Clubs, domain.Five), domain.NewCard(domain.Clubs, domain.Four)},
+		domain.SeatWest:  {domain.NewCard(domain.Hearts, domain.Six), domain.NewCard(domain.Hearts, domain.Four)},
+		domain.SeatNorth: {domain.NewCard(domain.Diamonds, domain.Seven), domain.NewCard(domain.Diamonds, domain.Four)},
+	})
+	
+	if err := sm.PlayCards(domain.SeatEast, []domain.Card{domain.NewCard(domain.Spades, domain.Three)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	if err := sm.Pass(domain.SeatSouth); err != nil {
+		t.Fatalf("Failed to pass: %v", err)
+	}
+	if err := sm.PlayCards(domain.SeatWest, []domain.Card{domain.NewCard(domain.Hearts, domain.Six)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	
+	// West出牌后South的Pass失效，一圈之后还可以继续压牌
+	if sm.GetTrickCtx().HasPlayerPassed(domain.SeatSouth) {
+		t.Error("Passes should be cleared after a play")
+	}
+	if err := sm.Pass(domain.SeatNorth); err != nil {
+		t.Fatalf("Failed to pass: %v", err)
+	}
+	if err := sm.Pass(domain.SeatEast); err != nil {
+		t.Fatalf("Failed to pass: %v", err)
+	}
+	if sm.GetTrickCtx().CurrentPlayer != domain.SeatSouth {
+		t.Errorf("Expected South to act again, got %s", sm.GetTrickCtx().CurrentPlayer)
+	}
+}
+
+func TestDealStateMachineSkipsFinishedSeatsAndPartnerLeads(t *testing.T) {
+	sm := newPlayingStateMachine(t, map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {domain.NewCard(domain.Spades, domain.Ace)},
+		domain.SeatSouth: {domain.NewCard(domain.Clubs, domain.Three), domain.NewCard(domain.Clubs, domain.Four)},
+		domain.SeatWest:  {domain.NewCard(domain.Hearts, domain.Three), domain.NewCard(domain.Hearts, domain.Four)},
+		domain.SeatNorth: {domain.NewCard(domain.Diamonds, domain.Three), domain.NewCard(domain.Diamonds, domain.Four)},
+	})
+	
+	if err := sm.PlayCards(domain.SeatEast, []domain.Card{domain.NewCard(domain.Spades, domain.Ace)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	if rankList := sm.GetDealCtx().RankList; len(rankList) != 1 || rankList[0] != domain.SeatEast {
+		t.Fatalf("Expected East to finish first, got %v", rankList)
+	}
+	
+	for _, seat := range []domain.SeatID{domain.SeatSouth, domain.SeatWest, domain.SeatNorth} {
+		if err := sm.Pass(seat); err != nil {
+			t.Fatalf("Failed to pass for %s: %v", seat, err)
+		}
+	}
+	
+	// 赢家East已出完，由对家West接风
+	trickCtx := sm.GetTrickCtx()
+	if trickCtx.TrickNumber != 2 {
+		t.Errorf("Expected trick number 2, got %d", trickCtx.TrickNumber)
+	}
+	if trickCtx.CurrentPlayer != domain.SeatWest {
+		t.Fatalf("Expected partner West to lead, got %s", trickCtx.CurrentPlayer)
+	}
+	
+	if err := sm.PlayCards(domain.SeatWest, []domain.Card{domain.NewCard(domain.Hearts, domain.Three)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	if err := sm.Pass(domain.SeatNorth); err != nil {
+		t.Fatalf("Failed to pass: %v", err)
+	}
+	
+	// 已出完的East被跳过
+	if sm.GetTrickCtx().CurrentPlayer != domain.SeatSouth {
+		t.Errorf("Expected finished East to be skipped, got %s", sm.GetTrickCtx().CurrentPlayer)
+	}
+}
+
+func TestDealStateMachineDoubleWinEndsDeal(t *testing.T) {
+	sm := newPlayingStateMachine(t, map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {domain.NewCard(domain.Spades, domain.Ace)},
+		domain.SeatSouth: {domain.NewCard(domain.Clubs, domain.Three), domain.NewCard(domain.Clubs, domain.Four)},
+		domain.SeatWest:  {domain.NewCard(domain.Hearts, domain.Three)},
+		domain.SeatNorth: {domain.NewCard(domain.Diamonds, domain.Three), domain.NewCard(domain.Diamonds, domain.Four)},
+	})
+	
+	if err := sm.PlayCards(domain.SeatEast, []domain.Card{domain.NewCard(domain.Spades, domain.Ace)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	for _, seat := range []domain.SeatID{domain.SeatSouth, domain.SeatWest, domain.SeatNorth} {
+		if err := sm.Pass(seat); err != nil {
+			t.Fatalf("Failed to pass for %s: %v", seat, err)
+		}
+	}
+	if err := sm.PlayCards(domain.SeatWest, []domain.Card{domain.NewCard(domain.Hearts, domain.Three)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	
+	if sm.GetCurrentPhase() != PhaseFinished {
+		t.Fatalf("Expected deal to finish on a double win, got phase %s", sm.GetCurrentPhase())
+	}
+	
+	expected := []domain.SeatID{domain.SeatEast, domain.SeatWest, domain.SeatNorth, domain.SeatSouth}
+	rankList := sm.GetDealCtx().RankList
+	if len(rankList) != len(expected) {
+		t.Fatalf("Expected complete rank list %v, got %v", expected, rankList)
+	}
+	for i, seat := range expected {
+		if rankList[i] != seat {
+			t.Errorf("Expected rank %d to be %s, got %s", i+1, seat, rankList[i])
+		}
+	}
+}
+
+func TestDealStateMachineLevelProgression(t *testing.T) {
+	sm := newPlayingStateMachine(t, map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {domain.NewCard(domain.Spades, domain.Ace)},
+		domain.SeatSouth: {domain.NewCard(domain.Clubs, domain.Three), domain.NewCard(domain.Clubs, domain.Four)},
+		domain.SeatWest:  {domain.NewCard(domain.Hearts, domain.Three)},
+		domain.SeatNorth: {domain.NewCard(domain.Diamonds, domain.Three), domain.NewCard(domain.Diamonds, domain.Four)},
+	})
+	before := sm.GetMatchCtx()
+	
+	sm.PlayCards(domain.SeatEast, []domain.Card{domain.NewCard(domain.Spades, domain.Ace)})
+	for _, seat := range []domain.SeatID{domain.SeatSouth, domain.SeatWest, domain.SeatNorth} {
+		sm.Pass(seat)
+	}
+	if err := sm.PlayCards(domain.SeatWest, []domain.Card{domain.NewCard(domain.Hearts, domain.Three)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	
+	// 双上升3级，对方不变
+	matchCtx := sm.GetMatchCtx()
+	if level := matchCtx.GetTeam(domain.TeamEastWest).Level; level != domain.Five {
+		t.Errorf("Expected East-West to reach level 5, got %s", level)
+	}
+	if level := matchCtx.GetTeam(domain.TeamSouthNorth).Level; level != domain.Two {
+		t.Errorf("Expected South-North to stay at level 2, got %s", level)
+	}
+	if matchCtx.Winner != nil {
+		t.Error("The match should not end below the target level")
+	}
+	
+	// 升级复制Team，不修改之前的MatchCtx
+	if level := before.GetTeam(domain.TeamEastWest).Level; level != domain.Two {
+		t.Errorf("The previous match context should keep level 2, got %s", level)
+	}
+	
+	// 下一Deal打头游队的级数
+	ranking := sm.GetDealCtx().RankList
+	if err := sm.SetDealSource(NewSeededDealSource()); err != nil {
+		t.Fatalf("Failed to set deal source: %v", err)
+	}
+	if err := sm.StartDeal(2, ranking); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	if err := sm.DealCards(); err != nil {
+		t.Fatalf("Failed to deal cards: %v", err)
+	}
+	if err := sm.DetermineTrump(); err != nil {
+		t.Fatalf("Failed to determine trump: %v", err)
+	}
+	if trump := sm.GetDealCtx().Trump; trump != domain.Five {
+		t.Errorf("Expected the second deal to be played at level 5, got %s", trump)
+	}
+}
+
+func TestDealStateMachineMatchWonAtTarget(t *testing.T) {
+	sm := newPlayingStateMachine(t, map[domain.SeatID][]domain.Card{
+		domain.SeatEast:  {domain.NewCard(domain.Spades, domain.Ace)},
+		domain.SeatSouth: {domain.NewCard(domain.Clubs, domain.Three), domain.NewCard(domain.Clubs, domain.Four)},
+		domain.SeatWest:  {domain.NewCard(domain.Hearts, domain.Three)},
+		domain.SeatNorth: {domain.NewCard(domain.Diamonds, domain.Three), domain.NewCard(domain.Diamonds, domain.Four)},
+	})
+	sm.matchCtx = sm.matchCtx.WithTeamLevel(domain.TeamEastWest, domain.Ace)
+	
+	sm.PlayCards(domain.SeatEast, []domain.Card{domain.NewCard(domain.Spades, domain.Ace)})
+	for _, seat := range []domain.SeatID{domain.SeatSouth, domain.SeatWest, domain.SeatNorth} {
+		sm.Pass(seat)
+	}
+	if err := sm.PlayCards(domain.SeatWest, []domain.Card{domain.NewCard(domain.Hearts, domain.Three)}); err != nil {
+		t.Fatalf("Failed to play cards: %v", err)
+	}
+	
+	matchCtx := sm.GetMatchCtx()
+	if matchCtx.Winner == nil || *matchCtx.Winner != domain.TeamEastWest {
+		t.Fatalf("Expected East-West to win the match at the target level, got %v", matchCtx.Winner)
+	}
+	if level := matchCtx.GetTeam(domain.TeamEastWest).Level; level != domain.Ace {
+		t.Errorf("Expected the winning level to stay at A, got %s", level)
+	}
+}
+
+func TestDealStateMachineDoubleDownTributePool(t *testing.T) {
+	hands, _ := domain.DealFromSeed(domain.DeriveDealSeed(12345, 2))
+	deal := &Deal{}
+	copy(deal.Hands[:], hands)
+	
+	// 两张大王都放到East手里，保证败方没有抗贡
+	bigJoker := domain.NewJoker(domain.BigJoker)
+	for seat := domain.SeatSouth; seat <= domain.SeatNorth; seat++ {
+		for i, card := range deal.Hands[seat] {
+			if card != bigJoker {
+				continue
+			}
+			for j, eastCard := range deal.Hands[domain.SeatEast] {
+				if eastCard != bigJoker {
+					deal.Hands[seat][i], deal.Hands[domain.SeatEast][j] = eastCard, card
+					break
+				}
+			}
+		}
+	}
+	deal.StartingCard = deal.Hands[domain.SeatEast][0]
+	source, err := NewFixedDealSource(deal, deal)
+	if err != nil {
+		t.Fatalf("Failed to create fixed deal source: %v", err)
+	}
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	matchCtx := domain.NewMatchCtx("test-match", players, 12345)
+	sm := NewDealStateMachine(matchCtx, event.NewEventBus(100))
+	sm.SetDealSource(source)
+	
+	lastRankings := []domain.SeatID{domain.SeatEast, domain.SeatWest, domain.SeatSouth, domain.SeatNorth}
+	if err := sm.StartDeal(2, lastRankings); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	if err := sm.DealCards(); err != nil {
+		t.Fatalf("Failed to deal cards: %v", err)
+	}
+	if err := sm.DetermineTrump(); err != nil {
+		t.Fatalf("Failed to determine trump: %v", err)
+	}
+	if err := sm.StartTribute(); err != nil {
+		t.Fatalf("Failed to start tribute: %v", err)
+	}
+	if sm.GetCurrentPhase() != PhaseTribute {
+		t.Fatalf("Expected tribute phase, got %s", sm.GetCurrentPhase())
+	}
+	
+	// 贡牌在第一名选择之前不进入任何人的手牌
+	for _, from := range []domain.SeatID{domain.SeatSouth, domain.SeatNorth} {
+		to := sm.GetDealCtx().TributeInfo.TributeRequests[from]
+		var card domain.Card
+		for _, option := range sm.GetTributeCardOptions(from) {
+			if domain.ValidateTributeCard(matchCtx.GetPlayer(from).GetHand(), option, sm.GetDealCtx().Trump) == nil {
+				card = option
+				break
+			}
+		}
+		if err := sm.GiveTribute(from, to, []domain.Card{card}); err != nil {
+			t.Fatalf("Failed to give tribute from %s: %v", from, err)
+		}
+		if matchCtx.GetPlayer(to).HandSize() != 27 {
+			t.Errorf("Tribute from %s should stay in the pool, %s holds %d cards", from, to, matchCtx.GetPlayer(to).HandSize())
+		}
+	}
+	
+	if sm.GetCurrentPhase() != PhaseTributeSelection {
+		t.Fatalf("Expected tribute selection phase, got %s", sm.GetCurrentPhase())
+	}
+	if err := sm.SelectTributeCard(domain.SeatSouth); err != nil {
+		t.Fatalf("Failed to select tribute card: %v", err)
+	}
+	for _, seat := range []domain.SeatID{domain.SeatEast, domain.SeatWest} {
+		if size := matchCtx.GetPlayer(seat).HandSize(); size != 28 {
+			t.Errorf("Expected %s to hold 28 cards after selection, got %d", seat, size)
+		}
+	}
+}
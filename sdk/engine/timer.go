@@ -0,0 +1,322 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// EnableTurnTimer 开启回合计时，超时的座位由引擎代为行动
+func (ge *GameEngine) EnableTurnTimer(config TimerConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+	
+	if !ge.isInitialized {
+		return fmt.Errorf("engine not initialized")
+	}
+	
+	ge.installClock(NewTurnClock(config))
+	return nil
+}
+
+// RestoreTurnClock 从快照恢复回合计时，计时中的座位保留已用时间
+func (ge *GameEngine) RestoreTurnClock(state *TurnClockState) error {
+	now := time.Now()
+	clock, err := RestoreTurnClock(state, now)
+	if err != nil {
+		return err
+	}
+	
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+	
+	if !ge.isInitialized {
+		return fmt.Errorf("engine not initialized")
+	}
+	
+	ge.installClock(clock)
+	return nil
+}
+
+// DisableTurnTimer 关闭回合计时
+func (ge *GameEngine) DisableTurnTimer() {
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+	
+	ge.stopClock()
+}
+
+// GetTurnClockState 返回当前计时状态，未开启计时时为nil
+func (ge *GameEngine) GetTurnClockState(now time.Time) *TurnClockState {
+	ge.mu.RLock()
+	defer ge.mu.RUnlock()
+	
+	if ge.clock == nil {
+		return nil
+	}
+	return ge.clock.State(now)
+}
+
+// SetTurnTimerCheck 替换后台按TickInterval调用的检查函数，nil恢复为CheckTurnTimer。
+// 服务用它在自己的锁内调用TimeoutActions，使超时行动与玩家行动走同一路径
+func (ge *GameEngine) SetTurnTimerCheck(check func(now time.Time) error) {
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+	
+	ge.timerCheck = check
+}
+
+// CheckTurnTimer 为计时中的座位发布TurnTimerTicked事件，并替超时的座位行动，见TimeoutActions
+func (ge *GameEngine) CheckTurnTimer(now time.Time) error {
+	_, err := ge.TimeoutActions(now)
+	return err
+}
+
+// TimeoutActions 为计时中的座位发布TurnTimerTicked事件，并替超时的座位行动，返回执行的行动数：
+// 跟牌时Pass，首出时出最小的单张，贡牌阶段进贡规则要求的牌，还贡阶段还最小的合法牌，
+// Double Down选择阶段选较大的贡牌。默认行动失败时改为该座位的任一合法行动，仍失败则停止
+// 该座位的计时并返回错误，不再反复重试
+func (ge *GameEngine) TimeoutActions(now time.Time) (int, error) {
+	ge.mu.Lock()
+	defer ge.mu.Unlock()
+	
+	if ge.clock == nil {
+		return 0, nil
+	}
+	
+	acted := 0
+	var errs []error
+	matchID := ge.stateMachine.GetMatchCtx().ID
+	for _, seat := range ge.clock.RunningSeats() {
+		if !ge.clock.Expired(seat, now) {
+			actionRemaining, bankRemaining := ge.clock.Remaining(seat, now)
+			ge.eventBus.Publish(event.NewTurnTimerTickedEvent(matchID, seat, actionRemaining, bankRemaining))
+			continue
+		}
+		
+		// 前一个自动行动可能已结束本阶段
+		if !ge.clock.IsRunning(seat) {
+			continue
+		}
+		
+		action, err := ge.actForSeat(seat)
+		if err != nil {
+			action, err = ge.actForSeatFallback(seat, err)
+		}
+		if err != nil {
+			ge.clock.Stop(seat, now)
+			errs = append(errs, fmt.Errorf("automatic action for %s failed: %w", seat.String(), err))
+			continue
+		}
+		ge.eventBus.Publish(event.NewTurnTimerExpiredEvent(matchID, seat, action))
+		ge.clock.Stop(seat, now)
+		ge.syncClock(now)
+		acted++
+	}
+	
+	return acted, errors.Join(errs...)
+}
+
+// installClock 替换计时器并启动后台检查（调用方持有锁）
+func (ge *GameEngine) installClock(clock *TurnClock) {
+	ge.stopClock()
+	
+	ge.clock = clock
+	ge.clockStop = make(chan struct{})
+	go ge.runTurnTimer(clock.Config().tickInterval(), ge.clockStop)
+	
+	ge.syncClock(time.Now())
+}
+
+func (ge *GameEngine) stopClock() {
+	if ge.clockStop != nil {
+		close(ge.clockStop)
+		ge.clockStop = nil
+	}
+	ge.clock = nil
+}
+
+func (ge *GameEngine) runTurnTimer(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case now := <-ticker.C:
+			ge.mu.RLock()
+			check := ge.timerCheck
+			ge.mu.RUnlock()
+			if check == nil {
+				check = ge.CheckTurnTimer
+			}
+			if err := check(now); err != nil {
+				log.Printf("turn timer: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// afterAction 座位行动后停止其计时，并为下一批需要行动的座位开始计时
+func (ge *GameEngine) afterAction(seat domain.SeatID) {
+	if ge.clock == nil {
+		return
+	}
+	
+	now := time.Now()
+	ge.clock.Stop(seat, now)
+	ge.syncClock(now)
+}
+
+// syncClock 让计时中的座位与当前需要行动的座位一致
+func (ge *GameEngine) syncClock(now time.Time) {
+	if ge.clock == nil {
+		return
+	}
+	
+	pending := make(map[domain.SeatID]bool)
+	for _, seat := range ge.pendingSeats() {
+		pending[seat] = true
+	}
+	
+	for _, seat := range ge.clock.RunningSeats() {
+		if !pending[seat] {
+			ge.clock.Stop(seat, now)
+		}
+	}
+	
+	matchID := ge.stateMachine.GetMatchCtx().ID
+	config := ge.clock.Config()
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if !pending[seat] || ge.clock.IsRunning(seat) {
+			continue
+		}
+		ge.clock.Start(seat, now)
+		_, bank := ge.clock.Remaining(seat, now)
+		ge.eventBus.Publish(event.NewTurnTimerStartedEvent(matchID, seat, config.ActionTime, bank, ge.clock.Deadline(seat)))
+	}
+}
+
+// pendingSeats 当前阶段需要行动的座位
+func (ge *GameEngine) pendingSeats() []domain.SeatID {
+	if ge.stateMachine.GetDealCtx() == nil {
+		return nil
+	}
+	return ge.stateMachine.GetState().PendingSeats()
+}
+
+// actForSeat 替超时的座位执行默认行动，返回行动名称
+func (ge *GameEngine) actForSeat(seat domain.SeatID) (string, error) {
+	action, err := ge.defaultAction(seat)
+	if err != nil {
+		return "", err
+	}
+	return timeoutActionName(action.Kind), ge.stateMachine.Apply(action)
+}
+
+// actForSeatFallback 默认行动失败后改为该座位的合法行动，能Pass时优先Pass
+func (ge *GameEngine) actForSeatFallback(seat domain.SeatID, cause error) (string, error) {
+	var fallback *Action
+	for _, action := range ge.stateMachine.GetState().LegalActions() {
+		if action.Seat != seat {
+			continue
+		}
+		if fallback == nil || action.Kind == ActionPass {
+			fallback = &action
+		}
+	}
+	if fallback == nil {
+		return "", fmt.Errorf("%w, and no legal action to fall back to", cause)
+	}
+	if err := ge.stateMachine.Apply(*fallback); err != nil {
+		return "", fmt.Errorf("%w, and fallback %s failed: %v", cause, fallback, err)
+	}
+	return timeoutActionName(fallback.Kind), nil
+}
+
+// defaultAction 超时座位的默认行动
+func (ge *GameEngine) defaultAction(seat domain.SeatID) (Action, error) {
+	sm := ge.stateMachine
+	dealCtx := sm.GetDealCtx()
+	hand := sm.GetMatchCtx().GetPlayer(seat).GetHand()
+	
+	switch sm.GetCurrentPhase() {
+	case PhaseTribute:
+		card, _ := domain.SelectTributeCard(hand, dealCtx.Trump)
+		to := dealCtx.TributeInfo.TributeRequests[seat]
+		return Action{Kind: ActionTribute, Seat: seat, Cards: []domain.Card{card}, Target: to}, nil
+	case PhaseTributeSelection:
+		return Action{Kind: ActionSelectTribute, Seat: seat, Target: largerTributeGiver(dealCtx)}, nil
+	case PhaseReturnTribute:
+		card, ok := smallestReturnCard(hand)
+		if !ok {
+			return Action{}, fmt.Errorf("no legal return tribute card")
+		}
+		to := dealCtx.TributeInfo.ReturnRequests[seat]
+		return Action{Kind: ActionReturnTribute, Seat: seat, Cards: []domain.Card{card}, Target: to}, nil
+	case PhaseFirstPlay, PhaseInProgress:
+		if sm.GetTrickCtx().LastPlay != nil {
+			return Action{Kind: ActionPass, Seat: seat}, nil
+		}
+		card := smallestSingle(hand, dealCtx.Trump)
+		return Action{Kind: ActionPlay, Seat: seat, Cards: []domain.Card{card}}, nil
+	default:
+		return Action{}, fmt.Errorf("no action pending in phase %s", sm.GetCurrentPhase().String())
+	}
+}
+
+// timeoutActionName TurnTimerExpired事件中的行动名称
+func timeoutActionName(kind ActionKind) string {
+	switch kind {
+	case ActionPlay:
+		return "play"
+	case ActionPass:
+		return "pass"
+	case ActionTribute:
+		return "tribute"
+	case ActionSelectTribute:
+		return "select"
+	case ActionReturnTribute:
+		return "return"
+	default:
+		return ""
+	}
+}
+
+// largerTributeGiver Double Down中贡牌较大的一家，相同时取上局第三名
+func largerTributeGiver(dealCtx *domain.DealCtx) domain.SeatID {
+	third := dealCtx.LastRankings[2]
+	fourth := dealCtx.LastRankings[3]
+	available := dealCtx.TributeInfo.AvailableCards
+	if domain.CompareCards(available[fourth], available[third], dealCtx.Trump) == domain.CmpGreater {
+		return fourth
+	}
+	return third
+}
+
+func smallestReturnCard(hand []domain.Card) (domain.Card, bool) {
+	for _, card := range domain.GetReturnTributeCardCandidates(hand) {
+		if domain.IsValidReturnTributeCard(hand, card) {
+			return card, true
+		}
+	}
+	return domain.Card{}, false
+}
+
+func smallestSingle(hand []domain.Card, trump domain.Rank) domain.Card {
+	smallest := hand[0]
+	for _, card := range hand[1:] {
+		if domain.CompareCards(card, smallest, trump) == domain.CmpLess {
+			smallest = card
+		}
+	}
+	return smallest
+}
@@ -0,0 +1,257 @@
+package env
+
+import (
+	"fmt"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/notation"
+)
+
+// 动作空间：按牌型与点数枚举的固定编号，花色由合法的具体牌组决定。
+//
+//	Pass                              1
+//	单张 2..A、小王、大王             15
+//	对子、三同张、四张炸弹 2..A       13 × 3
+//	王炸（小王数, 大王数）            6
+//	顺子（起点, 长度5..13）           45
+//	同花顺（花色, 起点）              36
+//	连对（起点, 对数3..13）           66
+//	钢板（起点, 三张数2..13）         78
+//	进贡、还贡（每种牌）              54 × 2
+//	选择贡牌（进贡者的相对座位）      4
+const NumActions = 398
+
+// passAction Pass的编号
+const passAction = 0
+
+// numCardKinds 牌的种类数：四种花色各13张加大小王
+const numCardKinds = 54
+
+// actionKey 动作空间中一个编号的含义
+type actionKey struct {
+	kind     bot.ActionKind
+	category domain.CardCategory // 出牌的牌型，其他行动为InvalidCategory
+	rank     domain.Rank         // 起始点数；王炸为小王数
+	length   int                 // 顺子、连对、钢板的点数个数；王炸为大王数
+	suit     domain.Suit         // 同花顺的花色，其他为domain.Joker
+	card     domain.Card         // 进贡、还贡的牌
+	relation int                 // 选择贡牌时进贡者的相对座位
+}
+
+var (
+	actionSpace []actionKey
+	actionIndex = make(map[actionKey]int)
+)
+
+func init() {
+	add := func(key actionKey) {
+		actionIndex[key] = len(actionSpace)
+		actionSpace = append(actionSpace, key)
+	}
+	play := func(category domain.CardCategory, rank domain.Rank, length int) {
+		add(actionKey{kind: bot.ActionPlay, category: category, rank: rank, length: length, suit: domain.Joker})
+	}
+	
+	add(actionKey{kind: bot.ActionPass, suit: domain.Joker})
+	for _, rank := range append(ranks(), domain.SmallJoker, domain.BigJoker) {
+		play(domain.Single, rank, 1)
+	}
+	for _, category := range []domain.CardCategory{domain.Pair, domain.Triple, domain.Bomb} {
+		for _, rank := range ranks() {
+			play(category, rank, 1)
+		}
+	}
+	for _, jokers := range [][2]int{{2, 0}, {1, 1}, {0, 2}, {2, 1}, {1, 2}, {2, 2}} {
+		play(domain.JokerBomb, domain.Rank(jokers[0]), jokers[1])
+	}
+	sequence := func(category domain.CardCategory, minLength int) {
+		for length := minLength; length <= 13; length++ {
+			for start := domain.Two; int(start)+length-1 <= int(domain.Ace); start++ {
+				play(category, start, length)
+			}
+		}
+	}
+	sequence(domain.Straight, 5)
+	for suit := domain.Hearts; suit <= domain.Spades; suit++ {
+		for start := domain.Two; start <= domain.Ten; start++ {
+			add(actionKey{kind: bot.ActionPlay, category: domain.Straight, rank: start, length: 5, suit: suit})
+		}
+	}
+	sequence(domain.PairStraight, 3)
+	sequence(domain.TripleStraight, 2)
+	for _, kind := range []bot.ActionKind{bot.ActionTribute, bot.ActionReturnTribute} {
+		for index := 0; index < numCardKinds; index++ {
+			add(actionKey{kind: kind, suit: domain.Joker, card: cardFromIndex(index)})
+		}
+	}
+	for relation := 0; relation < 4; relation++ {
+		add(actionKey{kind: bot.ActionSelectTribute, suit: domain.Joker, relation: relation})
+	}
+	
+	if len(actionSpace) != NumActions {
+		panic(fmt.Sprintf("action space has %d actions, expected %d", len(actionSpace), NumActions))
+	}
+}
+
+// ranks 2到A
+func ranks() []domain.Rank {
+	ranks := make([]domain.Rank, 0, 13)
+	for rank := domain.Two; rank <= domain.Ace; rank++ {
+		ranks = append(ranks, rank)
+	}
+	return ranks
+}
+
+// cardIndex 牌的种类编号：花色*13+点数，小王52，大王53
+func cardIndex(card domain.Card) int {
+	if card.IsJoker() {
+		return 52 + int(card.Rank-domain.SmallJoker)
+	}
+	return int(card.Suit)*13 + int(card.Rank)
+}
+
+func cardFromIndex(index int) domain.Card {
+	if index >= 52 {
+		return domain.NewJoker(domain.SmallJoker + domain.Rank(index-52))
+	}
+	return domain.NewCard(domain.Suit(index/13), domain.Rank(index%13))
+}
+
+// relation other相对seat的位置：0自己，1下家，2对家，3上家
+func relation(seat, other domain.SeatID) int {
+	return (int(other) - int(seat) + 4) % 4
+}
+
+// groupKey 合法牌组在动作空间中的含义，不在动作空间中（如多于4张的炸弹）时返回false
+func groupKey(group *domain.CardGroup) (actionKey, bool) {
+	key := actionKey{kind: bot.ActionPlay, category: group.Category, rank: group.Rank, length: 1, suit: domain.Joker}
+	switch group.Category {
+	case domain.Single, domain.Pair, domain.Triple:
+	case domain.Bomb:
+		if group.Size != 4 {
+			return actionKey{}, false
+		}
+	case domain.JokerBomb:
+		key.rank, key.length = 0, 0
+		for _, card := range group.Cards {
+			if card.Rank == domain.SmallJoker {
+				key.rank++
+			} else {
+				key.length++
+			}
+		}
+	case domain.Straight:
+		key.length = group.Size
+		if group.GetCATValue() > 0 {
+			key.suit = group.Cards[0].Suit
+		}
+	case domain.PairStraight:
+		key.length = group.Size / 2
+	case domain.TripleStraight:
+		key.length = group.Size / 3
+	default:
+		return actionKey{}, false
+	}
+	_, ok := actionIndex[key]
+	return key, ok
+}
+
+// LegalActions 列出obs下的合法行动，键为动作编号，值为执行时使用的具体行动。
+// 同一编号有多种具体牌组时（如花色不同的顺子）取枚举到的第一种
+func LegalActions(obs *bot.Observation) map[int]bot.Action {
+	legal := make(map[int]bot.Action)
+	switch obs.Phase {
+	case engine.PhaseFirstPlay, engine.PhaseInProgress:
+		if !obs.IsLeading() {
+			legal[passAction] = bot.Pass()
+		}
+		for _, group := range domain.EnumerateCardGroups(obs.Hand) {
+			if !obs.IsLeading() && !domain.CanFollow(group, obs.LastPlay, obs.Trump) {
+				continue
+			}
+			key, ok := groupKey(group)
+			if !ok {
+				continue
+			}
+			if _, seen := legal[actionIndex[key]]; !seen {
+				legal[actionIndex[key]] = bot.Play(group.Cards)
+			}
+		}
+	case engine.PhaseTribute:
+		for _, card := range obs.Hand {
+			if domain.ValidateTributeCard(obs.Hand, card, obs.Trump) == nil {
+				legal[actionIndex[actionKey{kind: bot.ActionTribute, suit: domain.Joker, card: card}]] = bot.Tribute(card)
+			}
+		}
+	case engine.PhaseReturnTribute:
+		for _, card := range obs.Hand {
+			if domain.IsValidReturnTributeCard(obs.Hand, card) {
+				legal[actionIndex[actionKey{kind: bot.ActionReturnTribute, suit: domain.Joker, card: card}]] = bot.ReturnTribute(card)
+			}
+		}
+	case engine.PhaseTributeSelection:
+		if obs.Tribute != nil {
+			for giver := range obs.Tribute.Available {
+				legal[actionIndex[actionKey{kind: bot.ActionSelectTribute, suit: domain.Joker, relation: relation(obs.Seat, giver)}]] = bot.SelectTribute(giver)
+			}
+		}
+	}
+	return legal
+}
+
+// ActionIndex 具体行动在动作空间中的编号，便于把其他Agent的决策作为训练标签
+func ActionIndex(obs *bot.Observation, action bot.Action) (int, bool) {
+	switch action.Kind {
+	case bot.ActionPass:
+		return passAction, true
+	case bot.ActionPlay:
+		group := domain.NewCardGroup(action.Cards)
+		if !group.IsValid() {
+			return 0, false
+		}
+		key, ok := groupKey(group)
+		return actionIndex[key], ok
+	case bot.ActionTribute, bot.ActionReturnTribute:
+		if len(action.Cards) != 1 {
+			return 0, false
+		}
+		index, ok := actionIndex[actionKey{kind: action.Kind, suit: domain.Joker, card: action.Cards[0]}]
+		return index, ok
+	case bot.ActionSelectTribute:
+		return actionIndex[actionKey{kind: action.Kind, suit: domain.Joker, relation: relation(obs.Seat, action.Target)}], true
+	}
+	return 0, false
+}
+
+// ActionName 动作编号的可读名称，如"Pass"、"Straight 5-9"、"Tribute H2"
+func ActionName(index int) string {
+	if index < 0 || index >= NumActions {
+		return fmt.Sprintf("Action(%d)", index)
+	}
+	
+	key := actionSpace[index]
+	switch key.kind {
+	case bot.ActionPass:
+		return "Pass"
+	case bot.ActionTribute, bot.ActionReturnTribute:
+		return fmt.Sprintf("%s %s", key.kind, notation.FormatCard(key.card))
+	case bot.ActionSelectTribute:
+		return fmt.Sprintf("%s +%d", key.kind, key.relation)
+	}
+	
+	switch key.category {
+	case domain.JokerBomb:
+		return fmt.Sprintf("%s %dSJ %dBJ", key.category, key.rank, key.length)
+	case domain.Straight, domain.PairStraight, domain.TripleStraight:
+		name := fmt.Sprintf("%s %s-%s", key.category, notation.FormatRank(key.rank), notation.FormatRank(key.rank+domain.Rank(key.length-1)))
+		if key.suit != domain.Joker {
+			name += " flush " + string("HDCS"[key.suit])
+		}
+		return name
+	}
+	if key.rank > domain.Ace {
+		return fmt.Sprintf("%s %s", key.category, notation.FormatCard(domain.NewJoker(key.rank)))
+	}
+	return fmt.Sprintf("%s %s", key.category, notation.FormatRank(key.rank))
+}
@@ -0,0 +1,97 @@
+package env
+
+import (
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// 观察向量的布局。座位一律按相对位置排列：0自己，1下家，2对家，3上家；
+// 牌按cardIndex排列，计数为张数（0-2），级数与主牌为2..A的one-hot
+const (
+	offsetHand       = 0                                  // 手牌 54
+	offsetPlayed     = offsetHand + numCardKinds          // 本Deal各座位打出的牌 4×54
+	offsetTable      = offsetPlayed + 4*numCardKinds      // 需要压过的牌 54
+	offsetCategory   = offsetTable + numCardKinds         // 需要压过的牌型 one-hot 9，首出时为InvalidCategory
+	offsetTablePlayer = offsetCategory + 9                // 需要压过的牌的出牌者 one-hot 4
+	offsetHandCounts = offsetTablePlayer + 4              // 各座位剩余手牌数/27 4
+	offsetFinished   = offsetHandCounts + 4               // 各座位是否已出完 4
+	offsetSeat       = offsetFinished + 4                 // 自己的绝对座位 one-hot 4
+	offsetPhase      = offsetSeat + 4                     // 阶段 one-hot 4：进贡、选择贡牌、还贡、出牌
+	offsetTrump      = offsetPhase + 4                    // 主牌点数 one-hot 13
+	offsetLevels     = offsetTrump + 13                   // 本队、对方级数 one-hot 13×2
+	offsetAvailable  = offsetLevels + 26                  // 各座位待选贡牌的(点数+1)/16，Double Down选择贡牌时使用 4
+	
+	// ObservationSize 观察向量的长度
+	ObservationSize = offsetAvailable + 4
+)
+
+// Encode 把obs编码为长度ObservationSize的数值向量写入dst并返回dst，dst为nil或长度不足时新分配
+func Encode(obs *bot.Observation, dst []float32) []float32 {
+	if len(dst) < ObservationSize {
+		dst = make([]float32, ObservationSize)
+	}
+	dst = dst[:ObservationSize]
+	for i := range dst {
+		dst[i] = 0
+	}
+	
+	for _, card := range obs.Hand {
+		dst[offsetHand+cardIndex(card)]++
+	}
+	for _, play := range obs.Plays {
+		base := offsetPlayed + relation(obs.Seat, play.Player)*numCardKinds
+		for _, card := range play.Cards {
+			dst[base+cardIndex(card)]++
+		}
+	}
+	
+	// 贡牌阶段的LastPlay可能是上一Deal最后一轮的牌，只在出牌阶段编码
+	playing := obs.Phase == engine.PhaseFirstPlay || obs.Phase == engine.PhaseInProgress
+	if playing && obs.LastPlay != nil {
+		for _, card := range obs.LastPlay.Cards {
+			dst[offsetTable+cardIndex(card)]++
+		}
+		dst[offsetCategory+int(obs.LastPlay.Category)] = 1
+		dst[offsetTablePlayer+relation(obs.Seat, obs.LastPlayer)] = 1
+	} else {
+		dst[offsetCategory+int(domain.InvalidCategory)] = 1
+	}
+	
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		dst[offsetHandCounts+relation(obs.Seat, seat)] = float32(obs.HandCounts[seat]) / 27
+		if obs.IsFinished(seat) {
+			dst[offsetFinished+relation(obs.Seat, seat)] = 1
+		}
+	}
+	dst[offsetSeat+int(obs.Seat)] = 1
+	
+	switch obs.Phase {
+	case engine.PhaseTribute:
+		dst[offsetPhase] = 1
+	case engine.PhaseTributeSelection:
+		dst[offsetPhase+1] = 1
+	case engine.PhaseReturnTribute:
+		dst[offsetPhase+2] = 1
+	case engine.PhaseFirstPlay, engine.PhaseInProgress:
+		dst[offsetPhase+3] = 1
+	}
+	
+	setRank(dst[offsetTrump:offsetTrump+13], obs.Trump)
+	team := domain.GetTeamFromSeat(obs.Seat)
+	setRank(dst[offsetLevels:offsetLevels+13], obs.Levels[team])
+	setRank(dst[offsetLevels+13:offsetLevels+26], obs.Levels[1-team])
+	
+	if obs.Tribute != nil && obs.Phase == engine.PhaseTributeSelection {
+		for giver, card := range obs.Tribute.Available {
+			dst[offsetAvailable+relation(obs.Seat, giver)] = float32(card.Rank+1) / 16
+		}
+	}
+	return dst
+}
+
+func setRank(dst []float32, rank domain.Rank) {
+	if rank >= domain.Two && rank <= domain.Ace {
+		dst[rank] = 1
+	}
+}
@@ -0,0 +1,248 @@
+// Package env 强化学习环境：调用者控制一个座位，其他座位由Agent代为行动，
+// 观察编码为固定长度的数值向量，行动从固定编号的动作空间中选取，接口仿照Gym的Reset/Step
+package env
+
+import (
+	"fmt"
+	"io"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+	"guandan/sdk/sim"
+)
+
+// maxSteps 两次决策之间其他座位行动次数的上限，防止Agent陷入死循环
+const maxSteps = 2000
+
+// Config 环境参数
+type Config struct {
+	Seat     domain.SeatID       // 由调用者控制的座位
+	Agents   [4]sim.AgentFactory // 其他座位的Agent，每局重新创建；Seat对应的项不使用
+	MaxDeals int                 // 每局最多进行的Deal数，0表示sim.DefaultMaxDeals，1表示每局只打一个Deal
+}
+
+func (c Config) maxDeals() int {
+	if c.MaxDeals <= 0 {
+		return sim.DefaultMaxDeals
+	}
+	return c.MaxDeals
+}
+
+// Observation 控制座位在一次决策时的观察
+type Observation struct {
+	Features []float32        // 长度ObservationSize，布局见Encode
+	Mask     []bool           // 长度NumActions，合法的动作为true；一局结束后全为false
+	Raw      *bot.Observation // 未编码的观察
+}
+
+// Info Step的附加信息
+type Info struct {
+	Deal     int             // 当前Deal序号，一局结束时为最后一个Deal
+	DealOver bool            // 本次Step中是否有Deal结束
+	RankList []domain.SeatID // 结束的Deal的排名，DealOver为false时为nil
+	Levels   [2]domain.Rank  // 两队的级数，按TeamID
+	Winner   *domain.TeamID  // 比赛胜者，未分出胜负时为nil
+}
+
+// Env 一局比赛的强化学习环境，一局从两队打2开始，到分出胜负或达到MaxDeals结束。
+// 奖励是每个Deal结束时控制座位所在队的升级数，对方升级时为负。Env不是并发安全的
+type Env struct {
+	config Config
+	ge     *engine.GameEngine
+	agents [4]bot.Agent
+	seed   int64
+	deal   int
+	last   []domain.SeatID // 上一Deal排名
+	winner *domain.TeamID
+	done   bool
+	raw    *bot.Observation
+	legal  map[int]bot.Action
+}
+
+// New 创建环境，调用Reset后开始第一局
+func New(config Config) (*Env, error) {
+	if !config.Seat.IsValid() {
+		return nil, fmt.Errorf("invalid seat %d", config.Seat)
+	}
+	for seat, factory := range config.Agents {
+		if factory == nil && domain.SeatID(seat) != config.Seat {
+			return nil, fmt.Errorf("no agent for seat %s", domain.SeatID(seat))
+		}
+	}
+	return &Env{config: config}, nil
+}
+
+// Reset 用seed开始新的一局，返回控制座位的第一个观察。同一seed的发牌与Agent都相同
+func (e *Env) Reset(seed int64) (*Observation, error) {
+	if err := e.reset(seed); err != nil {
+		return nil, err
+	}
+	return e.observation(), nil
+}
+
+// Step 控制座位执行动作action，其他座位行动到再次轮到控制座位或一局结束。
+// 返回新的观察、奖励、一局是否结束与附加信息；action不合法时返回错误，状态不变
+func (e *Env) Step(action int) (*Observation, float64, bool, Info, error) {
+	reward, info, err := e.step(action)
+	if err != nil {
+		return nil, 0, false, Info{}, err
+	}
+	return e.observation(), reward, e.done, info, nil
+}
+
+// Close 关闭实现了io.Closer的Agent
+func (e *Env) Close() {
+	for seat, agent := range e.agents {
+		if closer, ok := agent.(io.Closer); ok {
+			closer.Close()
+		}
+		e.agents[seat] = nil
+	}
+}
+
+func (e *Env) reset(seed int64) error {
+	e.Close()
+	
+	players := make([]*domain.Player, 4)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		players[seat] = domain.NewPlayer(fmt.Sprintf("env-%s", seat), seat.String(), seat)
+	}
+	ge := engine.NewGameEngine(event.NewEventBus(100))
+	if err := ge.Initialize(domain.NewMatchCtx(domain.MatchID(fmt.Sprintf("env-%d", seed)), players, seed)); err != nil {
+		return err
+	}
+	for seat, factory := range e.config.Agents {
+		if domain.SeatID(seat) != e.config.Seat {
+			e.agents[seat] = factory(domain.SeatID(seat), seed)
+		}
+	}
+	
+	e.ge, e.seed = ge, seed
+	e.deal, e.last, e.winner, e.done = 0, nil, nil, false
+	if err := e.startDeal(); err != nil {
+		return err
+	}
+	_, _, err := e.advance()
+	return err
+}
+
+func (e *Env) step(action int) (float64, Info, error) {
+	if e.ge == nil {
+		return 0, Info{}, fmt.Errorf("Reset must be called before Step")
+	}
+	if e.done {
+		return 0, Info{}, fmt.Errorf("episode is over, call Reset")
+	}
+	concrete, ok := e.legal[action]
+	if !ok {
+		return 0, Info{}, fmt.Errorf("action %d (%s) is not legal", action, ActionName(action))
+	}
+	if err := bot.Apply(e.ge, e.config.Seat, concrete); err != nil {
+		return 0, Info{}, fmt.Errorf("%s rejected: %w", concrete, err)
+	}
+	return e.advance()
+}
+
+func (e *Env) startDeal() error {
+	e.deal++
+	if err := e.ge.StartDeal(e.deal, e.last); err != nil {
+		return err
+	}
+	if err := e.ge.DealCards(); err != nil {
+		return err
+	}
+	if err := e.ge.DetermineTrump(); err != nil {
+		return err
+	}
+	return e.ge.StartTribute()
+}
+
+// advance 让其他座位行动到轮到控制座位或一局结束，结算期间结束的Deal
+func (e *Env) advance() (float64, Info, error) {
+	seat := e.config.Seat
+	reward := 0.0
+	info := Info{}
+	for steps := 0; ; steps++ {
+		if steps > maxSteps {
+			return reward, info, fmt.Errorf("deal %d: control did not return within %d actions", e.deal, maxSteps)
+		}
+		
+		switch e.ge.GetCurrentPhase() {
+		case engine.PhaseRankList, engine.PhaseFinished:
+			rankList := append([]domain.SeatID(nil), e.ge.GetDealCtx().RankList...)
+			if len(rankList) != 4 {
+				return reward, info, fmt.Errorf("deal %d: incomplete rank list %v", e.deal, rankList)
+			}
+			winner := domain.GetTeamFromSeat(rankList[0])
+			outcome := domain.DetermineTributeScenario(rankList)
+			gain := float64(domain.LevelGain(outcome))
+			if winner != domain.GetTeamFromSeat(seat) {
+				gain = -gain
+			}
+			reward += gain
+			info.DealOver, info.RankList = true, rankList
+			
+			if e.winner = e.ge.GetGameWinner(); e.winner != nil {
+				e.done = true
+			}
+			if e.deal >= e.config.maxDeals() {
+				e.done = true
+			}
+			e.last = rankList
+			if e.done {
+				return reward, e.update(info), nil
+			}
+			if err := e.startDeal(); err != nil {
+				return reward, info, fmt.Errorf("deal %d: %w", e.deal, err)
+			}
+			continue
+		}
+		
+		pending := e.ge.GetPendingSeats()
+		if len(pending) == 0 {
+			return reward, info, fmt.Errorf("deal %d: no seat to act in phase %s", e.deal, e.ge.GetCurrentPhase())
+		}
+		if pending[0] == seat {
+			return reward, e.update(info), nil
+		}
+		if _, _, err := bot.Step(e.ge, e.agents); err != nil {
+			return reward, info, fmt.Errorf("deal %d: %w", e.deal, err)
+		}
+	}
+}
+
+// update 记录控制座位当前的观察与合法动作，补全info
+func (e *Env) update(info Info) Info {
+	e.raw = bot.Observe(e.ge, e.config.Seat)
+	e.legal = nil
+	if !e.done {
+		e.legal = LegalActions(e.raw)
+	}
+	
+	info.Deal = e.deal
+	info.Levels = e.raw.Levels
+	info.Winner = e.winner
+	return info
+}
+
+// write 把当前观察写入features与mask
+func (e *Env) write(features []float32, mask []bool) {
+	Encode(e.raw, features)
+	for i := range mask {
+		mask[i] = false
+	}
+	for index := range e.legal {
+		mask[index] = true
+	}
+}
+
+func (e *Env) observation() *Observation {
+	obs := &Observation{
+		Features: make([]float32, ObservationSize),
+		Mask:     make([]bool, NumActions),
+		Raw:      e.raw,
+	}
+	e.write(obs.Features, obs.Mask)
+	return obs
+}
@@ -0,0 +1,211 @@
+package env
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/sim"
+)
+
+func heuristicConfig(seat domain.SeatID, maxDeals int) Config {
+	factory := func(domain.SeatID, int64) bot.Agent { return bot.NewHeuristic() }
+	return Config{
+		Seat:     seat,
+		Agents:   [4]sim.AgentFactory{factory, factory, factory, factory},
+		MaxDeals: maxDeals,
+	}
+}
+
+func legalIndices(mask []bool) []int {
+	var indices []int
+	for index, legal := range mask {
+		if legal {
+			indices = append(indices, index)
+		}
+	}
+	return indices
+}
+
+func TestActionSpace(t *testing.T) {
+	names := make(map[string]int)
+	for index := 0; index < NumActions; index++ {
+		name := ActionName(index)
+		if other, seen := names[name]; seen {
+			t.Errorf("Actions %d and %d are both named %q", other, index, name)
+		}
+		names[name] = index
+	}
+	
+	cards := func(text ...string) []domain.Card {
+		parsed := make([]domain.Card, len(text))
+		for i, s := range text {
+			card, err := domain.ParseCard(s)
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", s, err)
+			}
+			parsed[i] = card
+		}
+		return parsed
+	}
+	for _, tc := range []struct {
+		cards []string
+		name  string
+	}{
+		{[]string{"SJ"}, "Single SJ"},
+		{[]string{"H5", "D5"}, "Pair 5"},
+		{[]string{"SJ", "BJ", "BJ"}, "JokerBomb 1SJ 2BJ"},
+		{[]string{"H3", "D4", "C5", "S6", "H7"}, "Straight 3-7"},
+		{[]string{"C3", "C4", "C5", "C6", "C7"}, "Straight 3-7 flush C"},
+		{[]string{"H9", "D9", "HT", "DT", "H11", "D11"}, "PairStraight 9-J"},
+	} {
+		index, ok := ActionIndex(&bot.Observation{}, bot.Play(cards(tc.cards...)))
+		if !ok || ActionName(index) != tc.name {
+			t.Errorf("%v mapped to %q, expected %q", tc.cards, ActionName(index), tc.name)
+		}
+	}
+}
+
+func TestEnvPlaysMatch(t *testing.T) {
+	e, err := New(heuristicConfig(domain.SeatSouth, 3))
+	if err != nil {
+		t.Fatalf("Failed to create env: %v", err)
+	}
+	defer e.Close()
+	
+	if _, _, _, _, err := e.Step(0); err == nil {
+		t.Error("Step before Reset should fail")
+	}
+	obs, err := e.Reset(7)
+	if err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	
+	policy := bot.NewHeuristic()
+	total, deals := 0.0, 0
+	var info Info
+	for done := false; !done; {
+		if len(obs.Features) != ObservationSize || len(obs.Mask) != NumActions {
+			t.Fatalf("Unexpected sizes %d and %d", len(obs.Features), len(obs.Mask))
+		}
+		for index := range LegalActions(obs.Raw) {
+			if !obs.Mask[index] {
+				t.Fatalf("Legal action %s is masked", ActionName(index))
+			}
+		}
+		if obs.Raw.Phase == engine.PhaseInProgress && obs.Raw.LastPlay != nil && !obs.Mask[0] {
+			t.Fatal("Pass should be legal when following")
+		}
+		
+		action, err := policy.Act(obs.Raw)
+		if err != nil {
+			t.Fatalf("Policy failed: %v", err)
+		}
+		index, ok := ActionIndex(obs.Raw, action)
+		if !ok || !obs.Mask[index] {
+			t.Fatalf("Heuristic action %s maps to masked action %s", action, ActionName(index))
+		}
+		
+		var reward float64
+		obs, reward, done, info, err = e.Step(index)
+		if err != nil {
+			t.Fatalf("Step %s failed: %v", ActionName(index), err)
+		}
+		total += reward
+		if info.DealOver {
+			deals++
+			if reward == 0 || len(info.RankList) != 4 {
+				t.Errorf("Deal end should carry a reward and rank list, got %v and %v", reward, info.RankList)
+			}
+		} else if reward != 0 {
+			t.Errorf("Reward %v without a deal ending", reward)
+		}
+	}
+	
+	if deals != 3 || info.Deal != 3 {
+		t.Errorf("Expected 3 deals, got %d (info %d)", deals, info.Deal)
+	}
+	if len(legalIndices(obs.Mask)) != 0 {
+		t.Error("Mask should be empty after the episode")
+	}
+	// 对方升级数的和等于本队奖励的相反数，因此本队奖励之和为两队从2起的升级数之差
+	team := domain.GetTeamFromSeat(domain.SeatSouth)
+	if gained := float64(info.Levels[team] - info.Levels[1-team]); info.Winner == nil && total != gained {
+		t.Errorf("Rewards add up to %v, levels differ by %v", total, gained)
+	}
+	if _, _, _, _, err := e.Step(0); err == nil {
+		t.Error("Step after the episode should fail")
+	}
+}
+
+func TestEnvRejectsIllegalAction(t *testing.T) {
+	e, err := New(heuristicConfig(domain.SeatEast, 1))
+	if err != nil {
+		t.Fatalf("Failed to create env: %v", err)
+	}
+	obs, err := e.Reset(3)
+	if err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	
+	for index, legal := range obs.Mask {
+		if legal {
+			continue
+		}
+		if _, _, _, _, err := e.Step(index); err == nil {
+			t.Fatalf("Masked action %s was accepted", ActionName(index))
+		}
+		break
+	}
+	if _, _, _, _, err := e.Step(legalIndices(obs.Mask)[0]); err != nil {
+		t.Errorf("Legal action failed after a rejected one: %v", err)
+	}
+}
+
+// runVec 用固定种子的随机策略推进steps步，返回奖励之和与最后的观察
+func runVec(t *testing.T, workers, steps int) (float64, []float32) {
+	t.Helper()
+	
+	v, err := NewVec(heuristicConfig(domain.SeatNorth, 1), 6, workers)
+	if err != nil {
+		t.Fatalf("Failed to create vec: %v", err)
+	}
+	defer v.Close()
+	
+	batch, err := v.Reset(100)
+	if err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	total, episodes := 0.0, 0
+	actions := make([]int, v.Len())
+	for step := 0; step < steps; step++ {
+		for i := range actions {
+			legal := legalIndices(batch.Mask[i*NumActions : (i+1)*NumActions])
+			actions[i] = legal[rng.Intn(len(legal))]
+		}
+		if batch, err = v.Step(actions); err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		for i, done := range batch.Dones {
+			total += batch.Rewards[i]
+			if done {
+				episodes++
+			}
+		}
+	}
+	if episodes == 0 {
+		t.Errorf("No episode finished in %d steps", steps)
+	}
+	return total, append([]float32(nil), batch.Features...)
+}
+
+func TestVecIsDeterministic(t *testing.T) {
+	serialReward, serialFeatures := runVec(t, 1, 60)
+	parallelReward, parallelFeatures := runVec(t, 3, 60)
+	if serialReward != parallelReward || !reflect.DeepEqual(serialFeatures, parallelFeatures) {
+		t.Errorf("Results depend on workers: %v vs %v", serialReward, parallelReward)
+	}
+}
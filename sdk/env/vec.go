@@ -0,0 +1,156 @@
+package env
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Batch 一批环境的观察与Step结果，第i个环境的观察是
+// Features[i*ObservationSize:(i+1)*ObservationSize]与Mask[i*NumActions:(i+1)*NumActions]。
+// Vec在每次调用时复用同一个Batch，需要保留时由调用者复制
+type Batch struct {
+	Features []float32
+	Mask     []bool
+	Rewards  []float64
+	Dones    []bool
+	Infos    []Info
+}
+
+// Vec 批量环境：n个Env分成workers组，每个goroutine依次推进一组中的所有Env。
+// 一局结束的Env在同一次Step中自动开始下一局，返回的观察属于新的一局，Rewards、Dones与Infos属于结束的一局
+type Vec struct {
+	envs    []*Env
+	workers int
+	stride  int64   // 同一个Env相邻两局种子的间隔
+	seeds   []int64 // 每个Env下一局的种子
+	batch   *Batch
+}
+
+// NewVec 创建n个使用同一config的环境，workers为0时使用runtime.NumCPU()
+func NewVec(config Config, n, workers int) (*Vec, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of environments must be positive")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > n {
+		workers = n
+	}
+	
+	v := &Vec{
+		envs:    make([]*Env, n),
+		workers: workers,
+		stride:  int64(n),
+		seeds:   make([]int64, n),
+		batch: &Batch{
+			Features: make([]float32, n*ObservationSize),
+			Mask:     make([]bool, n*NumActions),
+			Rewards:  make([]float64, n),
+			Dones:    make([]bool, n),
+			Infos:    make([]Info, n),
+		},
+	}
+	for i := range v.envs {
+		env, err := New(config)
+		if err != nil {
+			return nil, err
+		}
+		v.envs[i] = env
+	}
+	return v, nil
+}
+
+// Len 环境数
+func (v *Vec) Len() int {
+	return len(v.envs)
+}
+
+// Env 第i个环境，用于查看未编码的观察
+func (v *Vec) Env(i int) *Env {
+	return v.envs[i]
+}
+
+// Reset 重新开始所有环境：第i个环境的第k局（从0起）使用种子seed+i+k*Len()，结果与workers无关
+func (v *Vec) Reset(seed int64) (*Batch, error) {
+	for i := range v.seeds {
+		v.seeds[i] = seed + int64(i)
+	}
+	err := v.run(func(i int) error {
+		v.batch.Rewards[i], v.batch.Dones[i], v.batch.Infos[i] = 0, false, Info{}
+		return v.resetEnv(i)
+	})
+	return v.batch, err
+}
+
+// Step 第i个环境执行actions[i]
+func (v *Vec) Step(actions []int) (*Batch, error) {
+	if len(actions) != len(v.envs) {
+		return nil, fmt.Errorf("expected %d actions, got %d", len(v.envs), len(actions))
+	}
+	
+	err := v.run(func(i int) error {
+		env := v.envs[i]
+		reward, info, err := env.step(actions[i])
+		if err != nil {
+			return err
+		}
+		v.batch.Rewards[i], v.batch.Dones[i], v.batch.Infos[i] = reward, env.done, info
+		if env.done {
+			return v.resetEnv(i)
+		}
+		v.write(i)
+		return nil
+	})
+	return v.batch, err
+}
+
+// Close 关闭所有环境的Agent
+func (v *Vec) Close() {
+	for _, env := range v.envs {
+		env.Close()
+	}
+}
+
+func (v *Vec) resetEnv(i int) error {
+	seed := v.seeds[i]
+	v.seeds[i] += v.stride
+	if err := v.envs[i].reset(seed); err != nil {
+		return fmt.Errorf("seed %d: %w", seed, err)
+	}
+	v.write(i)
+	return nil
+}
+
+func (v *Vec) write(i int) {
+	v.envs[i].write(
+		v.batch.Features[i*ObservationSize:(i+1)*ObservationSize],
+		v.batch.Mask[i*NumActions:(i+1)*NumActions],
+	)
+}
+
+// run 把环境按连续区间分给workers个goroutine，返回编号最小的环境的错误
+func (v *Vec) run(f func(i int) error) error {
+	errs := make([]error, len(v.envs))
+	var wg sync.WaitGroup
+	chunk := (len(v.envs) + v.workers - 1) / v.workers
+	for start := 0; start < len(v.envs); start += chunk {
+		end := min(start+chunk, len(v.envs))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				errs[i] = f(i)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("environment %d: %w", i, err)
+		}
+	}
+	return nil
+}
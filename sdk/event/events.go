@@ -32,9 +32,9 @@ func (e BaseEvent) MatchID() domain.MatchID {
 
 type MatchCreatedEvent struct {
 	BaseEvent
-	Players []domain.Player
-	Teams   [2]domain.Team
-	Seed    int64
+	Players        []domain.Player
+	Teams          [2]domain.Team
+	SeedCommitment string // 比赛种子的承诺值，种子由所有Deal种子的来源，比赛结束时才在MatchEndedEvent中公开
 }
 
 func NewMatchCreatedEvent(matchID domain.MatchID, players []domain.Player, teams [2]domain.Team, seed int64) *MatchCreatedEvent {
@@ -44,9 +44,9 @@ func NewMatchCreatedEvent(matchID domain.MatchID, players []domain.Player, teams
 			EventTime:     time.Now(),
 			MatchIDValue:  matchID,
 		},
-		Players: players,
-		Teams:   teams,
-		Seed:    seed,
+		Players:        players,
+		Teams:          teams,
+		SeedCommitment: domain.MatchSeedCommitment(seed),
 	}
 }
 
@@ -55,9 +55,10 @@ type DealStartedEvent struct {
 	DealNumber  int
 	Trump       domain.Rank
 	FirstPlayer domain.SeatID
+	Levels      [2]domain.Rank // 本Deal开始时两队的级数，按TeamID
 }
 
-func NewDealStartedEvent(matchID domain.MatchID, dealNumber int, trump domain.Rank, firstPlayer domain.SeatID) *DealStartedEvent {
+func NewDealStartedEvent(matchID domain.MatchID, dealNumber int, trump domain.Rank, firstPlayer domain.SeatID, levels [2]domain.Rank) *DealStartedEvent {
 	return &DealStartedEvent{
 		BaseEvent: BaseEvent{
 			EventTypeName: "DealStarted",
@@ -67,6 +68,7 @@ func NewDealStartedEvent(matchID domain.MatchID, dealNumber int, trump domain.Ra
 		DealNumber:  dealNumber,
 		Trump:       trump,
 		FirstPlayer: firstPlayer,
+		Levels:      levels,
 	}
 }
 
@@ -86,6 +88,67 @@ func NewCardsDealtEvent(matchID domain.MatchID, hands map[domain.SeatID][]domain
 	}
 }
 
+// ShuffleRecordedEvent 发牌时公布本Deal种子的承诺，种子本身在DealEndedEvent中揭示
+type ShuffleRecordedEvent struct {
+	BaseEvent
+	DealNumber int
+	Commitment string
+}
+
+func NewShuffleRecordedEvent(matchID domain.MatchID, dealNumber int, commitment string) *ShuffleRecordedEvent {
+	return &ShuffleRecordedEvent{
+		BaseEvent: BaseEvent{
+			EventTypeName: "ShuffleRecorded",
+			EventTime:     time.Now(),
+			MatchIDValue:  matchID,
+		},
+		DealNumber: dealNumber,
+		Commitment: commitment,
+	}
+}
+
+// ShuffleCommittedEvent 公平洗牌模式下，在发牌前公布服务器种子的承诺
+type ShuffleCommittedEvent struct {
+	BaseEvent
+	DealNumber int
+	Commitment string
+}
+
+func NewShuffleCommittedEvent(matchID domain.MatchID, dealNumber int, commitment string) *ShuffleCommittedEvent {
+	return &ShuffleCommittedEvent{
+		BaseEvent: BaseEvent{
+			EventTypeName: "ShuffleCommitted",
+			EventTime:     time.Now(),
+			MatchIDValue:  matchID,
+		},
+		DealNumber: dealNumber,
+		Commitment: commitment,
+	}
+}
+
+// ShuffleRevealedEvent Deal结束后揭示服务器种子和各座位提交的客户端熵
+type ShuffleRevealedEvent struct {
+	BaseEvent
+	DealNumber  int
+	Commitment  string
+	ServerSeed  []byte
+	ClientSeeds map[domain.SeatID][]byte
+}
+
+func NewShuffleRevealedEvent(matchID domain.MatchID, dealNumber int, commitment string, serverSeed []byte, clientSeeds map[domain.SeatID][]byte) *ShuffleRevealedEvent {
+	return &ShuffleRevealedEvent{
+		BaseEvent: BaseEvent{
+			EventTypeName: "ShuffleRevealed",
+			EventTime:     time.Now(),
+			MatchIDValue:  matchID,
+		},
+		DealNumber:  dealNumber,
+		Commitment:  commitment,
+		ServerSeed:  serverSeed,
+		ClientSeeds: clientSeeds,
+	}
+}
+
 type TrumpDeterminedEvent struct {
 	BaseEvent
 	CurrentLevel domain.Rank
@@ -214,21 +277,27 @@ func NewPlayerFinishedEvent(matchID domain.MatchID, player domain.SeatID, positi
 
 type DealEndedEvent struct {
 	BaseEvent
-	DealNumber int
-	RankList   []domain.SeatID
-	WinnerTeam domain.TeamID
+	DealNumber        int
+	RankList          []domain.SeatID
+	WinnerTeam        domain.TeamID
+	DealtHands        map[domain.SeatID][]domain.Card // 发牌时的原始手牌，用于校验洗牌
+	ShuffleCommitment string                          // 公平洗牌模式下的服务器种子承诺
+	DealSeed          int64                           // 派生的Deal种子，Deal结束后才揭示（公平洗牌或预设牌局时为0）
 }
 
-func NewDealEndedEvent(matchID domain.MatchID, dealNumber int, rankList []domain.SeatID, winnerTeam domain.TeamID) *DealEndedEvent {
+func NewDealEndedEvent(matchID domain.MatchID, dealNumber int, rankList []domain.SeatID, winnerTeam domain.TeamID, dealtHands map[domain.SeatID][]domain.Card, shuffleCommitment string, dealSeed int64) *DealEndedEvent {
 	return &DealEndedEvent{
 		BaseEvent: BaseEvent{
 			EventTypeName: "DealEnded",
 			EventTime:     time.Now(),
 			MatchIDValue:  matchID,
 		},
-		DealNumber: dealNumber,
-		RankList:   rankList,
-		WinnerTeam: winnerTeam,
+		DealNumber:        dealNumber,
+		RankList:          rankList,
+		WinnerTeam:        winnerTeam,
+		DealtHands:        dealtHands,
+		ShuffleCommitment: shuffleCommitment,
+		DealSeed:          dealSeed,
 	}
 }
 
@@ -236,9 +305,10 @@ type MatchEndedEvent struct {
 	BaseEvent
 	WinnerTeam domain.TeamID
 	FinalScore map[domain.TeamID]int
+	Seed       int64 // 比赛种子，可与MatchCreatedEvent中的承诺核对
 }
 
-func NewMatchEndedEvent(matchID domain.MatchID, winnerTeam domain.TeamID, finalScore map[domain.TeamID]int) *MatchEndedEvent {
+func NewMatchEndedEvent(matchID domain.MatchID, winnerTeam domain.TeamID, finalScore map[domain.TeamID]int, seed int64) *MatchEndedEvent {
 	return &MatchEndedEvent{
 		BaseEvent: BaseEvent{
 			EventTypeName: "MatchEnded",
@@ -247,6 +317,7 @@ func NewMatchEndedEvent(matchID domain.MatchID, winnerTeam domain.TeamID, finalS
 		},
 		WinnerTeam: winnerTeam,
 		FinalScore: finalScore,
+		Seed:       seed,
 	}
 }
 
@@ -308,6 +379,99 @@ func NewFirstPlayerDeterminedEvent(matchID domain.MatchID, firstPlayer domain.Se
 	}
 }
 
+// TurnTimerStartedEvent 座位开始计时；Deadline为基础时间与剩余备用时间都用完的时刻
+type TurnTimerStartedEvent struct {
+	BaseEvent
+	Player     domain.SeatID
+	ActionTime time.Duration
+	TimeBank   time.Duration
+	Deadline   time.Time
+}
+
+func NewTurnTimerStartedEvent(matchID domain.MatchID, player domain.SeatID, actionTime, timeBank time.Duration, deadline time.Time) *TurnTimerStartedEvent {
+	return &TurnTimerStartedEvent{
+		BaseEvent: BaseEvent{
+			EventTypeName: "TurnTimerStarted",
+			EventTime:     time.Now(),
+			MatchIDValue:  matchID,
+		},
+		Player:     player,
+		ActionTime: actionTime,
+		TimeBank:   timeBank,
+		Deadline:   deadline,
+	}
+}
+
+// TurnTimerTickedEvent 计时中座位的剩余时间
+type TurnTimerTickedEvent struct {
+	BaseEvent
+	Player            domain.SeatID
+	ActionRemaining   time.Duration
+	TimeBankRemaining time.Duration
+}
+
+func NewTurnTimerTickedEvent(matchID domain.MatchID, player domain.SeatID, actionRemaining, timeBankRemaining time.Duration) *TurnTimerTickedEvent {
+	return &TurnTimerTickedEvent{
+		BaseEvent: BaseEvent{
+			EventTypeName: "TurnTimerTicked",
+			EventTime:     time.Now(),
+			MatchIDValue:  matchID,
+		},
+		Player:            player,
+		ActionRemaining:   actionRemaining,
+		TimeBankRemaining: timeBankRemaining,
+	}
+}
+
+// TurnTimerExpiredEvent 座位超时，引擎代为执行Action（pass/play/tribute/select/return）
+type TurnTimerExpiredEvent struct {
+	BaseEvent
+	Player domain.SeatID
+	Action string
+}
+
+func NewTurnTimerExpiredEvent(matchID domain.MatchID, player domain.SeatID, action string) *TurnTimerExpiredEvent {
+	return &TurnTimerExpiredEvent{
+		BaseEvent: BaseEvent{
+			EventTypeName: "TurnTimerExpired",
+			EventTime:     time.Now(),
+			MatchIDValue:  matchID,
+		},
+		Player: player,
+		Action: action,
+	}
+}
+
+// 座位操作方切换的原因
+const (
+	ControlReasonDisconnect = "disconnect" // 玩家断线超过等待时间
+	ControlReasonRequest    = "request"    // 玩家请求托管
+	ControlReasonReconnect  = "reconnect"  // 玩家重新连接
+	ControlReasonCancel     = "cancel"     // 玩家取消托管
+	ControlReasonLobby      = "lobby"      // 开局前在大厅为空座位添加的机器人
+)
+
+// SeatControlChangedEvent 座位改由机器人代打（托管）或交还玩家；机器人的行动与玩家一样经由服务执行
+type SeatControlChangedEvent struct {
+	BaseEvent
+	Player domain.SeatID
+	Bot    bool
+	Reason string
+}
+
+func NewSeatControlChangedEvent(matchID domain.MatchID, player domain.SeatID, bot bool, reason string) *SeatControlChangedEvent {
+	return &SeatControlChangedEvent{
+		BaseEvent: BaseEvent{
+			EventTypeName: "SeatControlChanged",
+			EventTime:     time.Now(),
+			MatchIDValue:  matchID,
+		},
+		Player: player,
+		Bot:    bot,
+		Reason: reason,
+	}
+}
+
 type EventBus struct {
 	mu           sync.RWMutex
 	subscribers  map[domain.MatchID][]chan<- DomainEvent
@@ -0,0 +1,242 @@
+package notation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"guandan/sdk/domain"
+)
+
+// Guandan Deal Notation (GDN)
+//
+// A plain-text record of one deal, modelled on PBN for bridge. A record is a
+// sequence of lines; blank lines and lines starting with '%' are ignored.
+//
+//	[Match "m-20240501-1"]      tag pairs: [Name "value"], value quoted like a Go string
+//	[Deal "2"]
+//	[LevelEW "5"]               team levels before the deal
+//	[LevelSN "3"]
+//	[Trump "5"]
+//	[East "Alice"]              player names
+//	[South "Bob"]
+//	[West "Carol"]
+//	[North "Dave"]
+//	[Previous "N E S W"]        ranking of the previous deal (drives tribute), deal > 1
+//	[Start "H5"]                starting card, first deal only
+//	[Result "E W S N"]          finishing order of this deal
+//
+//	East:  H2 H2 D5 ... BJ      the four hands as dealt, before tribute
+//	South: ...
+//	West:  ...
+//	North: ...
+//
+//	Tribute: N>E BJ             tribute given, in order
+//	Select: W                   Double Down: the first place took the card given by W
+//	Return: E>N H3              return tribute, in order
+//
+//	1. E:H3 S:H5 W:- N:- E:-    tricks: seat:cards (comma separated) or seat:- for a pass
+//	2. E:D4,D4 S:- W:- N:-
+//
+// Seats are E, S, W, N in tricks and tributes. Cards use the ASCII form accepted
+// by domain.ParseCard: suit H/D/C/S followed by 2-9, T, J, Q, K or A, and SJ/BJ
+// for the jokers. Because "SJ" is the small joker, the jack of spades is written
+// "S11". Levels and trump are written as a bare rank.
+
+// Transfer 一次贡牌或还贡
+type Transfer struct {
+	From domain.SeatID
+	To   domain.SeatID
+	Card domain.Card
+}
+
+// Action 一次出牌；Cards为空表示Pass
+type Action struct {
+	Seat  domain.SeatID
+	Cards []domain.Card
+}
+
+func (a Action) IsPass() bool {
+	return len(a.Cards) == 0
+}
+
+// Trick 一轮出牌
+type Trick struct {
+	Actions []Action
+}
+
+// Record 一个Deal的完整记录
+type Record struct {
+	Match        string
+	Deal         int
+	Levels       [2]domain.Rank // 按TeamID索引
+	Trump        domain.Rank
+	Players      [4]string      // 按座位索引的玩家名称
+	Previous     []domain.SeatID
+	StartingCard *domain.Card
+	Result       []domain.SeatID
+	
+	Hands     [4][]domain.Card
+	Tributes  []Transfer
+	Selection *domain.SeatID // Double Down中第一名选择的贡牌来自哪一家
+	Returns   []Transfer
+	Tricks    []Trick
+}
+
+// NewRecord 创建空记录，等级默认为2
+func NewRecord() *Record {
+	return &Record{
+		Deal:   1,
+		Levels: [2]domain.Rank{domain.Two, domain.Two},
+		Trump:  domain.Two,
+	}
+}
+
+// FormatCard 返回domain.ParseCard可解析的ASCII牌面
+func FormatCard(card domain.Card) string {
+	if card.IsJoker() {
+		if card.Rank == domain.BigJoker {
+			return "BJ"
+		}
+		return "SJ"
+	}
+	
+	if card.Suit == domain.Spades && card.Rank == domain.Jack {
+		return "S11"
+	}
+	
+	return suitLetter(card.Suit) + FormatRank(card.Rank)
+}
+
+// FormatRank 返回等级/点数的ASCII写法（10写作T）
+func FormatRank(rank domain.Rank) string {
+	if rank == domain.Ten {
+		return "T"
+	}
+	return rank.String()
+}
+
+// ParseRank 解析FormatRank的输出
+func ParseRank(text string) (domain.Rank, error) {
+	card, err := domain.ParseCard("H" + text)
+	if err != nil {
+		return domain.Two, fmt.Errorf("invalid rank: %s", text)
+	}
+	return card.Rank, nil
+}
+
+// SeatLetter 座位的单字母写法
+func SeatLetter(seat domain.SeatID) string {
+	return seat.String()[:1]
+}
+
+// ParseSeat 解析座位（单字母或完整英文名，不区分大小写）
+func ParseSeat(text string) (domain.SeatID, error) {
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if strings.EqualFold(text, SeatLetter(seat)) || strings.EqualFold(text, seat.String()) {
+			return seat, nil
+		}
+	}
+	return domain.SeatEast, fmt.Errorf("invalid seat: %s", text)
+}
+
+func suitLetter(suit domain.Suit) string {
+	switch suit {
+	case domain.Hearts:
+		return "H"
+	case domain.Diamonds:
+		return "D"
+	case domain.Clubs:
+		return "C"
+	case domain.Spades:
+		return "S"
+	default:
+		return "?"
+	}
+}
+
+func formatCards(cards []domain.Card, sep string) string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = FormatCard(card)
+	}
+	return strings.Join(names, sep)
+}
+
+func formatSeats(seats []domain.SeatID) string {
+	letters := make([]string, len(seats))
+	for i, seat := range seats {
+		letters[i] = SeatLetter(seat)
+	}
+	return strings.Join(letters, " ")
+}
+
+// Write 以GDN格式写出记录
+func Write(w io.Writer, rec *Record) error {
+	bw := bufio.NewWriter(w)
+	
+	tag := func(name, value string) {
+		fmt.Fprintf(bw, "[%s %s]\n", name, strconv.Quote(value))
+	}
+	
+	tag("Match", rec.Match)
+	tag("Deal", strconv.Itoa(rec.Deal))
+	tag("LevelEW", FormatRank(rec.Levels[domain.TeamEastWest]))
+	tag("LevelSN", FormatRank(rec.Levels[domain.TeamSouthNorth]))
+	tag("Trump", FormatRank(rec.Trump))
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		tag(seat.String(), rec.Players[seat])
+	}
+	if len(rec.Previous) > 0 {
+		tag("Previous", formatSeats(rec.Previous))
+	}
+	if rec.StartingCard != nil {
+		tag("Start", FormatCard(*rec.StartingCard))
+	}
+	if len(rec.Result) > 0 {
+		tag("Result", formatSeats(rec.Result))
+	}
+	
+	bw.WriteString("\n")
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		fmt.Fprintf(bw, "%-6s %s\n", seat.String()+":", formatCards(rec.Hands[seat], " "))
+	}
+	
+	if len(rec.Tributes) > 0 || rec.Selection != nil || len(rec.Returns) > 0 {
+		bw.WriteString("\n")
+	}
+	for _, t := range rec.Tributes {
+		fmt.Fprintf(bw, "Tribute: %s>%s %s\n", SeatLetter(t.From), SeatLetter(t.To), FormatCard(t.Card))
+	}
+	if rec.Selection != nil {
+		fmt.Fprintf(bw, "Select: %s\n", SeatLetter(*rec.Selection))
+	}
+	for _, t := range rec.Returns {
+		fmt.Fprintf(bw, "Return: %s>%s %s\n", SeatLetter(t.From), SeatLetter(t.To), FormatCard(t.Card))
+	}
+	
+	if len(rec.Tricks) > 0 {
+		bw.WriteString("\n")
+	}
+	for i, trick := range rec.Tricks {
+		fmt.Fprintf(bw, "%d.", i+1)
+		for _, action := range trick.Actions {
+			played := "-"
+			if !action.IsPass() {
+				played = formatCards(action.Cards, ",")
+			}
+			fmt.Fprintf(bw, " %s:%s", SeatLetter(action.Seat), played)
+		}
+		bw.WriteString("\n")
+	}
+	
+	return bw.Flush()
+}
+
+// Format 返回记录的GDN文本
+func Format(rec *Record) string {
+	var sb strings.Builder
+	Write(&sb, rec)
+	return sb.String()
+}
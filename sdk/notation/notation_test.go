@@ -0,0 +1,358 @@
+package notation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+)
+
+func newTestPlayers() []*domain.Player {
+	return []*domain.Player{
+		domain.NewPlayer("p1", "Alice", domain.SeatEast),
+		domain.NewPlayer("p2", "Bob", domain.SeatSouth),
+		domain.NewPlayer("p3", "Carol", domain.SeatWest),
+		domain.NewPlayer("p4", "Dave", domain.SeatNorth),
+	}
+}
+
+// smallestFollowing 返回能跟上桌面出牌的最小单张或对子，首家出最小单张；没有则返回nil
+func smallestFollowing(ge *engine.GameEngine, seat domain.SeatID) []domain.Card {
+	hand := ge.GetPlayerHand(seat)
+	trump := ge.GetDealCtx().Trump
+	size := 1
+	if lastPlay := ge.GetLastPlay(); lastPlay != nil {
+		switch lastPlay.Category {
+		case domain.Single:
+			size = 1
+		case domain.Pair:
+			size = 2
+		default:
+			return nil
+		}
+	}
+	
+	var best []domain.Card
+	for i, card := range hand {
+		candidate := []domain.Card{card}
+		if size == 2 {
+			candidate = nil
+			for _, other := range hand[i+1:] {
+				if other.Rank == card.Rank && other.IsJoker() == card.IsJoker() {
+					candidate = []domain.Card{card, other}
+					break
+				}
+			}
+			if candidate == nil {
+				continue
+			}
+		}
+		if ge.CanPlayCards(seat, candidate) != nil {
+			continue
+		}
+		if best == nil || domain.CompareCards(candidate[0], best[0], trump) == domain.CmpLess {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// playOut 用简单策略把当前Deal打完
+func playOut(t *testing.T, ge *engine.GameEngine) {
+	t.Helper()
+	
+	for step := 0; step < 2000; step++ {
+		phase := ge.GetCurrentPhase()
+		if phase == engine.PhaseFinished || phase == engine.PhaseRankList {
+			return
+		}
+		
+		seat := ge.GetCurrentPlayer()
+		if cards := smallestFollowing(ge, seat); cards != nil {
+			if err := ge.PlayCards(seat, cards); err != nil {
+				t.Fatalf("%s failed to play %v: %v", seat, cards, err)
+			}
+			continue
+		}
+		if err := ge.Pass(seat); err != nil {
+			t.Fatalf("%s failed to pass: %v", seat, err)
+		}
+	}
+	t.Fatal("deal did not finish")
+}
+
+// recordEvents 把订阅到的事件交给记录器，直到收到DealEnded
+func recordEvents(t *testing.T, eventChan <-chan event.DomainEvent, recorder *Recorder) {
+	t.Helper()
+	
+	timeout := time.After(2 * time.Second)
+	for !recorder.IsFinished() {
+		select {
+		case e := <-eventChan:
+			recorder.Apply(e)
+		case <-timeout:
+			t.Fatal("timed out waiting for DealEnded event")
+		}
+	}
+}
+
+func startEngine(t *testing.T, matchID domain.MatchID, source engine.DealSource) (*engine.GameEngine, *domain.MatchCtx, <-chan event.DomainEvent) {
+	t.Helper()
+	
+	eventBus := event.NewEventBus(1000)
+	eventBus.Start()
+	t.Cleanup(eventBus.Stop)
+	
+	eventChan, unsubscribe := eventBus.Subscribe(matchID)
+	t.Cleanup(unsubscribe)
+	
+	matchCtx := domain.NewMatchCtx(matchID, newTestPlayers(), 20240501)
+	ge := engine.NewGameEngine(eventBus)
+	if err := ge.Initialize(matchCtx); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	if source != nil {
+		if err := ge.SetDealSource(source); err != nil {
+			t.Fatalf("Failed to set deal source: %v", err)
+		}
+	}
+	return ge, matchCtx, eventChan
+}
+
+func TestFormatCardRoundTrip(t *testing.T) {
+	deck := domain.NewDeck()
+	for _, card := range deck.Cards {
+		text := FormatCard(card)
+		parsed, err := domain.ParseCard(text)
+		if err != nil {
+			t.Fatalf("ParseCard(%q) failed: %v", text, err)
+		}
+		if parsed != card {
+			t.Errorf("%v formatted as %q parsed back as %v", card, text, parsed)
+		}
+	}
+	
+	if FormatCard(domain.NewCard(domain.Spades, domain.Jack)) != "S11" {
+		t.Error("Jack of spades should be written S11 to avoid the small joker")
+	}
+	if FormatCard(domain.NewCard(domain.Hearts, domain.Ten)) != "HT" {
+		t.Error("Ten should be written T")
+	}
+}
+
+func TestRecordFirstDealRoundTrip(t *testing.T) {
+	ge, matchCtx, eventChan := startEngine(t, "gdn-1", nil)
+	recorder := NewRecorder(matchCtx, nil)
+	
+	if err := ge.StartDeal(1, nil); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	recorder.SetStartingCard(*ge.GetStartingCard())
+	
+	playOut(t, ge)
+	recordEvents(t, eventChan, recorder)
+	
+	rec := recorder.Record()
+	if len(rec.Result) != 4 {
+		t.Fatalf("Expected a full ranking, got %v", rec.Result)
+	}
+	if rec.Players[domain.SeatWest] != "Carol" {
+		t.Errorf("Expected West to be Carol, got %q", rec.Players[domain.SeatWest])
+	}
+	
+	text := Format(rec)
+	parsed, err := ParseString(text)
+	if err != nil {
+		t.Fatalf("Failed to parse written record: %v\n%s", err, text)
+	}
+	
+	if again := Format(parsed); again != text {
+		t.Errorf("Record did not round-trip:\n%s\n---\n%s", text, again)
+	}
+	
+	replayed, err := Replay(parsed)
+	if err != nil {
+		t.Fatalf("Failed to replay record: %v\n%s", err, text)
+	}
+	if winner := rec.Result[0]; len(replayed.GetPlayerHand(winner)) != 0 {
+		t.Errorf("%s finished first but still has cards after replay", winner)
+	}
+	
+	// 没有Start标签时由首个出牌者推导Starting Card
+	parsed.StartingCard = nil
+	if _, err := Replay(parsed); err != nil {
+		t.Errorf("Replay without starting card failed: %v", err)
+	}
+}
+
+func TestRecordDoubleDownTribute(t *testing.T) {
+	// 上一Deal East、West双上：South、North分别进贡，East先选
+	previous := []domain.SeatID{domain.SeatEast, domain.SeatWest, domain.SeatSouth, domain.SeatNorth}
+	source := engine.NewConstrainedDealSource(engine.HoldsCards(domain.SeatEast,
+		domain.NewJoker(domain.BigJoker),
+		domain.NewJoker(domain.BigJoker),
+	))
+	
+	ge, matchCtx, eventChan := startEngine(t, "gdn-2", source)
+	matchCtx.GetTeam(domain.TeamEastWest).Level = domain.Five
+	recorder := NewRecorder(matchCtx, previous)
+	
+	if err := ge.StartDeal(2, previous); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	ge.DealCards()
+	ge.DetermineTrump()
+	if err := ge.StartTribute(); err != nil {
+		t.Fatalf("Failed to start tribute: %v", err)
+	}
+	
+	trump := ge.GetDealCtx().Trump
+	for _, giver := range []domain.SeatID{domain.SeatSouth, domain.SeatNorth} {
+		card, _ := domain.SelectTributeCard(ge.GetPlayerHand(giver), trump)
+		to := ge.GetDealCtx().TributeInfo.TributeRequests[giver]
+		if err := ge.GiveTribute(giver, to, []domain.Card{card}); err != nil {
+			t.Fatalf("Failed to give tribute from %s: %v", giver, err)
+		}
+	}
+	if err := ge.SelectTributeCard(domain.SeatNorth); err != nil {
+		t.Fatalf("Failed to select tribute card: %v", err)
+	}
+	for _, receiver := range []domain.SeatID{domain.SeatEast, domain.SeatWest} {
+		to := ge.GetDealCtx().TributeInfo.ReturnRequests[receiver]
+		var card domain.Card
+		for _, candidate := range domain.GetReturnTributeCardCandidates(ge.GetPlayerHand(receiver)) {
+			if domain.IsValidReturnTributeCard(ge.GetPlayerHand(receiver), candidate) {
+				card = candidate
+				break
+			}
+		}
+		if err := ge.GiveReturnTribute(receiver, to, []domain.Card{card}); err != nil {
+			t.Fatalf("Failed to return tribute from %s: %v", receiver, err)
+		}
+	}
+	
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if size := len(ge.GetPlayerHand(seat)); size != 27 {
+			t.Errorf("%s should hold 27 cards after tribute, got %d", seat, size)
+		}
+	}
+	
+	playOut(t, ge)
+	recordEvents(t, eventChan, recorder)
+	
+	rec := recorder.Record()
+	if len(rec.Tributes) != 2 || len(rec.Returns) != 2 || rec.Selection == nil {
+		t.Fatalf("Expected 2 tributes, a selection and 2 returns, got %d, %v, %d", len(rec.Tributes), rec.Selection, len(rec.Returns))
+	}
+	if rec.Trump != domain.Five {
+		t.Errorf("Expected trump 5, got %s", rec.Trump)
+	}
+	
+	text := Format(rec)
+	for _, want := range []string{"[Previous \"E W S N\"]", "Select: N", "[Trump \"5\"]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in record:\n%s", want, text)
+		}
+	}
+	
+	parsed, err := ParseString(text)
+	if err != nil {
+		t.Fatalf("Failed to parse written record: %v", err)
+	}
+	if _, err := Replay(parsed); err != nil {
+		t.Fatalf("Failed to replay record: %v\n%s", err, text)
+	}
+}
+
+func TestReplayRejectsIllegalPlay(t *testing.T) {
+	hands, _ := domain.DealFromSeed(3)
+	rec := NewRecord()
+	copy(rec.Hands[:], hands)
+	
+	// South在East出牌前抢先出牌
+	rec.Tricks = []Trick{{Actions: []Action{
+		{Seat: domain.SeatSouth, Cards: []domain.Card{hands[domain.SeatSouth][0]}},
+	}}}
+	start := hands[domain.SeatEast][0]
+	rec.StartingCard = &start
+	
+	_, err := Replay(rec)
+	if err == nil || !strings.Contains(err.Error(), "trick 1, action 1") {
+		t.Errorf("Expected an error pointing at trick 1 action 1, got %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	hands := "East: H2\nSouth: H3\nWest: H4\nNorth: H5\n"
+	
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+	}{
+		{"bad card in hand", "East: H2 X9\n", 1, 10},
+		{"unknown label", "[Deal \"1\"]\nFoo H2\n", 2, 1},
+		{"unquoted tag", "[Deal 1]\n", 1, 7},
+		{"bad trump", "[Trump \"Z\"]\n", 1, 8},
+		{"duplicate tag", "[Deal \"1\"]\n[Deal \"2\"]\n", 2, 2},
+		{"bad tribute seat", hands + "Tribute: N>X BJ\n", 5, 12},
+		{"bad trick card", hands + "1. E:H2,HX\n", 5, 9},
+		{"trick out of order", hands + "2. E:H2\n", 5, 1},
+		{"bad pass token", hands + "1. E:H2 S\n", 5, 9},
+		{"missing hand", "East: H2\n", 2, 1},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseString(tt.input)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected ParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column {
+				t.Errorf("Expected line %d column %d, got %d:%d (%s)", tt.line, tt.column, parseErr.Line, parseErr.Column, parseErr.Msg)
+			}
+		})
+	}
+}
+
+func TestParseHandWrittenRecord(t *testing.T) {
+	text := `% a hand-written fragment
+[Match "club-night"]
+[Deal "1"]
+[East "Alice"]
+[Start "S11"]
+
+East:  S11 HT
+South: SJ
+West:  BJ D2
+North: C3
+
+1. E:S11 S:SJ W:BJ N:-
+2. W:D2 N:-
+`
+	rec, err := ParseString(text)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	
+	if rec.Match != "club-night" || rec.Players[domain.SeatEast] != "Alice" {
+		t.Errorf("Unexpected header: %+v", rec)
+	}
+	if *rec.StartingCard != domain.NewCard(domain.Spades, domain.Jack) {
+		t.Errorf("Expected jack of spades as starting card, got %v", *rec.StartingCard)
+	}
+	if rec.Hands[domain.SeatSouth][0] != domain.NewJoker(domain.SmallJoker) {
+		t.Errorf("Expected small joker in South's hand, got %v", rec.Hands[domain.SeatSouth][0])
+	}
+	if len(rec.Tricks) != 2 || !rec.Tricks[0].Actions[3].IsPass() {
+		t.Errorf("Unexpected tricks: %+v", rec.Tricks)
+	}
+}
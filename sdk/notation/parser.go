@@ -0,0 +1,325 @@
+package notation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"guandan/sdk/domain"
+)
+
+// ParseError 带行列位置的解析错误（行列均从1开始）
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// field 行内的一个空白分隔的字段及其起始列
+type field struct {
+	text   string
+	column int
+}
+
+func splitFields(line string) []field {
+	var fields []field
+	start := -1
+	for i, r := range line {
+		if r == ' ' || r == '\t' {
+			if start >= 0 {
+				fields = append(fields, field{text: line[start:i], column: utf8.RuneCountInString(line[:start]) + 1})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, field{text: line[start:], column: utf8.RuneCountInString(line[:start]) + 1})
+	}
+	return fields
+}
+
+type parser struct {
+	rec      *Record
+	line     int
+	seenTags map[string]bool
+	seenHand [4]bool
+}
+
+func (p *parser) errorf(column int, format string, args ...interface{}) error {
+	return &ParseError{Line: p.line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Parse 解析GDN文本
+func Parse(r io.Reader) (*Record, error) {
+	p := &parser{
+		rec:      NewRecord(),
+		seenTags: make(map[string]bool),
+	}
+	
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.line++
+		if err := p.parseLine(scanner.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if !p.seenHand[seat] {
+			return nil, &ParseError{Line: p.line + 1, Column: 1, Msg: fmt.Sprintf("missing hand for %s", seat)}
+		}
+	}
+	
+	return p.rec, nil
+}
+
+// ParseString 解析GDN字符串
+func ParseString(text string) (*Record, error) {
+	return Parse(strings.NewReader(text))
+}
+
+func (p *parser) parseLine(line string) error {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "%") {
+		return nil
+	}
+	
+	fields := splitFields(line)
+	first := fields[0]
+	
+	switch {
+	case strings.HasPrefix(first.text, "["):
+		return p.parseTag(line, first.column)
+	case strings.HasSuffix(first.text, "."):
+		return p.parseTrick(fields)
+	case strings.HasSuffix(first.text, ":"):
+		label := strings.TrimSuffix(first.text, ":")
+		switch label {
+		case "Tribute":
+			return p.parseTransfer(fields, &p.rec.Tributes)
+		case "Return":
+			return p.parseTransfer(fields, &p.rec.Returns)
+		case "Select":
+			return p.parseSelect(fields)
+		}
+		return p.parseHand(label, fields)
+	}
+	
+	return p.errorf(first.column, "unexpected %q", first.text)
+}
+
+func (p *parser) parseTag(line string, column int) error {
+	body := strings.TrimSpace(line)
+	if !strings.HasSuffix(body, "]") {
+		return p.errorf(column, "tag is not closed with ']'")
+	}
+	body = strings.TrimSuffix(strings.TrimPrefix(body, "["), "]")
+	
+	name, quoted, found := strings.Cut(body, " ")
+	if !found || name == "" {
+		return p.errorf(column+1, "expected [Name \"value\"]")
+	}
+	
+	valueColumn := column + 1 + utf8.RuneCountInString(name) + 1
+	value, err := strconv.Unquote(strings.TrimSpace(quoted))
+	if err != nil {
+		return p.errorf(valueColumn, "tag value must be a quoted string")
+	}
+	
+	if p.seenTags[name] {
+		return p.errorf(column+1, "duplicate tag %s", name)
+	}
+	p.seenTags[name] = true
+	
+	if err := p.applyTag(name, value); err != nil {
+		return p.errorf(valueColumn, "%s: %v", name, err)
+	}
+	return nil
+}
+
+func (p *parser) applyTag(name, value string) error {
+	switch name {
+	case "Match":
+		p.rec.Match = value
+	case "Deal":
+		deal, err := strconv.Atoi(value)
+		if err != nil || deal < 1 {
+			return fmt.Errorf("invalid deal number %q", value)
+		}
+		p.rec.Deal = deal
+	case "LevelEW", "LevelSN", "Trump":
+		rank, err := ParseRank(value)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "LevelEW":
+			p.rec.Levels[domain.TeamEastWest] = rank
+		case "LevelSN":
+			p.rec.Levels[domain.TeamSouthNorth] = rank
+		default:
+			p.rec.Trump = rank
+		}
+	case "East", "South", "West", "North":
+		seat, _ := ParseSeat(name)
+		p.rec.Players[seat] = value
+	case "Previous", "Result":
+		seats, err := parseRanking(value)
+		if err != nil {
+			return err
+		}
+		if name == "Previous" {
+			p.rec.Previous = seats
+		} else {
+			p.rec.Result = seats
+		}
+	case "Start":
+		card, err := domain.ParseCard(value)
+		if err != nil {
+			return err
+		}
+		p.rec.StartingCard = &card
+	default:
+		// 未知标签忽略，便于扩展
+	}
+	return nil
+}
+
+func parseRanking(value string) ([]domain.SeatID, error) {
+	var seats []domain.SeatID
+	seen := make(map[domain.SeatID]bool)
+	for _, text := range strings.Fields(value) {
+		seat, err := ParseSeat(text)
+		if err != nil {
+			return nil, err
+		}
+		if seen[seat] {
+			return nil, fmt.Errorf("seat %s listed twice", seat)
+		}
+		seen[seat] = true
+		seats = append(seats, seat)
+	}
+	return seats, nil
+}
+
+func (p *parser) parseHand(label string, fields []field) error {
+	seat, err := ParseSeat(label)
+	if err != nil {
+		return p.errorf(fields[0].column, "%v", err)
+	}
+	if p.seenHand[seat] {
+		return p.errorf(fields[0].column, "duplicate hand for %s", seat)
+	}
+	p.seenHand[seat] = true
+	
+	cards := make([]domain.Card, 0, len(fields)-1)
+	for _, f := range fields[1:] {
+		card, err := domain.ParseCard(f.text)
+		if err != nil {
+			return p.errorf(f.column, "%v", err)
+		}
+		cards = append(cards, card)
+	}
+	p.rec.Hands[seat] = cards
+	return nil
+}
+
+// parseTransfer 解析"Tribute: N>E BJ"或"Return: E>N H3"
+func (p *parser) parseTransfer(fields []field, transfers *[]Transfer) error {
+	if len(fields) != 3 {
+		column := fields[len(fields)-1].column
+		return p.errorf(column, "expected \"%s <from>><to> <card>\"", fields[0].text)
+	}
+	
+	fromText, toText, found := strings.Cut(fields[1].text, ">")
+	if !found {
+		return p.errorf(fields[1].column, "expected <from>><to>, got %q", fields[1].text)
+	}
+	from, err := ParseSeat(fromText)
+	if err != nil {
+		return p.errorf(fields[1].column, "%v", err)
+	}
+	to, err := ParseSeat(toText)
+	if err != nil {
+		return p.errorf(fields[1].column+utf8.RuneCountInString(fromText)+1, "%v", err)
+	}
+	
+	card, err := domain.ParseCard(fields[2].text)
+	if err != nil {
+		return p.errorf(fields[2].column, "%v", err)
+	}
+	
+	*transfers = append(*transfers, Transfer{From: from, To: to, Card: card})
+	return nil
+}
+
+func (p *parser) parseSelect(fields []field) error {
+	if len(fields) != 2 {
+		return p.errorf(fields[0].column, "expected \"Select: <seat>\"")
+	}
+	if p.rec.Selection != nil {
+		return p.errorf(fields[0].column, "duplicate selection")
+	}
+	seat, err := ParseSeat(fields[1].text)
+	if err != nil {
+		return p.errorf(fields[1].column, "%v", err)
+	}
+	p.rec.Selection = &seat
+	return nil
+}
+
+// parseTrick 解析"3. E:D4,D4 S:- W:-"
+func (p *parser) parseTrick(fields []field) error {
+	number, err := strconv.Atoi(strings.TrimSuffix(fields[0].text, "."))
+	if err != nil {
+		return p.errorf(fields[0].column, "invalid trick number %q", fields[0].text)
+	}
+	if number != len(p.rec.Tricks)+1 {
+		return p.errorf(fields[0].column, "expected trick %d, got %d", len(p.rec.Tricks)+1, number)
+	}
+	if len(fields) == 1 {
+		return p.errorf(fields[0].column, "trick %d has no actions", number)
+	}
+	
+	trick := Trick{}
+	for _, f := range fields[1:] {
+		seatText, played, found := strings.Cut(f.text, ":")
+		if !found {
+			return p.errorf(f.column, "expected <seat>:<cards>, got %q", f.text)
+		}
+		seat, err := ParseSeat(seatText)
+		if err != nil {
+			return p.errorf(f.column, "%v", err)
+		}
+		
+		action := Action{Seat: seat}
+		if played != "-" {
+			column := f.column + utf8.RuneCountInString(seatText) + 1
+			for _, text := range strings.Split(played, ",") {
+				card, err := domain.ParseCard(text)
+				if err != nil {
+					return p.errorf(column, "%v", err)
+				}
+				action.Cards = append(action.Cards, card)
+				column += utf8.RuneCountInString(text) + 1
+			}
+		}
+		trick.Actions = append(trick.Actions, action)
+	}
+	
+	p.rec.Tricks = append(p.rec.Tricks, trick)
+	return nil
+}
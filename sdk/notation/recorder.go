@@ -0,0 +1,104 @@
+package notation
+
+import (
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// Recorder 根据引擎事件构建一个Deal的记录
+type Recorder struct {
+	rec              *Record
+	current          Trick
+	tributesExpected int
+	finished         bool
+}
+
+// NewRecorder 创建记录器，玩家名称和队伍等级取自比赛上下文，等级随后以DealStartedEvent中的为准；
+// 上一Deal的排名（Previous）不在事件中，需要由调用方设置
+func NewRecorder(matchCtx *domain.MatchCtx, previous []domain.SeatID) *Recorder {
+	rec := NewRecord()
+	rec.Match = string(matchCtx.ID)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if player := matchCtx.GetPlayer(seat); player != nil {
+			rec.Players[seat] = player.Name
+		}
+	}
+	for _, team := range matchCtx.Teams {
+		if team != nil {
+			rec.Levels[team.ID] = team.Level
+		}
+	}
+	if len(previous) > 0 {
+		rec.Previous = append([]domain.SeatID(nil), previous...)
+	}
+	
+	return &Recorder{rec: rec}
+}
+
+// Apply 处理一个领域事件
+func (r *Recorder) Apply(e event.DomainEvent) {
+	switch ev := e.(type) {
+	case *event.DealStartedEvent:
+		r.rec.Deal = ev.DealNumber
+		r.rec.Levels = ev.Levels
+	case *event.CardsDealtEvent:
+		for seat, hand := range ev.Hands {
+			r.rec.Hands[seat] = append([]domain.Card(nil), hand...)
+		}
+	case *event.TrumpDeterminedEvent:
+		r.rec.Trump = ev.Trump
+	case *event.TributeRequestedEvent:
+		r.tributesExpected = len(ev.RequiredTributes)
+	case *event.TributeGivenEvent:
+		// 贡牌与还贡使用同一事件，先到的为贡牌
+		transfer := Transfer{From: ev.From, To: ev.To, Card: ev.Cards[0]}
+		if len(r.rec.Tributes) < r.tributesExpected {
+			r.rec.Tributes = append(r.rec.Tributes, transfer)
+		} else {
+			r.rec.Returns = append(r.rec.Returns, transfer)
+		}
+	case *event.TributeCardSelectedEvent:
+		giver := ev.SelectedFrom
+		r.rec.Selection = &giver
+	case *event.CardsPlayedEvent:
+		r.current.Actions = append(r.current.Actions, Action{
+			Seat:  ev.Player,
+			Cards: append([]domain.Card(nil), ev.Cards...),
+		})
+	case *event.PlayerPassedEvent:
+		r.current.Actions = append(r.current.Actions, Action{Seat: ev.Player})
+	case *event.TrickWonEvent:
+		r.closeTrick()
+	case *event.DealEndedEvent:
+		r.closeTrick()
+		r.rec.Result = append([]domain.SeatID(nil), ev.RankList...)
+		r.finished = true
+	}
+}
+
+func (r *Recorder) closeTrick() {
+	if len(r.current.Actions) == 0 {
+		return
+	}
+	r.rec.Tricks = append(r.rec.Tricks, r.current)
+	r.current = Trick{}
+}
+
+// SetStartingCard 记录首Deal的Starting Card
+func (r *Recorder) SetStartingCard(card domain.Card) {
+	r.rec.StartingCard = &card
+}
+
+// IsFinished 是否已收到DealEnded事件
+func (r *Recorder) IsFinished() bool {
+	return r.finished
+}
+
+// Record 返回目前为止的记录（进行中的一轮也包含在内）
+func (r *Recorder) Record() *Record {
+	rec := *r.rec
+	if len(r.current.Actions) > 0 {
+		rec.Tricks = append(append([]Trick(nil), r.rec.Tricks...), r.current)
+	}
+	return &rec
+}
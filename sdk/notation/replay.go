@@ -0,0 +1,168 @@
+package notation
+
+import (
+	"fmt"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+)
+
+// Replay 在新的引擎上重放记录，校验每一步都合法且结果与记录一致，返回重放后的引擎
+func Replay(rec *Record) (*engine.GameEngine, error) {
+	return ReplayEach(rec, nil)
+}
+
+// ReplayEach 与Replay相同，并在执行每个行动（贡牌、选择贡牌、还贡、出牌、Pass）之前
+// 以当时的引擎调用before，可用于取得每个决策点的观察。before不应修改引擎
+func ReplayEach(rec *Record, before func(ge *engine.GameEngine, action engine.Action)) (*engine.GameEngine, error) {
+	if before == nil {
+		before = func(*engine.GameEngine, engine.Action) {}
+	}
+	
+	players := make([]*domain.Player, 4)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		name := rec.Players[seat]
+		if name == "" {
+			name = seat.String()
+		}
+		players[seat] = domain.NewPlayer(fmt.Sprintf("p%d", int(seat)+1), name, seat)
+	}
+	
+	matchCtx := domain.NewMatchCtx(domain.MatchID(rec.Match), players, 0)
+	matchCtx.GetTeam(domain.TeamEastWest).Level = rec.Levels[domain.TeamEastWest]
+	matchCtx.GetTeam(domain.TeamSouthNorth).Level = rec.Levels[domain.TeamSouthNorth]
+	
+	deal := &engine.Deal{Hands: rec.Hands}
+	if rec.Deal == 1 {
+		startingCard, err := replayStartingCard(rec)
+		if err != nil {
+			return nil, err
+		}
+		deal.StartingCard = startingCard
+	}
+	source, err := engine.NewFixedDealSource(deal)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hands: %w", err)
+	}
+	
+	// 重放引擎不需要订阅事件，总线不启动
+	gameEngine := engine.NewGameEngine(event.NewEventBus(1000))
+	if err := gameEngine.Initialize(matchCtx); err != nil {
+		return nil, err
+	}
+	if err := gameEngine.SetDealSource(replaySource{deal: rec.Deal, source: source}); err != nil {
+		return nil, err
+	}
+	
+	if err := gameEngine.StartDeal(rec.Deal, rec.Previous); err != nil {
+		return nil, err
+	}
+	if err := gameEngine.DealCards(); err != nil {
+		return nil, err
+	}
+	if err := gameEngine.DetermineTrump(); err != nil {
+		return nil, err
+	}
+	if trump := gameEngine.GetDealCtx().Trump; trump != rec.Trump {
+		return nil, fmt.Errorf("engine determined trump %s, record says %s", FormatRank(trump), FormatRank(rec.Trump))
+	}
+	if err := gameEngine.StartTribute(); err != nil {
+		return nil, err
+	}
+	
+	for i, t := range rec.Tributes {
+		before(gameEngine, engine.Action{Kind: engine.ActionTribute, Seat: t.From, Cards: []domain.Card{t.Card}, Target: t.To})
+		if err := gameEngine.GiveTribute(t.From, t.To, []domain.Card{t.Card}); err != nil {
+			return nil, fmt.Errorf("tribute %d (%s>%s %s): %w", i+1, SeatLetter(t.From), SeatLetter(t.To), FormatCard(t.Card), err)
+		}
+	}
+	if rec.Selection != nil {
+		if len(rec.Previous) > 0 {
+			before(gameEngine, engine.Action{Kind: engine.ActionSelectTribute, Seat: rec.Previous[0], Target: *rec.Selection})
+		}
+		if err := gameEngine.SelectTributeCard(*rec.Selection); err != nil {
+			return nil, fmt.Errorf("selection %s: %w", SeatLetter(*rec.Selection), err)
+		}
+	}
+	for i, t := range rec.Returns {
+		before(gameEngine, engine.Action{Kind: engine.ActionReturnTribute, Seat: t.From, Cards: []domain.Card{t.Card}, Target: t.To})
+		if err := gameEngine.GiveReturnTribute(t.From, t.To, []domain.Card{t.Card}); err != nil {
+			return nil, fmt.Errorf("return %d (%s>%s %s): %w", i+1, SeatLetter(t.From), SeatLetter(t.To), FormatCard(t.Card), err)
+		}
+	}
+	
+	for i, trick := range rec.Tricks {
+		for j, action := range trick.Actions {
+			if current := gameEngine.GetTrickCtx(); current == nil || current.TrickNumber != i+1 {
+				return nil, fmt.Errorf("trick %d, action %d: previous trick has not ended", i+1, j+1)
+			}
+			
+			var err error
+			if action.IsPass() {
+				before(gameEngine, engine.Action{Kind: engine.ActionPass, Seat: action.Seat})
+				err = gameEngine.Pass(action.Seat)
+			} else {
+				before(gameEngine, engine.Action{Kind: engine.ActionPlay, Seat: action.Seat, Cards: action.Cards})
+				err = gameEngine.PlayCards(action.Seat, action.Cards)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("trick %d, action %d (%s): %w", i+1, j+1, SeatLetter(action.Seat), err)
+			}
+		}
+	}
+	
+	if len(rec.Result) > 0 {
+		phase := gameEngine.GetCurrentPhase()
+		if phase != engine.PhaseFinished && phase != engine.PhaseRankList {
+			return nil, fmt.Errorf("record has a result but the deal is still in phase %s", phase)
+		}
+		rankList := gameEngine.GetDealCtx().RankList
+		if formatSeats(rankList) != formatSeats(rec.Result) {
+			return nil, fmt.Errorf("engine result %s differs from recorded result %s", formatSeats(rankList), formatSeats(rec.Result))
+		}
+	}
+	
+	return gameEngine, nil
+}
+
+// replayStartingCard 返回记录中的Starting Card；未记录时从首个出牌者手中选一张
+// 不被其前面座位持有的牌，使引擎确定出同一个首出者
+func replayStartingCard(rec *Record) (domain.Card, error) {
+	if rec.StartingCard != nil {
+		return *rec.StartingCard, nil
+	}
+	
+	if len(rec.Tricks) == 0 || len(rec.Tricks[0].Actions) == 0 {
+		return rec.Hands[domain.SeatEast][0], nil
+	}
+	
+	leader := rec.Tricks[0].Actions[0].Seat
+	for _, card := range rec.Hands[leader] {
+		heldEarlier := false
+		for seat := domain.SeatEast; seat < leader; seat++ {
+			for _, other := range rec.Hands[seat] {
+				if other == card {
+					heldEarlier = true
+				}
+			}
+		}
+		if !heldEarlier {
+			return card, nil
+		}
+	}
+	
+	return domain.Card{}, fmt.Errorf("cannot choose a starting card for leader %s; add a Start tag", leader)
+}
+
+// replaySource 把记录中的手牌作为指定Deal编号的发牌
+type replaySource struct {
+	deal   int
+	source *engine.FixedDealSource
+}
+
+func (s replaySource) NextDeal(matchCtx *domain.MatchCtx, dealNumber int) (*engine.Deal, error) {
+	if dealNumber != s.deal {
+		return nil, fmt.Errorf("record is for deal %d, not deal %d", s.deal, dealNumber)
+	}
+	return s.source.NextDeal(matchCtx, 1)
+}
@@ -0,0 +1,326 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/notation"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second // 未设置MoveTime时等待ready或bestmove的上限
+	DefaultGrace   = time.Second      // 设置MoveTime时在其之外额外等待的时间
+)
+
+// ErrNoMove 引擎回复了"bestmove none"
+var ErrNoMove = errors.New("engine returned no move")
+
+// ExternalConfig 外部引擎进程的配置
+type ExternalConfig struct {
+	Command  []string      // 可执行文件与参数
+	Dir      string        // 工作目录，空表示当前目录
+	Env      []string      // 附加的环境变量（KEY=VALUE）
+	MoveTime time.Duration // 随go发送的思考时间，0表示不限制
+	Timeout  time.Duration // 等待回复的上限，0表示MoveTime+DefaultGrace或DefaultTimeout
+	Fallback bot.Agent     // 引擎超时、崩溃或回复非法行动时代为行动，nil表示bot.NewHeuristic()
+	Stderr   io.Writer     // 引擎的标准错误输出，nil表示丢弃
+}
+
+func (c ExternalConfig) timeout() time.Duration {
+	switch {
+	case c.Timeout > 0:
+		return c.Timeout
+	case c.MoveTime > 0:
+		return c.MoveTime + DefaultGrace
+	default:
+		return DefaultTimeout
+	}
+}
+
+// ExternalAgent 把说本协议的外部进程包装为bot.Agent。引擎的任何故障都不会传给调用者：
+// 超时、崩溃或回复非法行动时结束该进程、记录错误并由Fallback代为行动，下次行动时重新启动进程
+type ExternalAgent struct {
+	config   ExternalConfig
+	fallback bot.Agent
+	
+	mu       sync.Mutex
+	process  *exec.Cmd
+	stdin    io.WriteCloser
+	lines    chan string // 引擎的标准输出，进程退出后关闭
+	seat     domain.SeatID
+	deal     int
+	matched  bool        // 当前进程是否已完成newmatch握手
+	failures int
+	lastErr  error
+}
+
+// NewExternalAgent 启动外部引擎进程
+func NewExternalAgent(config ExternalConfig) (*ExternalAgent, error) {
+	if len(config.Command) == 0 {
+		return nil, fmt.Errorf("engine command is empty")
+	}
+	
+	agent := &ExternalAgent{config: config, fallback: config.Fallback}
+	if agent.fallback == nil {
+		agent.fallback = bot.NewHeuristic()
+	}
+	if err := agent.start(); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// Act 把观察发给引擎并等待bestmove；引擎故障时返回Fallback的行动
+func (a *ExternalAgent) Act(obs *bot.Observation) (bot.Action, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	action, err := a.ask(obs)
+	if err == nil {
+		return action, nil
+	}
+	
+	a.failures++
+	a.lastErr = err
+	if !errors.Is(err, ErrNoMove) {
+		a.stop()
+	}
+	return a.fallback.Act(obs)
+}
+
+// Failures 引擎出错而由Fallback代为行动的次数
+func (a *ExternalAgent) Failures() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.failures
+}
+
+// LastError 最近一次引擎故障，没有时为nil
+func (a *ExternalAgent) LastError() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastErr
+}
+
+// Close 发送quit并等待进程退出，超时后强制结束
+func (a *ExternalAgent) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	if a.process == nil {
+		return nil
+	}
+	
+	io.WriteString(a.stdin, "quit\n")
+	a.stdin.Close()
+	timer := time.NewTimer(DefaultGrace)
+	defer timer.Stop()
+	for exited := false; !exited; {
+		select {
+		case _, open := <-a.lines:
+			exited = !open
+		case <-timer.C:
+			exited = true
+		}
+	}
+	a.stop()
+	return nil
+}
+
+func (a *ExternalAgent) ask(obs *bot.Observation) (bot.Action, error) {
+	if a.process == nil {
+		if err := a.start(); err != nil {
+			return bot.Action{}, err
+		}
+	}
+	
+	if !a.matched || a.seat != obs.Seat || obs.DealNumber < a.deal {
+		if err := a.send(fmt.Sprintf("newmatch %s\n", notation.SeatLetter(obs.Seat))); err != nil {
+			return bot.Action{}, err
+		}
+		if _, err := a.await("ready"); err != nil {
+			return bot.Action{}, err
+		}
+		a.matched, a.seat, a.deal = true, obs.Seat, 0
+	}
+	
+	if obs.DealNumber != a.deal {
+		if err := a.send(fmt.Sprintf("deal %d\n", obs.DealNumber)); err != nil {
+			return bot.Action{}, err
+		}
+		a.deal = obs.DealNumber
+	}
+	
+	var command strings.Builder
+	WriteObservation(&command, obs)
+	if a.config.MoveTime > 0 {
+		fmt.Fprintf(&command, "go movetime %d\n", a.config.MoveTime.Milliseconds())
+	} else {
+		command.WriteString("go\n")
+	}
+	if err := a.send(command.String()); err != nil {
+		return bot.Action{}, err
+	}
+	
+	reply, err := a.await("bestmove")
+	if err != nil {
+		return bot.Action{}, err
+	}
+	if strings.TrimSpace(reply) == "none" {
+		return bot.Action{}, ErrNoMove
+	}
+	action, err := ParseAction(reply)
+	if err != nil {
+		return bot.Action{}, err
+	}
+	if err := checkAction(obs, action); err != nil {
+		return bot.Action{}, fmt.Errorf("illegal move %q: %w", reply, err)
+	}
+	return action, nil
+}
+
+func (a *ExternalAgent) start() error {
+	process := exec.Command(a.config.Command[0], a.config.Command[1:]...)
+	process.Dir = a.config.Dir
+	if len(a.config.Env) > 0 {
+		process.Env = append(os.Environ(), a.config.Env...)
+	}
+	process.Stderr = a.config.Stderr
+	process.WaitDelay = DefaultGrace
+	
+	stdin, err := process.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := process.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := process.Start(); err != nil {
+		return fmt.Errorf("failed to start engine: %w", err)
+	}
+	
+	lines := make(chan string, 16)
+	go func() {
+		defer close(lines)
+		scanner := newScanner(stdout)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	
+	a.process, a.stdin, a.lines = process, stdin, lines
+	a.matched = false
+	return nil
+}
+
+// stop 结束进程，回收资源
+func (a *ExternalAgent) stop() {
+	if a.process == nil {
+		return
+	}
+	a.stdin.Close()
+	a.process.Process.Kill()
+	a.process.Wait()
+	for range a.lines {
+		// 丢弃剩余输出，Wait关闭管道后读协程结束
+	}
+	a.process, a.stdin, a.lines = nil, nil, nil
+	a.matched = false
+}
+
+func (a *ExternalAgent) send(text string) error {
+	if _, err := io.WriteString(a.stdin, text); err != nil {
+		return fmt.Errorf("failed to write to engine: %w", err)
+	}
+	return nil
+}
+
+// await 等待以keyword开头的一行，返回其余部分，跳过info等其他输出
+func (a *ExternalAgent) await(keyword string) (string, error) {
+	timer := time.NewTimer(a.config.timeout())
+	defer timer.Stop()
+	
+	for {
+		select {
+		case line, open := <-a.lines:
+			if !open {
+				return "", fmt.Errorf("engine exited while waiting for %s", keyword)
+			}
+			fields := strings.Fields(line)
+			if len(fields) > 0 && fields[0] == keyword {
+				return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), keyword)), nil
+			}
+		case <-timer.C:
+			return "", fmt.Errorf("engine did not send %s within %s", keyword, a.config.timeout())
+		}
+	}
+}
+
+// checkAction 按观察检查行动是否合法，使非法回复在进入引擎前就由Fallback接管
+func checkAction(obs *bot.Observation, action bot.Action) error {
+	switch obs.Phase {
+	case engine.PhaseFirstPlay, engine.PhaseInProgress:
+		switch action.Kind {
+		case bot.ActionPass:
+			if obs.IsLeading() {
+				return fmt.Errorf("cannot pass when leading")
+			}
+			return nil
+		case bot.ActionPlay:
+			if !holds(obs.Hand, action.Cards) {
+				return fmt.Errorf("cards are not in hand")
+			}
+			if !domain.CanFollow(domain.NewCardGroup(action.Cards), obs.LastPlay, obs.Trump) {
+				return fmt.Errorf("cards do not beat the table")
+			}
+			return nil
+		}
+	case engine.PhaseTribute, engine.PhaseReturnTribute:
+		if action.Kind == bot.ActionTribute || action.Kind == bot.ActionReturnTribute {
+			if !holds(obs.Hand, action.Cards) {
+				return fmt.Errorf("card is not in hand")
+			}
+			return nil
+		}
+	case engine.PhaseTributeSelection:
+		if action.Kind == bot.ActionSelectTribute {
+			if obs.Tribute == nil || !hasGiver(obs.Tribute.Available, action.Target) {
+				return fmt.Errorf("no tribute card from %s", action.Target)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not allowed in phase %s", action.Kind, obs.Phase)
+}
+
+func holds(hand, cards []domain.Card) bool {
+	remaining := append([]domain.Card(nil), hand...)
+	for _, card := range cards {
+		found := false
+		for i, held := range remaining {
+			if held == card {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func hasGiver(available map[domain.SeatID]domain.Card, giver domain.SeatID) bool {
+	_, ok := available[giver]
+	return ok
+}
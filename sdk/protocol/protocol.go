@@ -0,0 +1,415 @@
+// Package protocol 掼蛋引擎文本协议（类似国际象棋的UCI），让任何语言编写的机器人
+// 通过标准输入输出接入模拟器和服务器。每条消息占一行，以空格分隔，牌用domain.ParseCard
+// 可解析的ASCII写法（H2、DT、S11、SJ、BJ等，见notation.FormatCard），座位用E/S/W/N。
+//
+// 主机发给引擎：
+//
+//	newmatch <座位>        开始一场比赛，引擎为该座位行动；引擎可先回复"id name <名称>"，必须回复"ready"
+//	deal <序号>            开始新的Deal
+//	observe                其后各行是本座位的观察，以"end"结束（格式见WriteObservation）
+//	go [movetime <毫秒>]   要求引擎对最近一次observe行动
+//	quit                   退出
+//
+// 引擎对每个go恰好回复一行bestmove：
+//
+//	bestmove play <牌...>
+//	bestmove pass
+//	bestmove tribute <牌>
+//	bestmove select <进贡者座位>
+//	bestmove return <牌>
+//	bestmove none          放弃决策，由主机代为行动
+//
+// 引擎可以随时输出"info <文本>"，主机忽略其内容；双方都忽略不认识的命令
+package protocol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/notation"
+)
+
+// WriteObservation 写出observe块：
+//
+//	observe
+//	seat E
+//	phase InProgress
+//	deal 3
+//	trump 2
+//	levels 2 4            东西、南北的级数
+//	counts 27 20 18 5     东南西北的剩余手牌数
+//	hand H2 H2 D5 ...
+//	last E S W N          上一Deal排名，首Deal省略
+//	ranks W               本Deal已出完的座位，没有时省略
+//	play E H3 H3          本Deal的每次出牌，按顺序
+//	play S pass
+//	trick 2               最后2条play属于当前一轮
+//	table E H3 H3         需要压过的牌，首出时省略
+//	tribute DoubleDown 0  贡牌场景与是否抗贡（0/1），无贡牌信息时省略以下各行
+//	request N E           进贡 from to
+//	return E N            还贡 from to
+//	given N BJ
+//	receiver N E
+//	available N BJ
+//	returned E H3
+//	end
+func WriteObservation(w io.Writer, obs *bot.Observation) error {
+	lines := []string{
+		"observe",
+		"seat " + notation.SeatLetter(obs.Seat),
+		"phase " + obs.Phase.String(),
+		"deal " + strconv.Itoa(obs.DealNumber),
+		"trump " + notation.FormatRank(obs.Trump),
+		fmt.Sprintf("levels %s %s", notation.FormatRank(obs.Levels[domain.TeamEastWest]), notation.FormatRank(obs.Levels[domain.TeamSouthNorth])),
+		fmt.Sprintf("counts %d %d %d %d", obs.HandCounts[0], obs.HandCounts[1], obs.HandCounts[2], obs.HandCounts[3]),
+		strings.TrimSpace("hand " + FormatCards(obs.Hand)),
+	}
+	if len(obs.LastRankings) > 0 {
+		lines = append(lines, "last "+formatSeats(obs.LastRankings))
+	}
+	if len(obs.RankList) > 0 {
+		lines = append(lines, "ranks "+formatSeats(obs.RankList))
+	}
+	for _, play := range obs.Plays {
+		lines = append(lines, "play "+formatPlay(play))
+	}
+	lines = append(lines, "trick "+strconv.Itoa(len(obs.Trick)))
+	if obs.LastPlay != nil {
+		lines = append(lines, fmt.Sprintf("table %s %s", notation.SeatLetter(obs.LastPlayer), FormatCards(obs.LastPlay.Cards)))
+	}
+	if view := obs.Tribute; view != nil {
+		immunity := 0
+		if view.HasImmunity {
+			immunity = 1
+		}
+		lines = append(lines, fmt.Sprintf("tribute %s %d", view.Scenario, immunity))
+		lines = appendSeatMap(lines, "request", view.Requests)
+		lines = appendSeatMap(lines, "return", view.Returns)
+		lines = appendCardMap(lines, "given", view.Given)
+		lines = appendSeatMap(lines, "receiver", view.Receivers)
+		lines = appendCardMap(lines, "available", view.Available)
+		lines = appendCardMap(lines, "returned", view.Returned)
+	}
+	lines = append(lines, "end")
+	
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// ReadObservation 解析observe与end之间的各行
+func ReadObservation(lines []string) (*bot.Observation, error) {
+	obs := &bot.Observation{}
+	trick := 0
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := readObservationLine(obs, fields, &trick); err != nil {
+			return nil, fmt.Errorf("%s: %w", fields[0], err)
+		}
+	}
+	
+	if trick > len(obs.Plays) {
+		return nil, fmt.Errorf("trick: %d plays in the current trick but %d in the deal", trick, len(obs.Plays))
+	}
+	obs.Trick = obs.Plays[len(obs.Plays)-trick:]
+	return obs, nil
+}
+
+func readObservationLine(obs *bot.Observation, fields []string, trick *int) error {
+	args := fields[1:]
+	var err error
+	switch fields[0] {
+	case "seat":
+		obs.Seat, err = parseSeat(args)
+	case "phase":
+		obs.Phase, err = parsePhase(args)
+	case "deal":
+		obs.DealNumber, err = parseInt(args)
+	case "trump":
+		obs.Trump, err = parseRank(args)
+	case "levels":
+		if len(args) != 2 {
+			return fmt.Errorf("expected 2 levels")
+		}
+		for team, arg := range args {
+			if obs.Levels[team], err = notation.ParseRank(arg); err != nil {
+				return err
+			}
+		}
+	case "counts":
+		if len(args) != 4 {
+			return fmt.Errorf("expected 4 hand counts")
+		}
+		for seat, arg := range args {
+			if obs.HandCounts[seat], err = strconv.Atoi(arg); err != nil {
+				return err
+			}
+		}
+	case "hand":
+		obs.Hand, err = ParseCards(args)
+	case "last":
+		obs.LastRankings, err = parseSeats(args)
+	case "ranks":
+		obs.RankList, err = parseSeats(args)
+	case "play":
+		var play domain.TrickPlay
+		if play, err = parsePlay(args); err == nil {
+			obs.Plays = append(obs.Plays, play)
+		}
+	case "trick":
+		*trick, err = parseInt(args)
+	case "table":
+		var play domain.TrickPlay
+		if play, err = parsePlay(args); err == nil {
+			if play.IsPass() {
+				return fmt.Errorf("table play cannot be a pass")
+			}
+			obs.LastPlayer, obs.LastPlay = play.Player, play.CardGroup
+		}
+	case "tribute":
+		if len(args) != 2 {
+			return fmt.Errorf("expected scenario and immunity")
+		}
+		scenario, err := parseScenario(args[0])
+		if err != nil {
+			return err
+		}
+		obs.Tribute = &bot.TributeView{
+			Scenario:    scenario,
+			HasImmunity: args[1] == "1",
+			Requests:    make(map[domain.SeatID]domain.SeatID),
+			Returns:     make(map[domain.SeatID]domain.SeatID),
+			Given:       make(map[domain.SeatID]domain.Card),
+			Receivers:   make(map[domain.SeatID]domain.SeatID),
+			Available:   make(map[domain.SeatID]domain.Card),
+			Returned:    make(map[domain.SeatID]domain.Card),
+		}
+	case "request", "return", "receiver", "given", "available", "returned":
+		if obs.Tribute == nil {
+			return fmt.Errorf("missing tribute line")
+		}
+		err = readTributeLine(obs.Tribute, fields[0], args)
+	}
+	return err
+}
+
+func readTributeLine(view *bot.TributeView, name string, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected two arguments")
+	}
+	seat, err := notation.ParseSeat(args[0])
+	if err != nil {
+		return err
+	}
+	
+	switch name {
+	case "request":
+		view.Requests[seat], err = notation.ParseSeat(args[1])
+	case "return":
+		view.Returns[seat], err = notation.ParseSeat(args[1])
+	case "receiver":
+		view.Receivers[seat], err = notation.ParseSeat(args[1])
+	case "given":
+		view.Given[seat], err = domain.ParseCard(args[1])
+	case "available":
+		view.Available[seat], err = domain.ParseCard(args[1])
+	case "returned":
+		view.Returned[seat], err = domain.ParseCard(args[1])
+	}
+	return err
+}
+
+// FormatAction bestmove之后的部分，例如"play H3 H3"
+func FormatAction(action bot.Action) string {
+	switch action.Kind {
+	case bot.ActionPlay:
+		return "play " + FormatCards(action.Cards)
+	case bot.ActionPass:
+		return "pass"
+	case bot.ActionTribute:
+		return "tribute " + FormatCards(action.Cards)
+	case bot.ActionSelectTribute:
+		return "select " + notation.SeatLetter(action.Target)
+	case bot.ActionReturnTribute:
+		return "return " + FormatCards(action.Cards)
+	default:
+		return "none"
+	}
+}
+
+// ParseAction 解析FormatAction的输出
+func ParseAction(text string) (bot.Action, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return bot.Action{}, fmt.Errorf("empty action")
+	}
+	
+	args := fields[1:]
+	switch fields[0] {
+	case "play":
+		cards, err := ParseCards(args)
+		if err != nil || len(cards) == 0 {
+			return bot.Action{}, fmt.Errorf("invalid play %q", text)
+		}
+		return bot.Play(cards), nil
+	case "pass":
+		return bot.Pass(), nil
+	case "tribute", "return":
+		cards, err := ParseCards(args)
+		if err != nil || len(cards) != 1 {
+			return bot.Action{}, fmt.Errorf("invalid %s %q", fields[0], text)
+		}
+		if fields[0] == "tribute" {
+			return bot.Tribute(cards[0]), nil
+		}
+		return bot.ReturnTribute(cards[0]), nil
+	case "select":
+		giver, err := parseSeat(args)
+		if err != nil {
+			return bot.Action{}, err
+		}
+		return bot.SelectTribute(giver), nil
+	default:
+		return bot.Action{}, fmt.Errorf("unknown action %q", text)
+	}
+}
+
+// FormatCards 以空格分隔的牌
+func FormatCards(cards []domain.Card) string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = notation.FormatCard(card)
+	}
+	return strings.Join(names, " ")
+}
+
+func ParseCards(fields []string) ([]domain.Card, error) {
+	cards := make([]domain.Card, 0, len(fields))
+	for _, field := range fields {
+		card, err := domain.ParseCard(field)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// newScanner 按行读取，允许很长的observe行
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return scanner
+}
+
+func formatPlay(play domain.TrickPlay) string {
+	if play.IsPass() {
+		return notation.SeatLetter(play.Player) + " pass"
+	}
+	return notation.SeatLetter(play.Player) + " " + FormatCards(play.Cards)
+}
+
+func parsePlay(args []string) (domain.TrickPlay, error) {
+	if len(args) < 2 {
+		return domain.TrickPlay{}, fmt.Errorf("expected seat and cards")
+	}
+	seat, err := notation.ParseSeat(args[0])
+	if err != nil {
+		return domain.TrickPlay{}, err
+	}
+	if args[1] == "pass" {
+		return domain.TrickPlay{Player: seat}, nil
+	}
+	cards, err := ParseCards(args[1:])
+	if err != nil {
+		return domain.TrickPlay{}, err
+	}
+	return domain.TrickPlay{Player: seat, Cards: cards, CardGroup: domain.NewCardGroup(cards)}, nil
+}
+
+func formatSeats(seats []domain.SeatID) string {
+	letters := make([]string, len(seats))
+	for i, seat := range seats {
+		letters[i] = notation.SeatLetter(seat)
+	}
+	return strings.Join(letters, " ")
+}
+
+func parseSeats(args []string) ([]domain.SeatID, error) {
+	seats := make([]domain.SeatID, len(args))
+	for i, arg := range args {
+		seat, err := notation.ParseSeat(arg)
+		if err != nil {
+			return nil, err
+		}
+		seats[i] = seat
+	}
+	return seats, nil
+}
+
+func parseSeat(args []string) (domain.SeatID, error) {
+	if len(args) != 1 {
+		return domain.SeatEast, fmt.Errorf("expected one seat")
+	}
+	return notation.ParseSeat(args[0])
+}
+
+func parseInt(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected one number")
+	}
+	return strconv.Atoi(args[0])
+}
+
+func parseRank(args []string) (domain.Rank, error) {
+	if len(args) != 1 {
+		return domain.Two, fmt.Errorf("expected one rank")
+	}
+	return notation.ParseRank(args[0])
+}
+
+func parsePhase(args []string) (engine.DealPhase, error) {
+	if len(args) == 1 {
+		for phase := engine.PhaseIdle; phase <= engine.PhaseFinished; phase++ {
+			if phase.String() == args[0] {
+				return phase, nil
+			}
+		}
+	}
+	return engine.PhaseIdle, fmt.Errorf("invalid phase %v", args)
+}
+
+func parseScenario(text string) (domain.TributeScenario, error) {
+	for scenario := domain.TributeScenarioNone; scenario <= domain.TributeScenarioPartnerLast; scenario++ {
+		if scenario.String() == text {
+			return scenario, nil
+		}
+	}
+	return domain.TributeScenarioNone, fmt.Errorf("invalid tribute scenario %s", text)
+}
+
+// appendSeatMap 按座位顺序写出，使输出稳定
+func appendSeatMap(lines []string, name string, m map[domain.SeatID]domain.SeatID) []string {
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if to, ok := m[seat]; ok {
+			lines = append(lines, fmt.Sprintf("%s %s %s", name, notation.SeatLetter(seat), notation.SeatLetter(to)))
+		}
+	}
+	return lines
+}
+
+func appendCardMap(lines []string, name string, m map[domain.SeatID]domain.Card) []string {
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if card, ok := m[seat]; ok {
+			lines = append(lines, fmt.Sprintf("%s %s %s", name, notation.SeatLetter(seat), notation.FormatCard(card)))
+		}
+	}
+	return lines
+}
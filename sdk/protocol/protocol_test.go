@@ -0,0 +1,187 @@
+package protocol
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+)
+
+// 设置GUANDAN_TEST_ENGINE时，测试二进制本身充当外部引擎
+func TestMain(m *testing.M) {
+	switch os.Getenv("GUANDAN_TEST_ENGINE") {
+	case "":
+		os.Exit(m.Run())
+	case "heuristic":
+		Serve(os.Stdin, os.Stdout, bot.NewHeuristic(), "test")
+	default:
+		misbehave(os.Getenv("GUANDAN_TEST_ENGINE"))
+	}
+	os.Exit(0)
+}
+
+// misbehave 握手正常，收到go后按mode出错
+func misbehave(mode string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		switch strings.Fields(scanner.Text() + " x")[0] {
+		case "newmatch":
+			fmt.Println("ready")
+		case "go":
+			switch mode {
+			case "hang":
+				time.Sleep(time.Minute)
+			case "crash":
+				os.Exit(3)
+			case "illegal":
+				fmt.Println("info about to cheat")
+				fmt.Println("bestmove play BJ BJ BJ BJ BJ")
+			case "none":
+				fmt.Println("bestmove none")
+			}
+		}
+	}
+}
+
+func testEngine(t *testing.T) *engine.GameEngine {
+	t.Helper()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	ge := engine.NewGameEngine(event.NewEventBus(100))
+	if err := ge.Initialize(domain.NewMatchCtx("protocol-match", players, 42)); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	ge.StartDeal(1, nil)
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	return ge
+}
+
+func externalAgent(t *testing.T, mode string, timeout time.Duration) *ExternalAgent {
+	t.Helper()
+	
+	agent, err := NewExternalAgent(ExternalConfig{
+		Command: []string{os.Args[0]},
+		Env:     []string{"GUANDAN_TEST_ENGINE=" + mode},
+		Timeout: timeout,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start engine: %v", err)
+	}
+	t.Cleanup(func() { agent.Close() })
+	return agent
+}
+
+func TestObservationRoundTrip(t *testing.T) {
+	ge := testEngine(t)
+	agents := [4]bot.Agent{bot.NewHeuristic(), bot.NewHeuristic(), bot.NewHeuristic(), bot.NewHeuristic()}
+	for i := 0; i < 12; i++ {
+		if _, _, err := bot.Step(ge, agents); err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+	}
+	
+	obs := bot.Observe(ge, ge.GetPendingSeats()[0])
+	obs.LastRankings = []domain.SeatID{domain.SeatNorth, domain.SeatEast, domain.SeatSouth, domain.SeatWest}
+	obs.Tribute = &bot.TributeView{
+		Scenario:  domain.TributeScenarioSingleLast,
+		Requests:  map[domain.SeatID]domain.SeatID{domain.SeatWest: domain.SeatNorth},
+		Returns:   map[domain.SeatID]domain.SeatID{domain.SeatNorth: domain.SeatWest},
+		Given:     map[domain.SeatID]domain.Card{domain.SeatWest: domain.NewJoker(domain.BigJoker)},
+		Receivers: map[domain.SeatID]domain.SeatID{domain.SeatWest: domain.SeatNorth},
+		Available: map[domain.SeatID]domain.Card{},
+		Returned:  map[domain.SeatID]domain.Card{domain.SeatNorth: domain.NewCard(domain.Spades, domain.Jack)},
+	}
+	
+	var text strings.Builder
+	if err := WriteObservation(&text, obs); err != nil {
+		t.Fatalf("Failed to write observation: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	if lines[0] != "observe" || lines[len(lines)-1] != "end" {
+		t.Fatalf("Unexpected block:\n%s", text.String())
+	}
+	
+	parsed, err := ReadObservation(lines[1 : len(lines)-1])
+	if err != nil {
+		t.Fatalf("Failed to read observation: %v\n%s", err, text.String())
+	}
+	
+	if parsed.Seat != obs.Seat || parsed.Phase != obs.Phase || parsed.Trump != obs.Trump || parsed.HandCounts != obs.HandCounts {
+		t.Errorf("Header mismatch: %+v vs %+v", parsed, obs)
+	}
+	if !reflect.DeepEqual(parsed.Hand, obs.Hand) || !reflect.DeepEqual(parsed.LastRankings, obs.LastRankings) {
+		t.Error("Hand or last rankings changed in the round trip")
+	}
+	if len(parsed.Plays) != len(obs.Plays) || len(parsed.Trick) != len(obs.Trick) {
+		t.Fatalf("Expected %d plays and %d in the trick, got %d and %d", len(obs.Plays), len(obs.Trick), len(parsed.Plays), len(parsed.Trick))
+	}
+	for i, play := range obs.Plays {
+		if parsed.Plays[i].Player != play.Player || !reflect.DeepEqual(parsed.Plays[i].Cards, play.Cards) {
+			t.Errorf("Play %d changed: %+v vs %+v", i, parsed.Plays[i], play)
+		}
+	}
+	if (parsed.LastPlay == nil) != (obs.LastPlay == nil) || (obs.LastPlay != nil && parsed.LastPlayer != obs.LastPlayer) {
+		t.Errorf("Table play changed: %v by %s", parsed.LastPlay, parsed.LastPlayer)
+	}
+	if !reflect.DeepEqual(parsed.Tribute, obs.Tribute) {
+		t.Errorf("Tribute view changed: %+v vs %+v", parsed.Tribute, obs.Tribute)
+	}
+	
+	for _, action := range []bot.Action{bot.Pass(), bot.Play(obs.Hand[:1]), bot.SelectTribute(domain.SeatWest), bot.ReturnTribute(obs.Hand[0])} {
+		parsed, err := ParseAction(FormatAction(action))
+		if err != nil || !reflect.DeepEqual(parsed, action) {
+			t.Errorf("Action %s changed to %s (%v)", action, parsed, err)
+		}
+	}
+}
+
+func TestExternalAgentPlaysDeal(t *testing.T) {
+	ge := testEngine(t)
+	external := externalAgent(t, "heuristic", 0)
+	agents := [4]bot.Agent{external, bot.NewHeuristic(), bot.NewHeuristic(), bot.NewHeuristic()}
+	
+	if err := bot.PlayDeal(ge, agents); err != nil {
+		t.Fatalf("Deal failed: %v", err)
+	}
+	if external.Failures() != 0 {
+		t.Errorf("Engine should not fail, last error: %v", external.LastError())
+	}
+}
+
+func TestExternalAgentFallsBack(t *testing.T) {
+	for _, mode := range []string{"hang", "crash", "illegal", "none"} {
+		t.Run(mode, func(t *testing.T) {
+			ge := testEngine(t)
+			seat := ge.GetPendingSeats()[0]
+			agent := externalAgent(t, mode, 200*time.Millisecond)
+			
+			for i := 0; i < 2; i++ {
+				obs := bot.Observe(ge, seat)
+				action, err := agent.Act(obs)
+				if err != nil {
+					t.Fatalf("Fallback should act, got %v", err)
+				}
+				if err := checkAction(obs, action); err != nil {
+					t.Fatalf("Fallback action %s is illegal: %v", action, err)
+				}
+			}
+			if agent.Failures() != 2 || agent.LastError() == nil {
+				t.Errorf("Expected 2 failures, got %d (%v)", agent.Failures(), agent.LastError())
+			}
+		})
+	}
+}
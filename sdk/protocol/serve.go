@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"guandan/sdk/bot"
+)
+
+// Serve 引擎一端的协议循环：从r读取主机的命令，用agent对每个go回复bestmove，
+// 直到收到quit或r结束。agent出错时回复"bestmove none"并用info说明原因
+func Serve(r io.Reader, w io.Writer, agent bot.Agent, name string) error {
+	scanner := newScanner(r)
+	var obs *bot.Observation
+	var block []string
+	inObservation := false
+	
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if inObservation {
+			if line != "end" {
+				block = append(block, line)
+				continue
+			}
+			inObservation = false
+			parsed, err := ReadObservation(block)
+			if err != nil {
+				obs = nil
+				if _, err := fmt.Fprintf(w, "info invalid observation: %v\n", err); err != nil {
+					return err
+				}
+				continue
+			}
+			obs = parsed
+			continue
+		}
+		
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		
+		var reply string
+		switch fields[0] {
+		case "newmatch":
+			obs = nil
+			reply = fmt.Sprintf("id name %s\nready", name)
+		case "observe":
+			inObservation = true
+			block = block[:0]
+		case "go":
+			move, reason := decide(agent, obs)
+			reply = "bestmove " + move
+			if reason != "" {
+				reply = "info " + reason + "\n" + reply
+			}
+		case "quit":
+			return nil
+		}
+		
+		if reply == "" {
+			continue
+		}
+		if _, err := io.WriteString(w, reply+"\n"); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// decide 返回bestmove之后的部分；无法决策时为"none"，并给出原因
+func decide(agent bot.Agent, obs *bot.Observation) (string, string) {
+	if obs == nil {
+		return "none", "no observation"
+	}
+	action, err := agent.Act(obs)
+	if err != nil {
+		return "none", strings.ReplaceAll(err.Error(), "\n", " ")
+	}
+	return FormatAction(action), ""
+}
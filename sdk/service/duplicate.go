@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"time"
+	"guandan/sdk/domain"
+	"guandan/sdk/sim"
+)
+
+// CreateDuplicateMatches 创建复式赛的两张桌，两张桌使用同一种子，每个Deal发到各座位的牌完全相同。
+// 比较的一方在tableA中坐东西、在tableB中坐南北，于是两队各打一次对方的牌。
+// 复式赛的牌由种子决定，因此不能使用自定义发牌来源或承诺-揭示洗牌
+func (gs *GameServiceImpl) CreateDuplicateMatches(tableA, tableB []*domain.Player, opt *MatchOptions) (domain.MatchID, domain.MatchID, error) {
+	options := MatchOptions{Seed: time.Now().UnixNano()}
+	if opt != nil {
+		options = *opt
+	}
+	
+	if options.DealSource != nil || options.ProvablyFair {
+		return "", "", fmt.Errorf("duplicate matches must deal from the shared seed")
+	}
+	
+	matchA, err := gs.CreateMatch(tableA, &options)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create table A: %w", err)
+	}
+	
+	matchB, err := gs.CreateMatch(tableB, &options)
+	if err != nil {
+		gs.DeleteMatch(matchA)
+		return "", "", fmt.Errorf("failed to create table B: %w", err)
+	}
+	
+	return matchA, matchB, nil
+}
+
+// GetDuplicateScore 按Deal序号比较两张桌已结束的Deal，分数以tableA的东西方为准
+func (gs *GameServiceImpl) GetDuplicateScore(tableA, tableB domain.MatchID) (*sim.DuplicateScore, error) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	
+	matchA, exists := gs.matches[tableA]
+	if !exists {
+		return nil, fmt.Errorf("match not found: %s", tableA)
+	}
+	
+	matchB, exists := gs.matches[tableB]
+	if !exists {
+		return nil, fmt.Errorf("match not found: %s", tableB)
+	}
+	
+	if matchA.MatchCtx.Seed != matchB.MatchCtx.Seed {
+		return nil, fmt.Errorf("matches %s and %s were not dealt from the same seed", tableA, tableB)
+	}
+	
+	boards := sim.ScoreBoards(matchA.MatchCtx.Seed, matchA.DealHistory, matchB.DealHistory)
+	return sim.NewDuplicateScore(boards), nil
+}
@@ -1,27 +1,45 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"sync"
 	"time"
+	"guandan/sdk/bot"
 	"guandan/sdk/domain"
 	"guandan/sdk/engine"
 	"guandan/sdk/event"
+	"guandan/sdk/sim"
 )
 
 type MatchOptions struct {
-	DealLimit int
-	Seed      int64
+	DealLimit    int
+	Seed         int64
+	ProvablyFair bool // 使用承诺-揭示洗牌，服务器种子在发牌前承诺、Deal结束后揭示
+	DealSource   engine.DealSource // 自定义发牌来源（预设/约束发牌），nil表示按种子随机发牌
+	TurnTimer    *engine.TimerConfig // 回合计时，超时由引擎代为行动；nil表示不计时
+	TargetLevel  *domain.Rank        // 目标级数（2到A），nil表示打到A
 }
 
 type GameService interface {
 	CreateMatch(players []*domain.Player, opt *MatchOptions) (domain.MatchID, error)
+	CreateDuplicateMatches(tableA, tableB []*domain.Player, opt *MatchOptions) (domain.MatchID, domain.MatchID, error)
+	GetDuplicateScore(tableA, tableB domain.MatchID) (*sim.DuplicateScore, error)
 	StartNextDeal(matchID domain.MatchID) error
 	PlayCards(matchID domain.MatchID, seat domain.SeatID, cards []domain.Card) error
 	Pass(matchID domain.MatchID, seat domain.SeatID) error
+	ApplyAction(matchID domain.MatchID, action engine.Action) error
+	GetPendingActions(matchID domain.MatchID) (map[domain.SeatID][]engine.ActionKind, error)
 	GetSnapshot(matchID domain.MatchID) (*MatchSnapshot, error)
 	Subscribe(matchID domain.MatchID, callback func(event.DomainEvent)) (func(), error)
 	GetValidPlays(matchID domain.MatchID, seat domain.SeatID) ([][]domain.Card, error)
+	SuggestPlays(matchID domain.MatchID, seat domain.SeatID) ([]bot.Suggestion, error)
+	Observe(matchID domain.MatchID, seat domain.SeatID) (*bot.Observation, error)
+	ApplyBotAction(matchID domain.MatchID, seat domain.SeatID, action bot.Action) error
+	SetSeatControl(matchID domain.MatchID, seat domain.SeatID, bot bool, reason string) error
+	ContributeEntropy(matchID domain.MatchID, seat domain.SeatID, entropy []byte) error
+	GetShuffleCommitment(matchID domain.MatchID) (int, string, error)
 	GetCurrentPlayer(matchID domain.MatchID) (domain.SeatID, error)
 	IsPlayerTurn(matchID domain.MatchID, seat domain.SeatID) (bool, error)
 	GetMatchState(matchID domain.MatchID) (*MatchState, error)
@@ -37,6 +55,7 @@ type MatchState struct {
 	Teams       [2]*domain.Team
 	IsFinished  bool
 	Winner      *domain.TeamID
+	BotSeats    [4]bool // 由机器人代打（托管）的座位
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -58,6 +77,7 @@ type MatchInstance struct {
 	Subscribers   map[string]func(event.DomainEvent)
 	SubscribersMu sync.RWMutex
 	DealHistory   [][]domain.SeatID // 存储每个Deal的排名历史
+	BotSeats      [4]bool           // 由机器人代打（托管）的座位
 }
 
 func NewGameService() GameService {
@@ -82,20 +102,50 @@ func (gs *GameServiceImpl) CreateMatch(players []*domain.Player, opt *MatchOptio
 	if opt == nil {
 		opt = &MatchOptions{
 			DealLimit: 0,
-			Seed:      time.Now().UnixNano(),
+			Seed:      RandomSeed(),
 		}
 	}
 	
+	if opt.ProvablyFair && opt.DealSource != nil {
+		return "", fmt.Errorf("provably fair shuffle cannot be combined with a custom deal source")
+	}
+	
+	if opt.TargetLevel != nil && (*opt.TargetLevel < domain.Two || *opt.TargetLevel > domain.Ace) {
+		return "", fmt.Errorf("target level must be between 2 and A, got %s", *opt.TargetLevel)
+	}
+	
 	matchID := gs.generateMatchID()
 	
 	matchCtx := domain.NewMatchCtx(matchID, players, opt.Seed)
 	matchCtx = matchCtx.WithState(domain.MatchStateCreated)
+	if opt.TargetLevel != nil {
+		matchCtx = matchCtx.WithTargetLevel(*opt.TargetLevel)
+	}
 	
 	gameEngine := engine.NewGameEngine(gs.eventBus)
 	if err := gameEngine.Initialize(matchCtx); err != nil {
 		return "", fmt.Errorf("failed to initialize game engine: %w", err)
 	}
 	
+	if opt.DealSource != nil {
+		if err := gameEngine.SetDealSource(opt.DealSource); err != nil {
+			return "", fmt.Errorf("failed to set deal source: %w", err)
+		}
+	}
+	
+	if opt.ProvablyFair {
+		if err := gameEngine.EnableFairShuffle(); err != nil {
+			return "", fmt.Errorf("failed to enable fair shuffle: %w", err)
+		}
+	}
+	
+	if opt.TurnTimer != nil {
+		gameEngine.SetTurnTimerCheck(gs.checkTurnTimer(matchID))
+		if err := gameEngine.EnableTurnTimer(*opt.TurnTimer); err != nil {
+			return "", fmt.Errorf("failed to enable turn timer: %w", err)
+		}
+	}
+	
 	matchInstance := &MatchInstance{
 		MatchCtx:    matchCtx,
 		Engine:      gameEngine,
@@ -158,9 +208,12 @@ func (gs *GameServiceImpl) StartNextDeal(matchID domain.MatchID) error {
 	
 	dealNumber := matchInstance.MatchCtx.CurrentDeal + 1
 	
-	// 获取上一Deal的排名，用于确定本Deal的首出者
+	// 获取上一Deal的排名，用于确定本Deal的首出者。DealHistory由事件异步更新，
+	// 上一Deal刚结束时可能还没有记录，优先使用引擎中的排名
 	var lastRankings []domain.SeatID
-	if len(matchInstance.DealHistory) > 0 {
+	if dealCtx := matchInstance.Engine.GetDealCtx(); dealCtx != nil && len(dealCtx.RankList) == 4 {
+		lastRankings = append([]domain.SeatID(nil), dealCtx.RankList...)
+	} else if len(matchInstance.DealHistory) > 0 {
 		lastRankings = matchInstance.DealHistory[len(matchInstance.DealHistory)-1]
 	}
 	
@@ -187,6 +240,21 @@ func (gs *GameServiceImpl) StartNextDeal(matchID domain.MatchID) error {
 }
 
 func (gs *GameServiceImpl) PlayCards(matchID domain.MatchID, seat domain.SeatID, cards []domain.Card) error {
+	if err := gs.ApplyAction(matchID, engine.Action{Kind: engine.ActionPlay, Seat: seat, Cards: cards}); err != nil {
+		return fmt.Errorf("failed to play cards: %w", err)
+	}
+	return nil
+}
+
+func (gs *GameServiceImpl) Pass(matchID domain.MatchID, seat domain.SeatID) error {
+	if err := gs.ApplyAction(matchID, engine.Action{Kind: engine.ActionPass, Seat: seat}); err != nil {
+		return fmt.Errorf("failed to pass: %w", err)
+	}
+	return nil
+}
+
+// ApplyAction 执行一个座位的行动（出牌、Pass、贡牌、选择贡牌、还贡）
+func (gs *GameServiceImpl) ApplyAction(matchID domain.MatchID, action engine.Action) error {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 	
@@ -199,8 +267,8 @@ func (gs *GameServiceImpl) PlayCards(matchID domain.MatchID, seat domain.SeatID,
 		return fmt.Errorf("match is not active: %s", matchID)
 	}
 	
-	if err := matchInstance.Engine.PlayCards(seat, cards); err != nil {
-		return fmt.Errorf("failed to play cards: %w", err)
+	if err := matchInstance.Engine.Apply(action); err != nil {
+		return err
 	}
 	
 	matchInstance.UpdatedAt = time.Now()
@@ -208,26 +276,36 @@ func (gs *GameServiceImpl) PlayCards(matchID domain.MatchID, seat domain.SeatID,
 	return nil
 }
 
-func (gs *GameServiceImpl) Pass(matchID domain.MatchID, seat domain.SeatID) error {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
+// checkTurnTimer 后台计时检查。超时的代为行动与玩家行动一样在服务锁内执行并更新UpdatedAt
+func (gs *GameServiceImpl) checkTurnTimer(matchID domain.MatchID) func(now time.Time) error {
+	return func(now time.Time) error {
+		gs.mu.Lock()
+		defer gs.mu.Unlock()
+		
+		matchInstance, exists := gs.matches[matchID]
+		if !exists || !matchInstance.IsActive {
+			return nil
+		}
+		
+		acted, err := matchInstance.Engine.TimeoutActions(now)
+		if acted > 0 {
+			matchInstance.UpdatedAt = time.Now()
+		}
+		return err
+	}
+}
+
+// GetPendingActions 每个需要行动的座位当前可以执行的行动类型
+func (gs *GameServiceImpl) GetPendingActions(matchID domain.MatchID) (map[domain.SeatID][]engine.ActionKind, error) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
 	
 	matchInstance, exists := gs.matches[matchID]
 	if !exists {
-		return fmt.Errorf("match not found: %s", matchID)
-	}
-	
-	if !matchInstance.IsActive {
-		return fmt.Errorf("match is not active: %s", matchID)
-	}
-	
-	if err := matchInstance.Engine.Pass(seat); err != nil {
-		return fmt.Errorf("failed to pass: %w", err)
+		return nil, fmt.Errorf("match not found: %s", matchID)
 	}
 	
-	matchInstance.UpdatedAt = time.Now()
-	
-	return nil
+	return matchInstance.Engine.PendingActions(), nil
 }
 
 func (gs *GameServiceImpl) GetSnapshot(matchID domain.MatchID) (*MatchSnapshot, error) {
@@ -280,6 +358,124 @@ func (gs *GameServiceImpl) GetValidPlays(matchID domain.MatchID, seat domain.Sea
 	return matchInstance.Engine.GetValidPlays(seat), nil
 }
 
+// SuggestPlays 提示：按启发式策略给轮到出牌的seat的合法出牌排名，相同点数的出法只保留一个；
+// 理由参考Belief对对手手牌的推断
+func (gs *GameServiceImpl) SuggestPlays(matchID domain.MatchID, seat domain.SeatID) ([]bot.Suggestion, error) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	
+	matchInstance, exists := gs.matches[matchID]
+	if !exists {
+		return nil, fmt.Errorf("match not found: %s", matchID)
+	}
+	
+	ge := matchInstance.Engine
+	if !canPlay(ge.PendingActions()[seat]) {
+		return nil, fmt.Errorf("failed to suggest plays: %w", engine.ErrNotYourTurn)
+	}
+	
+	hints := &bot.Heuristic{HintDeals: bot.DefaultHintDeals}
+	return hints.Suggest(bot.Observe(ge, seat), ge.GetValidPlays(seat))
+}
+
+// Observe seat在当前状态下的观察，供机器人在服务中的比赛里决策；决策不持有服务的锁
+func (gs *GameServiceImpl) Observe(matchID domain.MatchID, seat domain.SeatID) (*bot.Observation, error) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	
+	matchInstance, exists := gs.matches[matchID]
+	if !exists {
+		return nil, fmt.Errorf("match not found: %s", matchID)
+	}
+	
+	return bot.Observe(matchInstance.Engine, seat), nil
+}
+
+// ApplyBotAction 执行机器人为seat选择的行动，贡牌、还贡的接收者按当前贡牌信息确定
+func (gs *GameServiceImpl) ApplyBotAction(matchID domain.MatchID, seat domain.SeatID, action bot.Action) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	
+	matchInstance, exists := gs.matches[matchID]
+	if !exists {
+		return fmt.Errorf("match not found: %s", matchID)
+	}
+	
+	if !matchInstance.IsActive {
+		return fmt.Errorf("match is not active: %s", matchID)
+	}
+	
+	if err := bot.Apply(matchInstance.Engine, seat, action); err != nil {
+		return err
+	}
+	
+	matchInstance.UpdatedAt = time.Now()
+	
+	return nil
+}
+
+// SetSeatControl 把seat切换为机器人代打（bot为true）或交还玩家，并发布SeatControlChangedEvent；
+// 操作方没有变化时不发布事件。服务只记录操作方，机器人的行动由调用方通过ApplyBotAction执行
+func (gs *GameServiceImpl) SetSeatControl(matchID domain.MatchID, seat domain.SeatID, bot bool, reason string) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	
+	matchInstance, exists := gs.matches[matchID]
+	if !exists {
+		return fmt.Errorf("match not found: %s", matchID)
+	}
+	if !seat.IsValid() {
+		return fmt.Errorf("invalid seat: %d", seat)
+	}
+	
+	if matchInstance.BotSeats[seat] == bot {
+		return nil
+	}
+	matchInstance.BotSeats[seat] = bot
+	matchInstance.UpdatedAt = time.Now()
+	gs.eventBus.Publish(event.NewSeatControlChangedEvent(matchID, seat, bot, reason))
+	
+	return nil
+}
+
+func canPlay(kinds []engine.ActionKind) bool {
+	for _, kind := range kinds {
+		if kind == engine.ActionPlay {
+			return true
+		}
+	}
+	return false
+}
+
+func (gs *GameServiceImpl) ContributeEntropy(matchID domain.MatchID, seat domain.SeatID, entropy []byte) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	
+	matchInstance, exists := gs.matches[matchID]
+	if !exists {
+		return fmt.Errorf("match not found: %s", matchID)
+	}
+	
+	if err := matchInstance.Engine.ContributeEntropy(seat, entropy); err != nil {
+		return fmt.Errorf("failed to contribute entropy: %w", err)
+	}
+	
+	return nil
+}
+
+func (gs *GameServiceImpl) GetShuffleCommitment(matchID domain.MatchID) (int, string, error) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	
+	matchInstance, exists := gs.matches[matchID]
+	if !exists {
+		return 0, "", fmt.Errorf("match not found: %s", matchID)
+	}
+	
+	dealNumber, commitment := matchInstance.Engine.GetShuffleCommitment()
+	return dealNumber, commitment, nil
+}
+
 func (gs *GameServiceImpl) GetCurrentPlayer(matchID domain.MatchID) (domain.SeatID, error) {
 	gs.mu.RLock()
 	defer gs.mu.RUnlock()
@@ -325,9 +521,10 @@ func (gs *GameServiceImpl) GetMatchState(matchID domain.MatchID) (*MatchState, e
 		CurrentDeal: matchInstance.MatchCtx.CurrentDeal,
 		Trump:       trump,
 		Players:     matchInstance.MatchCtx.Players.All(),
-		Teams:       matchInstance.MatchCtx.Teams,
+		Teams:       matchInstance.Engine.GetMatchCtx().Teams,
 		IsFinished:  matchInstance.Engine.IsGameFinished(),
 		Winner:      matchInstance.Engine.GetGameWinner(),
+		BotSeats:    matchInstance.BotSeats,
 		CreatedAt:   matchInstance.CreatedAt,
 		UpdatedAt:   matchInstance.UpdatedAt,
 	}, nil
@@ -343,6 +540,7 @@ func (gs *GameServiceImpl) DeleteMatch(matchID domain.MatchID) error {
 	}
 	
 	matchInstance.IsActive = false
+	matchInstance.Engine.DisableTurnTimer()
 	
 	matchInstance.SubscribersMu.Lock()
 	for subscriberID := range matchInstance.Subscribers {
@@ -357,6 +555,15 @@ func (gs *GameServiceImpl) DeleteMatch(matchID domain.MatchID) error {
 	return nil
 }
 
+// RandomSeed 返回不可预测的比赛种子。种子决定所有Deal的发牌，不能用可猜测的时间
+func RandomSeed() int64 {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	return int64(binary.BigEndian.Uint64(buf[:]))
+}
+
 func (gs *GameServiceImpl) generateMatchID() domain.MatchID {
 	gs.idSeed++
 	return domain.MatchID(fmt.Sprintf("match_%d_%d", time.Now().Unix(), gs.idSeed))
@@ -391,6 +598,8 @@ func (gs *GameServiceImpl) createSnapshot(matchInstance *MatchInstance) *MatchSn
 		snapshot.TrickCtx = *trickCtx
 	}
 	
+	snapshot.TurnClock = matchInstance.Engine.GetTurnClockState(time.Now())
+	
 	snapshot.Hands = make(map[domain.SeatID][]domain.Card)
 	for _, player := range matchInstance.MatchCtx.Players.All() {
 		snapshot.Hands[player.SeatID] = player.GetHand()
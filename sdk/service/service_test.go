@@ -1,12 +1,15 @@
 package service
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
+	"guandan/sdk/bot"
 	"guandan/sdk/domain"
 	"guandan/sdk/engine"
 	"guandan/sdk/event"
+	"guandan/sdk/sim"
 )
 
 func TestGameServiceInitialization(t *testing.T) {
@@ -57,6 +60,181 @@ func TestGameServiceCreateMatch(t *testing.T) {
 	}
 }
 
+func TestGameServiceProvablyFairShuffle(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 12345, ProvablyFair: true})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	
+	dealNumber, commitment, err := service.GetShuffleCommitment(matchID)
+	if err != nil {
+		t.Fatalf("Failed to get shuffle commitment: %v", err)
+	}
+	
+	if dealNumber != 1 || len(commitment) != 64 {
+		t.Errorf("Expected a commitment for deal 1, got deal %d commitment %q", dealNumber, commitment)
+	}
+	
+	if err := service.ContributeEntropy(matchID, domain.SeatSouth, []byte("south")); err != nil {
+		t.Errorf("Failed to contribute entropy: %v", err)
+	}
+	
+	if err := service.ContributeEntropy(matchID, domain.SeatSouth, []byte("again")); err == nil {
+		t.Error("Second contribution from the same seat should be rejected")
+	}
+	
+	if err := service.StartNextDeal(matchID); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	
+	snapshot, err := service.GetSnapshot(matchID)
+	if err != nil {
+		t.Fatalf("Failed to get snapshot: %v", err)
+	}
+	
+	if snapshot.DealCtx.ShuffleCommitment != commitment {
+		t.Errorf("Deal should use the published commitment %s, got %s", commitment, snapshot.DealCtx.ShuffleCommitment)
+	}
+}
+
+func TestGameServicePresetDeal(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	jokers := []domain.Card{
+		domain.NewJoker(domain.BigJoker),
+		domain.NewJoker(domain.BigJoker),
+	}
+	source := engine.NewConstrainedDealSource(engine.HoldsCards(domain.SeatWest, jokers...))
+	
+	if _, err := service.CreateMatch(players, &MatchOptions{Seed: 1, ProvablyFair: true, DealSource: source}); err == nil {
+		t.Error("Provably fair shuffle should not be combined with a custom deal source")
+	}
+	
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 1, DealSource: source})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	
+	if err := service.StartNextDeal(matchID); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	
+	west := players[domain.SeatWest]
+	if !west.HasCards(jokers) {
+		t.Error("West should hold both big jokers")
+	}
+}
+
+func TestGameServiceTurnTimerSnapshot(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	if _, err := service.CreateMatch(players, &MatchOptions{Seed: 1, TurnTimer: &engine.TimerConfig{}}); err == nil {
+		t.Error("Turn timer without action time should be rejected")
+	}
+	
+	timer := &engine.TimerConfig{ActionTime: 20 * time.Second, TimeBank: time.Minute}
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 1, TurnTimer: timer})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	defer service.DeleteMatch(matchID)
+	
+	if err := service.StartNextDeal(matchID); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	
+	snapshot, err := service.GetSnapshot(matchID)
+	if err != nil {
+		t.Fatalf("Failed to get snapshot: %v", err)
+	}
+	if snapshot.TurnClock == nil {
+		t.Fatal("Snapshot should include the turn clock")
+	}
+	
+	data, err := snapshot.ToJSON()
+	if err != nil {
+		t.Fatalf("Failed to serialize snapshot: %v", err)
+	}
+	var restored MatchSnapshot
+	if err := restored.FromJSON(data); err != nil {
+		t.Fatalf("Failed to deserialize snapshot: %v", err)
+	}
+	
+	current, _ := service.GetCurrentPlayer(matchID)
+	if _, running := restored.TurnClock.Elapsed[current]; !running || len(restored.TurnClock.Elapsed) != 1 {
+		t.Errorf("Expected only %s on the clock, got %v", current, restored.TurnClock.Elapsed)
+	}
+	if restored.TurnClock.Config != *timer || restored.TurnClock.Banks[current] != time.Minute {
+		t.Errorf("Unexpected restored clock: %+v", restored.TurnClock)
+	}
+	
+	clone := restored.Clone()
+	clone.TurnClock.Elapsed[current] = time.Hour
+	if restored.TurnClock.Elapsed[current] == time.Hour {
+		t.Error("Cloned snapshot should not share the turn clock")
+	}
+}
+
+func TestGameServiceTurnTimerTimeout(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	timer := &engine.TimerConfig{ActionTime: 20 * time.Millisecond, TickInterval: 5 * time.Millisecond}
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 1, TurnTimer: timer})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	defer service.DeleteMatch(matchID)
+	
+	if err := service.StartNextDeal(matchID); err != nil {
+		t.Fatalf("Failed to start deal: %v", err)
+	}
+	before, _ := service.GetMatchState(matchID)
+	
+	// 超时的代为行动经过服务，更新UpdatedAt
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err := service.GetMatchState(matchID)
+		if err != nil {
+			t.Fatalf("Failed to get match state: %v", err)
+		}
+		if state.UpdatedAt.After(before.UpdatedAt) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("A timeout action should update the match through the service")
+}
+
 func TestGameServiceStartNextDeal(t *testing.T) {
 	service := NewGameService()
 	
@@ -92,6 +270,99 @@ func TestGameServiceStartNextDeal(t *testing.T) {
 	}
 }
 
+func TestGameServiceTargetLevel(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	invalid := domain.Rank(domain.SmallJoker)
+	if _, err := service.CreateMatch(players, &MatchOptions{Seed: 12345, TargetLevel: &invalid}); err == nil {
+		t.Error("Expected an error for a target level above A")
+	}
+	
+	// 目标级数为3：头游队升级到3为止，到3的一队赢得Deal且对家不是末游时结束比赛
+	target := domain.Three
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 12345, TargetLevel: &target})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	
+	heuristic := bot.NewHeuristic()
+	declarer := domain.TeamEastWest
+	for deal := 1; deal <= 20; deal++ {
+		if err := service.StartNextDeal(matchID); err != nil {
+			t.Fatalf("Failed to start deal %d: %v", deal, err)
+		}
+		before, err := service.GetMatchState(matchID)
+		if err != nil {
+			t.Fatalf("Failed to get match state: %v", err)
+		}
+		levels := [2]domain.Rank{before.Teams[0].Level, before.Teams[1].Level}
+		if before.Trump != levels[declarer] {
+			t.Fatalf("Deal %d: expected the previous winner's level %s as trump, got %s", deal, levels[declarer], before.Trump)
+		}
+		
+		for step := 0; step < 1000; step++ {
+			pending, err := service.GetPendingActions(matchID)
+			if err != nil {
+				t.Fatalf("Failed to get pending actions: %v", err)
+			}
+			if len(pending) == 0 {
+				break
+			}
+			for seat := range pending {
+				obs, err := service.Observe(matchID, seat)
+				if err != nil {
+					t.Fatalf("Failed to observe %s: %v", seat, err)
+				}
+				action, err := heuristic.Act(obs)
+				if err != nil {
+					t.Fatalf("%s failed to choose an action: %v", seat, err)
+				}
+				if err := service.ApplyBotAction(matchID, seat, action); err != nil {
+					t.Fatalf("%s failed to act: %v", seat, err)
+				}
+				break
+			}
+		}
+		
+		state, err := service.GetMatchState(matchID)
+		if err != nil {
+			t.Fatalf("Failed to get match state: %v", err)
+		}
+		snapshot, _ := service.GetSnapshot(matchID)
+		rankList := snapshot.DealCtx.RankList
+		winner := domain.GetTeamFromSeat(rankList[0])
+		outcome := domain.DetermineTributeScenario(rankList)
+		wantLevel, wantWon := domain.AdvanceLevel(levels[winner], outcome, target)
+		if got := state.Teams[winner].Level; got != wantLevel {
+			t.Fatalf("Deal %d: expected %s to reach level %s, got %s", deal, winner, wantLevel, got)
+		}
+		if state.Teams[winner.OpposingTeam()].Level != levels[winner.OpposingTeam()] {
+			t.Errorf("Deal %d: the losing team's level should not change", deal)
+		}
+		if state.IsFinished != wantWon {
+			t.Fatalf("Deal %d: expected finished=%v, got %v", deal, wantWon, state.IsFinished)
+		}
+		if state.IsFinished {
+			if state.Winner == nil || *state.Winner != winner {
+				t.Errorf("Expected %s to win the match, got %v", winner, state.Winner)
+			}
+			if err := service.StartNextDeal(matchID); err == nil {
+				t.Error("No deal should start after the match is won")
+			}
+			return
+		}
+		declarer = winner
+	}
+	t.Fatal("Expected the match to end within 20 deals")
+}
+
 func TestGameServicePlayCards(t *testing.T) {
 	service := NewGameService()
 	
@@ -150,6 +421,243 @@ func TestGameServicePlayCards(t *testing.T) {
 	}
 }
 
+func TestGameServiceApplyAction(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 12345})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	if err := service.StartNextDeal(matchID); err != nil {
+		t.Fatalf("Failed to start next deal: %v", err)
+	}
+	
+	pending, err := service.GetPendingActions(matchID)
+	if err != nil {
+		t.Fatalf("Failed to get pending actions: %v", err)
+	}
+	
+	leader, _ := service.GetCurrentPlayer(matchID)
+	if len(pending) != 1 || len(pending[leader]) != 1 || pending[leader][0] != engine.ActionPlay {
+		t.Fatalf("Expected %s to lead with Play only, got %v", leader, pending)
+	}
+	
+	validPlays, _ := service.GetValidPlays(matchID, leader)
+	if err := service.ApplyAction(matchID, engine.Action{Kind: engine.ActionPass, Seat: leader}); err == nil {
+		t.Error("Leader should not be able to pass")
+	}
+	if err := service.ApplyAction(matchID, engine.Action{Kind: engine.ActionPlay, Seat: leader, Cards: validPlays[0]}); err != nil {
+		t.Errorf("Failed to apply play: %v", err)
+	}
+	
+	if _, err := service.GetPendingActions("nonexistent"); err == nil {
+		t.Error("Should return error for nonexistent match")
+	}
+}
+
+func TestGameServiceSuggestPlays(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 12345})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	if err := service.StartNextDeal(matchID); err != nil {
+		t.Fatalf("Failed to start next deal: %v", err)
+	}
+	
+	leader, _ := service.GetCurrentPlayer(matchID)
+	suggestions, err := service.SuggestPlays(matchID, leader)
+	if err != nil {
+		t.Fatalf("Failed to suggest plays: %v", err)
+	}
+	validPlays, _ := service.GetValidPlays(matchID, leader)
+	if len(suggestions) == 0 || len(suggestions) > len(validPlays) {
+		t.Fatalf("Expected between 1 and %d suggestions, got %d", len(validPlays), len(suggestions))
+	}
+	if err := service.PlayCards(matchID, leader, suggestions[0].Action.Cards); err != nil {
+		t.Errorf("Top suggestion should be playable: %v", err)
+	}
+	
+	if _, err := service.SuggestPlays(matchID, leader); !errors.Is(err, engine.ErrNotYourTurn) {
+		t.Errorf("Expected ErrNotYourTurn after playing, got %v", err)
+	}
+}
+
+func TestGameServiceSeatControl(t *testing.T) {
+	service := NewGameService()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	
+	matchID, err := service.CreateMatch(players, &MatchOptions{Seed: 12345})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	
+	changes := make(chan *event.SeatControlChangedEvent, 4)
+	unsubscribe, err := service.Subscribe(matchID, func(e event.DomainEvent) {
+		if ev, ok := e.(*event.SeatControlChangedEvent); ok {
+			changes <- ev
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer unsubscribe()
+	
+	if err := service.StartNextDeal(matchID); err != nil {
+		t.Fatalf("Failed to start next deal: %v", err)
+	}
+	if err := service.SetSeatControl(matchID, domain.SeatWest, true, event.ControlReasonRequest); err != nil {
+		t.Fatalf("Failed to hand West to the bot: %v", err)
+	}
+	// 操作方没有变化时不发布事件
+	if err := service.SetSeatControl(matchID, domain.SeatWest, true, event.ControlReasonDisconnect); err != nil {
+		t.Fatalf("Failed to repeat the takeover: %v", err)
+	}
+	
+	state, _ := service.GetMatchState(matchID)
+	if state.BotSeats != [4]bool{false, false, true, false} {
+		t.Errorf("Expected only West under bot control, got %v", state.BotSeats)
+	}
+	
+	// 托管的座位与玩家一样经由服务行动
+	for step := 0; step < 20; step++ {
+		pending, _ := service.GetPendingActions(matchID)
+		if _, ok := pending[domain.SeatWest]; ok {
+			break
+		}
+		for seat := range pending {
+			obs, _ := service.Observe(matchID, seat)
+			action, _ := bot.NewHeuristic().Act(obs)
+			if err := service.ApplyBotAction(matchID, seat, action); err != nil {
+				t.Fatalf("%s failed to act: %v", seat, err)
+			}
+			break
+		}
+	}
+	obs, err := service.Observe(matchID, domain.SeatWest)
+	if err != nil {
+		t.Fatalf("Failed to observe West: %v", err)
+	}
+	action, err := bot.NewHeuristic().Act(obs)
+	if err != nil {
+		t.Fatalf("Bot failed to choose an action: %v", err)
+	}
+	if err := service.ApplyBotAction(matchID, domain.SeatWest, action); err != nil {
+		t.Errorf("Bot action for West failed: %v", err)
+	}
+	
+	if err := service.SetSeatControl(matchID, domain.SeatWest, false, event.ControlReasonCancel); err != nil {
+		t.Fatalf("Failed to return West to the player: %v", err)
+	}
+	
+	for _, expected := range []bool{true, false} {
+		select {
+		case ev := <-changes:
+			if ev.Player != domain.SeatWest || ev.Bot != expected {
+				t.Errorf("Unexpected event %+v", ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for SeatControlChanged")
+		}
+	}
+	select {
+	case ev := <-changes:
+		t.Errorf("Unexpected extra event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+	
+	if err := service.SetSeatControl("nonexistent", domain.SeatWest, true, event.ControlReasonRequest); err == nil {
+		t.Error("Expected an error for a non-existent match")
+	}
+}
+
+func TestGameServiceDuplicateMatches(t *testing.T) {
+	service := NewGameService()
+	
+	newTable := func(prefix string) []*domain.Player {
+		return []*domain.Player{
+			domain.NewPlayer(prefix+"1", "Player1", domain.SeatEast),
+			domain.NewPlayer(prefix+"2", "Player2", domain.SeatSouth),
+			domain.NewPlayer(prefix+"3", "Player3", domain.SeatWest),
+			domain.NewPlayer(prefix+"4", "Player4", domain.SeatNorth),
+		}
+	}
+	
+	if _, _, err := service.CreateDuplicateMatches(newTable("a"), newTable("b"), &MatchOptions{Seed: 5, ProvablyFair: true}); err == nil {
+		t.Error("Duplicate matches should not use a provably fair shuffle")
+	}
+	
+	tableA, tableB, err := service.CreateDuplicateMatches(newTable("a"), newTable("b"), &MatchOptions{Seed: 5})
+	if err != nil {
+		t.Fatalf("Failed to create duplicate matches: %v", err)
+	}
+	
+	impl := service.(*GameServiceImpl)
+	agents := [4]bot.Agent{bot.NewHeuristic(), bot.NewHeuristic(), bot.NewHeuristic(), bot.NewHeuristic()}
+	for _, matchID := range []domain.MatchID{tableA, tableB} {
+		if err := service.StartNextDeal(matchID); err != nil {
+			t.Fatalf("Failed to start deal: %v", err)
+		}
+	}
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		handA := impl.matches[tableA].Engine.GetPlayerHand(seat)
+		handB := impl.matches[tableB].Engine.GetPlayerHand(seat)
+		if len(handA) != len(handB) || !impl.matches[tableB].MatchCtx.GetPlayer(seat).HasCards(handA) {
+			t.Fatalf("%s should get the same hand at both tables", seat)
+		}
+	}
+	for _, matchID := range []domain.MatchID{tableA, tableB} {
+		if err := bot.PlayDeal(impl.matches[matchID].Engine, agents); err != nil {
+			t.Fatalf("Failed to play deal: %v", err)
+		}
+	}
+	
+	// Deal排名由事件异步记录
+	var score *sim.DuplicateScore
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if score, err = service.GetDuplicateScore(tableA, tableB); err == nil && len(score.Boards) == 1 {
+			break
+		}
+	}
+	if err != nil || len(score.Boards) != 1 {
+		t.Fatalf("Expected one scored board, got %v, %v", score, err)
+	}
+	
+	// 同样的牌、同样的打法，比较的一方在两张桌上得失相抵
+	if board := score.Boards[0]; board.Seed != 5 || board.TableA == 0 || board.Score() != 0 {
+		t.Errorf("Unexpected board %+v", board)
+	}
+	
+	other, err := service.CreateMatch(newTable("c"), &MatchOptions{Seed: 6})
+	if err != nil {
+		t.Fatalf("Failed to create match: %v", err)
+	}
+	if _, err := service.GetDuplicateScore(tableA, other); err == nil {
+		t.Error("Matches with different seeds cannot be compared")
+	}
+}
+
 func TestGameServiceInvalidOperations(t *testing.T) {
 	service := NewGameService()
 	
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 	"guandan/sdk/domain"
+	"guandan/sdk/engine"
 	"guandan/sdk/event"
 )
 
@@ -15,6 +16,7 @@ type MatchSnapshot struct {
 	DealCtx     domain.DealCtx                 `json:"deal_ctx"`
 	TrickCtx    domain.TrickCtx                `json:"trick_ctx"`
 	Hands       map[domain.SeatID][]domain.Card `json:"hands"`
+	TurnClock   *engine.TurnClockState         `json:"turn_clock,omitempty"` // 回合计时状态，恢复时传给GameEngine.RestoreTurnClock
 	History     []event.DomainEvent            `json:"history"`
 	CreatedAt   time.Time                      `json:"created_at"`
 	UpdatedAt   time.Time                      `json:"updated_at"`
@@ -110,6 +112,15 @@ func (s *MatchSnapshot) Clone() *MatchSnapshot {
 		copy(clone.Hands[seat], hand)
 	}
 	
+	if s.TurnClock != nil {
+		turnClock := *s.TurnClock
+		turnClock.Elapsed = make(map[domain.SeatID]time.Duration)
+		for seat, elapsed := range s.TurnClock.Elapsed {
+			turnClock.Elapsed[seat] = elapsed
+		}
+		clone.TurnClock = &turnClock
+	}
+	
 	clone.History = make([]event.DomainEvent, len(s.History))
 	copy(clone.History, s.History)
 	
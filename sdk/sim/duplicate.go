@@ -0,0 +1,158 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+)
+
+// DefaultBoards 复式赛每张桌默认进行的Deal数
+const DefaultBoards = 8
+
+// Board 复式赛中同一副牌（同一种子、同一Deal序号）在两张桌上的结果。
+// A桌比较的一方坐东西，B桌坐南北，因而拿到A桌对手的牌；分数都以比较的一方为准，输掉为负
+type Board struct {
+	Seed   int64 `json:"seed"`
+	Number int   `json:"number"`
+	TableA int   `json:"tableA"`
+	TableB int   `json:"tableB"`
+}
+
+// Score 比较的一方在这副牌上的净升级数，双方实力相当时期望为0
+func (b Board) Score() int {
+	return b.TableA + b.TableB
+}
+
+// DuplicateScore 复式赛的比分。置信区间按正态近似，把每副牌的净升级数视为独立样本
+type DuplicateScore struct {
+	Boards []Board `json:"boards"`
+	Mean   float64 `json:"mean"`   // 平均每副牌的净升级数
+	StdDev float64 `json:"stdDev"` // 每副牌净升级数的样本标准差
+	Low    float64 `json:"low"`    // 均值的95%置信区间下限
+	High   float64 `json:"high"`   // 均值的95%置信区间上限
+}
+
+// z95 标准正态分布的97.5%分位数
+const z95 = 1.959964
+
+// NewDuplicateScore 计算boards的均值与95%置信区间，少于2副牌时区间退化为均值
+func NewDuplicateScore(boards []Board) *DuplicateScore {
+	score := &DuplicateScore{Boards: boards}
+	if len(boards) == 0 {
+		return score
+	}
+	
+	total := 0.0
+	for _, board := range boards {
+		total += float64(board.Score())
+	}
+	score.Mean = total / float64(len(boards))
+	score.Low, score.High = score.Mean, score.Mean
+	if len(boards) < 2 {
+		return score
+	}
+	
+	squares := 0.0
+	for _, board := range boards {
+		diff := float64(board.Score()) - score.Mean
+		squares += diff * diff
+	}
+	score.StdDev = math.Sqrt(squares / float64(len(boards)-1))
+	margin := z95 * score.StdDev / math.Sqrt(float64(len(boards)))
+	score.Low, score.High = score.Mean-margin, score.Mean+margin
+	return score
+}
+
+// ScoreBoards 按Deal序号配对两张桌的排名历史，只计两张桌都打完的Deal。
+// tableA中比较的一方坐东西，tableB中坐南北
+func ScoreBoards(seed int64, tableA, tableB [][]domain.SeatID) []Board {
+	boards := make([]Board, 0, len(tableA))
+	for i := 0; i < len(tableA) && i < len(tableB); i++ {
+		boards = append(boards, Board{
+			Seed:   seed,
+			Number: i + 1,
+			TableA: domain.TeamLevelGain(tableA[i], domain.TeamEastWest),
+			TableB: domain.TeamLevelGain(tableB[i], domain.TeamSouthNorth),
+		})
+	}
+	return boards
+}
+
+// SwapTeams B桌的座位：每个Agent移到下一个座位，搭档不变，东西与南北两队互换方位
+func SwapTeams(agents [4]AgentFactory) [4]AgentFactory {
+	var swapped [4]AgentFactory
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		swapped[seat.Next()] = agents[seat]
+	}
+	return swapped
+}
+
+// DuplicateSummary 复式赛的结果，比较的一方是cfg.Agents中东西方的Agent
+type DuplicateSummary struct {
+	Pairs    int             `json:"pairs"` // 两张桌都正常结束的对局数
+	Score    *DuplicateScore `json:"score"`
+	Failures []Failure       `json:"failures"`
+	Elapsed  time.Duration   `json:"elapsed"`
+}
+
+// RunDuplicate 进行cfg.Matches对复式对局：每对的两张桌使用同一种子，各进行cfg.Boards个Deal，
+// B桌的Agent按SwapTeams换位，使两队各打一次对方的牌
+func RunDuplicate(cfg Config) (*DuplicateSummary, error) {
+	if cfg.Matches <= 0 {
+		return nil, fmt.Errorf("number of matches must be positive")
+	}
+	for seat, factory := range cfg.Agents {
+		if factory == nil {
+			return nil, fmt.Errorf("no agent for seat %s", domain.SeatID(seat))
+		}
+	}
+	
+	start := time.Now()
+	boards := make([][]Board, cfg.Matches)
+	failures := make([]*Failure, cfg.Matches)
+	swapped := SwapTeams(cfg.Agents)
+	parallel(cfg, func(match int) {
+		seed := cfg.seed(match)
+		play := func(agents [4]bot.Agent) (*MatchResult, error) {
+			return PlayBoards(seed, agents, cfg.boards())
+		}
+		
+		tableA, failure := runMatch(seed, cfg.Agents, play)
+		if failure != nil {
+			failure.Error = "table A: " + failure.Error
+			failures[match] = failure
+			return
+		}
+		tableB, failure := runMatch(seed, swapped, play)
+		if failure != nil {
+			failure.Error = "table B: " + failure.Error
+			failures[match] = failure
+			return
+		}
+		boards[match] = ScoreBoards(seed, tableA.rankings(), tableB.rankings())
+	})
+	
+	summary := &DuplicateSummary{}
+	var all []Board
+	for match := range boards {
+		if failures[match] != nil {
+			summary.Failures = append(summary.Failures, *failures[match])
+			continue
+		}
+		summary.Pairs++
+		all = append(all, boards[match]...)
+	}
+	summary.Score = NewDuplicateScore(all)
+	summary.Elapsed = time.Since(start)
+	return summary, nil
+}
+
+func (r *MatchResult) rankings() [][]domain.SeatID {
+	rankings := make([][]domain.SeatID, len(r.Deals))
+	for i, deal := range r.Deals {
+		rankings[i] = deal.RankList
+	}
+	return rankings
+}
@@ -0,0 +1,295 @@
+// Package sim 用真实的引擎批量运行机器人比赛并汇总统计，规则错误会作为失败的比赛
+// 连同种子一起报告，因此也可用作引擎的压力测试
+package sim
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+)
+
+// DefaultMaxDeals 一场比赛最多进行的Deal数，达到后按未分胜负结束
+const DefaultMaxDeals = 100
+
+// AgentFactory 为一场比赛的某个座位创建Agent，seed为该场比赛的种子。
+// 每场比赛单独创建Agent，因此Agent不必并发安全；实现了io.Closer的Agent在比赛结束后关闭
+type AgentFactory func(seat domain.SeatID, seed int64) bot.Agent
+
+// Config 模拟参数
+type Config struct {
+	Matches   int
+	Seed      int64           // 第一场比赛的种子
+	FixedSeed bool            // 所有比赛都使用Seed，否则依次使用Seed, Seed+1, ...
+	Workers   int             // 并行比赛数，0表示runtime.NumCPU()
+	MaxDeals  int             // 0表示DefaultMaxDeals
+	Boards    int             // 复式赛每张桌的Deal数，0表示DefaultBoards
+	Agents    [4]AgentFactory // 按座位
+}
+
+func (c Config) workers() int {
+	if c.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return c.Workers
+}
+
+func (c Config) maxDeals() int {
+	if c.MaxDeals <= 0 {
+		return DefaultMaxDeals
+	}
+	return c.MaxDeals
+}
+
+func (c Config) boards() int {
+	if c.Boards <= 0 {
+		return DefaultBoards
+	}
+	return c.Boards
+}
+
+func (c Config) seed(match int) int64 {
+	if c.FixedSeed {
+		return c.Seed
+	}
+	return c.Seed + int64(match)
+}
+
+// DealResult 一个Deal的结果
+type DealResult struct {
+	Number    int
+	RankList  []domain.SeatID
+	Winner    domain.TeamID
+	LevelGain int                    // 赢家队的升级数
+	Outcome   domain.TributeScenario // 本Deal排名对应的场景（DoubleDown/SingleLast/PartnerLast）
+	Tribute   domain.TributeScenario // 本Deal开始时由上一Deal排名决定的贡牌场景，首Deal为None
+	Immunity  bool                   // 是否抗贡
+	Bombs     int                    // 打出的炸弹与同花顺数
+	Plays     int                    // 出牌次数，不含Pass
+	Actions   int                    // 出牌与Pass次数
+}
+
+// MatchResult 一场比赛的结果
+type MatchResult struct {
+	Seed   int64
+	Winner *domain.TeamID // 达到MaxDeals仍未分出胜负时为nil
+	Levels [2]domain.Rank // 比赛结束时两队的级数，按TeamID
+	Deals  []DealResult
+}
+
+// PlayMatch 用agents在新的引擎上进行一场比赛。两队从2打起，级数与胜负由引擎按规则决定
+func PlayMatch(seed int64, agents [4]bot.Agent, maxDeals int) (*MatchResult, error) {
+	if maxDeals <= 0 {
+		maxDeals = DefaultMaxDeals
+	}
+	return playMatch(seed, agents, maxDeals, true)
+}
+
+// PlayBoards 与PlayMatch相同，但不论比赛是否分出胜负都恰好进行boards个Deal，供复式赛使用
+func PlayBoards(seed int64, agents [4]bot.Agent, boards int) (*MatchResult, error) {
+	if boards <= 0 {
+		return nil, fmt.Errorf("number of boards must be positive")
+	}
+	return playMatch(seed, agents, boards, false)
+}
+
+func playMatch(seed int64, agents [4]bot.Agent, deals int, stopAtWinner bool) (*MatchResult, error) {
+	ge, err := newEngine(seed, [2]domain.Rank{domain.Two, domain.Two})
+	if err != nil {
+		return nil, err
+	}
+	
+	result := &MatchResult{Seed: seed, Levels: engineLevels(ge)}
+	var lastRankings []domain.SeatID
+	for number := 1; number <= deals; number++ {
+		// 比赛已分出胜负的复式赛桌在新的引擎上按当时的级数继续，发牌只取决于种子与Deal编号
+		if ge.IsGameFinished() {
+			if ge, err = newEngine(seed, result.Levels); err != nil {
+				return result, err
+			}
+		}
+		deal, err := playDeal(ge, agents, number, lastRankings)
+		if err != nil {
+			return result, fmt.Errorf("deal %d: %w", number, err)
+		}
+		result.Deals = append(result.Deals, *deal)
+		result.Levels = engineLevels(ge)
+		lastRankings = deal.RankList
+		
+		if winner := ge.GetGameWinner(); winner != nil && result.Winner == nil {
+			result.Winner = winner
+			if stopAtWinner {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// newEngine 创建一场比赛的引擎，两队从levels打起
+func newEngine(seed int64, levels [2]domain.Rank) (*engine.GameEngine, error) {
+	players := make([]*domain.Player, 4)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		players[seat] = domain.NewPlayer(fmt.Sprintf("sim-%s", seat), seat.String(), seat)
+	}
+	matchCtx := domain.NewMatchCtx(domain.MatchID(fmt.Sprintf("sim-%d", seed)), players, seed)
+	for _, team := range matchCtx.Teams {
+		team.Level = levels[team.ID]
+	}
+	ge := engine.NewGameEngine(event.NewEventBus(100))
+	if err := ge.Initialize(matchCtx); err != nil {
+		return nil, err
+	}
+	return ge, nil
+}
+
+// engineLevels 引擎中两队当前的级数，按TeamID
+func engineLevels(ge *engine.GameEngine) [2]domain.Rank {
+	var levels [2]domain.Rank
+	for _, team := range ge.GetMatchCtx().Teams {
+		levels[team.ID] = team.Level
+	}
+	return levels
+}
+
+func playDeal(ge *engine.GameEngine, agents [4]bot.Agent, number int, lastRankings []domain.SeatID) (*DealResult, error) {
+	if err := ge.StartDeal(number, lastRankings); err != nil {
+		return nil, err
+	}
+	if err := ge.DealCards(); err != nil {
+		return nil, err
+	}
+	if err := ge.DetermineTrump(); err != nil {
+		return nil, err
+	}
+	if err := ge.StartTribute(); err != nil {
+		return nil, err
+	}
+	
+	deal := &DealResult{Number: number}
+	if info := ge.GetDealCtx().TributeInfo; info != nil {
+		deal.Tribute = info.Scenario
+		deal.Immunity = info.HasImmunity
+	}
+	if err := bot.PlayDeal(ge, agents); err != nil {
+		return nil, err
+	}
+	
+	dealCtx := ge.GetDealCtx()
+	if len(dealCtx.RankList) != 4 {
+		return nil, fmt.Errorf("incomplete rank list %v", dealCtx.RankList)
+	}
+	deal.RankList = append([]domain.SeatID(nil), dealCtx.RankList...)
+	deal.Winner = domain.GetTeamFromSeat(deal.RankList[0])
+	deal.Outcome = domain.DetermineTributeScenario(deal.RankList)
+	deal.LevelGain = domain.LevelGain(deal.Outcome)
+	
+	// 打出的牌加上各座位的余牌必须恰好是两副牌（双下时两名输家都有余牌）
+	cards := 0
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		cards += len(ge.GetPlayerHand(seat))
+	}
+	for _, play := range ge.GetDealPlays() {
+		deal.Actions++
+		if play.IsPass() {
+			continue
+		}
+		deal.Plays++
+		cards += len(play.Cards)
+		if group := domain.NewCardGroup(play.Cards); group.GetCATValue() > 0 {
+			deal.Bombs++
+		}
+	}
+	if cards != 108 {
+		return nil, fmt.Errorf("%d cards accounted for at the end of the deal", cards)
+	}
+	return deal, nil
+}
+
+// Failure 一场因错误中止的比赛，用Seed可以复现
+type Failure struct {
+	Seed  int64  `json:"seed"`
+	Error string `json:"error"`
+}
+
+// Run 按cfg并行运行cfg.Matches场比赛。出错或panic的比赛记入Summary.Failures，不影响其他比赛
+func Run(cfg Config) (*Summary, error) {
+	if cfg.Matches <= 0 {
+		return nil, fmt.Errorf("number of matches must be positive")
+	}
+	for seat, factory := range cfg.Agents {
+		if factory == nil {
+			return nil, fmt.Errorf("no agent for seat %s", domain.SeatID(seat))
+		}
+	}
+	
+	start := time.Now()
+	results := make([]*MatchResult, cfg.Matches)
+	failures := make([]*Failure, cfg.Matches)
+	parallel(cfg, func(match int) {
+		seed := cfg.seed(match)
+		results[match], failures[match] = runMatch(seed, cfg.Agents, func(agents [4]bot.Agent) (*MatchResult, error) {
+			return PlayMatch(seed, agents, cfg.maxDeals())
+		})
+	})
+	
+	// 按比赛顺序汇总，结果与并行度无关
+	summary := &Summary{}
+	for match := range results {
+		if failures[match] != nil {
+			summary.Failures = append(summary.Failures, *failures[match])
+			continue
+		}
+		summary.Add(results[match])
+	}
+	summary.Elapsed = time.Since(start)
+	return summary, nil
+}
+
+// parallel 用cfg.workers()个goroutine对0到cfg.Matches-1调用run
+func parallel(cfg Config, run func(match int)) {
+	matches := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for match := range matches {
+				run(match)
+			}
+		}()
+	}
+	for match := 0; match < cfg.Matches; match++ {
+		matches <- match
+	}
+	close(matches)
+	wg.Wait()
+}
+
+// runMatch 为每个座位创建Agent并调用play，把错误和panic转换为Failure
+func runMatch(seed int64, factories [4]AgentFactory, play func([4]bot.Agent) (*MatchResult, error)) (result *MatchResult, failure *Failure) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, failure = nil, &Failure{Seed: seed, Error: fmt.Sprintf("panic: %v", r)}
+		}
+	}()
+	
+	var agents [4]bot.Agent
+	for seat, factory := range factories {
+		agents[seat] = factory(domain.SeatID(seat), seed)
+		// 外部引擎等需要释放资源的Agent在比赛结束后关闭
+		if closer, ok := agents[seat].(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+	result, err := play(agents)
+	if err != nil {
+		return nil, &Failure{Seed: seed, Error: err.Error()}
+	}
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package sim
+
+import (
+	"testing"
+	"guandan/sdk/bot"
+	"guandan/sdk/domain"
+)
+
+func heuristicAgents() [4]AgentFactory {
+	factory := func(domain.SeatID, int64) bot.Agent { return bot.NewHeuristic() }
+	return [4]AgentFactory{factory, factory, factory, factory}
+}
+
+func TestPlayMatchProgressesLevels(t *testing.T) {
+	var agents [4]bot.Agent
+	for seat := range agents {
+		agents[seat] = bot.NewHeuristic()
+	}
+	
+	result, err := PlayMatch(7, agents, 4)
+	if err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if len(result.Deals) < 2 {
+		t.Fatalf("Expected several deals on one engine, got %d", len(result.Deals))
+	}
+	
+	var levels [2]domain.Rank
+	for i, deal := range result.Deals {
+		if deal.Number != i+1 || len(deal.RankList) != 4 {
+			t.Fatalf("Unexpected deal %+v", deal)
+		}
+		if i == 0 && deal.Tribute != domain.TributeScenarioNone {
+			t.Errorf("First deal should have no tribute, got %s", deal.Tribute)
+		}
+		if i > 0 && deal.Tribute != result.Deals[i-1].Outcome {
+			t.Errorf("Deal %d tribute %s should follow the previous outcome %s", deal.Number, deal.Tribute, result.Deals[i-1].Outcome)
+		}
+		if deal.Plays == 0 || deal.Actions < deal.Plays {
+			t.Errorf("Deal %d has %d plays in %d actions", deal.Number, deal.Plays, deal.Actions)
+		}
+		levels[deal.Winner] += domain.Rank(deal.LevelGain)
+	}
+	
+	if result.Winner == nil && result.Levels != levels {
+		t.Errorf("Expected levels %v from the deal results, got %v", levels, result.Levels)
+	}
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	cfg := Config{Matches: 4, Seed: 3, Workers: 2, MaxDeals: 3, Agents: heuristicAgents()}
+	
+	first, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(first.Failures) > 0 {
+		t.Fatalf("Unexpected failures: %v", first.Failures)
+	}
+	if first.Matches != 4 || first.Deals == 0 {
+		t.Fatalf("Unexpected summary %+v", first)
+	}
+	
+	outcomes := 0
+	for _, count := range first.Outcomes {
+		outcomes += count
+	}
+	if outcomes != first.Deals {
+		t.Errorf("Every deal should have an outcome, got %v for %d deals", first.Outcomes, first.Deals)
+	}
+	
+	cfg.Workers = 1
+	second, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if second.Deals != first.Deals || second.Bombs != first.Bombs || second.Actions != first.Actions {
+		t.Errorf("Results should not depend on the number of workers: %+v vs %+v", first, second)
+	}
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	agents := heuristicAgents()
+	agents[domain.SeatNorth] = func(domain.SeatID, int64) bot.Agent { return nil }
+	
+	summary, err := Run(Config{Matches: 2, Seed: 1, Agents: agents})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(summary.Failures) != 2 || summary.Matches != 0 {
+		t.Errorf("Expected both matches to fail, got %+v", summary)
+	}
+	if summary.Failures[0].Seed != 1 || summary.Failures[1].Seed != 2 {
+		t.Errorf("Failures should be reported with their seeds, got %v", summary.Failures)
+	}
+	
+	if _, err := Run(Config{Matches: 1}); err == nil {
+		t.Error("Missing agents should be rejected")
+	}
+}
+
+func TestDuplicateScore(t *testing.T) {
+	east := []domain.SeatID{domain.SeatEast, domain.SeatWest, domain.SeatSouth, domain.SeatNorth}
+	south := []domain.SeatID{domain.SeatSouth, domain.SeatEast, domain.SeatNorth, domain.SeatWest}
+	
+	// A桌东西双下升3级，B桌同样是东西双下，比较的一方坐南北输3级；A桌的第2副牌B桌还没有打
+	boards := ScoreBoards(9, [][]domain.SeatID{east, east}, [][]domain.SeatID{east})
+	if len(boards) != 1 {
+		t.Fatalf("Expected only boards played on both tables, got %v", boards)
+	}
+	if boards[0].TableA != 3 || boards[0].TableB != -3 || boards[0].Score() != 0 {
+		t.Errorf("Unexpected board %+v", boards[0])
+	}
+	
+	// B桌南北头游、对家三游，比较的一方单落赢2级
+	boards = ScoreBoards(9, [][]domain.SeatID{east}, [][]domain.SeatID{south})
+	if boards[0].TableA != 3 || boards[0].TableB != 2 || boards[0].Score() != 5 {
+		t.Errorf("Unexpected board %+v", boards[0])
+	}
+	
+	score := NewDuplicateScore([]Board{{TableA: 3, TableB: -1}, {TableA: -2, TableB: 2}, {TableA: 1, TableB: 1}})
+	if score.Mean != 4.0/3 || score.Low >= score.Mean || score.High <= score.Mean {
+		t.Errorf("Unexpected score %+v", score)
+	}
+	if single := NewDuplicateScore([]Board{{TableA: 2}}); single.Low != 2 || single.High != 2 {
+		t.Errorf("A single board should have a degenerate interval, got %+v", single)
+	}
+}
+
+func TestRunDuplicateSameAgentsIsBalanced(t *testing.T) {
+	summary, err := RunDuplicate(Config{Matches: 3, Seed: 11, Boards: 2, Agents: heuristicAgents()})
+	if err != nil {
+		t.Fatalf("RunDuplicate failed: %v", err)
+	}
+	if len(summary.Failures) > 0 || summary.Pairs != 3 || len(summary.Score.Boards) != 6 {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+	
+	// 同一个确定性的Agent坐四个座位时，两张桌的第1副牌完全对称，净升级数为0
+	for _, board := range summary.Score.Boards {
+		if board.Number == 1 && board.Score() != 0 {
+			t.Errorf("Board %+v should cancel out between the tables", board)
+		}
+	}
+}
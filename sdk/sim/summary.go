@@ -0,0 +1,97 @@
+package sim
+
+import (
+	"time"
+	"guandan/sdk/domain"
+)
+
+// Summary 多场比赛的累计统计，比率由方法计算
+type Summary struct {
+	Matches      int           `json:"matches"`      // 正常结束的比赛数（含未分胜负）
+	Decided      int           `json:"decided"`      // 分出胜负的比赛数
+	Wins         [2]int        `json:"wins"`         // 按TeamID
+	Deals        int           `json:"deals"`
+	LevelGain    [2]int        `json:"levelGain"`    // 各队赢得Deal时累计的升级数
+	Outcomes     [4]int        `json:"outcomes"`     // 按TributeScenario统计的Deal排名
+	TributeDeals int           `json:"tributeDeals"` // 需要进贡或抗贡的Deal数
+	Immunities   int           `json:"immunities"`
+	Bombs        int           `json:"bombs"`
+	Plays        int           `json:"plays"`
+	Actions      int           `json:"actions"`
+	Failures     []Failure     `json:"failures"`
+	Elapsed      time.Duration `json:"elapsed"`
+}
+
+// Add 把一场比赛的结果计入统计
+func (s *Summary) Add(result *MatchResult) {
+	s.Matches++
+	if result.Winner != nil {
+		s.Decided++
+		s.Wins[*result.Winner]++
+	}
+	
+	for _, deal := range result.Deals {
+		s.Deals++
+		s.LevelGain[deal.Winner] += deal.LevelGain
+		s.Outcomes[deal.Outcome]++
+		if deal.Tribute != domain.TributeScenarioNone {
+			s.TributeDeals++
+			if deal.Immunity {
+				s.Immunities++
+			}
+		}
+		s.Bombs += deal.Bombs
+		s.Plays += deal.Plays
+		s.Actions += deal.Actions
+	}
+}
+
+// WinRate team赢得的比赛占分出胜负的比赛的比例
+func (s *Summary) WinRate(team domain.TeamID) float64 {
+	return ratio(s.Wins[team], s.Decided)
+}
+
+// LevelsPerDeal team平均每Deal的升级数
+func (s *Summary) LevelsPerDeal(team domain.TeamID) float64 {
+	return ratio(s.LevelGain[team], s.Deals)
+}
+
+// OutcomeRate 排名为outcome（DoubleDown/SingleLast/PartnerLast）的Deal的比例
+func (s *Summary) OutcomeRate(outcome domain.TributeScenario) float64 {
+	return ratio(s.Outcomes[outcome], s.Deals)
+}
+
+// TributeRate 实际进贡的Deal的比例
+func (s *Summary) TributeRate() float64 {
+	return ratio(s.TributeDeals-s.Immunities, s.Deals)
+}
+
+// ImmunityRate 需要进贡的Deal中抗贡的比例
+func (s *Summary) ImmunityRate() float64 {
+	return ratio(s.Immunities, s.TributeDeals)
+}
+
+func (s *Summary) BombsPerDeal() float64 {
+	return ratio(s.Bombs, s.Deals)
+}
+
+// DealLength 平均每Deal的出牌与Pass次数
+func (s *Summary) DealLength() float64 {
+	return ratio(s.Actions, s.Deals)
+}
+
+// PlaysPerDeal 平均每Deal的出牌次数，不含Pass
+func (s *Summary) PlaysPerDeal() float64 {
+	return ratio(s.Plays, s.Deals)
+}
+
+func (s *Summary) DealsPerMatch() float64 {
+	return ratio(s.Deals, s.Matches)
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
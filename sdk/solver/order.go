@@ -0,0 +1,155 @@
+package solver
+
+import (
+	"sort"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// move 一个候选行动与排序所需的信息
+type move struct {
+	action   engine.Action
+	group    *domain.CardGroup // Pass时为nil
+	key      uint64
+	finishes bool // 打出后手牌出完
+}
+
+// class 排序中的类别：普通出牌优先，其次Pass，炸弹最后
+func (m move) class() int {
+	switch {
+	case m.group == nil:
+		return 1
+	case m.group.IsBomb():
+		return 2
+	default:
+		return 0
+	}
+}
+
+// passKey Pass的键，与任何牌组的键都不同的概率可以忽略
+const passKey = 0x9e3779b97f4a7c15
+
+// moves 当前座位的候选行动，牌面相同的牌组只算一种。顺序：能出完手牌的、普通出牌（张数多的优先，
+// 同张数按CompareCardGroups从小到大）、Pass、炸弹（从小到大）
+func (s *Solver) moves(state engine.State) []move {
+	seat := state.Trick.CurrentPlayer
+	hand := state.Hands[seat]
+	trump := state.Deal.Trump
+	
+	var moves []move
+	for _, group := range domain.EnumerateCardGroups(hand) {
+		if !domain.CanFollow(group, state.Trick.LastPlay, trump) {
+			continue
+		}
+		moves = append(moves, move{
+			action:   engine.Action{Kind: engine.ActionPlay, Seat: seat, Cards: group.Cards},
+			group:    group,
+			key:      cardsKey(group.Cards),
+			finishes: group.Size == len(hand),
+		})
+	}
+	if state.Trick.LastPlay != nil {
+		moves = append(moves, move{action: engine.Action{Kind: engine.ActionPass, Seat: seat}, key: passKey})
+	}
+	
+	sort.SliceStable(moves, func(i, j int) bool {
+		a, b := moves[i], moves[j]
+		if a.finishes != b.finishes {
+			return a.finishes
+		}
+		if a.class() != b.class() {
+			return a.class() < b.class()
+		}
+		if a.group == nil || b.group == nil {
+			return false
+		}
+		if a.class() == 0 && a.group.Size != b.group.Size {
+			return a.group.Size > b.group.Size
+		}
+		return domain.CompareCardGroups(a.group, b.group, trump) == domain.CmpLess
+	})
+	return moves
+}
+
+// promote 把键为key的行动移到最前，其余顺序不变
+func promote(moves []move, key uint64) {
+	for i, m := range moves {
+		if m.key == key {
+			copy(moves[1:i+1], moves[:i])
+			moves[0] = m
+			return
+		}
+	}
+}
+
+// mix splitmix64的终结函数，把结构化的输入打散为64位散列
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// 散列各组成部分的标签，放在输入的高位以区分
+const (
+	tagHand = iota + 1
+	tagPlayer
+	tagLastPlay
+	tagLastPlayer
+	tagPassed
+	tagRank
+	tagTrump
+	tagCard
+)
+
+func tagged(tag, value uint64) uint64 {
+	return mix(tag<<48 | value)
+}
+
+// cardsKey 一组牌按牌面计数的散列，与顺序无关
+func cardsKey(cards []domain.Card) uint64 {
+	var counts [76]uint8
+	for _, card := range cards {
+		counts[card.ID()]++
+	}
+	var key uint64
+	for id, count := range counts {
+		if count > 0 {
+			key ^= tagged(tagCard, uint64(id)<<8|uint64(count))
+		}
+	}
+	return key
+}
+
+// hashState 置换表的键：四家手牌按牌面计数的Zobrist式散列，加上当前座位、需要压过的牌、
+// 最后出牌者、已Pass的座位、已出完的名次与级牌。出牌记录不影响之后的规则，不计入
+func hashState(state engine.State) uint64 {
+	var key uint64
+	for seat, hand := range state.Hands {
+		var counts [76]uint8
+		for _, card := range hand {
+			counts[card.ID()]++
+		}
+		for id, count := range counts {
+			if count > 0 {
+				key ^= tagged(tagHand, uint64(seat)<<16|uint64(id)<<8|uint64(count))
+			}
+		}
+	}
+	
+	trick := state.Trick
+	key ^= tagged(tagPlayer, uint64(trick.CurrentPlayer))
+	if trick.LastPlay != nil {
+		key ^= mix(tagLastPlay<<48 ^ cardsKey(trick.LastPlay.Cards))
+		key ^= tagged(tagLastPlayer, uint64(trick.LastPlayer))
+	}
+	for seat, passed := range trick.PassedPlayers {
+		if passed {
+			key ^= tagged(tagPassed, uint64(seat))
+		}
+	}
+	for i, seat := range state.Deal.RankList {
+		key ^= tagged(tagRank, uint64(i)<<8|uint64(seat))
+	}
+	return key ^ tagged(tagTrump, uint64(state.Deal.Trump))
+}
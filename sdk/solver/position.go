@@ -0,0 +1,81 @@
+package solver
+
+import (
+	"fmt"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// Position 一个四家手牌全部公开的出牌阶段残局，用于构造谜题；分析实际对局时可直接用GameEngine.GetState
+type Position struct {
+	Hands      [4][]domain.Card
+	Trump      domain.Rank
+	RankList   []domain.SeatID // 已出完牌的座位，按名次
+	ToPlay     domain.SeatID
+	LastPlay   []domain.Card // 本轮需要压过的牌，为空表示ToPlay首出
+	LastPlayer domain.SeatID // LastPlay的出牌者，其后到ToPlay之前仍有手牌的座位视为已Pass
+}
+
+// State 校验残局并构造出牌阶段的engine.State
+func (p Position) State() (engine.State, error) {
+	finished := [4]bool{}
+	for _, seat := range p.RankList {
+		if !seat.IsValid() || finished[seat] {
+			return engine.State{}, fmt.Errorf("invalid rank list %v", p.RankList)
+		}
+		finished[seat] = true
+	}
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		if finished[seat] && len(p.Hands[seat]) > 0 {
+			return engine.State{}, fmt.Errorf("%s is in the rank list but still has %d cards", seat, len(p.Hands[seat]))
+		}
+		if !finished[seat] && len(p.Hands[seat]) == 0 {
+			return engine.State{}, fmt.Errorf("%s has no cards but is not in the rank list", seat)
+		}
+	}
+	if over(p.RankList) {
+		return engine.State{}, fmt.Errorf("deal is already over with rank list %v", p.RankList)
+	}
+	if !p.ToPlay.IsValid() || finished[p.ToPlay] {
+		return engine.State{}, fmt.Errorf("%s cannot be the seat to play", p.ToPlay)
+	}
+	
+	deal := domain.NewDealCtx(1, p.Trump, p.ToPlay).WithRankList(p.RankList)
+	trick := domain.NewTrickCtx(1, p.ToPlay)
+	if len(p.LastPlay) > 0 {
+		group := domain.NewCardGroup(p.LastPlay)
+		if !group.IsValid() {
+			return engine.State{}, fmt.Errorf("last play %v is not a valid combination", p.LastPlay)
+		}
+		if !p.LastPlayer.IsValid() || p.LastPlayer == p.ToPlay {
+			return engine.State{}, fmt.Errorf("%s cannot be the last player when %s is to play", p.LastPlayer, p.ToPlay)
+		}
+		trick = trick.WithLastPlay(group, p.LastPlayer)
+		for seat := p.LastPlayer.Next(); seat != p.ToPlay; seat = seat.Next() {
+			if !finished[seat] {
+				trick = trick.WithPlayerPassed(seat)
+			}
+		}
+	}
+	trick = trick.WithCurrentPlayer(p.ToPlay)
+	
+	var hands [4][]domain.Card
+	for seat := range hands {
+		hands[seat] = append([]domain.Card(nil), p.Hands[seat]...)
+	}
+	return engine.State{
+		Phase:  engine.PhaseInProgress,
+		Levels: [2]domain.Rank{domain.Two, domain.Two},
+		Hands:  hands,
+		Deal:   deal.WithState(domain.DealStateInProgress),
+		Trick:  trick,
+	}, nil
+}
+
+// over 与引擎相同：三家出完，或同一队两家率先出完时Deal结束
+func over(rankList []domain.SeatID) bool {
+	if len(rankList) >= 3 {
+		return true
+	}
+	return len(rankList) == 2 && domain.GetTeamFromSeat(rankList[0]) == domain.GetTeamFromSeat(rankList[1])
+}
@@ -0,0 +1,292 @@
+// Package solver 双明手残局求解：四家手牌全部公开时，用alpha-beta搜索求出双方完美应对下的Deal结果与主变例。
+// 规则由engine.State执行，与引擎完全一致；适用于剩余手牌合计约20张以内的残局
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+// DefaultMaxNodes 每次求解默认的搜索节点上限
+const DefaultMaxNodes = 5000000
+
+// ErrNodeLimit 搜索节点数超过Config.MaxNodes
+var ErrNodeLimit = errors.New("node limit exceeded")
+
+// 分数为东西队的升级数，南北队获胜时为负，取值-3..3
+const (
+	minScore = -4
+	maxScore = 4
+)
+
+// Config 求解参数
+type Config struct {
+	MaxNodes int // 每次Solve或Moves的搜索节点上限，0表示DefaultMaxNodes
+}
+
+// Outcome 一个Deal的结果：头游所在的队与其队友的名次
+type Outcome struct {
+	Winner   domain.TeamID
+	Scenario domain.TributeScenario // DoubleDown为1-2，SingleLast为1-3，PartnerLast为1-4
+}
+
+// Gain team的升级数，输掉时为负
+func (o Outcome) Gain(team domain.TeamID) int {
+	gain := domain.LevelGain(o.Scenario)
+	if team != o.Winner {
+		return -gain
+	}
+	return gain
+}
+
+func (o Outcome) String() string {
+	places := map[domain.TributeScenario]string{
+		domain.TributeScenarioDoubleDown:  "1-2",
+		domain.TributeScenarioSingleLast:  "1-3",
+		domain.TributeScenarioPartnerLast: "1-4",
+	}
+	return fmt.Sprintf("%s %s", o.Winner, places[o.Scenario])
+}
+
+// outcomeOf 由东西队的分数还原结果
+func outcomeOf(score int) Outcome {
+	winner := domain.TeamEastWest
+	if score < 0 {
+		winner, score = domain.TeamSouthNorth, -score
+	}
+	scenario := domain.TributeScenarioPartnerLast
+	switch score {
+	case 3:
+		scenario = domain.TributeScenarioDoubleDown
+	case 2:
+		scenario = domain.TributeScenarioSingleLast
+	}
+	return Outcome{Winner: winner, Scenario: scenario}
+}
+
+// Result 求解结果
+type Result struct {
+	Outcome Outcome
+	Score   int             // 东西队的升级数，南北队获胜时为负
+	PV      []engine.Action // 主变例：双方完美应对下直到Deal结束的出牌与Pass
+	Nodes   int             // 本次搜索的节点数
+}
+
+// MoveValue 当前座位的一个出牌及其完美应对下的结果
+type MoveValue struct {
+	Action  engine.Action
+	Outcome Outcome
+	Score   int
+}
+
+// Solver 带置换表的双明手求解器。置换表在多次求解之间保留，分析同一Deal的多个局面时可复用；
+// Solver不是并发安全的
+type Solver struct {
+	config Config
+	table  map[uint64]entry
+	nodes  int
+}
+
+// entry 置换表项：分数与其界的类型，以及最佳出牌的键
+type entry struct {
+	score int8
+	bound int8
+	best  uint64
+}
+
+const (
+	boundExact = iota
+	boundLower // 实际分数不小于score
+	boundUpper // 实际分数不大于score
+)
+
+// New 创建求解器
+func New(config Config) *Solver {
+	if config.MaxNodes <= 0 {
+		config.MaxNodes = DefaultMaxNodes
+	}
+	return &Solver{config: config, table: make(map[uint64]entry)}
+}
+
+// Solve 求出state在双方完美应对下的结果与主变例。state须处于出牌阶段或已结束
+func (s *Solver) Solve(state engine.State) (*Result, error) {
+	state, err := s.prepare(state)
+	if err != nil {
+		return nil, err
+	}
+	
+	score, err := s.search(state, minScore, maxScore)
+	if err != nil {
+		return nil, err
+	}
+	pv, err := s.principalVariation(state, score)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Outcome: outcomeOf(score), Score: score, PV: pv, Nodes: s.nodes}, nil
+}
+
+// Moves 求出当前座位每个合法出牌（含Pass）在完美应对下的结果，按对当前座位所在队从好到差排列
+func (s *Solver) Moves(state engine.State) ([]MoveValue, error) {
+	state, err := s.prepare(state)
+	if err != nil {
+		return nil, err
+	}
+	if state.IsOver() {
+		return nil, nil
+	}
+	
+	var values []MoveValue
+	for _, m := range s.moves(state) {
+		child, _, err := state.Apply(m.action)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.action, err)
+		}
+		score, err := s.search(child, minScore, maxScore)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, MoveValue{Action: m.action, Outcome: outcomeOf(score), Score: score})
+	}
+	
+	team := domain.GetTeamFromSeat(state.Trick.CurrentPlayer)
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Outcome.Gain(team) > values[j].Outcome.Gain(team)
+	})
+	return values, nil
+}
+
+// prepare 校验阶段并去掉与规则无关的出牌记录，减少搜索中的复制
+func (s *Solver) prepare(state engine.State) (engine.State, error) {
+	s.nodes = 0
+	if state.IsOver() {
+		return state, nil
+	}
+	if state.Phase != engine.PhaseFirstPlay && state.Phase != engine.PhaseInProgress || state.Trick == nil {
+		return state, fmt.Errorf("cannot solve a position in phase %s", state.Phase)
+	}
+	
+	state = state.Clone()
+	state.Plays = nil
+	state.DealtHands = nil
+	trick := *state.Trick
+	trick.PlayHistory = nil
+	state.Trick = &trick
+	return state, nil
+}
+
+// score 已结束的Deal中东西队的升级数
+func score(state engine.State) int {
+	rankList := state.Deal.RankList
+	outcome := Outcome{
+		Winner:   domain.GetTeamFromSeat(rankList[0]),
+		Scenario: domain.DetermineTributeScenario(rankList),
+	}
+	return outcome.Gain(domain.TeamEastWest)
+}
+
+// search fail-soft的alpha-beta搜索，东西队取最大、南北队取最小
+func (s *Solver) search(state engine.State, alpha, beta int) (int, error) {
+	if state.IsOver() {
+		return score(state), nil
+	}
+	s.nodes++
+	if s.nodes > s.config.MaxNodes {
+		return 0, ErrNodeLimit
+	}
+	
+	key := hashState(state)
+	cached, hit := s.table[key]
+	if hit {
+		value := int(cached.score)
+		switch cached.bound {
+		case boundExact:
+			return value, nil
+		case boundLower:
+			alpha = max(alpha, value)
+		case boundUpper:
+			beta = min(beta, value)
+		}
+		if alpha >= beta {
+			return value, nil
+		}
+	}
+	
+	moves := s.moves(state)
+	if hit {
+		promote(moves, cached.best)
+	}
+	
+	maximizing := domain.GetTeamFromSeat(state.Trick.CurrentPlayer) == domain.TeamEastWest
+	alpha0, beta0 := alpha, beta
+	best, bestKey := maxScore, uint64(0)
+	if maximizing {
+		best = minScore
+	}
+	for _, m := range moves {
+		child, _, err := state.Apply(m.action)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", m.action, err)
+		}
+		value, err := s.search(child, alpha, beta)
+		if err != nil {
+			return 0, err
+		}
+		
+		if maximizing && value > best || !maximizing && value < best {
+			best, bestKey = value, m.key
+		}
+		if maximizing {
+			alpha = max(alpha, value)
+		} else {
+			beta = min(beta, value)
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	
+	bound := boundExact
+	if best <= alpha0 {
+		bound = boundUpper
+	} else if best >= beta0 {
+		bound = boundLower
+	}
+	s.table[key] = entry{score: int8(best), bound: int8(bound), best: bestKey}
+	return best, nil
+}
+
+// principalVariation 从state出发每步选择保持score的出牌，直到Deal结束
+func (s *Solver) principalVariation(state engine.State, score int) ([]engine.Action, error) {
+	var pv []engine.Action
+	for !state.IsOver() {
+		found := false
+		moves := s.moves(state)
+		if cached, ok := s.table[hashState(state)]; ok {
+			promote(moves, cached.best)
+		}
+		for _, m := range moves {
+			child, _, err := state.Apply(m.action)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", m.action, err)
+			}
+			value, err := s.search(child, score-1, score+1)
+			if err != nil {
+				return nil, err
+			}
+			if value == score {
+				pv = append(pv, m.action)
+				state = child
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no move keeps the score %d after %d moves", score, len(pv))
+		}
+	}
+	return pv, nil
+}
@@ -0,0 +1,210 @@
+package solver
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+)
+
+func cards(t *testing.T, texts ...string) []domain.Card {
+	t.Helper()
+	var result []domain.Card
+	for _, text := range texts {
+		card, err := domain.ParseCard(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, card)
+	}
+	return result
+}
+
+// minimax 不剪枝、不用置换表的穷举，作为对照
+func minimax(state engine.State) int {
+	if state.IsOver() {
+		return score(state)
+	}
+	maximizing := domain.GetTeamFromSeat(state.Trick.CurrentPlayer) == domain.TeamEastWest
+	best := maxScore
+	if maximizing {
+		best = minScore
+	}
+	for _, action := range state.LegalActions() {
+		child, _, err := state.Apply(action)
+		if err != nil {
+			continue
+		}
+		value := minimax(child)
+		if maximizing && value > best || !maximizing && value < best {
+			best = value
+		}
+	}
+	return best
+}
+
+// replay 在state上执行主变例，返回结束时东西队的分数
+func replay(t *testing.T, state engine.State, pv []engine.Action) int {
+	t.Helper()
+	for i, action := range pv {
+		next, _, err := state.Apply(action)
+		if err != nil {
+			t.Fatalf("pv move %d %s: %v", i, action, err)
+		}
+		state = next
+	}
+	if !state.IsOver() {
+		t.Fatalf("pv of %d moves does not end the deal", len(pv))
+	}
+	return score(state)
+}
+
+func TestSolvePuzzle(t *testing.T) {
+	// 东家首出对3，西家以对A接手出完，东家接风出单张K，东西双上
+	position := Position{
+		Hands: [4][]domain.Card{
+			cards(t, "H3", "D3", "SK"),
+			cards(t, "H5", "D5", "C9"),
+			cards(t, "HA", "DA"),
+			cards(t, "SQ", "CQ", "H4"),
+		},
+		Trump:  domain.Two,
+		ToPlay: domain.SeatEast,
+	}
+	state, err := position.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	result, err := New(Config{}).Solve(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := minimax(state); result.Score != want {
+		t.Fatalf("solver scored %d, exhaustive search %d", result.Score, want)
+	}
+	if got := replay(t, state, result.PV); got != result.Score {
+		t.Fatalf("pv ends with %d, solver reports %d", got, result.Score)
+	}
+	if result.Outcome.Gain(domain.TeamEastWest) != result.Score {
+		t.Fatalf("outcome %s does not match score %d", result.Outcome, result.Score)
+	}
+	t.Logf("%s in %d nodes: %v", result.Outcome, result.Nodes, result.PV)
+}
+
+func TestSolveMatchesExhaustiveSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	deck := domain.NewDeck().Cards
+	solver := New(Config{})
+	
+	for i := 0; i < 40; i++ {
+		rng.Shuffle(len(deck), func(a, b int) { deck[a], deck[b] = deck[b], deck[a] })
+		var position Position
+		position.Trump = domain.Rank(rng.Intn(13))
+		position.ToPlay = domain.SeatID(rng.Intn(4))
+		next := 0
+		for seat := range position.Hands {
+			n := 1 + rng.Intn(3)
+			position.Hands[seat] = append([]domain.Card(nil), deck[next:next+n]...)
+			next += n
+		}
+		state, err := position.State()
+		if err != nil {
+			t.Fatal(err)
+		}
+		
+		result, err := solver.Solve(state)
+		if err != nil {
+			t.Fatalf("position %d: %v", i, err)
+		}
+		if want := minimax(state); result.Score != want {
+			t.Fatalf("position %d %v: solver scored %d, exhaustive search %d", i, position.Hands, result.Score, want)
+		}
+		if got := replay(t, state, result.PV); got != result.Score {
+			t.Fatalf("position %d: pv ends with %d, solver reports %d", i, got, result.Score)
+		}
+		
+		moves, err := solver.Moves(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(moves) == 0 || moves[0].Score != result.Score {
+			t.Fatalf("position %d: best move %+v does not reach %d", i, moves, result.Score)
+		}
+	}
+}
+
+func TestSolveFollowing(t *testing.T) {
+	// 南家出的对K东家压不住，西家已Pass；北家已出完
+	position := Position{
+		Hands: [4][]domain.Card{
+			cards(t, "H4", "D4", "S9"),
+			cards(t, "C7"),
+			cards(t, "H6", "HJ"),
+			nil,
+		},
+		Trump:      domain.Two,
+		RankList:   []domain.SeatID{domain.SeatNorth},
+		ToPlay:     domain.SeatEast,
+		LastPlay:   cards(t, "HK", "DK"),
+		LastPlayer: domain.SeatSouth,
+	}
+	state, err := position.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Trick.HasPlayerPassed(domain.SeatWest) || state.Trick.HasPlayerPassed(domain.SeatNorth) {
+		t.Fatalf("unexpected passes %v", state.Trick.PassedPlayers)
+	}
+	
+	result, err := New(Config{}).Solve(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := minimax(state); result.Score != want {
+		t.Fatalf("solver scored %d, exhaustive search %d", result.Score, want)
+	}
+	// 东家只能Pass，南家出完7后北家、南家双上
+	if result.Outcome != (Outcome{Winner: domain.TeamSouthNorth, Scenario: domain.TributeScenarioDoubleDown}) {
+		t.Fatalf("expected South-North 1-2, got %s (pv %v)", result.Outcome, result.PV)
+	}
+}
+
+func TestNodeLimit(t *testing.T) {
+	position := Position{
+		Hands: [4][]domain.Card{
+			cards(t, "H3", "D4", "S5", "C6"),
+			cards(t, "H7", "D8", "S9", "CT"),
+			cards(t, "HJ", "DQ", "SK", "CA"),
+			cards(t, "D3", "S4", "C5", "H6"),
+		},
+		Trump:  domain.Two,
+		ToPlay: domain.SeatEast,
+	}
+	state, err := position.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(Config{MaxNodes: 10}).Solve(state); !errors.Is(err, ErrNodeLimit) {
+		t.Fatalf("expected ErrNodeLimit, got %v", err)
+	}
+}
+
+func TestPositionValidation(t *testing.T) {
+	hands := [4][]domain.Card{cards(t, "H3"), cards(t, "H4"), cards(t, "H5"), nil}
+	tests := []struct {
+		name     string
+		position Position
+	}{
+		{"finished seat missing from rank list", Position{Hands: hands, ToPlay: domain.SeatEast}},
+		{"seat to play has finished", Position{Hands: hands, RankList: []domain.SeatID{domain.SeatNorth}, ToPlay: domain.SeatNorth}},
+		{"invalid last play", Position{Hands: hands, RankList: []domain.SeatID{domain.SeatNorth}, ToPlay: domain.SeatEast,
+			LastPlay: cards(t, "H7", "D9"), LastPlayer: domain.SeatWest}},
+	}
+	for _, tt := range tests {
+		if _, err := tt.position.State(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
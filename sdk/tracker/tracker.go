@@ -0,0 +1,197 @@
+// Package tracker 记牌器：只根据比赛的公开事件记录已出的牌、贡牌去向和各座位剩余手牌数，
+// 结合某个座位自己的手牌推算其他座位手中还剩哪些牌
+package tracker
+
+import (
+	"sync"
+	"guandan/sdk/domain"
+	"guandan/sdk/event"
+)
+
+// Tracker 一场比赛的记牌器，可直接作为GameService.Subscribe的回调
+type Tracker struct {
+	mu         sync.RWMutex
+	dealNumber int
+	played     map[domain.Card]int
+	handCounts [4]int
+	finished   [4]bool
+	known      [4][]domain.Card // 公开的贡牌、还贡牌，持有者打出前仍在其手中
+}
+
+func New() *Tracker {
+	return &Tracker{played: make(map[domain.Card]int)}
+}
+
+// StraightFlush 仍可能存在的同花顺，High为最大的牌点
+type StraightFlush struct {
+	Suit domain.Suit
+	High domain.Rank
+}
+
+// Report 某个座位视角的记牌结果
+type Report struct {
+	Seat            domain.SeatID
+	DealNumber      int
+	Remaining       map[domain.Rank]int // 其他座位手中每个点数（含大小王）的剩余张数
+	HandCounts      [4]int
+	Known           map[domain.SeatID][]domain.Card // 其他座位手中已知的贡牌、还贡牌
+	Bombs           []domain.Rank                   // 其他座位仍可能组成四张及以上炸弹的点数
+	JokerBomb       bool                            // 王炸是否仍可能出现（同一家至少有两张王）
+	StraightFlushes []StraightFlush
+}
+
+// StraightFlushPossible 其他座位是否仍可能有同花顺
+func (r *Report) StraightFlushPossible() bool {
+	return len(r.StraightFlushes) > 0
+}
+
+// Handle 处理一个比赛事件，返回记牌结果是否因此变化；非公开或无关的事件被忽略
+func (t *Tracker) Handle(e event.DomainEvent) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	switch e := e.(type) {
+	case *event.DealStartedEvent:
+		t.dealNumber = e.DealNumber
+		t.played = make(map[domain.Card]int)
+		t.handCounts = [4]int{}
+		t.finished = [4]bool{}
+		t.known = [4][]domain.Card{}
+	case *event.CardsDealtEvent:
+		// 只使用公开的手牌张数
+		for seat, hand := range e.Hands {
+			t.handCounts[seat] = len(hand)
+		}
+	case *event.TributeGivenEvent:
+		for _, card := range e.Cards {
+			t.take(e.From, card)
+			t.give(e.To, card)
+		}
+	case *event.TributeCardSelectedEvent:
+		// Double Down的贡牌在选择后才确定去向
+		t.take(t.holder(e.SelectedCard), e.SelectedCard)
+		t.give(e.Selector, e.SelectedCard)
+		t.take(t.holder(e.RemainingCard), e.RemainingCard)
+		t.give(e.RemainingTo, e.RemainingCard)
+	case *event.CardsPlayedEvent:
+		for _, card := range e.Cards {
+			t.played[card]++
+			t.take(e.Player, card)
+		}
+	case *event.PlayerFinishedEvent:
+		t.finished[e.Player] = true
+	default:
+		return false
+	}
+	return true
+}
+
+// take 从seat手中减少一张牌，若是已知的贡牌则不再记为已知
+func (t *Tracker) take(seat domain.SeatID, card domain.Card) {
+	if !seat.IsValid() {
+		return
+	}
+	t.handCounts[seat]--
+	for i, known := range t.known[seat] {
+		if known == card {
+			t.known[seat] = append(t.known[seat][:i:i], t.known[seat][i+1:]...)
+			break
+		}
+	}
+}
+
+func (t *Tracker) give(seat domain.SeatID, card domain.Card) {
+	t.handCounts[seat]++
+	t.known[seat] = append(t.known[seat], card)
+}
+
+// holder 已知持有card的座位，找不到时返回无效座位
+func (t *Tracker) holder(card domain.Card) domain.SeatID {
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		for _, known := range t.known[seat] {
+			if known == card {
+				return seat
+			}
+		}
+	}
+	return domain.SeatID(-1)
+}
+
+// Report 计算seat视角的记牌结果，hand为该座位当前的手牌
+func (t *Tracker) Report(seat domain.SeatID, hand []domain.Card) *Report {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	
+	unseen := make(map[domain.Card]int)
+	for _, card := range domain.NewDeck().Cards {
+		unseen[card]++
+	}
+	for _, card := range hand {
+		unseen[card]--
+	}
+	for card, count := range t.played {
+		unseen[card] -= count
+	}
+	
+	report := &Report{
+		Seat:       seat,
+		DealNumber: t.dealNumber,
+		Remaining:  make(map[domain.Rank]int),
+		HandCounts: t.handCounts,
+		Known:      make(map[domain.SeatID][]domain.Card),
+	}
+	for card, count := range unseen {
+		if count > 0 {
+			report.Remaining[card.Rank] += count
+		}
+	}
+	
+	// 炸弹和同花顺必须在同一家手中，至少要有一个未出完的其他座位手牌足够多
+	largestHand := 0
+	for other := domain.SeatEast; other <= domain.SeatNorth; other++ {
+		if other == seat || t.finished[other] {
+			continue
+		}
+		if t.handCounts[other] > largestHand {
+			largestHand = t.handCounts[other]
+		}
+		if len(t.known[other]) > 0 {
+			report.Known[other] = append([]domain.Card(nil), t.known[other]...)
+		}
+	}
+	
+	if largestHand >= 4 {
+		for rank := domain.Two; rank <= domain.Ace; rank++ {
+			if report.Remaining[rank] >= 4 {
+				report.Bombs = append(report.Bombs, rank)
+			}
+		}
+	}
+	if largestHand >= domain.MinJokerBomb {
+		report.JokerBomb = report.Remaining[domain.SmallJoker]+report.Remaining[domain.BigJoker] >= domain.MinJokerBomb
+	}
+	if largestHand >= 5 {
+		report.StraightFlushes = straightFlushes(unseen)
+	}
+	return report
+}
+
+// straightFlushes 五张同花连续点数都未出现过的同花顺，点数从2到A不循环
+func straightFlushes(unseen map[domain.Card]int) []StraightFlush {
+	var possible []StraightFlush
+	for suit := domain.Hearts; suit <= domain.Spades; suit++ {
+		for high := domain.Six; high <= domain.Ace; high++ {
+			complete := true
+			for rank := high - 4; rank <= high; rank++ {
+				if unseen[domain.NewCard(suit, rank)] <= 0 {
+					complete = false
+					break
+				}
+			}
+			if complete {
+				possible = append(possible, StraightFlush{Suit: suit, High: high})
+			}
+		}
+	}
+	return possible
+}
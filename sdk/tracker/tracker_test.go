@@ -0,0 +1,157 @@
+package tracker
+
+import (
+	"math/rand"
+	"testing"
+	"guandan/sdk/domain"
+	"guandan/sdk/engine"
+	"guandan/sdk/event"
+)
+
+func newPlayingState(t *testing.T) engine.State {
+	t.Helper()
+	
+	players := []*domain.Player{
+		domain.NewPlayer("p1", "Player1", domain.SeatEast),
+		domain.NewPlayer("p2", "Player2", domain.SeatSouth),
+		domain.NewPlayer("p3", "Player3", domain.SeatWest),
+		domain.NewPlayer("p4", "Player4", domain.SeatNorth),
+	}
+	ge := engine.NewGameEngine(event.NewEventBus(100))
+	if err := ge.Initialize(domain.NewMatchCtx("tracker-match", players, 12345)); err != nil {
+		t.Fatalf("Failed to initialize engine: %v", err)
+	}
+	ge.StartDeal(1, nil)
+	ge.DealCards()
+	ge.DetermineTrump()
+	ge.StartTribute()
+	
+	state, err := ge.GetState()
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	return state
+}
+
+func dealtEvents(state engine.State) []event.DomainEvent {
+	hands := make(map[domain.SeatID][]domain.Card)
+	for seat := range state.Hands {
+		hands[domain.SeatID(seat)] = state.Hands[seat]
+	}
+	return []event.DomainEvent{
+		event.NewDealStartedEvent(state.MatchID, 1, domain.Two, domain.SeatEast, [2]domain.Rank{}),
+		event.NewCardsDealtEvent(state.MatchID, hands),
+	}
+}
+
+func TestTrackerMatchesHiddenHands(t *testing.T) {
+	state := newPlayingState(t)
+	tracker := New()
+	for _, e := range dealtEvents(state) {
+		tracker.Handle(e)
+	}
+	
+	rng := rand.New(rand.NewSource(7))
+	for steps := 0; !state.IsOver(); steps++ {
+		if steps > 2000 {
+			t.Fatal("Deal did not finish")
+		}
+		
+		actions := state.LegalActions()
+		next, events, err := state.Apply(actions[rng.Intn(len(actions))])
+		if err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		state = next
+		for _, e := range events {
+			tracker.Handle(e)
+		}
+		
+		for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+			report := tracker.Report(seat, state.Hands[seat])
+			actual := make(map[domain.Rank]int)
+			for other := range state.Hands {
+				if domain.SeatID(other) == seat {
+					continue
+				}
+				for _, card := range state.Hands[other] {
+					actual[card.Rank]++
+				}
+			}
+			for rank := domain.Two; rank <= domain.BigJoker; rank++ {
+				if report.Remaining[rank] != actual[rank] {
+					t.Fatalf("Step %d: %s sees %d of rank %s remaining, actual %d", steps, seat, report.Remaining[rank], rank, actual[rank])
+				}
+			}
+			if report.HandCounts[seat] != len(state.Hands[seat]) {
+				t.Fatalf("Step %d: hand count of %s is %d, actual %d", steps, seat, report.HandCounts[seat], len(state.Hands[seat]))
+			}
+		}
+	}
+}
+
+func TestTrackerTributeAndPossibleBombs(t *testing.T) {
+	const matchID = domain.MatchID("tracker-match")
+	tracker := New()
+	tracker.Handle(event.NewDealStartedEvent(matchID, 2, domain.Two, domain.SeatEast, [2]domain.Rank{}))
+	
+	hands := make(map[domain.SeatID][]domain.Card)
+	for seat := domain.SeatEast; seat <= domain.SeatNorth; seat++ {
+		hands[seat] = make([]domain.Card, 27)
+	}
+	tracker.Handle(event.NewCardsDealtEvent(matchID, hands))
+	
+	bigJoker := domain.NewJoker(domain.BigJoker)
+	if !tracker.Handle(event.NewTributeGivenEvent(matchID, domain.SeatSouth, domain.SeatEast, []domain.Card{bigJoker})) {
+		t.Fatal("Tribute should change the tracker")
+	}
+	
+	report := tracker.Report(domain.SeatWest, nil)
+	if known := report.Known[domain.SeatEast]; len(known) != 1 || known[0] != bigJoker {
+		t.Errorf("Expected East to hold the tributed big joker, got %v", report.Known)
+	}
+	if report.HandCounts[domain.SeatEast] != 28 || report.HandCounts[domain.SeatSouth] != 26 {
+		t.Errorf("Unexpected hand counts after tribute: %v", report.HandCounts)
+	}
+	if !report.JokerBomb || len(report.Bombs) != 13 || !report.StraightFlushPossible() {
+		t.Errorf("Expected every bomb to be possible, got %v, joker bomb %v", report.Bombs, report.JokerBomb)
+	}
+	
+	// 打出五张5后只剩三张5，两张红桃5都已出，含红桃5的同花顺也不再可能
+	fives := []domain.Card{
+		domain.NewCard(domain.Hearts, domain.Five), domain.NewCard(domain.Hearts, domain.Five),
+		domain.NewCard(domain.Spades, domain.Five), domain.NewCard(domain.Clubs, domain.Five),
+		domain.NewCard(domain.Diamonds, domain.Five),
+	}
+	tracker.Handle(event.NewCardsPlayedEvent(matchID, domain.SeatEast, fives, domain.NewCardGroup(fives)))
+	// 三张王打出后只剩East手中的大王，王炸也不再可能
+	jokers := []domain.Card{bigJoker, domain.NewJoker(domain.SmallJoker), domain.NewJoker(domain.SmallJoker)}
+	tracker.Handle(event.NewCardsPlayedEvent(matchID, domain.SeatSouth, jokers, domain.NewCardGroup(jokers)))
+	tracker.Handle(event.NewPlayerPassedEvent(matchID, domain.SeatNorth))
+	
+	report = tracker.Report(domain.SeatWest, nil)
+	if report.Remaining[domain.Five] != 3 {
+		t.Errorf("Expected 3 fives remaining, got %d", report.Remaining[domain.Five])
+	}
+	for _, rank := range report.Bombs {
+		if rank == domain.Five {
+			t.Error("A bomb of fives should no longer be possible")
+		}
+	}
+	if report.JokerBomb {
+		t.Error("Joker bomb should no longer be possible")
+	}
+	for _, sf := range report.StraightFlushes {
+		if sf.Suit == domain.Hearts && sf.High >= domain.Six && sf.High <= domain.Nine {
+			t.Errorf("Straight flush %v should no longer be possible", sf)
+		}
+	}
+	if len(report.Known[domain.SeatEast]) != 1 {
+		t.Errorf("East still holds the tributed joker, got %v", report.Known)
+	}
+	
+	tracker.Handle(event.NewCardsPlayedEvent(matchID, domain.SeatEast, []domain.Card{bigJoker}, nil))
+	if report = tracker.Report(domain.SeatWest, nil); len(report.Known) != 0 {
+		t.Errorf("Played tribute cards should no longer be known, got %v", report.Known)
+	}
+}